@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/olxbr/network-api/pkg/db"
+	"github.com/olxbr/network-api/pkg/net"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "list the networks to backfill without storing them")
+	flag.Parse()
+
+	cfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	d := db.New(dynamodb.NewFromConfig(cfg))
+	nm := net.New(d)
+
+	filled, err := nm.BackfillSubnets(context.Background(), *dryRun)
+	for _, n := range filled {
+		log.Printf("Network %s (%s): %d subnets", n.ID, n.CIDR, len(n.Subnets))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *dryRun {
+		log.Printf("Dry run: %d networks to backfill", len(filled))
+		return
+	}
+	log.Printf("Backfilled %d networks", len(filled))
+}
@@ -2,12 +2,17 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"reflect"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"github.com/olxbr/network-api/pkg/db"
+	"github.com/olxbr/network-api/pkg/net"
 	"github.com/olxbr/network-api/pkg/secret"
 	"github.com/olxbr/network-api/pkg/types"
 )
@@ -22,6 +27,15 @@ var validate *validator.Validate
 
 func init() {
 	validate = validator.New()
+	// report fields by the name clients send them with
+	validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	validate.RegisterStructValidation(poolRequestValidation, types.PoolRequest{}, types.PoolUpdateRequest{})
 }
 
 func New(database db.Database, s secret.Secrets) *api {
@@ -41,21 +55,50 @@ func (a *api) RegisterRoutes() {
 	v1 := api.PathPrefix("/v1").Subrouter()
 	v1.HandleFunc("/networks", a.ListNetworks).Methods(http.MethodGet)
 	v1.HandleFunc("/networks", a.CreateNetwork).Methods(http.MethodPost)
+	v1.HandleFunc("/networks/check", a.CheckNetworks).Methods(http.MethodPost)
+	v1.HandleFunc("/networks/import", a.ImportNetworks).Methods(http.MethodPost)
+	v1.HandleFunc("/networks/validate", a.ValidateNetwork).Methods(http.MethodPost)
 	v1.HandleFunc("/networks/{id}", a.DetailNetwork).Methods(http.MethodGet)
 	v1.HandleFunc("/networks/{id}", a.UpdateNetwork).Methods(http.MethodPut)
 	v1.HandleFunc("/networks/{id}", a.DeleteNetwork).Methods(http.MethodDelete)
-	v1.HandleFunc("/networks/{id}/subnets", a.GenerateSubnets).Methods(http.MethodGet)
+	v1.HandleFunc("/networks/{id}/subnets", a.ListSubnets).Methods(http.MethodGet)
+	v1.HandleFunc("/networks/{id}/subnets/{name}", a.DetailSubnet).Methods(http.MethodGet)
+	v1.HandleFunc("/networks/{id}/status", a.NetworkStatus).Methods(http.MethodGet)
+	v1.HandleFunc("/networks/{id}/provider-events", a.ProviderEvent).Methods(http.MethodPost)
+	v1.HandleFunc("/networks/{id}/cidrs", a.AddNetworkCIDR).Methods(http.MethodPost)
+	v1.HandleFunc("/networks/{id}/check", a.CheckNetwork).Methods(http.MethodPost)
 
 	v1.HandleFunc("/pools", a.ListPools).Methods(http.MethodGet)
 	v1.HandleFunc("/pools", a.CreatePool).Methods(http.MethodPost)
+	v1.HandleFunc("/pools/tree", a.PoolTree).Methods(http.MethodGet)
 	v1.HandleFunc("/pools/{id}", a.DetailPool).Methods(http.MethodGet)
+	v1.HandleFunc("/pools/{id}", a.UpdatePool).Methods(http.MethodPut)
 	v1.HandleFunc("/pools/{id}", a.DeletePool).Methods(http.MethodDelete)
+	v1.HandleFunc("/pools/{id}/usage", a.PoolUsage).Methods(http.MethodGet)
+	v1.HandleFunc("/pools/{id}/exclusions", a.UpdatePoolExclusions).Methods(http.MethodPut)
+	v1.HandleFunc("/pools/{id}/labels", a.UpdatePoolLabels).Methods(http.MethodPut)
+	v1.HandleFunc("/pools/{id}/policy", a.UpdatePoolPolicy).Methods(http.MethodPut)
 
 	v1.HandleFunc("/providers", a.ListProviders).Methods(http.MethodGet)
 	v1.HandleFunc("/providers", a.CreateProvider).Methods(http.MethodPost)
 	v1.HandleFunc("/providers/{name}", a.DetailProvider).Methods(http.MethodGet)
 	v1.HandleFunc("/providers/{name}", a.UpdateProvider).Methods(http.MethodPut)
 	v1.HandleFunc("/providers/{name}", a.DeleteProvider).Methods(http.MethodDelete)
+
+	v1.HandleFunc("/layouts", a.ListLayouts).Methods(http.MethodGet)
+	v1.HandleFunc("/layouts", a.CreateLayout).Methods(http.MethodPost)
+	v1.HandleFunc("/layouts/{name}", a.DetailLayout).Methods(http.MethodGet)
+	v1.HandleFunc("/layouts/{name}", a.UpdateLayout).Methods(http.MethodPut)
+	v1.HandleFunc("/layouts/{name}", a.DeleteLayout).Methods(http.MethodDelete)
+
+	v1.HandleFunc("/rules", a.ListRules).Methods(http.MethodGet)
+	v1.HandleFunc("/rules", a.CreateRule).Methods(http.MethodPost)
+	v1.HandleFunc("/rules/evaluate", a.EvaluateRules).Methods(http.MethodPost)
+	v1.HandleFunc("/rules/{name}", a.DetailRule).Methods(http.MethodGet)
+	v1.HandleFunc("/rules/{name}", a.UpdateRule).Methods(http.MethodPut)
+	v1.HandleFunc("/rules/{name}", a.DeleteRule).Methods(http.MethodDelete)
+
+	v1.HandleFunc("/lookup", a.Lookup).Methods(http.MethodGet)
 }
 
 func (a *api) GetHandler() http.Handler {
@@ -71,12 +114,86 @@ func writeJson(w http.ResponseWriter, v interface{}, code int) {
 	}
 }
 
+// writeError responds with err, using the status code of its type when it
+// has one and code otherwise.
 func writeError(w http.ResponseWriter, err error, code int) {
+	resp, code := errorResponse(err, code)
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 	w.WriteHeader(code)
-	e := json.NewEncoder(w).Encode(types.NewSingleErrorResponse(err.Error()))
+	e := json.NewEncoder(w).Encode(resp)
 	if e != nil {
 		log.Printf("failed to write json for error: %v", err)
 	}
 }
+
+func errorResponse(err error, code int) (*types.ErrorResponse, int) {
+	resp := types.NewSingleErrorResponse(err.Error())
+
+	var validationErrs validator.ValidationErrors
+	var overlapErr net.OverlapError
+	var notInPoolErr net.NetworkNotInPoolError
+	var exhaustedErr net.PoolExhaustedError
+	var excludedErr net.ExcludedError
+	var notInParentErr net.PoolNotInParentError
+	var outsideErr net.NetworksOutsidePoolError
+	var inUseErr net.PoolInUseError
+	var noPoolErr net.NoPoolMatchError
+	var policyErr net.PolicyViolationError
+	var ruleErr net.RuleViolationError
+	switch {
+	case errors.As(err, &validationErrs):
+		resp.Code = types.ErrorInvalid
+		resp.Errors = fieldErrors(validationErrs)
+	case errors.Is(err, db.ErrInvalidToken):
+		resp.Code, code = types.ErrorInvalid, http.StatusBadRequest
+	case errors.Is(err, db.ErrNotFound):
+		resp.Code, code = types.ErrorNotFound, http.StatusNotFound
+	case errors.As(err, &overlapErr):
+		resp.Code, code = types.ErrorOverlap, http.StatusConflict
+		resp.Conflicts = overlapErr.Conflicts
+	case errors.As(err, &outsideErr):
+		resp.Code, code = types.ErrorConflict, http.StatusConflict
+		resp.Conflicts = outsideErr.Networks
+	case errors.As(err, &inUseErr):
+		resp.Code, code = types.ErrorConflict, http.StatusConflict
+		resp.Conflicts = inUseErr.Networks
+	case errors.Is(err, db.ErrConflict):
+		resp.Code, code = types.ErrorConflict, http.StatusConflict
+	case errors.As(err, &noPoolErr):
+		// tested first as it wraps the error of the last pool skipped
+		resp.Code, code = types.ErrorNoPoolMatch, http.StatusUnprocessableEntity
+		resp.Skipped = noPoolErr.Skipped
+	case errors.As(err, &notInPoolErr):
+		resp.Code, code = types.ErrorNotInPool, http.StatusUnprocessableEntity
+	case errors.As(err, &notInParentErr):
+		resp.Code, code = types.ErrorNotInPool, http.StatusUnprocessableEntity
+	case errors.As(err, &exhaustedErr):
+		resp.Code, code = types.ErrorPoolExhausted, http.StatusUnprocessableEntity
+	case errors.As(err, &excludedErr):
+		resp.Code, code = types.ErrorExcluded, http.StatusUnprocessableEntity
+	case errors.As(err, &policyErr):
+		resp.Code, code = types.ErrorPolicy, http.StatusUnprocessableEntity
+		resp.Violations = policyErr.Violations
+	case errors.As(err, &ruleErr):
+		resp.Code, code = types.ErrorRule, http.StatusUnprocessableEntity
+		resp.Rules = ruleErr.Results
+	}
+	return resp, code
+}
+
+// fieldErrors keys each failed validation by the path of its field in the
+// request body, e.g. subnets[0].cidr.
+func fieldErrors(errs validator.ValidationErrors) map[string]string {
+	fields := map[string]string{}
+	for _, fe := range errs {
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		tag := fe.Tag()
+		// or'ed tags already carry their params
+		if fe.Param() != "" && !strings.Contains(tag, "|") {
+			tag += "=" + fe.Param()
+		}
+		fields[field] = fmt.Sprintf("failed on the '%s' tag", tag)
+	}
+	return fields
+}
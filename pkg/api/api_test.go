@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	fakeDb "github.com/olxbr/network-api/pkg/db/fake"
+	fakeSecrets "github.com/olxbr/network-api/pkg/secret/fake"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMalformedBody(t *testing.T) {
+	handlers := map[string]func(a *api) http.HandlerFunc{
+		"add network cidr": func(a *api) http.HandlerFunc { return a.AddNetworkCIDR },
+		"create rule":      func(a *api) http.HandlerFunc { return a.CreateRule },
+		"update rule":      func(a *api) http.HandlerFunc { return a.UpdateRule },
+		"evaluate rules":   func(a *api) http.HandlerFunc { return a.EvaluateRules },
+		"create layout":    func(a *api) http.HandlerFunc { return a.CreateLayout },
+		"update layout":    func(a *api) http.HandlerFunc { return a.UpdateLayout },
+		"update provider":  func(a *api) http.HandlerFunc { return a.UpdateProvider },
+	}
+	for name, handler := range handlers {
+		t.Run(name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			db.On("GetRule", mock.Anything, mock.Anything).Return(&types.Rule{}, nil)
+			db.On("GetLayout", mock.Anything, mock.Anything).Return(&types.Layout{}, nil)
+			db.On("GetProvider", mock.Anything, mock.Anything).Return(&types.Provider{}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":`))
+			w := httptest.NewRecorder()
+			a := New(db, &fakeSecrets.Secrets{})
+
+			handler(a)(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			e := &types.ErrorResponse{}
+			err := json.NewDecoder(w.Body).Decode(e)
+			require.NoError(t, err)
+			assert.Equal(t, "unexpected EOF", e.Errors["_all"])
+		})
+	}
+}
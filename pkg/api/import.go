@@ -0,0 +1,241 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+
+	"github.com/olxbr/network-api/pkg/net"
+	"github.com/olxbr/network-api/pkg/provider"
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// ImportNetworks registers the networks a provider already runs in an
+// account as legacy networks, skipping the ones the API knows about or that
+// overlap with its networks. With dryRun it only reports what it would do.
+func (a *api) ImportNetworks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ir := &types.NetworkImportRequest{}
+	err := json.NewDecoder(r.Body).Decode(ir)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = validate.Struct(ir)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	p, err := a.DB.GetPool(ctx, ir.PoolID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	if p.IsIPv6() {
+		writeError(w, fmt.Errorf("pool %s is an IPv6 pool, use ipv6PoolID", ir.PoolID), http.StatusBadRequest)
+		return
+	}
+
+	var p6 *types.Pool
+	if ir.IPv6PoolID != "" {
+		p6, err = a.DB.GetPool(ctx, ir.IPv6PoolID)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+		if !p6.IsIPv6() {
+			writeError(w, fmt.Errorf("pool %s is not an IPv6 pool", ir.IPv6PoolID), http.StatusBadRequest)
+			return
+		}
+		if err := checkSameDomain(p, p6); err != nil {
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	pm := provider.New(a.DB, a.Secrets)
+	pc, err := pm.GetClient(ctx, ir.Provider)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	wh, err := pc.QueryNetworks(ctx, ir.Account, p.Region, ir.Environment)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	networks, err := a.DB.ScanNetworks(ctx)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	known := map[string]bool{}
+	for _, n := range networks {
+		if n.VpcID != "" && n.HoldsAddresses() {
+			known[n.VpcID] = true
+		}
+	}
+
+	selected := map[string]bool{}
+	for _, id := range ir.VpcIDs {
+		selected[id] = true
+	}
+
+	nm := net.New(a.DB)
+	check, err := nm.NewNetworkCheck(ctx, p.RoutingDomain)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	planned := []netip.Prefix{}
+	results := []*types.NetworkImportResult{}
+	for _, dn := range wh.Networks {
+		if len(selected) > 0 && !selected[dn.VpcID] {
+			continue
+		}
+
+		res := &types.NetworkImportResult{
+			VpcID:  dn.VpcID,
+			Name:   dn.Name,
+			CIDR:   dn.CIDR,
+			Action: types.ImportSkip,
+		}
+		results = append(results, res)
+
+		if dn.NetworkID != "" || known[dn.VpcID] {
+			res.Reason = "already registered"
+			continue
+		}
+		// default VPCs share the same CIDR in every region
+		if dn.Default && len(selected) == 0 {
+			res.Reason = "default VPC, select it with vpcIDs to import"
+			continue
+		}
+
+		prefixes, err := importPrefixes(dn, p6 != nil)
+		if err != nil {
+			res.Reason = err.Error()
+			continue
+		}
+		if len(prefixes) > 1 {
+			res.IPv6CIDR = prefixes[1].String()
+		}
+
+		err = checkImport(check, []*types.Pool{p, p6}, prefixes, planned)
+		if err != nil {
+			res.Reason = err.Error()
+			continue
+		}
+		planned = append(planned, prefixes...)
+
+		res.Action = types.ImportCreate
+		if ir.DryRun {
+			continue
+		}
+
+		n, err := a.importNetwork(ctx, nm, ir, dn, prefixes, p, p6)
+		if err != nil {
+			res.Action = types.ImportSkip
+			res.Reason = err.Error()
+			continue
+		}
+		res.NetworkID = n.ID
+	}
+
+	writeJson(w, types.NetworkImportResponse{
+		DryRun: ir.DryRun,
+		Items:  results,
+	}, http.StatusOK)
+}
+
+// importPrefixes parses the blocks of a discovered network. The IPv6 block
+// is only imported along with an IPv6 pool.
+func importPrefixes(dn *types.DiscoveredNetwork, withIPv6 bool) ([]netip.Prefix, error) {
+	v4, err := netip.ParsePrefix(dn.CIDR)
+	if err != nil {
+		return nil, err
+	}
+	prefixes := []netip.Prefix{v4.Masked()}
+
+	if withIPv6 && dn.IPv6CIDR != "" {
+		v6, err := netip.ParsePrefix(dn.IPv6CIDR)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, v6.Masked())
+	}
+	return prefixes, nil
+}
+
+// checkImport makes sure the prefixes lie in their pool and are free in the
+// routing domain, both among the networks the API knows about and the ones
+// picked earlier in the same import.
+func checkImport(check *net.NetworkCheck, pools []*types.Pool, prefixes, planned []netip.Prefix) error {
+	for i, prefix := range prefixes {
+		err := net.CheckInPool(pools[i], prefix)
+		if err != nil {
+			return err
+		}
+		err = check.Check(prefix)
+		if err != nil {
+			return err
+		}
+		for _, other := range planned {
+			if other.Overlaps(prefix) {
+				return fmt.Errorf("network %s overlaps with %s from this import", prefix.String(), other.String())
+			}
+		}
+	}
+	return nil
+}
+
+// importNetwork reserves the prefixes and stores the discovered network as
+// an active legacy network.
+func (a *api) importNetwork(ctx context.Context, nm *net.NetworkManager, ir *types.NetworkImportRequest, dn *types.DiscoveredNetwork, prefixes []netip.Prefix, p, p6 *types.Pool) (*types.Network, error) {
+	n := &types.Network{
+		ID:          types.NewUUID(),
+		Provider:    ir.Provider,
+		Region:      p.Region,
+		Account:     ir.Account,
+		Environment: ir.Environment,
+		VpcID:       dn.VpcID,
+		Info:        dn.Name,
+		Legacy:      true,
+
+		RoutingDomain: p.RoutingDomain,
+	}
+
+	pools := []*types.Pool{p, p6}
+	for i, prefix := range prefixes {
+		err := nm.ReserveNetwork(ctx, n, pools[i], prefix)
+		if err != nil {
+			for _, reserved := range prefixes[:i] {
+				if err := nm.ReleaseNetwork(ctx, n.RoutingDomain, reserved); err != nil {
+					log.Printf("failed to release network %s: %v", reserved.String(), err)
+				}
+			}
+			return nil, err
+		}
+	}
+
+	n.CIDR = prefixes[0].String()
+	if len(prefixes) > 1 {
+		n.IPv6CIDR = prefixes[1].String()
+	}
+	n.SetStatus(types.StatusActive, "imported from "+dn.VpcID)
+
+	err := a.DB.PutNetwork(ctx, n)
+	if err != nil {
+		releaseNetwork(ctx, nm, n)
+		return nil, err
+	}
+	return n, nil
+}
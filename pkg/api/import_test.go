@@ -0,0 +1,226 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	fakeDb "github.com/olxbr/network-api/pkg/db/fake"
+	fakeSecrets "github.com/olxbr/network-api/pkg/secret/fake"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanImportNetworks(t *testing.T) {
+	pool := &types.Pool{
+		ID:         types.NewUUID(),
+		Region:     "us-east-1",
+		SubnetIP:   "10.0.0.0",
+		SubnetMask: types.Int(8),
+	}
+	ipv6Pool := &types.Pool{
+		ID:         types.NewUUID(),
+		Region:     "us-east-1",
+		SubnetIP:   "2600:1f18:1000::",
+		SubnetMask: types.Int(40),
+	}
+	discovered := []*types.DiscoveredNetwork{
+		{VpcID: "vpc-1", Name: "payments", CIDR: "10.50.0.0/16", IPv6CIDR: "2600:1f18:1000:100::/56"},
+		{VpcID: "vpc-2", CIDR: "10.20.0.0/16", NetworkID: "f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0"},
+		{VpcID: "vpc-3", CIDR: "172.31.0.0/16", Default: true},
+		{VpcID: "vpc-4", CIDR: "10.10.0.0/16"},
+		{VpcID: "vpc-5", CIDR: "10.50.0.0/20"},
+		{VpcID: "vpc-6", CIDR: "10.60.0.0/16"},
+		{VpcID: "vpc-7", CIDR: "10.80.0.0/16", IPv6CIDR: "2600:1f18:2000::/56"},
+	}
+	provider := func(w http.ResponseWriter, r *http.Request) {
+		pw := &types.ProviderWebhook{}
+		_ = json.NewDecoder(r.Body).Decode(pw)
+		if pw.Event != types.QueryNetwork || pw.Region != "us-east-1" || pw.Account != "123" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(&types.ProviderWebhookResponse{
+			StatusCode: 200,
+			Networks:   discovered,
+		})
+	}
+	existing := []*types.Network{
+		{ID: types.NewUUID(), CIDR: "10.10.0.0/16", Status: types.StatusActive},
+		{ID: types.NewUUID(), CIDR: "10.70.0.0/16", VpcID: "vpc-6", Status: types.StatusActive},
+	}
+	prepareQuery := func(db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+		db.On("GetPool", mock.Anything, pool.ID.String()).Return(pool, nil)
+		db.On("GetPool", mock.Anything, ipv6Pool.ID.String()).Return(ipv6Pool, nil)
+		db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+			WebhookURL: url,
+		}, nil)
+		s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+		db.On("ScanNetworks", mock.Anything).Return(existing, nil)
+		db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+		db.On("ScanPools", mock.Anything).Return([]*types.Pool{pool, ipv6Pool}, nil)
+	}
+	reasons := func(items []*types.NetworkImportResult) map[string]string {
+		m := map[string]string{}
+		for _, i := range items {
+			m[i.VpcID] = fmt.Sprintf("%s %s", i.Action, i.Reason)
+		}
+		return m
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		provider http.HandlerFunc
+		prepare  func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string)
+		assert   func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "dry run report",
+			body:     fmt.Sprintf(`{"provider":"aws","account":"123","environment":"prod","poolID":"%s","dryRun":true}`, pool.ID.String()),
+			provider: provider,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				prepareQuery(db, s, url)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				db.AssertNotCalled(t, "PutNetwork", mock.Anything, mock.Anything)
+				require.Equal(t, http.StatusOK, w.Code)
+				ir := &types.NetworkImportResponse{}
+				err := json.NewDecoder(w.Body).Decode(ir)
+				require.NoError(t, err)
+				assert.True(t, ir.DryRun)
+				assert.Equal(t, map[string]string{
+					"vpc-1": "import ",
+					"vpc-2": "skip already registered",
+					"vpc-3": "skip default VPC, select it with vpcIDs to import",
+					"vpc-4": fmt.Sprintf("skip network 10.10.0.0/16 overlaps with 10.10.0.0/16 (network %s)", existing[0].ID),
+					"vpc-5": "skip network 10.50.0.0/20 overlaps with 10.50.0.0/16 from this import",
+					"vpc-6": "skip already registered",
+					"vpc-7": "import ",
+				}, reasons(ir.Items))
+				assert.Empty(t, ir.Items[0].IPv6CIDR)
+				db.AssertNumberOfCalls(t, "ScanReservations", 1)
+				db.AssertNumberOfCalls(t, "ScanPools", 1)
+			},
+		},
+		{
+			name:     "import selected networks",
+			body:     fmt.Sprintf(`{"provider":"aws","account":"123","environment":"prod","poolID":"%s","ipv6PoolID":"%s","vpcIDs":["vpc-1","vpc-3","vpc-7"]}`, pool.ID.String(), ipv6Pool.ID.String()),
+			provider: provider,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				prepareQuery(db, s, url)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.MatchedBy(func(r *types.Reservation) bool {
+					return r.CIDR == "10.50.0.0/16"
+				}), pool).Return(nil).Once()
+				db.On("ReserveNetwork", mock.Anything, mock.MatchedBy(func(r *types.Reservation) bool {
+					return r.CIDR == "2600:1f18:1000:100::/56"
+				}), ipv6Pool).Return(nil).Once()
+				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+					return n.Legacy && n.Status == types.StatusActive && n.Region == "us-east-1" &&
+						n.Environment == "prod" && n.Account == "123"
+				})).Return(nil).Once()
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				require.Equal(t, http.StatusOK, w.Code)
+				ir := &types.NetworkImportResponse{}
+				err := json.NewDecoder(w.Body).Decode(ir)
+				require.NoError(t, err)
+				assert.False(t, ir.DryRun)
+				require.Len(t, ir.Items, 3)
+				assert.Equal(t, types.ImportCreate, ir.Items[0].Action)
+				assert.Equal(t, "2600:1f18:1000:100::/56", ir.Items[0].IPv6CIDR)
+				assert.NotNil(t, ir.Items[0].NetworkID)
+				// selected networks outside the pools are reported, not imported
+				assert.Equal(t, map[string]string{
+					"vpc-1": "import ",
+					"vpc-3": "skip network 172.31.0.0/16 not in pool range 10.0.0.0-10.255.255.255",
+					"vpc-7": "skip network 2600:1f18:2000::/56 not in pool range 2600:1f18:1000::-2600:1f18:10ff:ffff:ffff:ffff:ffff:ffff",
+				}, reasons(ir.Items))
+			},
+		},
+		{
+			name:     "reservation conflict",
+			body:     fmt.Sprintf(`{"provider":"aws","account":"123","environment":"prod","poolID":"%s","vpcIDs":["vpc-1"]}`, pool.ID.String()),
+			provider: provider,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				prepareQuery(db, s, url)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, pool).Return(fmt.Errorf("conflict"))
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutNetwork", mock.Anything, mock.Anything)
+				require.Equal(t, http.StatusOK, w.Code)
+				ir := &types.NetworkImportResponse{}
+				err := json.NewDecoder(w.Body).Decode(ir)
+				require.NoError(t, err)
+				assert.Equal(t, map[string]string{"vpc-1": "skip conflict"}, reasons(ir.Items))
+			},
+		},
+		{
+			name: "provider fails",
+			body: fmt.Sprintf(`{"provider":"aws","account":"123","environment":"prod","poolID":"%s"}`, pool.ID.String()),
+			provider: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				prepareQuery(db, s, url)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, w.Code)
+				assert.Contains(t, w.Body.String(), "error querying networks: 500 Internal Server Error")
+			},
+		},
+		{
+			name: "ipv6 pool as pool",
+			body: fmt.Sprintf(`{"provider":"aws","account":"123","environment":"prod","poolID":"%s"}`, ipv6Pool.ID.String()),
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				db.On("GetPool", mock.Anything, ipv6Pool.ID.String()).Return(ipv6Pool, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "GetProvider", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "missing account",
+			body: fmt.Sprintf(`{"provider":"aws","environment":"prod","poolID":"%s"}`, pool.ID.String()),
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, `{"code":"invalid","errors":{"account":"failed on the 'required' tag"}}`+"\n", w.Body.String())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var url string
+			if tt.provider != nil {
+				server := httptest.NewServer(tt.provider)
+				defer server.Close()
+				url = server.URL
+			}
+
+			db := &fakeDb.Database{}
+			s := &fakeSecrets.Secrets{}
+			tt.prepare(t, db, s, url)
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			api := New(db, s)
+
+			api.ImportNetworks(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/olxbr/network-api/pkg/db"
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+func (a *api) ListLayouts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	o, err := listOptions(r.URL.Query(), layoutSorts)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	layouts, next, err := a.DB.ListLayouts(ctx, o)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	sortItems(layouts, o, layoutSorts)
+
+	writeJson(w, types.LayoutListResponse{
+		Items:     layouts,
+		NextToken: next,
+	}, http.StatusOK)
+}
+
+func (a *api) CreateLayout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	lr := &types.LayoutRequest{}
+	err := json.NewDecoder(r.Body).Decode(lr)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = validate.Struct(lr)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	_, err = a.DB.GetLayout(ctx, lr.Name)
+	if err == nil {
+		writeError(w, fmt.Errorf("layout %s already exists: %w", lr.Name, db.ErrConflict), http.StatusConflict)
+		return
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	l := &types.Layout{
+		Name:    lr.Name,
+		AZCount: lr.AZCount,
+		Tiers:   lr.Tiers,
+	}
+	err = a.DB.PutLayout(ctx, l)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, l, http.StatusCreated)
+}
+
+func (a *api) DetailLayout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+	l, err := a.DB.GetLayout(ctx, params["name"])
+
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, l, http.StatusOK)
+}
+
+// UpdateLayout replaces the layout tiers. Networks keep the layout they were
+// created with.
+func (a *api) UpdateLayout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	l, err := a.DB.GetLayout(ctx, params["name"])
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	lr := &types.LayoutRequest{}
+	err = json.NewDecoder(r.Body).Decode(lr)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	lr.Name = l.Name
+
+	err = validate.Struct(lr)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	l.AZCount = lr.AZCount
+	l.Tiers = lr.Tiers
+	err = a.DB.PutLayout(ctx, l)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, l, http.StatusOK)
+}
+
+func (a *api) DeleteLayout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	l, err := a.DB.GetLayout(ctx, params["name"])
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = a.DB.DeleteLayout(ctx, l.Name)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, l, http.StatusOK)
+}
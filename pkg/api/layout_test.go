@@ -0,0 +1,209 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	dbpkg "github.com/olxbr/network-api/pkg/db"
+	fakeDb "github.com/olxbr/network-api/pkg/db/fake"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanListLayouts(t *testing.T) {
+	db := &fakeDb.Database{}
+	db.On("ListLayouts", mock.Anything, mock.Anything).Return([]*types.Layout{
+		{Name: "small", AZCount: 2},
+		{Name: "default", AZCount: 3},
+	}, "", nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/?sort=name", nil)
+	w := httptest.NewRecorder()
+	api := New(db, nil)
+
+	api.ListLayouts(w, req)
+
+	db.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, w.Code)
+	l := &types.LayoutListResponse{}
+	err := json.NewDecoder(w.Body).Decode(l)
+	require.NoError(t, err)
+	require.Len(t, l.Items, 2)
+	assert.Equal(t, "default", l.Items[0].Name)
+	assert.Equal(t, "small", l.Items[1].Name)
+}
+
+func TestCanCreateLayout(t *testing.T) {
+	valid := types.LayoutRequest{
+		Name:    "default",
+		AZCount: 3,
+		Tiers: []*types.LayoutTier{
+			{Name: "private", Type: types.Private, Weight: 2},
+			{Name: "public", Type: types.Public, Weight: 1},
+			{Name: "tgw", Type: types.TransitGateway, PrefixLength: 28},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		payload interface{}
+		prepare func(t *testing.T, db *fakeDb.Database)
+		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "missing payload data",
+			payload: types.LayoutRequest{},
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"name":"failed on the 'required' tag"`)
+				assert.Contains(t, w.Body.String(), `"azCount":"failed on the 'required' tag"`)
+				assert.Contains(t, w.Body.String(), `"tiers":"failed on the 'required' tag"`)
+			},
+		},
+		{
+			name: "invalid tiers",
+			payload: types.LayoutRequest{
+				Name:    "broken",
+				AZCount: 7,
+				Tiers: []*types.LayoutTier{
+					{Name: "private", Type: types.Private},
+					{Name: "public", Type: "dmz", PrefixLength: 24, Weight: 1, NamePattern: "public"},
+				},
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"azCount":"failed on the 'max=6' tag"`)
+				assert.Contains(t, w.Body.String(), `"tiers[0].prefixLength":"failed on the 'required_without=Weight' tag"`)
+				assert.Contains(t, w.Body.String(), `"tiers[1].type":"failed on the 'oneof=private public transitGateway isolated' tag"`)
+				assert.Contains(t, w.Body.String(), `"tiers[1].prefixLength":"failed on the 'excluded_with=Weight' tag"`)
+				assert.Contains(t, w.Body.String(), `"tiers[1].namePattern":"failed on the 'contains={az}|contains={zone}' tag"`)
+			},
+		},
+		{
+			name:    "layout already exists",
+			payload: valid,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetLayout", mock.Anything, "default").Return(&types.Layout{Name: "default"}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutLayout", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusConflict, w.Code)
+				assert.Contains(t, w.Body.String(), "layout default already exists")
+			},
+		},
+		{
+			name:    "valid payload data",
+			payload: valid,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetLayout", mock.Anything, "default").Return(nil, dbpkg.NotFoundError{Kind: "layout", ID: "default"})
+				db.On("PutLayout", mock.Anything, mock.MatchedBy(func(l *types.Layout) bool {
+					return l.Name == "default" && l.AZCount == 3 && len(l.Tiers) == 3
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusCreated, w.Code)
+				l := &types.Layout{}
+				err := json.NewDecoder(w.Body).Decode(l)
+				require.NoError(t, err)
+				assert.Equal(t, "default", l.Name)
+				assert.Equal(t, 28, l.Tiers[2].PrefixLength)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			tt.prepare(t, db)
+
+			payload := &bytes.Buffer{}
+			err := json.NewEncoder(payload).Encode(tt.payload)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/", payload)
+			w := httptest.NewRecorder()
+			api := New(db, nil)
+
+			api.CreateLayout(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
+
+func TestCanUpdateLayout(t *testing.T) {
+	db := &fakeDb.Database{}
+	db.On("GetLayout", mock.Anything, "default").Return(&types.Layout{
+		Name:    "default",
+		AZCount: 3,
+		Tiers:   []*types.LayoutTier{{Name: "private", Type: types.Private, Weight: 1}},
+	}, nil)
+	db.On("PutLayout", mock.Anything, mock.MatchedBy(func(l *types.Layout) bool {
+		return l.Name == "default" && l.AZCount == 2 && l.Tiers[0].Type == types.Isolated
+	})).Return(nil)
+
+	payload := &bytes.Buffer{}
+	err := json.NewEncoder(payload).Encode(types.LayoutRequest{
+		AZCount: 2,
+		Tiers:   []*types.LayoutTier{{Name: "data", Type: types.Isolated, Weight: 1}},
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPut, "/", payload)
+	req = mux.SetURLVars(req, map[string]string{"name": "default"})
+	w := httptest.NewRecorder()
+	api := New(db, nil)
+
+	api.UpdateLayout(w, req)
+
+	db.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCanDeleteLayout(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, db *fakeDb.Database)
+		code    int
+	}{
+		{
+			name: "valid delete",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetLayout", mock.Anything, "default").Return(&types.Layout{Name: "default"}, nil)
+				db.On("DeleteLayout", mock.Anything, "default").Return(nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "layout not found",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetLayout", mock.Anything, "default").Return(nil, dbpkg.NotFoundError{Kind: "layout", ID: "default"})
+			},
+			code: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			tt.prepare(t, db)
+
+			req := httptest.NewRequest(http.MethodDelete, "/", nil)
+			req = mux.SetURLVars(req, map[string]string{"name": "default"})
+			w := httptest.NewRecorder()
+			api := New(db, nil)
+
+			api.DeleteLayout(w, req)
+
+			db.AssertExpectations(t)
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+var networkSorts = map[string]func(a, b *types.Network) int{
+	"provider":    func(a, b *types.Network) int { return strings.Compare(a.Provider, b.Provider) },
+	"region":      func(a, b *types.Network) int { return strings.Compare(a.Region, b.Region) },
+	"account":     func(a, b *types.Network) int { return strings.Compare(a.Account, b.Account) },
+	"environment": func(a, b *types.Network) int { return strings.Compare(a.Environment, b.Environment) },
+	"status":      func(a, b *types.Network) int { return strings.Compare(string(a.Status), string(b.Status)) },
+	"cidr":        func(a, b *types.Network) int { return compareCIDR(a.CIDR, b.CIDR) },
+}
+
+var poolSorts = map[string]func(a, b *types.Pool) int{
+	"name":   func(a, b *types.Pool) int { return strings.Compare(a.Name, b.Name) },
+	"region": func(a, b *types.Pool) int { return strings.Compare(a.Region, b.Region) },
+	"cidr":   func(a, b *types.Pool) int { return compareCIDR(a.SubnetIP, b.SubnetIP) },
+}
+
+var providerSorts = map[string]func(a, b *types.Provider) int{
+	"name": func(a, b *types.Provider) int { return strings.Compare(a.Name, b.Name) },
+}
+
+var layoutSorts = map[string]func(a, b *types.Layout) int{
+	"name": func(a, b *types.Layout) int { return strings.Compare(a.Name, b.Name) },
+}
+
+var ruleSorts = map[string]func(a, b *types.Rule) int{
+	"name": func(a, b *types.Rule) int { return strings.Compare(a.Name, b.Name) },
+}
+
+// listOptions reads the paging and sorting parameters, sort must name one of
+// the given comparisons.
+func listOptions[T any](q url.Values, sorts map[string]func(a, b T) int) (*types.ListOptions, error) {
+	o := &types.ListOptions{
+		NextToken: q.Get("nextToken"),
+		Sort:      q.Get("sort"),
+		Order:     types.SortOrder(q.Get("order")),
+	}
+	if l := q.Get("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit %q", l)
+		}
+		o.Limit = limit
+	}
+
+	err := validate.Struct(o)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := sorts[o.Sort]; o.Sort != "" && !ok {
+		keys := slices.Sorted(maps.Keys(sorts))
+		return nil, fmt.Errorf("invalid sort %q, use one of %s", o.Sort, strings.Join(keys, ", "))
+	}
+	return o, nil
+}
+
+// sortItems orders a page, sorting only applies within the page returned.
+func sortItems[T any](items []T, o *types.ListOptions, sorts map[string]func(a, b T) int) {
+	compare, ok := sorts[o.Sort]
+	if !ok {
+		return
+	}
+	slices.SortStableFunc(items, func(a, b T) int {
+		if o.Order == types.SortDesc {
+			return compare(b, a)
+		}
+		return compare(a, b)
+	})
+}
+
+func networkFilter(q url.Values) (*types.NetworkFilter, error) {
+	f := &types.NetworkFilter{
+		Provider:    q.Get("provider"),
+		Region:      q.Get("region"),
+		Account:     q.Get("account"),
+		Environment: q.Get("environment"),
+		PoolID:      q.Get("poolID"),
+		Contains:    q.Get("contains"),
+	}
+	err := validate.Struct(f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// networkMatcher checks the filters the database cannot: the pool, through
+// the reservations made in it, and the addresses a network contains.
+func (a *api) networkMatcher(ctx context.Context, f *types.NetworkFilter) (func(n *types.Network) bool, error) {
+	var inPool map[string]bool
+	if f.PoolID != "" {
+		reservations, err := a.DB.ScanReservations(ctx)
+		if err != nil {
+			return nil, err
+		}
+		inPool = map[string]bool{}
+		for _, r := range reservations {
+			if r.PoolID == f.PoolID {
+				inPool[r.NetworkID] = true
+			}
+		}
+	}
+
+	var contains netip.Prefix
+	if f.Contains != "" {
+		var err error
+		contains, err = parsePrefixOrAddr(f.Contains)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return func(n *types.Network) bool {
+		if inPool != nil && (n.ID == nil || !inPool[n.ID.String()]) {
+			return false
+		}
+		if contains.IsValid() {
+			return slices.ContainsFunc(n.Prefixes(), func(p netip.Prefix) bool {
+				return p.Bits() <= contains.Bits() && p.Contains(contains.Addr())
+			})
+		}
+		return true
+	}, nil
+}
+
+// parsePrefixOrAddr reads a CIDR, or a single address as a host prefix.
+func parsePrefixOrAddr(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		return p.Masked(), err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// compareCIDR orders networks by address then size, falling back to the
+// text for anything that does not parse.
+func compareCIDR(a, b string) int {
+	pa, errA := parsePrefixOrAddr(a)
+	pb, errB := parsePrefixOrAddr(b)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	if c := pa.Addr().Compare(pb.Addr()); c != 0 {
+		return c
+	}
+	return cmp.Compare(pa.Bits(), pb.Bits())
+}
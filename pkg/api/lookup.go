@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/olxbr/network-api/pkg/net"
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// Lookup tells which pool, network and subnet own the ip or cidr given in
+// the query, or the free range around them when nothing does. Without
+// routingDomain every domain holding them is reported.
+func (a *api) Lookup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	lr := &types.LookupRequest{
+		IP:   q.Get("ip"),
+		CIDR: q.Get("cidr"),
+
+		RoutingDomain: q.Get("routingDomain"),
+	}
+	err := validate.Struct(lr)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	query := lr.IP
+	if lr.CIDR != "" {
+		query = lr.CIDR
+	}
+	prefix, err := parsePrefixOrAddr(query)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	nm := net.New(a.DB)
+	var items []*types.LookupResponse
+	if lr.RoutingDomain != "" {
+		var res *types.LookupResponse
+		res, err = nm.Lookup(ctx, lr.RoutingDomain, prefix)
+		items = []*types.LookupResponse{res}
+	} else {
+		items, err = nm.LookupAll(ctx, prefix)
+	}
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, types.LookupListResponse{Items: items}, http.StatusOK)
+}
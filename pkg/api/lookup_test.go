@@ -0,0 +1,165 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	fakeDb "github.com/olxbr/network-api/pkg/db/fake"
+	fakeSecrets "github.com/olxbr/network-api/pkg/secret/fake"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	pool := &types.Pool{
+		ID:         types.NewUUID(),
+		Name:       "prod",
+		SubnetIP:   "10.0.0.0",
+		SubnetMask: types.Int(16),
+	}
+	network := &types.Network{
+		ID:            types.NewUUID(),
+		CIDR:          "10.0.0.0/20",
+		PrivateSubnet: true,
+		Status:        types.StatusActive,
+	}
+	prepareScan := func(db *fakeDb.Database) {
+		db.On("ScanPools", mock.Anything).Return([]*types.Pool{pool}, nil)
+		db.On("ScanNetworks", mock.Anything).Return([]*types.Network{network}, nil)
+		db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		prepare func(t *testing.T, db *fakeDb.Database)
+		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "ip in a network",
+			query: "ip=10.0.4.20",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				prepareScan(db)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				resp := &types.LookupListResponse{}
+				err := json.NewDecoder(w.Body).Decode(resp)
+				require.NoError(t, err)
+				require.Len(t, resp.Items, 1)
+				lr := resp.Items[0]
+				assert.Equal(t, "10.0.4.20/32", lr.Query)
+				assert.Equal(t, types.LookupAllocated, lr.Status)
+				assert.Equal(t, pool.ID, lr.Pool.ID)
+				assert.Equal(t, network.ID, lr.Network.ID)
+				assert.Equal(t, "private02", lr.Subnet.Name)
+				assert.Equal(t, 1, *lr.AZIndex)
+			},
+		},
+		{
+			name:  "unallocated cidr",
+			query: "cidr=10.0.20.0/22",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				prepareScan(db)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				resp := &types.LookupListResponse{}
+				err := json.NewDecoder(w.Body).Decode(resp)
+				require.NoError(t, err)
+				require.Len(t, resp.Items, 1)
+				lr := resp.Items[0]
+				assert.Equal(t, "10.0.20.0/22", lr.Query)
+				assert.Equal(t, types.LookupUnallocated, lr.Status)
+				assert.Nil(t, lr.Network)
+				assert.Equal(t, "10.0.16.0-10.0.255.255", lr.FreeRange)
+			},
+		},
+		{
+			name:  "ip in every routing domain",
+			query: "ip=10.0.4.20",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{
+					pool,
+					{ID: types.NewUUID(), Name: "lab", SubnetIP: "10.0.0.0", SubnetMask: types.Int(16), RoutingDomain: "sandbox"},
+				}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{network}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				resp := &types.LookupListResponse{}
+				err := json.NewDecoder(w.Body).Decode(resp)
+				require.NoError(t, err)
+				require.Len(t, resp.Items, 2)
+				assert.Equal(t, types.LookupAllocated, resp.Items[0].Status)
+				assert.Equal(t, "sandbox", resp.Items[1].RoutingDomain)
+				assert.Equal(t, types.LookupUnallocated, resp.Items[1].Status)
+				assert.Equal(t, "lab", resp.Items[1].Pool.Name)
+			},
+		},
+		{
+			name:  "ip in a routing domain",
+			query: "ip=10.0.4.20&routingDomain=sandbox",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				prepareScan(db)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				resp := &types.LookupListResponse{}
+				err := json.NewDecoder(w.Body).Decode(resp)
+				require.NoError(t, err)
+				require.Len(t, resp.Items, 1)
+				assert.Equal(t, "sandbox", resp.Items[0].RoutingDomain)
+				assert.Equal(t, types.LookupUnallocated, resp.Items[0].Status)
+				assert.Nil(t, resp.Items[0].Pool)
+			},
+		},
+		{
+			name:    "missing query",
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "ScanPools", mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, `{"code":"invalid","errors":{"ip":"failed on the 'required_without=CIDR' tag"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name:    "ip and cidr",
+			query:   "ip=10.0.4.20&cidr=10.0.0.0/20",
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"ip":"failed on the 'excluded_with=CIDR' tag"`)
+			},
+		},
+		{
+			name:    "invalid ip",
+			query:   "ip=10.0.4",
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"ip":"failed on the 'ip' tag"`)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			s := &fakeSecrets.Secrets{}
+			tt.prepare(t, db)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/lookup?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			api := New(db, s)
+
+			api.Lookup(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
@@ -1,29 +1,82 @@
 package api
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/olxbr/network-api/pkg/db"
 	"github.com/olxbr/network-api/pkg/net"
 	"github.com/olxbr/network-api/pkg/provider"
 	"github.com/olxbr/network-api/pkg/types"
 )
 
+// defaultIPv6SubnetSize is the size of the IPv6 block allocated to a
+// dual-stack network when the request does not set one.
+const defaultIPv6SubnetSize = 56
+
+// checkConcurrency bounds how many networks a bulk check sends to the
+// providers at once.
+const checkConcurrency = 10
+
 func (a *api) ListNetworks(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	nets, err := a.DB.ScanNetworks(ctx)
+	q := r.URL.Query()
 
+	o, err := listOptions(q, networkSorts)
 	if err != nil {
 		writeError(w, err, http.StatusBadRequest)
 		return
 	}
+	f, err := networkFilter(q)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	match, err := a.networkMatcher(ctx, f)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	// filters are applied after the database read its limit of items, keep
+	// reading until the page is full or the table exhausted
+	nets := []*types.Network{}
+	page := *o
+	var next string
+	for {
+		listed, token, err := a.DB.ListNetworks(ctx, f, &page)
+		if err != nil {
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
+		nets = append(nets, slices.DeleteFunc(listed, func(n *types.Network) bool {
+			return !match(n)
+		})...)
+
+		next = token
+		if o.Limit == 0 || next == "" || len(nets) >= o.Limit {
+			break
+		}
+		page.NextToken = next
+		page.Limit = o.Limit - len(nets)
+	}
+	sortItems(nets, o, networkSorts)
 
 	writeJson(w, types.NetworkListResponse{
-		Items: nets,
+		Items:     nets,
+		NextToken: next,
 	}, http.StatusOK)
 }
 
@@ -33,7 +86,7 @@ func (a *api) CreateNetwork(w http.ResponseWriter, r *http.Request) {
 	nr := &types.NetworkRequest{}
 	err := json.NewDecoder(r.Body).Decode(nr)
 	if err != nil {
-		writeError(w, err, http.StatusInternalServerError)
+		writeError(w, err, http.StatusBadRequest)
 		return
 	}
 
@@ -51,20 +104,90 @@ func (a *api) CreateNetwork(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	p, err := a.DB.GetPool(ctx, nr.PoolID)
-	if err != nil {
-		writeError(w, err, http.StatusInternalServerError)
-		return
+	// without a pool ID the pool is picked once the CIDR is known
+	var p *types.Pool
+	if nr.PoolID != "" {
+		p, err = a.DB.GetPool(ctx, nr.PoolID)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+		if p.IsIPv6() {
+			writeError(w, fmt.Errorf("pool %s is an IPv6 pool, use ipv6PoolID", nr.PoolID), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var p6 *types.Pool
+	if nr.IPv6PoolID != "" {
+		p6, err = a.DB.GetPool(ctx, nr.IPv6PoolID)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+		if !p6.IsIPv6() {
+			writeError(w, fmt.Errorf("pool %s is not an IPv6 pool", nr.IPv6PoolID), http.StatusBadRequest)
+			return
+		}
+		if p != nil {
+			if err := checkSameDomain(p, p6); err != nil {
+				writeError(w, err, http.StatusBadRequest)
+				return
+			}
+		}
 	}
 
+	var s *net.PoolSelector
+	if p == nil {
+		s = poolSelector(nr, p6)
+		if d := s.Labels[types.RoutingDomainLabel]; p6 != nil && d != p6.RoutingDomain {
+			writeError(w, fmt.Errorf("pool %s is in routing domain %q, not %q of the selector", p6.Name, p6.RoutingDomain, d), http.StatusBadRequest)
+			return
+		}
+	}
+	var selection *types.PoolSelection
+
 	n := &types.Network{
 		ID:          types.NewUUID(),
 		Account:     nr.Account,
-		Region:      p.Region,
 		Provider:    nr.Provider,
 		Environment: nr.Environment,
 		Info:        nr.Info,
 	}
+	if p != nil {
+		n.Region, n.RoutingDomain = p.Region, p.RoutingDomain
+	}
+
+	// the network keeps its own copy, later layout changes do not reach it
+	if nr.Layout != "" {
+		n.Layout, err = a.DB.GetLayout(ctx, nr.Layout)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// rules are checked before anything is allocated, against each pool
+	// tried when the selector picks it
+	rules, _, err := a.DB.ListRules(ctx, nil)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	ruleInput := net.RuleInput{Request: nr, Pool: p, Provider: pc.Provider, Layout: n.Layout}
+	if s != nil {
+		s.Check = func(p *types.Pool) error {
+			in := ruleInput
+			in.Pool = p
+			return net.CheckRules(rules, in)
+		}
+	} else {
+		err = net.CheckRules(rules, ruleInput)
+		if err != nil {
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
 
 	if nr.AttachTGW != nil {
 		n.AttachTGW = types.ToBool(nr.AttachTGW)
@@ -83,6 +206,11 @@ func (a *api) CreateNetwork(w http.ResponseWriter, r *http.Request) {
 		n.Reserved = types.ToBool(nr.Reserved)
 	}
 
+	if (n.Reserved || n.Legacy) && len(nr.Subnets) > 0 {
+		writeError(w, fmt.Errorf("reserved and legacy networks take no subnets"), http.StatusBadRequest)
+		return
+	}
+
 	if n.Reserved || n.Legacy {
 		ipprefix, err := netip.ParsePrefix(nr.CIDR)
 		if err != nil {
@@ -90,30 +218,141 @@ func (a *api) CreateNetwork(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		err = nm.CheckNetwork(ctx, ipprefix)
+		if s != nil {
+			p, selection, err = nm.SelectPoolOf(ctx, *s, ipprefix)
+			if err == nil && p6 != nil {
+				err = checkSameDomain(p, p6)
+			}
+			if err != nil {
+				writeError(w, err, http.StatusBadRequest)
+				return
+			}
+			n.Region, n.RoutingDomain = p.Region, p.RoutingDomain
+		}
+
+		// legacy networks may predate the pool, reservations must fit in it
+		if n.Reserved {
+			err = net.CheckInPool(p, ipprefix)
+			if err != nil {
+				writeError(w, err, http.StatusBadRequest)
+				return
+			}
+		}
+
+		err = nm.ReserveNetwork(ctx, n, p, ipprefix)
 		if err != nil {
 			writeError(w, err, http.StatusBadRequest)
 			return
 		}
 		n.CIDR = ipprefix.String()
+
+		if nr.IPv6CIDR != "" {
+			ipv6prefix, err := netip.ParsePrefix(nr.IPv6CIDR)
+			if err != nil {
+				releaseNetwork(ctx, nm, n)
+				writeError(w, err, http.StatusBadRequest)
+				return
+			}
+
+			if n.Reserved && p6 != nil {
+				err = net.CheckInPool(p6, ipv6prefix)
+			}
+			if err == nil {
+				err = nm.ReserveNetwork(ctx, n, p6, ipv6prefix)
+			}
+			if err != nil {
+				releaseNetwork(ctx, nm, n)
+				writeError(w, err, http.StatusBadRequest)
+				return
+			}
+			n.IPv6CIDR = ipv6prefix.String()
+		}
 	} else {
-		ipprefix, err := nm.AllocateNetwork(ctx, nr.PoolID, int(nr.SubnetSize))
+		var ipprefix netip.Prefix
+		if s != nil {
+			p, ipprefix, selection, err = nm.AllocateSelected(ctx, *s, n, int(nr.SubnetSize), nr.Strategy)
+		} else {
+			ipprefix, err = nm.AllocateNetwork(ctx, nr.PoolID, n, int(nr.SubnetSize), nr.Strategy)
+		}
 		if err != nil {
 			writeError(w, err, http.StatusBadRequest)
 			return
 		}
+		n.Region, n.RoutingDomain = p.Region, p.RoutingDomain
 		n.CIDR = ipprefix.String()
+
+		if p6 != nil {
+			// the IPv6 block is released in the domain of the network
+			if err := checkSameDomain(p, p6); err != nil {
+				releaseNetwork(ctx, nm, n)
+				writeError(w, err, http.StatusBadRequest)
+				return
+			}
+
+			size := nr.IPv6SubnetSize
+			if size == 0 {
+				size = defaultIPv6SubnetSize
+			}
+			ipv6prefix, err := nm.AllocateNetwork(ctx, nr.IPv6PoolID, n, size, nr.Strategy)
+			if err != nil {
+				releaseNetwork(ctx, nm, n)
+				writeError(w, err, http.StatusBadRequest)
+				return
+			}
+			n.IPv6CIDR = ipv6prefix.String()
+		}
 	}
 
-	var wh *types.ProviderWebhookResponse
-	if !n.Reserved || !n.Legacy {
-		wh, err = pc.CreateNetwork(ctx, n)
+	// the subnet plan is kept with the network as it is provisioned
+	if !n.Reserved && !n.Legacy {
+		if len(nr.Subnets) > 0 {
+			n.Subnets, err = net.PlanSubnets(n, nr.Subnets)
+		} else {
+			n.Subnets, err = net.GenerateSubnets(n)
+		}
 		if err != nil {
+			releaseNetwork(ctx, nm, n)
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// reserved and legacy networks are only recorded, never provisioned
+	if n.Reserved || n.Legacy {
+		n.SetStatus(types.StatusActive, "")
+		err = a.DB.PutNetwork(ctx, n)
+		if err != nil {
+			releaseNetwork(ctx, nm, n)
 			writeError(w, err, http.StatusInternalServerError)
 			return
 		}
+
+		writeJson(w, &types.NetworkResponse{Network: n, Selection: selection}, http.StatusCreated)
+		return
+	}
+
+	n.SetStatus(types.StatusPending, "")
+	err = a.DB.PutNetwork(ctx, n)
+	if err != nil {
+		releaseNetwork(ctx, nm, n)
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	// the provider only acknowledges the event, progress is then polled
+	// through the network status
+	wh, err := pc.CreateNetwork(ctx, n)
+	if err != nil {
+		releaseNetwork(ctx, nm, n)
+		n.SetStatus(types.StatusFailed, err.Error())
+		if perr := a.DB.PutNetwork(ctx, n); perr != nil {
+			log.Printf("failed to record network %s as failed: %v", n.ID.String(), perr)
+		}
+		writeError(w, err, http.StatusInternalServerError)
+		return
 	}
 
+	n.SetStatus(types.StatusProvisioning, "")
 	err = a.DB.PutNetwork(ctx, n)
 	if err != nil {
 		writeError(w, err, http.StatusInternalServerError)
@@ -121,10 +360,11 @@ func (a *api) CreateNetwork(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := &types.NetworkResponse{
-		Network: n,
-		Webhook: wh,
+		Network:   n,
+		Webhook:   wh,
+		Selection: selection,
 	}
-	writeJson(w, resp, http.StatusCreated)
+	writeJson(w, resp, http.StatusAccepted)
 }
 
 func (a *api) DetailNetwork(w http.ResponseWriter, r *http.Request) {
@@ -147,12 +387,14 @@ func (a *api) UpdateNetwork(w http.ResponseWriter, r *http.Request) {
 	n, err := a.DB.GetNetwork(ctx, params["id"])
 	if err != nil {
 		writeError(w, err, http.StatusInternalServerError)
+		return
 	}
 
 	nr := &types.NetworkUpdateRequest{}
 	err = json.NewDecoder(r.Body).Decode(nr)
 	if err != nil {
-		writeError(w, err, http.StatusInternalServerError)
+		writeError(w, err, http.StatusBadRequest)
+		return
 	}
 
 	if nr.VpcID != nil {
@@ -179,9 +421,140 @@ func (a *api) DeleteNetwork(w http.ResponseWriter, r *http.Request) {
 	n, err := a.DB.GetNetwork(ctx, params["id"])
 	if err != nil {
 		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if n.Status == types.StatusDeleted {
+		writeJson(w, n, http.StatusOK)
+		return
+	}
+
+	status := types.StatusDeleted
+	reason := ""
+	// reserved and legacy networks were never provisioned by the provider
+	if !n.Reserved && !n.Legacy {
+		pm := provider.New(a.DB, a.Secrets)
+		pc, err := pm.GetClient(ctx, n.Provider)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		wh, err := pc.DeleteNetwork(ctx, n)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		status = types.StatusDeleting
+		if wh.Status == types.StatusDeleted {
+			status = types.StatusDeleted
+		}
+		reason = wh.Reason
+	}
+
+	err = a.setNetworkStatus(ctx, n, status, reason)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	code := http.StatusOK
+	if n.Status != types.StatusDeleted {
+		code = http.StatusAccepted
+	}
+	writeJson(w, n, code)
+}
+
+// NetworkStatus returns the network status and its history. Networks still
+// being provisioned or deleted are refreshed from the provider first.
+func (a *api) NetworkStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	n, err := a.DB.GetNetwork(ctx, params["id"])
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if n.Status == types.StatusProvisioning || n.Status == types.StatusDeleting {
+		a.refreshNetworkStatus(ctx, n)
+	}
+
+	history := n.StatusHistory
+	if history == nil {
+		history = []*types.StatusChange{}
+	}
+	writeJson(w, types.NetworkStatusResponse{
+		ID:      n.ID,
+		Status:  n.Status,
+		History: history,
+	}, http.StatusOK)
+}
+
+// ProviderEvent receives the outcome of a network event from the provider
+// that owns the network, recording its status and the resources it created.
+func (a *api) ProviderEvent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	n, err := a.DB.GetNetwork(ctx, params["id"])
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
 	}
 
-	err = a.DB.DeleteNetwork(ctx, n.ID.String())
+	if !a.authorizeProvider(ctx, r, n.Provider) {
+		writeError(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	pe := &types.ProviderEvent{}
+	err = json.NewDecoder(r.Body).Decode(pe)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = validate.Struct(pe)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if n.Status == types.StatusDeleted && pe.Status != types.StatusDeleted {
+		writeError(w, fmt.Errorf("network %s is deleted", n.ID.String()), http.StatusConflict)
+		return
+	}
+
+	if pe.VpcID != "" {
+		n.VpcID = pe.VpcID
+	}
+	for _, s := range pe.Subnets {
+		if s.ID == "" {
+			continue
+		}
+		if n.SubnetIDs == nil {
+			n.SubnetIDs = map[string]string{}
+		}
+		n.SubnetIDs[s.CIDR] = s.ID
+
+		for _, ns := range n.Subnets {
+			if ns.CIDR == s.CIDR {
+				ns.ID = s.ID
+				if s.AZ != "" {
+					ns.AZ = s.AZ
+				}
+			}
+		}
+	}
+
+	if pe.Status == n.Status {
+		err = a.DB.PutNetwork(ctx, n)
+	} else {
+		err = a.setNetworkStatus(ctx, n, pe.Status, pe.Reason)
+	}
 	if err != nil {
 		writeError(w, err, http.StatusInternalServerError)
 		return
@@ -190,7 +563,147 @@ func (a *api) DeleteNetwork(w http.ResponseWriter, r *http.Request) {
 	writeJson(w, n, http.StatusOK)
 }
 
-func (a *api) GenerateSubnets(w http.ResponseWriter, r *http.Request) {
+// CheckNetwork compares the network with what its provider runs, recording
+// whether it is in sync, drifted or missing.
+func (a *api) CheckNetwork(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	n, err := a.DB.GetNetwork(ctx, params["id"])
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if n.Reserved || n.Legacy {
+		writeError(w, errors.New("reserved and legacy networks are not managed by a provider"), http.StatusBadRequest)
+		return
+	}
+
+	err = a.checkNetwork(ctx, n)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, n, http.StatusOK)
+}
+
+// CheckNetworks runs CheckNetwork on every network managed by a provider.
+// Networks are checked concurrently, a failure is reported on its own item.
+func (a *api) CheckNetworks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	networks, err := a.DB.ScanNetworks(ctx)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	managed := []*types.Network{}
+	for _, n := range networks {
+		if !n.Reserved && !n.Legacy && n.HoldsAddresses() {
+			managed = append(managed, n)
+		}
+	}
+
+	results := make([]*types.NetworkCheckResult, len(managed))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, checkConcurrency)
+	for i, n := range managed {
+		res := &types.NetworkCheckResult{ID: n.ID}
+		results[i] = res
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			err := a.checkNetwork(ctx, n)
+			if err != nil {
+				res.Error = err.Error()
+				return
+			}
+			res.Drift = n.Drift
+		}()
+	}
+	wg.Wait()
+
+	writeJson(w, types.NetworkCheckResponse{
+		Items: results,
+	}, http.StatusOK)
+}
+
+// ValidateNetwork reports whether the CIDRs could be reserved, listing the
+// networks they overlap with and whether they fit in the given pools.
+func (a *api) ValidateNetwork(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vr := &types.NetworkValidateRequest{}
+	err := json.NewDecoder(r.Body).Decode(vr)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = validate.Struct(vr)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	res := &types.NetworkValidateResponse{}
+	prefixes := []netip.Prefix{}
+	domain, domainSet := vr.RoutingDomain, vr.RoutingDomain != ""
+	for _, c := range []struct{ cidr, poolID string }{
+		{vr.CIDR, vr.PoolID},
+		{vr.IPv6CIDR, vr.IPv6PoolID},
+	} {
+		if c.cidr == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(c.cidr)
+		if err != nil {
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
+		prefix = prefix.Masked()
+		prefixes = append(prefixes, prefix)
+
+		if c.poolID == "" {
+			continue
+		}
+		p, err := a.DB.GetPool(ctx, c.poolID)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+		if !domainSet {
+			domain, domainSet = p.RoutingDomain, true
+		} else if p.RoutingDomain != domain {
+			res.Errors = append(res.Errors, fmt.Sprintf("pool %s is not in routing domain %q", p.Name, domain))
+		}
+		if err := net.CheckInPool(p, prefix); err != nil {
+			res.Errors = append(res.Errors, err.Error())
+		} else if err := net.CheckExclusions(p, prefix); err != nil {
+			res.Errors = append(res.Errors, err.Error())
+		}
+	}
+
+	nm := net.New(a.DB)
+	res.Conflicts, err = nm.Overlaps(ctx, domain, prefixes...)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	res.Valid = len(res.Conflicts) == 0 && len(res.Errors) == 0
+
+	writeJson(w, res, http.StatusOK)
+}
+
+func (a *api) ListSubnets(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	params := mux.Vars(r)
 	n, err := a.DB.GetNetwork(ctx, params["id"])
@@ -201,9 +714,10 @@ func (a *api) GenerateSubnets(w http.ResponseWriter, r *http.Request) {
 
 	if n.Reserved || n.Legacy {
 		writeError(w, errors.New("cannot generate subnets for reserved or legacy networks"), http.StatusBadRequest)
+		return
 	}
 
-	snets, err := net.GenerateSubnets(n)
+	snets, err := net.NetworkSubnets(n)
 	if err != nil {
 		writeError(w, err, http.StatusInternalServerError)
 		return
@@ -213,3 +727,289 @@ func (a *api) GenerateSubnets(w http.ResponseWriter, r *http.Request) {
 		Subnets: snets,
 	}, http.StatusOK)
 }
+
+func (a *api) DetailSubnet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+	n, err := a.DB.GetNetwork(ctx, params["id"])
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	// reserved and legacy networks have no subnets of their own
+	var snets []*types.Subnet
+	if !n.Reserved && !n.Legacy {
+		snets, err = net.NetworkSubnets(n)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	for _, s := range snets {
+		if s.Name == params["name"] {
+			writeJson(w, s, http.StatusOK)
+			return
+		}
+	}
+	writeError(w, db.NotFoundError{Kind: "subnet", ID: params["name"]}, http.StatusNotFound)
+}
+
+// AddNetworkCIDR allocates a secondary block for an active network and has
+// the provider associate it. Without a pool in the request the block comes
+// from the pool holding the network primary CIDR.
+func (a *api) AddNetworkCIDR(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	cr := &types.NetworkCIDRRequest{}
+	err := json.NewDecoder(r.Body).Decode(cr)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = validate.Struct(cr)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	n, err := a.DB.GetNetwork(ctx, params["id"])
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if n.Reserved || n.Legacy {
+		writeError(w, fmt.Errorf("reserved and legacy networks are not provisioned, their CIDRs cannot grow"), http.StatusBadRequest)
+		return
+	}
+	if n.Status != types.StatusActive {
+		writeError(w, fmt.Errorf("network %s is %s, only active networks take new CIDRs", n.ID.String(), n.Status), http.StatusConflict)
+		return
+	}
+
+	nm := net.New(a.DB)
+	var p *types.Pool
+	if cr.PoolID == "" {
+		p, err = nm.PoolOf(ctx, n.RoutingDomain, n.IPPrefix())
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+		if p == nil {
+			writeError(w, fmt.Errorf("no pool holds network %s, set poolID", n.CIDR), http.StatusBadRequest)
+			return
+		}
+		cr.PoolID = p.ID.String()
+	} else {
+		p, err = a.DB.GetPool(ctx, cr.PoolID)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+		if p.IsIPv6() {
+			writeError(w, fmt.Errorf("pool %s is an IPv6 pool, secondary CIDRs are IPv4", cr.PoolID), http.StatusBadRequest)
+			return
+		}
+		if p.RoutingDomain != n.RoutingDomain {
+			writeError(w, fmt.Errorf("pool %s is not in routing domain %q of network %s", p.Name, n.RoutingDomain, n.ID.String()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	pm := provider.New(a.DB, a.Secrets)
+	pc, err := pm.GetClient(ctx, n.Provider)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	// the rules see the network as if it was requested from the target pool
+	rules, _, err := a.DB.ListRules(ctx, nil)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	err = net.CheckRules(rules, net.RuleInput{Request: cidrRequest(n, cr), Pool: p, Provider: pc.Provider, Layout: n.Layout})
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	prefix, err := nm.AllocateNetwork(ctx, cr.PoolID, n, cr.SubnetSize, cr.Strategy)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	wh, err := pc.AddCIDR(ctx, n, prefix.String())
+	if err == nil && wh.Status == types.StatusFailed {
+		err = fmt.Errorf("provider failed to add CIDR %s: %s", prefix.String(), wh.Reason)
+	}
+	if err != nil {
+		if rerr := nm.ReleaseNetwork(ctx, n.RoutingDomain, prefix); rerr != nil {
+			log.Printf("failed to release network %s: %v", prefix.String(), rerr)
+		}
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	sc := &types.SecondaryCIDR{
+		CIDR:          prefix.String(),
+		PoolID:        cr.PoolID,
+		AssociationID: wh.ID,
+		Status:        wh.Status,
+	}
+	if sc.Status == "" {
+		sc.Status = types.StatusActive
+	}
+	n.SecondaryCIDRs = append(n.SecondaryCIDRs, sc)
+
+	err = a.DB.PutNetwork(ctx, n)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, n, http.StatusCreated)
+}
+
+// cidrRequest describes a secondary CIDR of network n as the request of a
+// network of its size from the pool of cr, for the rules to evaluate.
+func cidrRequest(n *types.Network, cr *types.NetworkCIDRRequest) *types.NetworkRequest {
+	nr := &types.NetworkRequest{
+		Account:       n.Account,
+		PoolID:        cr.PoolID,
+		Provider:      n.Provider,
+		Environment:   n.Environment,
+		Region:        n.Region,
+		Info:          n.Info,
+		SubnetSize:    cr.SubnetSize,
+		Strategy:      cr.Strategy,
+		AttachTGW:     types.Bool(n.AttachTGW),
+		PrivateSubnet: types.Bool(n.PrivateSubnet),
+		PublicSubnet:  types.Bool(n.PublicSubnet),
+	}
+	if n.Layout != nil {
+		nr.Layout = n.Layout.Name
+	}
+	for _, sn := range n.Subnets {
+		nr.Subnets = append(nr.Subnets, &types.SubnetRequest{Name: sn.Name, Type: sn.Type, CIDR: sn.CIDR})
+	}
+	return nr
+}
+
+// releaseNetwork frees the CIDRs reserved for a network that failed to be created.
+func releaseNetwork(ctx context.Context, nm *net.NetworkManager, n *types.Network) {
+	for _, prefix := range n.Prefixes() {
+		err := nm.ReleaseNetwork(ctx, n.RoutingDomain, prefix)
+		if err != nil {
+			log.Printf("failed to release network %s: %v", prefix.String(), err)
+		}
+	}
+}
+
+// poolSelector picks the pool of a network created without a pool ID from
+// the region and selector of the request. Dual-stack networks only look in
+// the routing domain of their IPv6 pool.
+func poolSelector(nr *types.NetworkRequest, p6 *types.Pool) *net.PoolSelector {
+	labels := map[string]string{}
+	for k, v := range nr.Selector {
+		labels[k] = v
+	}
+	if _, ok := labels[types.RoutingDomainLabel]; !ok && p6 != nil {
+		labels[types.RoutingDomainLabel] = p6.RoutingDomain
+	}
+	return &net.PoolSelector{Region: nr.Region, Environment: nr.Environment, Labels: labels}
+}
+
+// checkSameDomain makes sure the IPv4 and IPv6 pools of a dual-stack network
+// are in the same routing domain.
+func checkSameDomain(p, p6 *types.Pool) error {
+	if p.RoutingDomain != p6.RoutingDomain {
+		return fmt.Errorf("pool %s is in routing domain %q, not %q of pool %s", p6.Name, p6.RoutingDomain, p.RoutingDomain, p.Name)
+	}
+	return nil
+}
+
+// authorizeProvider checks the request carries the token registered for the
+// provider, the same one the API sends along with its webhooks.
+func (a *api) authorizeProvider(ctx context.Context, r *http.Request, name string) bool {
+	auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || auth == "" {
+		return false
+	}
+
+	token, err := a.Secrets.GetAPIToken(ctx, name)
+	if err != nil {
+		log.Printf("failed to get token for provider %s: %v", name, err)
+		return false
+	}
+	if token == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(auth), []byte(token)) == 1
+}
+
+// refreshNetworkStatus asks the provider how the network is doing. Failures
+// are only logged, the stored status is still good to report.
+func (a *api) refreshNetworkStatus(ctx context.Context, n *types.Network) {
+	err := a.checkNetwork(ctx, n)
+	if err != nil {
+		log.Printf("failed to check network %s: %v", n.ID.String(), err)
+	}
+}
+
+// checkNetwork sends check_network to the provider. The reported status is
+// applied to networks still being provisioned or deleted, the drift report
+// is recorded for active ones.
+func (a *api) checkNetwork(ctx context.Context, n *types.Network) error {
+	pm := provider.New(a.DB, a.Secrets)
+	pc, err := pm.GetClient(ctx, n.Provider)
+	if err != nil {
+		return err
+	}
+
+	wh, err := pc.CheckNetwork(ctx, n)
+	if err != nil {
+		return err
+	}
+
+	if n.Status == types.StatusProvisioning || n.Status == types.StatusDeleting {
+		err = a.setNetworkStatus(ctx, n, wh.Status, wh.Reason)
+		if err != nil {
+			return err
+		}
+	}
+
+	if n.Status != types.StatusActive || wh.Drift == nil {
+		return nil
+	}
+	n.Drift = wh.Drift
+	n.Drift.CheckedAt = time.Now().UTC()
+	return a.DB.PutNetwork(ctx, n)
+}
+
+// setNetworkStatus records a status change reported for the network, freeing
+// its CIDRs once it failed or is gone.
+func (a *api) setNetworkStatus(ctx context.Context, n *types.Network, status types.NetworkStatus, reason string) error {
+	if status == "" || status == n.Status {
+		return nil
+	}
+
+	if n.HoldsAddresses() && (status == types.StatusFailed || status == types.StatusDeleted) {
+		for _, prefix := range n.Prefixes() {
+			err := a.DB.ReleaseNetwork(ctx, types.ReservationKey(n.RoutingDomain, prefix.String()))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	n.SetStatus(status, reason)
+	return a.DB.PutNetwork(ctx, n)
+}
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/gorilla/mux"
+	dbpkg "github.com/olxbr/network-api/pkg/db"
 	fakeDb "github.com/olxbr/network-api/pkg/db/fake"
 	fakeSecrets "github.com/olxbr/network-api/pkg/secret/fake"
 	"github.com/olxbr/network-api/pkg/types"
@@ -19,15 +20,25 @@ import (
 )
 
 func TestCanListNetworks(t *testing.T) {
+	id01, id02, id03 := types.NewUUID(), types.NewUUID(), types.NewUUID()
+	listed := func() []*types.Network {
+		return []*types.Network{
+			{ID: id01, CIDR: "10.1.0.0/16", Provider: "aws", Region: "us-east-1", Account: "1234"},
+			{ID: id02, CIDR: "10.2.0.0/16", Provider: "aws", Region: "us-east-1", Account: "1234"},
+			{ID: id03, CIDR: "10.0.0.0/8", Provider: "aws", Region: "us-east-1", Account: "1234", IPv6CIDR: "2600:1f18:1000:100::/56"},
+		}
+	}
+
 	tests := []struct {
 		name    string
+		query   string
 		prepare func(t *testing.T, db *fakeDb.Database)
 		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
 	}{
 		{
 			name: "empty response",
 			prepare: func(t *testing.T, db *fakeDb.Database) {
-				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				db.On("ListNetworks", mock.Anything, mock.Anything, mock.Anything).Return([]*types.Network{}, "", nil)
 			},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
@@ -38,7 +49,7 @@ func TestCanListNetworks(t *testing.T) {
 		{
 			name: "fail to read from database",
 			prepare: func(t *testing.T, db *fakeDb.Database) {
-				db.On("ScanNetworks", mock.Anything).Return(nil, fmt.Errorf("error"))
+				db.On("ListNetworks", mock.Anything, mock.Anything, mock.Anything).Return(nil, "", fmt.Errorf("error"))
 			},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
@@ -49,7 +60,7 @@ func TestCanListNetworks(t *testing.T) {
 		{
 			name: "networks",
 			prepare: func(t *testing.T, db *fakeDb.Database) {
-				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+				db.On("ListNetworks", mock.Anything, mock.Anything, mock.Anything).Return([]*types.Network{
 					{
 						ID:          types.NewUUID(),
 						CIDR:        "10.0.0.0/16",
@@ -68,7 +79,7 @@ func TestCanListNetworks(t *testing.T) {
 						Environment: "qa",
 						Info:        "Second VPC",
 					},
-				}, nil)
+				}, "", nil)
 			},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
@@ -81,13 +92,124 @@ func TestCanListNetworks(t *testing.T) {
 				assert.Equal(t, "10.1.0.0/16", n.Items[1].CIDR)
 			},
 		},
+		{
+			name:  "filtered and sorted page",
+			query: "provider=aws&region=us-east-1&contains=10.2.3.4&sort=cidr&order=desc&limit=3",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("ListNetworks", mock.Anything, &types.NetworkFilter{
+					Provider: "aws",
+					Region:   "us-east-1",
+					Contains: "10.2.3.4",
+				}, &types.ListOptions{
+					Limit: 3,
+					Sort:  "cidr",
+					Order: types.SortDesc,
+				}).Return(listed(), "next", nil)
+				// the filtered out network is made up for from the next page
+				db.On("ListNetworks", mock.Anything, mock.Anything, &types.ListOptions{
+					NextToken: "next",
+					Limit:     1,
+					Sort:      "cidr",
+					Order:     types.SortDesc,
+				}).Return([]*types.Network{{ID: types.NewUUID(), CIDR: "10.2.3.0/24"}}, "after", nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				n := &types.NetworkListResponse{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				assert.Equal(t, "after", n.NextToken)
+				require.Len(t, n.Items, 3)
+				assert.Equal(t, "10.2.3.0/24", n.Items[0].CIDR)
+				assert.Equal(t, "10.2.0.0/16", n.Items[1].CIDR)
+				assert.Equal(t, "10.0.0.0/8", n.Items[2].CIDR)
+			},
+		},
+		{
+			name:  "filtered pages until the table ends",
+			query: "contains=10.9.0.0&limit=2",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("ListNetworks", mock.Anything, mock.Anything, &types.ListOptions{Limit: 2}).Return(listed(), "next", nil)
+				db.On("ListNetworks", mock.Anything, mock.Anything, &types.ListOptions{NextToken: "next", Limit: 1}).Return([]*types.Network{}, "", nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				n := &types.NetworkListResponse{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				assert.Empty(t, n.NextToken)
+				require.Len(t, n.Items, 1)
+				assert.Equal(t, id03, n.Items[0].ID)
+			},
+		},
+		{
+			name:  "networks in a pool",
+			query: "poolID=pool6&contains=2600:1f18:1000:100::/64",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+					{CIDR: "10.0.0.0/8", NetworkID: id03.String(), PoolID: "pool4"},
+					{CIDR: "2600:1f18:1000:100::/56", NetworkID: id03.String(), PoolID: "pool6"},
+					{CIDR: "10.1.0.0/16", NetworkID: id01.String(), PoolID: "pool4"},
+				}, nil)
+				db.On("ListNetworks", mock.Anything, mock.Anything, mock.Anything).Return(listed(), "", nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				n := &types.NetworkListResponse{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				require.Len(t, n.Items, 1)
+				assert.Equal(t, id03, n.Items[0].ID)
+			},
+		},
+		{
+			name:    "unknown sort",
+			query:   "sort=vpcID",
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, "{\"errors\":{\"_all\":\"invalid sort \\\"vpcID\\\", use one of account, cidr, environment, provider, region, status\"}}\n", w.Body.String())
+			},
+		},
+		{
+			name:    "limit too large",
+			query:   "limit=5000",
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, "{\"code\":\"invalid\",\"errors\":{\"limit\":\"failed on the 'max=1000' tag\"}}\n", w.Body.String())
+			},
+		},
+		{
+			name:    "invalid contains",
+			query:   "contains=10.0.0.300",
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"contains":"failed on the 'cidr|ip' tag"`)
+			},
+		},
+		{
+			name:  "invalid page token",
+			query: "limit=10&nextToken=bogus",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("ListNetworks", mock.Anything, mock.Anything, mock.Anything).Return(nil, "", dbpkg.ErrInvalidToken)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, "{\"code\":\"invalid\",\"errors\":{\"_all\":\"invalid page token\"}}\n", w.Body.String())
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			db := &fakeDb.Database{}
 			tt.prepare(t, db)
 
-			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
 			w := httptest.NewRecorder()
 			api := New(db, nil)
 
@@ -99,11 +221,21 @@ func TestCanListNetworks(t *testing.T) {
 }
 
 func TestCanCreateNetwork(t *testing.T) {
+	selectedFull, selectedSpare := types.NewUUID(), types.NewUUID()
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("{\"id\":\"123456789012\",\"statusCode\":201}"))
 	}))
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	planned := &types.ProviderWebhook{}
+	planServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(planned)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("{\"id\":\"123456789012\",\"statusCode\":201}"))
+	}))
 
 	tests := []struct {
 		name    string
@@ -117,7 +249,7 @@ func TestCanCreateNetwork(t *testing.T) {
 			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
-				assert.Equal(t, http.StatusInternalServerError, w.Code)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
 				assert.Equal(t, "{\"errors\":{\"_all\":\"json: cannot unmarshal string into Go value of type types.NetworkRequest\"}}\n", w.Body.String())
 			},
 		},
@@ -130,7 +262,7 @@ func TestCanCreateNetwork(t *testing.T) {
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
 				assert.Equal(t, http.StatusBadRequest, w.Code)
-				assert.True(t, strings.Contains(w.Body.String(), "Key: 'NetworkRequest.Account' Error:Field validation for 'Account' failed on the 'required' tag"))
+				assert.Contains(t, w.Body.String(), `"account":"failed on the 'required' tag"`)
 			},
 		},
 		{
@@ -150,6 +282,7 @@ func TestCanCreateNetwork(t *testing.T) {
 				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
 					WebhookURL: server.URL,
 				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
 				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
 				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
 					Region:     "us-east-1",
@@ -157,6 +290,12 @@ func TestCanCreateNetwork(t *testing.T) {
 					SubnetMask: types.Int(8),
 				}, nil)
 				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.MatchedBy(func(r *types.Reservation) bool {
+					return r.CIDR == "10.0.0.0/20"
+				}), mock.Anything).Return(nil)
 				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
 					return (n.Account == "1234" &&
 						n.Region == "us-east-1" &&
@@ -171,7 +310,7 @@ func TestCanCreateNetwork(t *testing.T) {
 			},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
-				assert.Equal(t, http.StatusCreated, w.Code)
+				assert.Equal(t, http.StatusAccepted, w.Code)
 				n := &types.NetworkResponse{}
 				err := json.NewDecoder(w.Body).Decode(n)
 				require.NoError(t, err)
@@ -181,6 +320,7 @@ func TestCanCreateNetwork(t *testing.T) {
 				assert.Equal(t, "prod", n.Network.Environment)
 				assert.Equal(t, "First VPC", n.Network.Info)
 				assert.Equal(t, "10.0.0.0/20", n.Network.CIDR)
+				assert.Equal(t, types.StatusProvisioning, n.Network.Status)
 				assert.Equal(t, "123456789012", n.Webhook.ID)
 			},
 		},
@@ -202,6 +342,7 @@ func TestCanCreateNetwork(t *testing.T) {
 				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
 					WebhookURL: server.URL,
 				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
 				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
 					Region:     "us-east-1",
 					SubnetIP:   "10.0.0.0",
@@ -209,6 +350,12 @@ func TestCanCreateNetwork(t *testing.T) {
 				}, nil)
 				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
 				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.MatchedBy(func(r *types.Reservation) bool {
+					return r.CIDR == "10.10.0.0/16"
+				}), mock.Anything).Return(nil)
 				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
 					return (n.Account == "1234" &&
 						n.Region == "us-east-1" &&
@@ -234,219 +381,2059 @@ func TestCanCreateNetwork(t *testing.T) {
 				assert.Equal(t, "prod", n.Network.Environment)
 				assert.Equal(t, "First VPC", n.Network.Info)
 				assert.Equal(t, "10.10.0.0/16", n.Network.CIDR)
-				assert.Equal(t, "123456789012", n.Webhook.ID)
+				assert.Equal(t, types.StatusActive, n.Network.Status)
+				assert.Nil(t, n.Webhook)
 			},
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			db := &fakeDb.Database{}
-			s := &fakeSecrets.Secrets{}
-			tt.prepare(t, db, s)
-
-			payload := &bytes.Buffer{}
-			err := json.NewEncoder(payload).Encode(tt.payload)
-			require.NoError(t, err)
-
-			req := httptest.NewRequest(http.MethodGet, "/", payload)
-			w := httptest.NewRecorder()
-			api := New(db, s)
-
-			api.CreateNetwork(w, req)
-
-			tt.assert(t, db, w)
-		})
-	}
-}
-
-func TestCanDetailNetwork(t *testing.T) {
-	tests := []struct {
-		name    string
-		id      string
-		prepare func(t *testing.T, db *fakeDb.Database)
-		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
-	}{
 		{
-			name: "valid network id",
-			id:   "1234",
-			prepare: func(t *testing.T, db *fakeDb.Database) {
-				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
-					ID:          types.NewUUID(),
-					Provider:    "aws",
-					Region:      "us-east-1",
-					Account:     "1234",
-					Environment: "prod",
-					CIDR:        "10.10.0.0/16",
-					VpcID:       "vpc-123456789012",
-					Info:        "Legacy VPC",
-					Legacy:      true,
+			name: "provider rejects network",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				PoolID:        "poolid",
+				Provider:      "broken",
+				Environment:   "prod",
+				SubnetSize:    20,
+				AttachTGW:     types.Bool(true),
+				PrivateSubnet: types.Bool(true),
+				PublicSubnet:  types.Bool(true),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "broken").Return(&types.Provider{
+					WebhookURL: failingServer.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				s.On("GetAPIToken", mock.Anything, "broken").Return("token", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				db.On("PutNetwork", mock.Anything, mock.Anything).Return(nil).Twice()
+				db.On("ReleaseNetwork", mock.Anything, "10.0.0.0/20").Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusInternalServerError, w.Code)
+				n := db.Calls[len(db.Calls)-1].Arguments.Get(1).(*types.Network)
+				assert.Equal(t, types.StatusFailed, n.Status)
+				require.Len(t, n.StatusHistory, 2)
+				assert.Equal(t, types.StatusPending, n.StatusHistory[0].Status)
+				assert.Equal(t, "error creating network: 500 Internal Server Error", n.StatusHistory[1].Reason)
+			},
+		},
+		{
+			name: "dual-stack network",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				PoolID:        "poolid",
+				IPv6PoolID:    "poolid6",
+				Provider:      "aws",
+				Environment:   "prod",
+				SubnetSize:    20,
+				AttachTGW:     types.Bool(true),
+				PrivateSubnet: types.Bool(true),
+				PublicSubnet:  types.Bool(true),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+				db.On("GetPool", mock.Anything, "poolid6").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "2600:1f18:1000::",
+					SubnetMask: types.Int(40),
 				}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.MatchedBy(func(r *types.Reservation) bool {
+					return r.CIDR == "10.0.0.0/20"
+				}), mock.Anything).Return(nil)
+				db.On("ReserveNetwork", mock.Anything, mock.MatchedBy(func(r *types.Reservation) bool {
+					return r.CIDR == "2600:1f18:1000::/56"
+				}), mock.Anything).Return(nil)
+				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+					return n.CIDR == "10.0.0.0/20" && n.IPv6CIDR == "2600:1f18:1000::/56"
+				})).Return(nil)
 			},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
-				assert.Equal(t, http.StatusOK, w.Code)
-				n := &types.Network{}
+				assert.Equal(t, http.StatusAccepted, w.Code)
+				n := &types.NetworkResponse{}
 				err := json.NewDecoder(w.Body).Decode(n)
 				require.NoError(t, err)
-				assert.NotNil(t, n.ID)
-				assert.Equal(t, "1234", n.Account)
-				assert.Equal(t, "us-east-1", n.Region)
-				assert.Equal(t, "aws", n.Provider)
-				assert.Equal(t, "prod", n.Environment)
-				assert.Equal(t, "Legacy VPC", n.Info)
-				assert.Equal(t, "10.10.0.0/16", n.CIDR)
+				assert.Equal(t, "10.0.0.0/20", n.Network.CIDR)
+				assert.Equal(t, "2600:1f18:1000::/56", n.Network.IPv6CIDR)
 			},
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			db := &fakeDb.Database{}
-			tt.prepare(t, db)
-
-			req := httptest.NewRequest(http.MethodGet, "/", nil)
-			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
-			w := httptest.NewRecorder()
-			api := New(db, nil)
-
-			api.DetailNetwork(w, req)
-
-			tt.assert(t, db, w)
-		})
-	}
-}
-
-func TestCanUpdateNetwork(t *testing.T) {
-	tests := []struct {
-		name    string
-		id      string
-		payload interface{}
-		prepare func(t *testing.T, db *fakeDb.Database)
-		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
-	}{
 		{
-			name: "valid update",
-			id:   "1234",
-			payload: &types.NetworkUpdateRequest{
-				Info: types.String("Old Legacy VPC"),
+			name: "network in a routing domain",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				PoolID:        "poolid",
+				Provider:      "aws",
+				Environment:   "sandbox",
+				SubnetSize:    20,
+				AttachTGW:     types.Bool(true),
+				PrivateSubnet: types.Bool(true),
+				PublicSubnet:  types.Bool(true),
 			},
-			prepare: func(t *testing.T, db *fakeDb.Database) {
-				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
-					ID:          types.NewUUID(),
-					Provider:    "aws",
-					Region:      "us-east-1",
-					Account:     "1234",
-					Environment: "prod",
-					CIDR:        "10.10.0.0/16",
-					VpcID:       "vpc-123456789012",
-					Info:        "Legacy VPC",
-					Legacy:      true,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:        "us-east-1",
+					SubnetIP:      "10.0.0.0",
+					SubnetMask:    types.Int(8),
+					RoutingDomain: "sandbox",
+				}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.0.0.0/20", Account: "5678"},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+					{CIDR: "10.0.0.0/20"},
 				}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.MatchedBy(func(r *types.Reservation) bool {
+					return r.CIDR == "10.0.0.0/20" && r.RoutingDomain == "sandbox"
+				}), mock.Anything).Return(nil)
 				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
-					return (n.Account == "1234" &&
-						n.Region == "us-east-1" &&
-						n.Provider == "aws" &&
-						n.Environment == "prod" &&
-						n.Info == "Old Legacy VPC" &&
-						n.CIDR == "10.10.0.0/16" &&
-						n.Legacy == true)
+					return n.CIDR == "10.0.0.0/20" && n.RoutingDomain == "sandbox"
 				})).Return(nil)
 			},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
-				assert.Equal(t, http.StatusOK, w.Code)
-				n := &types.Network{}
+				assert.Equal(t, http.StatusAccepted, w.Code)
+				n := &types.NetworkResponse{}
 				err := json.NewDecoder(w.Body).Decode(n)
 				require.NoError(t, err)
-				assert.NotNil(t, n.ID)
-				assert.Equal(t, "1234", n.Account)
-				assert.Equal(t, "us-east-1", n.Region)
-				assert.Equal(t, "aws", n.Provider)
-				assert.Equal(t, "prod", n.Environment)
-				assert.Equal(t, "Old Legacy VPC", n.Info)
-				assert.Equal(t, "10.10.0.0/16", n.CIDR)
+				assert.Equal(t, "10.0.0.0/20", n.Network.CIDR)
+				assert.Equal(t, "sandbox", n.Network.RoutingDomain)
 			},
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			db := &fakeDb.Database{}
-			tt.prepare(t, db)
-
-			payload := &bytes.Buffer{}
-			err := json.NewEncoder(payload).Encode(tt.payload)
+		{
+			name: "dual-stack pools in different routing domains",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				PoolID:        "poolid",
+				IPv6PoolID:    "poolid6",
+				Provider:      "aws",
+				Environment:   "prod",
+				SubnetSize:    20,
+				AttachTGW:     types.Bool(true),
+				PrivateSubnet: types.Bool(true),
+				PublicSubnet:  types.Bool(true),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Name:       "prod",
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+				db.On("GetPool", mock.Anything, "poolid6").Return(&types.Pool{
+					Name:          "sandbox6",
+					Region:        "us-east-1",
+					SubnetIP:      "2600:1f18:1000::",
+					SubnetMask:    types.Int(40),
+					RoutingDomain: "sandbox",
+				}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `pool sandbox6 is in routing domain \"sandbox\", not \"\" of pool prod`)
+			},
+		},
+		{
+			name: "selector in another routing domain than the ipv6 pool",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				Region:        "us-east-1",
+				Selector:      map[string]string{"purpose": "shared", "routingDomain": "sandbox"},
+				IPv6PoolID:    "poolid6",
+				Provider:      "aws",
+				Environment:   "prod",
+				SubnetSize:    20,
+				AttachTGW:     types.Bool(true),
+				PrivateSubnet: types.Bool(true),
+				PublicSubnet:  types.Bool(true),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("GetPool", mock.Anything, "poolid6").Return(&types.Pool{
+					Name:       "prod6",
+					Region:     "us-east-1",
+					SubnetIP:   "2600:1f18:1000::",
+					SubnetMask: types.Int(40),
+				}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "ScanPools", mock.Anything)
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `pool prod6 is in routing domain \"\", not \"sandbox\" of the selector`)
+			},
+		},
+		{
+			name: "pool picked by selector",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				Region:        "us-east-1",
+				Selector:      map[string]string{"purpose": "shared"},
+				Provider:      "aws",
+				Environment:   "prod",
+				SubnetSize:    20,
+				AttachTGW:     types.Bool(true),
+				PrivateSubnet: types.Bool(true),
+				PublicSubnet:  types.Bool(true),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				full := &types.Pool{
+					ID: selectedFull, Name: "full", Region: "us-east-1", SubnetIP: "10.0.0.0", SubnetMask: types.Int(20),
+					Labels: map[string]string{"purpose": "shared"}, Priority: 10,
+				}
+				spare := &types.Pool{
+					ID: selectedSpare, Name: "spare", Region: "us-east-1", SubnetIP: "10.1.0.0", SubnetMask: types.Int(16),
+					Labels: map[string]string{"purpose": "shared"}, Priority: 1,
+				}
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{full, spare}, nil)
+				db.On("GetPool", mock.Anything, selectedFull.String()).Return(full, nil)
+				db.On("GetPool", mock.Anything, selectedSpare.String()).Return(spare, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{{CIDR: "10.0.0.0/20"}}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{{CIDR: "10.0.0.0/20"}}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.MatchedBy(func(r *types.Reservation) bool {
+					return r.CIDR == "10.1.0.0/20" && r.PoolID == selectedSpare.String()
+				}), mock.Anything).Return(nil)
+				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+					return n.CIDR == "10.1.0.0/20" && n.Region == "us-east-1"
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusAccepted, w.Code)
+				n := &types.NetworkResponse{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				assert.Equal(t, "10.1.0.0/20", n.Network.CIDR)
+				require.NotNil(t, n.Selection)
+				assert.Equal(t, "spare", n.Selection.Pool)
+				assert.Equal(t, "priority 1 pool matching region us-east-1, environment prod, purpose=shared with a free /20", n.Selection.Reason)
+				require.Len(t, n.Selection.Skipped, 1)
+				assert.Equal(t, "full", n.Selection.Skipped[0].Pool)
+			},
+		},
+		{
+			name: "every pool of the selector skipped",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				Region:        "us-east-1",
+				Selector:      map[string]string{"purpose": "shared"},
+				Provider:      "aws",
+				Environment:   "prod",
+				SubnetSize:    20,
+				AttachTGW:     types.Bool(true),
+				PrivateSubnet: types.Bool(true),
+				PublicSubnet:  types.Bool(true),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				full := &types.Pool{
+					ID: selectedFull, Name: "full", Region: "us-east-1", SubnetIP: "10.0.0.0", SubnetMask: types.Int(20),
+					Labels: map[string]string{"purpose": "shared"},
+				}
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{full}, nil)
+				db.On("GetPool", mock.Anything, selectedFull.String()).Return(full, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{{CIDR: "10.0.0.0/20"}}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{{CIDR: "10.0.0.0/20"}}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				e := &types.ErrorResponse{}
+				err := json.NewDecoder(w.Body).Decode(e)
+				require.NoError(t, err)
+				// not the pool_exhausted of the last pool tried
+				assert.Equal(t, types.ErrorNoPoolMatch, e.Code)
+				require.Len(t, e.Skipped, 1)
+				assert.Equal(t, "full", e.Skipped[0].Pool)
+				assert.Equal(t, selectedFull.String(), e.Skipped[0].PoolID)
+				assert.Contains(t, e.Skipped[0].Reason, "no more networks available")
+			},
+		},
+		{
+			name: "no pool matches the selector",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				Region:        "us-east-1",
+				Selector:      map[string]string{"purpose": "edge"},
+				Provider:      "aws",
+				Environment:   "prod",
+				SubnetSize:    20,
+				AttachTGW:     types.Bool(true),
+				PrivateSubnet: types.Bool(true),
+				PublicSubnet:  types.Bool(true),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{
+					{ID: selectedSpare, Name: "spare", Region: "us-east-1", SubnetIP: "10.1.0.0", SubnetMask: types.Int(16), Labels: map[string]string{"purpose": "shared"}},
+				}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Equal(t, `{"code":"no_pool_match","errors":{"_all":"no pool matches region us-east-1, environment prod, purpose=edge"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name: "pool id along with a region",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				PoolID:        "poolid",
+				Region:        "us-east-1",
+				Provider:      "aws",
+				Environment:   "prod",
+				SubnetSize:    20,
+				AttachTGW:     types.Bool(true),
+				PrivateSubnet: types.Bool(true),
+				PublicSubnet:  types.Bool(true),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, `{"code":"invalid","errors":{"region":"failed on the 'excluded_with=PoolID' tag"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name: "pool policy violated",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				PoolID:        "poolid",
+				Provider:      "aws",
+				Environment:   "dev",
+				SubnetSize:    20,
+				AttachTGW:     types.Bool(true),
+				PrivateSubnet: types.Bool(true),
+				PublicSubnet:  types.Bool(true),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Name:       "prod",
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+					Policy: &types.PoolPolicy{
+						PrefixLengths: []int{22},
+						Environments:  []string{"prod"},
+					},
+				}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				e := &types.ErrorResponse{}
+				err := json.NewDecoder(w.Body).Decode(e)
+				require.NoError(t, err)
+				assert.Equal(t, types.ErrorPolicy, e.Code)
+				assert.Equal(t, []string{"prefix length /20 not one of /22", "environment dev not one of [prod]"}, e.Violations)
+			},
+		},
+		{
+			name: "rules turn the network down",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				PoolID:        "poolid",
+				Provider:      "aws",
+				Environment:   "prod",
+				SubnetSize:    20,
+				AttachTGW:     types.Bool(false),
+				PrivateSubnet: types.Bool(true),
+				PublicSubnet:  types.Bool(true),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					Name:       "aws",
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{
+					{
+						Name:    "prod-tgw",
+						When:    []*types.RuleCondition{{Field: "environment", Operator: types.RuleIn, Values: []string{"prod"}}},
+						Require: []*types.RuleCondition{{Field: "attachTGW", Operator: types.RuleIn, Values: []string{"true"}}},
+					},
+					{
+						Name:    "public-justified",
+						When:    []*types.RuleCondition{{Field: "publicSubnet", Operator: types.RuleIn, Values: []string{"true"}}},
+						Require: []*types.RuleCondition{{Field: "info", Operator: types.RulePresent}},
+					},
+					{
+						Name:    "aws-only",
+						Require: []*types.RuleCondition{{Field: "provider", Operator: types.RuleIn, Values: []string{"aws"}}},
+					},
+				}, "", nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Name:       "shared",
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				e := &types.ErrorResponse{}
+				err := json.NewDecoder(w.Body).Decode(e)
+				require.NoError(t, err)
+				assert.Equal(t, types.ErrorRule, e.Code)
+				assert.Equal(t, []*types.RuleResult{
+					{Rule: "aws-only", Outcome: types.RulePass},
+					{Rule: "prod-tgw", Outcome: types.RuleFail, Reason: "attachTGW false not one of [true]"},
+					{Rule: "public-justified", Outcome: types.RuleFail, Reason: "info not set"},
+				}, e.Rules)
+				assert.Equal(t, "network request fails rules: prod-tgw: attachTGW false not one of [true]; public-justified: info not set", e.Errors["_all"])
+			},
+		},
+		{
+			name: "IPv6 pool as IPv4 pool",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				PoolID:        "poolid6",
+				Provider:      "aws",
+				Environment:   "prod",
+				SubnetSize:    20,
+				AttachTGW:     types.Bool(true),
+				PrivateSubnet: types.Bool(true),
+				PublicSubnet:  types.Bool(true),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("GetPool", mock.Anything, "poolid6").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "2600:1f18:1000::",
+					SubnetMask: types.Int(40),
+				}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, "{\"errors\":{\"_all\":\"pool poolid6 is an IPv6 pool, use ipv6PoolID\"}}\n", w.Body.String())
+			},
+		},
+		{
+			name: "reserved network already taken",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				PoolID:        "poolid",
+				Provider:      "aws",
+				Environment:   "prod",
+				CIDR:          "10.10.0.0/16",
+				AttachTGW:     types.Bool(false),
+				PrivateSubnet: types.Bool(false),
+				PublicSubnet:  types.Bool(false),
+				Reserved:      types.Bool(true),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(dbpkg.ErrConflict)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "PutNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusConflict, w.Code)
+			},
+		},
+		{
+			name: "reserved network overlaps",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				PoolID:        "poolid",
+				Provider:      "aws",
+				Environment:   "prod",
+				CIDR:          "10.10.0.0/24",
+				AttachTGW:     types.Bool(false),
+				PrivateSubnet: types.Bool(false),
+				PublicSubnet:  types.Bool(false),
+				Reserved:      types.Bool(true),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.10.0.0/16", Account: "4321", Environment: "dev"},
+					{CIDR: "10.11.0.0/16", Account: "4321", Environment: "dev"},
+				}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusConflict, w.Code)
+				er := &types.ErrorResponse{}
+				err := json.NewDecoder(w.Body).Decode(er)
+				require.NoError(t, err)
+				assert.Equal(t, types.ErrorOverlap, er.Code)
+				assert.Equal(t, "network 10.10.0.0/24 overlaps with 10.10.0.0/16 (account 4321, environment dev)", er.Errors["_all"])
+				require.Len(t, er.Conflicts, 1)
+				assert.Equal(t, "4321", er.Conflicts[0].Account)
+			},
+		},
+		{
+			name: "reserved network partially overlaps",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				PoolID:        "poolid",
+				Provider:      "aws",
+				Environment:   "prod",
+				CIDR:          "10.20.0.0/16",
+				AttachTGW:     types.Bool(false),
+				PrivateSubnet: types.Bool(false),
+				PublicSubnet:  types.Bool(false),
+				Reserved:      types.Bool(true),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.20.5.0/24", Account: "4321", Environment: "dev"},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+					{CIDR: "10.20.5.0/24"},
+					{CIDR: "10.20.128.0/20", NetworkID: "f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0"},
+				}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusConflict, w.Code)
+				er := &types.ErrorResponse{}
+				err := json.NewDecoder(w.Body).Decode(er)
+				require.NoError(t, err)
+				assert.Equal(t, "network 10.20.0.0/16 overlaps with 10.20.5.0/24 (account 4321, environment dev), "+
+					"10.20.128.0/20 (network f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0, pending)", er.Errors["_all"])
+				require.Len(t, er.Conflicts, 2)
+			},
+		},
+		{
+			name: "reserved network outside the pool",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				PoolID:        "poolid",
+				Provider:      "aws",
+				Environment:   "prod",
+				CIDR:          "172.16.0.0/16",
+				AttachTGW:     types.Bool(false),
+				PrivateSubnet: types.Bool(false),
+				PublicSubnet:  types.Bool(false),
+				Reserved:      types.Bool(true),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Equal(t, "{\"code\":\"not_in_pool\",\"errors\":{\"_all\":\"network 172.16.0.0/16 not in pool range 10.0.0.0-10.255.255.255\"}}\n", w.Body.String())
+			},
+		},
+		{
+			name: "reserved network hits an exclusion",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				PoolID:        "poolid",
+				Provider:      "aws",
+				Environment:   "prod",
+				CIDR:          "10.200.0.0/16",
+				AttachTGW:     types.Bool(false),
+				PrivateSubnet: types.Bool(false),
+				PublicSubnet:  types.Bool(false),
+				Reserved:      types.Bool(true),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				p := &types.Pool{
+					Name:       "prod",
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+					Exclusions: []*types.Exclusion{{CIDR: "10.200.0.0/13", Reason: "partner VPN", Owner: "network-team"}},
+				}
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(p, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{p}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Equal(t, "{\"code\":\"excluded\",\"errors\":{\"_all\":\"network 10.200.0.0/16 overlaps 10.200.0.0/13 excluded from pool prod by network-team: partner VPN\"}}\n", w.Body.String())
+			},
+		},
+		{
+			name: "layout network",
+			payload: types.NetworkRequest{
+				Account:     "1234",
+				PoolID:      "poolid",
+				Provider:    "aws",
+				Environment: "prod",
+				SubnetSize:  20,
+				Layout:      "app-data",
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+				db.On("GetLayout", mock.Anything, "app-data").Return(&types.Layout{
+					Name:    "app-data",
+					AZCount: 2,
+					Tiers: []*types.LayoutTier{
+						{Name: "app", Type: types.Private, Weight: 2},
+						{Name: "data", Type: types.Isolated, Weight: 1},
+					},
+				}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+					return n.Layout != nil && n.Layout.Name == "app-data" && !n.PrivateSubnet
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusAccepted, w.Code)
+				n := &types.NetworkResponse{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				require.NotNil(t, n.Network.Layout)
+				assert.Len(t, n.Network.Layout.Tiers, 2)
+				require.Len(t, n.Network.Subnets, 4)
+				assert.Equal(t, "app01", n.Network.Subnets[0].Name)
+				assert.Equal(t, 1, n.Network.Subnets[3].AZIndex)
+			},
+		},
+		{
+			name: "explicit subnets",
+			payload: types.NetworkRequest{
+				Account:     "1234",
+				PoolID:      "poolid",
+				Provider:    "aws",
+				Environment: "prod",
+				SubnetSize:  16,
+				Subnets: []*types.SubnetRequest{
+					{Name: "private01", Type: types.Private, CIDR: "0.0.0.0/17"},
+					{Name: "pods01", Type: types.Private, CIDR: "0.0.128.0/18"},
+					{Name: "public01", Type: types.Public, CIDR: "0.0.192.0/26"},
+				},
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: planServer.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				db.On("PutNetwork", mock.Anything, mock.Anything).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusAccepted, w.Code)
+				n := &types.NetworkResponse{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				require.Len(t, n.Network.Subnets, 3)
+				assert.Equal(t, "10.0.128.0/18", n.Network.Subnets[1].CIDR)
+				assert.Equal(t, 1, n.Network.Subnets[1].AZIndex)
+				require.Len(t, planned.Subnets, 3)
+				assert.Equal(t, "pods01", planned.Subnets[1].Name)
+				assert.Equal(t, "10.0.192.0/26", planned.Subnets[2].CIDR)
+			},
+		},
+		{
+			name: "small network allowed by the pool policy",
+			payload: types.NetworkRequest{
+				Account:     "1234",
+				PoolID:      "poolid",
+				Provider:    "aws",
+				Environment: "prod",
+				SubnetSize:  26,
+				Subnets: []*types.SubnetRequest{
+					{Name: "private01", Type: types.Private, CIDR: "0.0.0.0/27"},
+					{Name: "private02", Type: types.Private, CIDR: "0.0.0.32/27"},
+				},
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: planServer.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(16),
+					Policy:     &types.PoolPolicy{PrefixLengths: []int{26, 27}},
+				}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.MatchedBy(func(r *types.Reservation) bool {
+					return r.CIDR == "10.0.0.0/26"
+				}), mock.Anything).Return(nil)
+				db.On("PutNetwork", mock.Anything, mock.Anything).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusAccepted, w.Code)
+				n := &types.NetworkResponse{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				assert.Equal(t, "10.0.0.0/26", n.Network.CIDR)
+				require.Len(t, n.Network.Subnets, 2)
+				assert.Equal(t, "10.0.0.32/27", n.Network.Subnets[1].CIDR)
+			},
+		},
+		{
+			name: "explicit subnets outside the network",
+			payload: types.NetworkRequest{
+				Account:     "1234",
+				PoolID:      "poolid",
+				Provider:    "aws",
+				Environment: "prod",
+				SubnetSize:  20,
+				Subnets: []*types.SubnetRequest{
+					{Name: "private01", Type: types.Private, CIDR: "0.0.0.0/19"},
+				},
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				db.On("ReleaseNetwork", mock.Anything, "10.0.0.0/20").Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "PutNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), "subnet private01: 0.0.0.0/19 is outside network 10.0.0.0/20")
+			},
+		},
+		{
+			name: "explicit subnets with a layout",
+			payload: types.NetworkRequest{
+				Account:     "1234",
+				PoolID:      "poolid",
+				Provider:    "aws",
+				Environment: "prod",
+				SubnetSize:  20,
+				Layout:      "app-data",
+				Subnets: []*types.SubnetRequest{
+					{Name: "private01", Type: types.Private, CIDR: "0.0.0.0/21"},
+					{Name: "private01", Type: "lambda", CIDR: "0.0.8.0/21"},
+				},
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				er := &types.ErrorResponse{}
+				err := json.NewDecoder(w.Body).Decode(er)
+				require.NoError(t, err)
+				assert.Equal(t, "failed on the 'excluded_with=Layout' tag", er.Errors["subnets"])
+			},
+		},
+		{
+			name: "explicit subnets on a reserved network",
+			payload: types.NetworkRequest{
+				Account:     "1234",
+				PoolID:      "poolid",
+				Provider:    "aws",
+				Environment: "prod",
+				CIDR:        "10.0.0.0/16",
+				Reserved:    types.Bool(true),
+				Subnets: []*types.SubnetRequest{
+					{Name: "private01", Type: types.Private, CIDR: "10.0.0.0/17"},
+				},
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), "reserved and legacy networks take no subnets")
+			},
+		},
+		{
+			name: "layout does not fit",
+			payload: types.NetworkRequest{
+				Account:     "1234",
+				PoolID:      "poolid",
+				Provider:    "aws",
+				Environment: "prod",
+				SubnetSize:  24,
+				Layout:      "wide",
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+				db.On("GetLayout", mock.Anything, "wide").Return(&types.Layout{
+					Name:    "wide",
+					AZCount: 6,
+					Tiers: []*types.LayoutTier{
+						{Name: "app", Type: types.Private, Weight: 1},
+						{Name: "web", Type: types.Public, Weight: 1},
+						{Name: "data", Type: types.Isolated, Weight: 1},
+					},
+				}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				db.On("ReleaseNetwork", mock.Anything, "10.0.0.0/24").Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "PutNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), "tier app of layout wide is smaller than a /28")
+			},
+		},
+		{
+			name: "unknown layout",
+			payload: types.NetworkRequest{
+				Account:     "1234",
+				PoolID:      "poolid",
+				Provider:    "aws",
+				Environment: "prod",
+				SubnetSize:  20,
+				Layout:      "missing",
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+				db.On("GetLayout", mock.Anything, "missing").Return(nil, dbpkg.NotFoundError{Kind: "layout", ID: "missing"})
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			name: "pool exhausted",
+			payload: types.NetworkRequest{
+				Account:       "1234",
+				PoolID:        "poolid",
+				Provider:      "aws",
+				Environment:   "prod",
+				SubnetSize:    20,
+				AttachTGW:     types.Bool(false),
+				PrivateSubnet: types.Bool(true),
+				PublicSubnet:  types.Bool(false),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: server.URL,
+				}, nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(16),
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.0.0.0/16"},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				e := &types.ErrorResponse{}
+				err := json.NewDecoder(w.Body).Decode(e)
+				require.NoError(t, err)
+				assert.Equal(t, types.ErrorPoolExhausted, e.Code)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			s := &fakeSecrets.Secrets{}
+			tt.prepare(t, db, s)
+
+			payload := &bytes.Buffer{}
+			err := json.NewEncoder(payload).Encode(tt.payload)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/", payload)
+			w := httptest.NewRecorder()
+			api := New(db, s)
+
+			api.CreateNetwork(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
+
+func TestCanDetailNetwork(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		prepare func(t *testing.T, db *fakeDb.Database)
+		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "valid network id",
+			id:   "1234",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
+					ID:          types.NewUUID(),
+					Provider:    "aws",
+					Region:      "us-east-1",
+					Account:     "1234",
+					Environment: "prod",
+					CIDR:        "10.10.0.0/16",
+					VpcID:       "vpc-123456789012",
+					Info:        "Legacy VPC",
+					Legacy:      true,
+				}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				n := &types.Network{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				assert.NotNil(t, n.ID)
+				assert.Equal(t, "1234", n.Account)
+				assert.Equal(t, "us-east-1", n.Region)
+				assert.Equal(t, "aws", n.Provider)
+				assert.Equal(t, "prod", n.Environment)
+				assert.Equal(t, "Legacy VPC", n.Info)
+				assert.Equal(t, "10.10.0.0/16", n.CIDR)
+			},
+		},
+		{
+			name: "network not found",
+			id:   "1234",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(nil, dbpkg.NotFoundError{Kind: "network", ID: "1234"})
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusNotFound, w.Code)
+				assert.Equal(t, "{\"code\":\"not_found\",\"errors\":{\"_all\":\"network 1234 not found\"}}\n", w.Body.String())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			tt.prepare(t, db)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+			w := httptest.NewRecorder()
+			api := New(db, nil)
+
+			api.DetailNetwork(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
+
+func TestCanUpdateNetwork(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		payload interface{}
+		prepare func(t *testing.T, db *fakeDb.Database)
+		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "network not found",
+			id:      "1234",
+			payload: &types.NetworkUpdateRequest{Info: types.String("Old Legacy VPC")},
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(nil, dbpkg.NotFoundError{Kind: "network", ID: "1234"})
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			name:    "invalid payload",
+			id:      "1234",
+			payload: "Old Legacy VPC",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{ID: types.NewUUID(), CIDR: "10.10.0.0/16"}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "valid update",
+			id:   "1234",
+			payload: &types.NetworkUpdateRequest{
+				Info: types.String("Old Legacy VPC"),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
+					ID:          types.NewUUID(),
+					Provider:    "aws",
+					Region:      "us-east-1",
+					Account:     "1234",
+					Environment: "prod",
+					CIDR:        "10.10.0.0/16",
+					VpcID:       "vpc-123456789012",
+					Info:        "Legacy VPC",
+					Legacy:      true,
+				}, nil)
+				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+					return (n.Account == "1234" &&
+						n.Region == "us-east-1" &&
+						n.Provider == "aws" &&
+						n.Environment == "prod" &&
+						n.Info == "Old Legacy VPC" &&
+						n.CIDR == "10.10.0.0/16" &&
+						n.Legacy == true)
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				n := &types.Network{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				assert.NotNil(t, n.ID)
+				assert.Equal(t, "1234", n.Account)
+				assert.Equal(t, "us-east-1", n.Region)
+				assert.Equal(t, "aws", n.Provider)
+				assert.Equal(t, "prod", n.Environment)
+				assert.Equal(t, "Old Legacy VPC", n.Info)
+				assert.Equal(t, "10.10.0.0/16", n.CIDR)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			tt.prepare(t, db)
+
+			payload := &bytes.Buffer{}
+			err := json.NewEncoder(payload).Encode(tt.payload)
 			require.NoError(t, err)
 
-			req := httptest.NewRequest(http.MethodGet, "/", payload)
+			req := httptest.NewRequest(http.MethodGet, "/", payload)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+			w := httptest.NewRecorder()
+			api := New(db, nil)
+
+			api.UpdateNetwork(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
+
+func TestCanDeleteNetwork(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		provider http.HandlerFunc
+		prepare  func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string)
+		assert   func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "valid delete",
+			id:   "1234",
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				uuid := types.NewUUID()
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
+					ID:          uuid,
+					Provider:    "aws",
+					Region:      "us-east-1",
+					Account:     "1234",
+					Environment: "prod",
+					CIDR:        "10.10.0.0/16",
+					VpcID:       "vpc-123456789012",
+					Info:        "Legacy VPC",
+					Legacy:      true,
+					Status:      types.StatusActive,
+				}, nil)
+				db.On("ReleaseNetwork", mock.Anything, "10.10.0.0/16").Return(nil)
+				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+					return n.Status == types.StatusDeleted
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "GetProvider", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusOK, w.Code)
+				n := &types.Network{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				assert.NotNil(t, n.ID)
+				assert.Equal(t, "1234", n.Account)
+				assert.Equal(t, "us-east-1", n.Region)
+				assert.Equal(t, "aws", n.Provider)
+				assert.Equal(t, "prod", n.Environment)
+				assert.Equal(t, "Legacy VPC", n.Info)
+				assert.Equal(t, "10.10.0.0/16", n.CIDR)
+				assert.Equal(t, types.StatusDeleted, n.Status)
+			},
+		},
+		{
+			name: "provisioned network",
+			id:   "1234",
+			provider: func(w http.ResponseWriter, r *http.Request) {
+				pw := &types.ProviderWebhook{}
+				_ = json.NewDecoder(r.Body).Decode(pw)
+				if pw.Event != types.DeleteNetwork {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte("{\"id\":\"network-1234\",\"statusCode\":200,\"status\":\"deleting\"}"))
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
+					ID:       types.NewUUID(),
+					Provider: "aws",
+					CIDR:     "10.10.0.0/16",
+					Status:   types.StatusActive,
+				}, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: url,
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+					return n.Status == types.StatusDeleting
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "ReleaseNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusAccepted, w.Code)
+				n := &types.Network{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				assert.Equal(t, types.StatusDeleting, n.Status)
+			},
+		},
+		{
+			name: "provisioned network already gone",
+			id:   "1234",
+			provider: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte("{\"id\":\"network-1234\",\"statusCode\":200,\"status\":\"deleted\"}"))
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
+					ID:       types.NewUUID(),
+					Provider: "aws",
+					CIDR:     "10.10.0.0/16",
+					IPv6CIDR: "2600:1f18:1000::/56",
+					Status:   types.StatusActive,
+				}, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: url,
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("ReleaseNetwork", mock.Anything, "10.10.0.0/16").Return(nil)
+				db.On("ReleaseNetwork", mock.Anything, "2600:1f18:1000::/56").Return(nil)
+				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+					return n.Status == types.StatusDeleted
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "provider fails to delete",
+			id:   "1234",
+			provider: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
+					ID:       types.NewUUID(),
+					Provider: "aws",
+					CIDR:     "10.10.0.0/16",
+					Status:   types.StatusActive,
+				}, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: url,
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "PutNetwork", mock.Anything, mock.Anything)
+				db.AssertNotCalled(t, "ReleaseNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusInternalServerError, w.Code)
+				assert.Equal(t, "{\"errors\":{\"_all\":\"error deleting network: 500 Internal Server Error\"}}\n", w.Body.String())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var url string
+			if tt.provider != nil {
+				server := httptest.NewServer(tt.provider)
+				defer server.Close()
+				url = server.URL
+			}
+
+			db := &fakeDb.Database{}
+			s := &fakeSecrets.Secrets{}
+			tt.prepare(t, db, s, url)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+			w := httptest.NewRecorder()
+			api := New(db, s)
+
+			api.DeleteNetwork(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
+
+func TestCanGetNetworkStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		provider http.HandlerFunc
+		prepare  func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string)
+		assert   func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "active network",
+			id:   "1234",
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				n := &types.Network{
+					ID:       types.NewUUID(),
+					Provider: "aws",
+					CIDR:     "10.10.0.0/16",
+				}
+				n.SetStatus(types.StatusPending, "")
+				n.SetStatus(types.StatusProvisioning, "")
+				n.SetStatus(types.StatusActive, "")
+				db.On("GetNetwork", mock.Anything, "1234").Return(n, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "GetProvider", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusOK, w.Code)
+				sr := &types.NetworkStatusResponse{}
+				err := json.NewDecoder(w.Body).Decode(sr)
+				require.NoError(t, err)
+				assert.Equal(t, types.StatusActive, sr.Status)
+				require.Len(t, sr.History, 3)
+				assert.Equal(t, types.StatusPending, sr.History[0].Status)
+				assert.Equal(t, types.StatusActive, sr.History[2].Status)
+			},
+		},
+		{
+			name: "provisioning network becomes active",
+			id:   "1234",
+			provider: func(w http.ResponseWriter, r *http.Request) {
+				pw := &types.ProviderWebhook{}
+				_ = json.NewDecoder(r.Body).Decode(pw)
+				if pw.Event != types.CheckNework {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte("{\"id\":\"network-1234\",\"statusCode\":200,\"status\":\"active\"}"))
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
+					ID:       types.NewUUID(),
+					Provider: "aws",
+					CIDR:     "10.10.0.0/16",
+					Status:   types.StatusProvisioning,
+				}, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: url,
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+					return n.Status == types.StatusActive
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "ReleaseNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusOK, w.Code)
+				sr := &types.NetworkStatusResponse{}
+				err := json.NewDecoder(w.Body).Decode(sr)
+				require.NoError(t, err)
+				assert.Equal(t, types.StatusActive, sr.Status)
+				assert.Len(t, sr.History, 1)
+			},
+		},
+		{
+			name: "provisioning network failed",
+			id:   "1234",
+			provider: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte("{\"id\":\"network-1234\",\"statusCode\":200,\"status\":\"failed\",\"reason\":\"rollback\"}"))
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
+					ID:       types.NewUUID(),
+					Provider: "aws",
+					CIDR:     "10.10.0.0/16",
+					Status:   types.StatusProvisioning,
+				}, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: url,
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("ReleaseNetwork", mock.Anything, "10.10.0.0/16").Return(nil)
+				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+					return n.Status == types.StatusFailed
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				sr := &types.NetworkStatusResponse{}
+				err := json.NewDecoder(w.Body).Decode(sr)
+				require.NoError(t, err)
+				assert.Equal(t, types.StatusFailed, sr.Status)
+				assert.Equal(t, "rollback", sr.History[0].Reason)
+			},
+		},
+		{
+			name: "provider unreachable",
+			id:   "1234",
+			provider: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
+					ID:       types.NewUUID(),
+					Provider: "aws",
+					CIDR:     "10.10.0.0/16",
+					Status:   types.StatusDeleting,
+				}, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: url,
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "PutNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusOK, w.Code)
+				sr := &types.NetworkStatusResponse{}
+				err := json.NewDecoder(w.Body).Decode(sr)
+				require.NoError(t, err)
+				assert.Equal(t, types.StatusDeleting, sr.Status)
+				assert.Empty(t, sr.History)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var url string
+			if tt.provider != nil {
+				server := httptest.NewServer(tt.provider)
+				defer server.Close()
+				url = server.URL
+			}
+
+			db := &fakeDb.Database{}
+			s := &fakeSecrets.Secrets{}
+			tt.prepare(t, db, s, url)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+			w := httptest.NewRecorder()
+			api := New(db, s)
+
+			api.NetworkStatus(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
+
+func TestCanReceiveProviderEvent(t *testing.T) {
+	provisioning := func() *types.Network {
+		return &types.Network{
+			ID:       types.NewUUID(),
+			Provider: "aws",
+			CIDR:     "10.10.0.0/16",
+			Status:   types.StatusProvisioning,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		auth    string
+		body    string
+		prepare func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets)
+		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "stack complete",
+			id:   "1234",
+			auth: "Bearer token",
+			body: `{"status":"active","vpcID":"vpc-1234","subnets":[{"id":"subnet-1","name":"private01","type":"private","cidr":"10.10.0.0/19"}]}`,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(provisioning(), nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+					return n.Status == types.StatusActive && n.VpcID == "vpc-1234"
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "ReleaseNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusOK, w.Code)
+				n := &types.Network{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				assert.Equal(t, "vpc-1234", n.VpcID)
+				assert.Equal(t, map[string]string{"10.10.0.0/19": "subnet-1"}, n.SubnetIDs)
+			},
+		},
+		{
+			name: "stack complete with stored subnets",
+			id:   "1234",
+			auth: "Bearer token",
+			body: `{"status":"active","vpcID":"vpc-1234","subnets":[{"id":"subnet-1","name":"private01","type":"private","cidr":"10.10.0.0/19","az":"us-east-1a"}]}`,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				n := provisioning()
+				n.Subnets = []*types.Subnet{
+					{Name: "private01", Type: types.Private, CIDR: "10.10.0.0/19"},
+					{Name: "public01", Type: types.Public, CIDR: "10.10.32.0/20"},
+				}
+				db.On("GetNetwork", mock.Anything, "1234").Return(n, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("PutNetwork", mock.Anything, mock.Anything).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				n := &types.Network{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				require.Len(t, n.Subnets, 2)
+				assert.Equal(t, "subnet-1", n.Subnets[0].ID)
+				assert.Equal(t, "us-east-1a", n.Subnets[0].AZ)
+				assert.Empty(t, n.Subnets[1].ID)
+			},
+		},
+		{
+			name: "stack failed",
+			id:   "1234",
+			auth: "Bearer token",
+			body: `{"status":"failed","reason":"The following resource(s) failed to create: [VPC]."}`,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(provisioning(), nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("ReleaseNetwork", mock.Anything, "10.10.0.0/16").Return(nil)
+				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+					return n.Status == types.StatusFailed &&
+						n.StatusHistory[0].Reason == "The following resource(s) failed to create: [VPC]."
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "wrong token",
+			id:   "1234",
+			auth: "Bearer other",
+			body: `{"status":"active"}`,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(provisioning(), nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+		{
+			name: "missing token",
+			id:   "1234",
+			body: `{"status":"active"}`,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(provisioning(), nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+		{
+			name: "invalid status",
+			id:   "1234",
+			auth: "Bearer token",
+			body: `{"status":"done"}`,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(provisioning(), nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "deleted network",
+			id:   "1234",
+			auth: "Bearer token",
+			body: `{"status":"active"}`,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				n := provisioning()
+				n.Status = types.StatusDeleted
+				db.On("GetNetwork", mock.Anything, "1234").Return(n, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusConflict, w.Code)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			s := &fakeSecrets.Secrets{}
+			tt.prepare(t, db, s)
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+			w := httptest.NewRecorder()
+			api := New(db, s)
+
+			api.ProviderEvent(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
+
+func TestCanCheckNetwork(t *testing.T) {
+	inSync := func(w http.ResponseWriter, r *http.Request) {
+		pw := &types.ProviderWebhook{}
+		_ = json.NewDecoder(r.Body).Decode(pw)
+		if pw.Event != types.CheckNework || pw.CIDR != "10.10.0.0/16" || len(pw.Subnets) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"id":"network-1234","statusCode":200,"status":"active","drift":{"status":"in_sync"}}`))
+	}
+
+	tests := []struct {
+		name     string
+		id       string
+		provider http.HandlerFunc
+		prepare  func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string)
+		assert   func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "active network in sync",
+			id:       "1234",
+			provider: inSync,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
+					ID:            types.NewUUID(),
+					Provider:      "aws",
+					CIDR:          "10.10.0.0/16",
+					PrivateSubnet: true,
+					Status:        types.StatusActive,
+				}, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: url,
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+					return n.Drift != nil && n.Drift.Status == types.DriftInSync && !n.Drift.CheckedAt.IsZero()
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				n := &types.Network{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				require.NotNil(t, n.Drift)
+				assert.Equal(t, types.DriftInSync, n.Drift.Status)
+			},
+		},
+		{
+			name: "active network missing",
+			id:   "1234",
+			provider: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`{"id":"network-1234","statusCode":200,"status":"deleted","drift":{"status":"missing"}}`))
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
+					ID:            types.NewUUID(),
+					Provider:      "aws",
+					CIDR:          "10.10.0.0/16",
+					PrivateSubnet: true,
+					Status:        types.StatusActive,
+				}, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: url,
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+					return n.Status == types.StatusActive && n.Drift.Status == types.DriftMissing
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "ReleaseNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name:     "provisioning network becomes active",
+			id:       "1234",
+			provider: inSync,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
+					ID:            types.NewUUID(),
+					Provider:      "aws",
+					CIDR:          "10.10.0.0/16",
+					PrivateSubnet: true,
+					Status:        types.StatusProvisioning,
+				}, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: url,
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("PutNetwork", mock.Anything, mock.Anything).Return(nil).Twice()
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				n := &types.Network{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				assert.Equal(t, types.StatusActive, n.Status)
+				require.NotNil(t, n.Drift)
+			},
+		},
+		{
+			name: "legacy network",
+			id:   "1234",
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
+					ID:       types.NewUUID(),
+					Provider: "aws",
+					CIDR:     "10.10.0.0/16",
+					Legacy:   true,
+					Status:   types.StatusActive,
+				}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "GetProvider", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "provider unreachable",
+			id:   "1234",
+			provider: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets, url string) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
+					ID:            types.NewUUID(),
+					Provider:      "aws",
+					CIDR:          "10.10.0.0/16",
+					PrivateSubnet: true,
+					Status:        types.StatusActive,
+				}, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+					WebhookURL: url,
+				}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var url string
+			if tt.provider != nil {
+				server := httptest.NewServer(tt.provider)
+				defer server.Close()
+				url = server.URL
+			}
+
+			db := &fakeDb.Database{}
+			s := &fakeSecrets.Secrets{}
+			tt.prepare(t, db, s, url)
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
 			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
 			w := httptest.NewRecorder()
-			api := New(db, nil)
+			api := New(db, s)
 
-			api.UpdateNetwork(w, req)
+			api.CheckNetwork(w, req)
 
 			tt.assert(t, db, w)
 		})
 	}
 }
 
-func TestCanDeleteNetwork(t *testing.T) {
+func TestCanCheckNetworks(t *testing.T) {
+	id01 := types.NewUUID()
+	id02 := types.NewUUID()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pw := &types.ProviderWebhook{}
+		_ = json.NewDecoder(r.Body).Decode(pw)
+		if pw.NetworkID == id02.String() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"statusCode":200,"status":"active","drift":{"status":"drifted","differences":["parameter VPCCidr is \"10.20.0.0/16\", expected \"10.10.0.0/16\""]}}`))
+	}))
+	defer server.Close()
+
+	db := &fakeDb.Database{}
+	s := &fakeSecrets.Secrets{}
+	db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+		{ID: id01, Provider: "aws", CIDR: "10.10.0.0/16", PrivateSubnet: true, Status: types.StatusActive},
+		{ID: id02, Provider: "aws", CIDR: "10.11.0.0/16", PrivateSubnet: true, Status: types.StatusActive},
+		{ID: types.NewUUID(), Provider: "aws", CIDR: "10.12.0.0/16", Legacy: true, Status: types.StatusActive},
+		{ID: types.NewUUID(), Provider: "aws", CIDR: "10.13.0.0/16", Status: types.StatusDeleted},
+	}, nil)
+	db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{
+		WebhookURL: server.URL,
+	}, nil)
+	s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+	db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+		return n.ID == id01 && n.Drift.Status == types.DriftDrifted
+	})).Return(nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	w := httptest.NewRecorder()
+	api := New(db, s)
+
+	api.CheckNetworks(w, req)
+
+	db.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, w.Code)
+	cr := &types.NetworkCheckResponse{}
+	err := json.NewDecoder(w.Body).Decode(cr)
+	require.NoError(t, err)
+	require.Len(t, cr.Items, 2)
+	assert.Equal(t, id01.String(), cr.Items[0].ID.String())
+	require.NotNil(t, cr.Items[0].Drift)
+	assert.Equal(t, types.DriftDrifted, cr.Items[0].Drift.Status)
+	assert.Len(t, cr.Items[0].Drift.Differences, 1)
+	assert.Equal(t, id02.String(), cr.Items[1].ID.String())
+	assert.Equal(t, "error checking network: 500 Internal Server Error", cr.Items[1].Error)
+}
+
+func TestCanValidateNetwork(t *testing.T) {
+	pool := &types.Pool{
+		ID:         types.NewUUID(),
+		Name:       "prod",
+		SubnetIP:   "10.0.0.0",
+		SubnetMask: types.Int(16),
+		Exclusions: []*types.Exclusion{{CIDR: "10.0.128.0/20", Reason: "on-prem datacenter", Owner: "infra"}},
+	}
+	existing := []*types.Network{
+		{ID: types.NewUUID(), CIDR: "10.0.4.0/24", IPv6CIDR: "2600:1f18::/56", Account: "1234", Environment: "prod", Status: types.StatusActive},
+		{ID: types.NewUUID(), CIDR: "10.0.8.0/24", Account: "4321", Environment: "dev", Status: types.StatusDeleted},
+	}
+	prepareScan := func(db *fakeDb.Database) {
+		db.On("ScanNetworks", mock.Anything).Return(existing, nil)
+		db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+	}
+
 	tests := []struct {
 		name    string
-		id      string
+		body    string
 		prepare func(t *testing.T, db *fakeDb.Database)
 		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
 	}{
 		{
-			name: "valid delete",
-			id:   "1234",
+			name: "free cidr",
+			body: fmt.Sprintf(`{"cidr":"10.0.8.0/22","poolID":"%s"}`, pool.ID.String()),
 			prepare: func(t *testing.T, db *fakeDb.Database) {
-				uuid := types.NewUUID()
-				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
-					ID:          uuid,
-					Provider:    "aws",
-					Region:      "us-east-1",
-					Account:     "1234",
-					Environment: "prod",
-					CIDR:        "10.10.0.0/16",
-					VpcID:       "vpc-123456789012",
-					Info:        "Legacy VPC",
-					Legacy:      true,
-				}, nil)
-				db.On("DeleteNetwork", mock.Anything, uuid.String()).Return(nil)
+				db.On("GetPool", mock.Anything, pool.ID.String()).Return(pool, nil)
+				prepareScan(db)
 			},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
-				db.AssertExpectations(t)
-				assert.Equal(t, http.StatusOK, w.Code)
-				n := &types.Network{}
-				err := json.NewDecoder(w.Body).Decode(n)
+				require.Equal(t, http.StatusOK, w.Code)
+				vr := &types.NetworkValidateResponse{}
+				err := json.NewDecoder(w.Body).Decode(vr)
 				require.NoError(t, err)
-				assert.NotNil(t, n.ID)
-				assert.Equal(t, "1234", n.Account)
-				assert.Equal(t, "us-east-1", n.Region)
-				assert.Equal(t, "aws", n.Provider)
-				assert.Equal(t, "prod", n.Environment)
-				assert.Equal(t, "Legacy VPC", n.Info)
-				assert.Equal(t, "10.10.0.0/16", n.CIDR)
+				assert.True(t, vr.Valid)
+				assert.Empty(t, vr.Conflicts)
+				assert.Empty(t, vr.Errors)
+			},
+		},
+		{
+			name: "partially overlapping cidr outside the pool",
+			body: fmt.Sprintf(`{"cidr":"10.0.0.0/15","ipv6CIDR":"2600:1f18::/48","poolID":"%s"}`, pool.ID.String()),
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, pool.ID.String()).Return(pool, nil)
+				prepareScan(db)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				require.Equal(t, http.StatusOK, w.Code)
+				vr := &types.NetworkValidateResponse{}
+				err := json.NewDecoder(w.Body).Decode(vr)
+				require.NoError(t, err)
+				assert.False(t, vr.Valid)
+				require.Len(t, vr.Conflicts, 1)
+				assert.Equal(t, existing[0].ID, vr.Conflicts[0].ID)
+				assert.Equal(t, "1234", vr.Conflicts[0].Account)
+				assert.Equal(t, []string{"network 10.0.0.0/15 not in pool range 10.0.0.0-10.0.255.255"}, vr.Errors)
+			},
+		},
+		{
+			name: "excluded cidr",
+			body: fmt.Sprintf(`{"cidr":"10.0.130.0/24","poolID":"%s"}`, pool.ID.String()),
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, pool.ID.String()).Return(pool, nil)
+				prepareScan(db)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				vr := &types.NetworkValidateResponse{}
+				err := json.NewDecoder(w.Body).Decode(vr)
+				require.NoError(t, err)
+				assert.False(t, vr.Valid)
+				assert.Empty(t, vr.Conflicts)
+				assert.Equal(t, []string{"network 10.0.130.0/24 overlaps 10.0.128.0/20 excluded from pool prod by infra: on-prem datacenter"}, vr.Errors)
+			},
+		},
+		{
+			name: "unknown pool",
+			body: `{"cidr":"10.0.8.0/22","poolID":"missing"}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, "missing").Return(nil, dbpkg.NotFoundError{Kind: "pool", ID: "missing"})
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			name:    "missing cidr",
+			body:    `{"poolID":"poolid"}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, `{"code":"invalid","errors":{"cidr":"failed on the 'required_without=IPv6CIDR' tag"}}`+"\n", w.Body.String())
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			db := &fakeDb.Database{}
+			s := &fakeSecrets.Secrets{}
 			tt.prepare(t, db)
 
-			req := httptest.NewRequest(http.MethodGet, "/", nil)
-			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
 			w := httptest.NewRecorder()
-			api := New(db, nil)
+			api := New(db, s)
 
-			api.DeleteNetwork(w, req)
+			api.ValidateNetwork(w, req)
 
 			tt.assert(t, db, w)
 		})
 	}
 }
 
-func TestCanGenerateSubnets(t *testing.T) {
+func TestCanListSubnets(t *testing.T) {
 	tests := []struct {
 		name    string
 		id      string
@@ -522,6 +2509,57 @@ func TestCanGenerateSubnets(t *testing.T) {
 				assert.Equal(t, types.TransitGateway, e.Subnets[8].Type)
 			},
 		},
+		{
+			name: "dual-stack network",
+			id:   "1234",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
+					ID:            types.NewUUID(),
+					CIDR:          "10.1.0.0/16",
+					IPv6CIDR:      "2600:1f18:1000:100::/56",
+					PrivateSubnet: true,
+					PublicSubnet:  true,
+					AttachTGW:     true,
+				}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				e := &types.SubnetResponse{}
+				err := json.NewDecoder(w.Body).Decode(e)
+				require.NoError(t, err)
+				assert.Len(t, e.Subnets, 9)
+				assert.Equal(t, "10.1.0.0/19", e.Subnets[0].CIDR)
+				assert.Equal(t, "2600:1f18:1000:100::/64", e.Subnets[0].IPv6CIDR)
+				assert.Equal(t, "2600:1f18:1000:101::/64", e.Subnets[1].IPv6CIDR)
+				assert.Equal(t, "2600:1f18:1000:108::/64", e.Subnets[8].IPv6CIDR)
+			},
+		},
+		{
+			name: "stored subnets",
+			id:   "1234",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(&types.Network{
+					ID:            types.NewUUID(),
+					CIDR:          "10.1.0.0/16",
+					PrivateSubnet: true,
+					PublicSubnet:  true,
+					AttachTGW:     true,
+					Subnets: []*types.Subnet{
+						{ID: "subnet-1", Name: "private01", Type: types.Private, CIDR: "10.1.0.0/18", AZ: "us-east-1a"},
+					},
+				}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				e := &types.SubnetResponse{}
+				err := json.NewDecoder(w.Body).Decode(e)
+				require.NoError(t, err)
+				require.Len(t, e.Subnets, 1)
+				assert.Equal(t, &types.Subnet{ID: "subnet-1", Name: "private01", Type: types.Private, CIDR: "10.1.0.0/18", AZ: "us-east-1a"}, e.Subnets[0])
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -533,7 +2571,337 @@ func TestCanGenerateSubnets(t *testing.T) {
 			w := httptest.NewRecorder()
 			api := New(db, nil)
 
-			api.GenerateSubnets(w, req)
+			api.ListSubnets(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
+
+func TestCanDetailSubnet(t *testing.T) {
+	tests := []struct {
+		name    string
+		subnet  string
+		network *types.Network
+		assert  func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "stored subnet",
+			subnet: "public02",
+			network: &types.Network{
+				ID:   types.NewUUID(),
+				CIDR: "10.1.0.0/16",
+				Subnets: []*types.Subnet{
+					{ID: "subnet-1", Name: "public01", Type: types.Public, CIDR: "10.1.32.0/20", AZIndex: 0, AZ: "us-east-1a"},
+					{ID: "subnet-2", Name: "public02", Type: types.Public, CIDR: "10.1.96.0/20", AZIndex: 1, AZ: "us-east-1b"},
+				},
+			},
+			assert: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				s := &types.Subnet{}
+				err := json.NewDecoder(w.Body).Decode(s)
+				require.NoError(t, err)
+				assert.Equal(t, "subnet-2", s.ID)
+				assert.Equal(t, "10.1.96.0/20", s.CIDR)
+				assert.Equal(t, 1, s.AZIndex)
+				assert.Equal(t, "us-east-1b", s.AZ)
+			},
+		},
+		{
+			name:   "generated subnet",
+			subnet: "tgw03",
+			network: &types.Network{
+				ID:            types.NewUUID(),
+				CIDR:          "10.1.0.0/16",
+				PrivateSubnet: true,
+				PublicSubnet:  true,
+				AttachTGW:     true,
+				SubnetIDs:     map[string]string{"10.1.176.0/28": "subnet-9"},
+			},
+			assert: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				s := &types.Subnet{}
+				err := json.NewDecoder(w.Body).Decode(s)
+				require.NoError(t, err)
+				assert.Equal(t, "subnet-9", s.ID)
+				assert.Equal(t, 2, s.AZIndex)
+			},
+		},
+		{
+			name:   "subnet not found",
+			subnet: "public04",
+			network: &types.Network{
+				ID:            types.NewUUID(),
+				CIDR:          "10.1.0.0/16",
+				PrivateSubnet: true,
+				PublicSubnet:  true,
+			},
+			assert: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, w.Code)
+				assert.Contains(t, w.Body.String(), "subnet public04 not found")
+			},
+		},
+		{
+			name:   "reserved network",
+			subnet: "private01",
+			network: &types.Network{
+				ID:       types.NewUUID(),
+				CIDR:     "10.1.0.0/16",
+				Reserved: true,
+			},
+			assert: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			db.On("GetNetwork", mock.Anything, "1234").Return(tt.network, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": "1234", "name": tt.subnet})
+			w := httptest.NewRecorder()
+			api := New(db, nil)
+
+			api.DetailSubnet(w, req)
+
+			db.AssertExpectations(t)
+			tt.assert(t, w)
+		})
+	}
+}
+
+func TestCanAddNetworkCIDR(t *testing.T) {
+	poolID := types.NewUUID()
+	pool := &types.Pool{
+		ID:         poolID,
+		Region:     "us-east-1",
+		SubnetIP:   "10.0.0.0",
+		SubnetMask: types.Int(16),
+	}
+	active := func() *types.Network {
+		return &types.Network{
+			ID:       types.NewUUID(),
+			Provider: "aws",
+			CIDR:     "10.0.0.0/20",
+			VpcID:    "vpc-1234",
+			Status:   types.StatusActive,
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pw := &types.ProviderWebhook{}
+		_ = json.NewDecoder(r.Body).Decode(pw)
+		if pw.Event != types.AddCIDR || pw.VpcID != "vpc-1234" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(&types.ProviderWebhookResponse{
+			StatusCode: http.StatusOK,
+			ID:         "vpc-cidr-assoc-1234",
+			Status:     types.StatusActive,
+		})
+	}))
+	defer server.Close()
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failingServer.Close()
+
+	tests := []struct {
+		name    string
+		body    string
+		prepare func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets)
+		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "from the network pool",
+			body: `{"subnetSize":20}`,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(active(), nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{pool}, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{WebhookURL: server.URL}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				db.On("GetPool", mock.Anything, poolID.String()).Return(pool, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{{CIDR: "10.0.0.0/20"}}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.MatchedBy(func(r *types.Reservation) bool {
+					return r.CIDR == "10.0.16.0/20"
+				}), mock.Anything).Return(nil)
+				db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+					return len(n.SecondaryCIDRs) == 1
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusCreated, w.Code)
+				n := &types.Network{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				assert.Equal(t, "10.0.0.0/20", n.CIDR)
+				assert.Equal(t, []*types.SecondaryCIDR{{
+					CIDR:          "10.0.16.0/20",
+					PoolID:        poolID.String(),
+					AssociationID: "vpc-cidr-assoc-1234",
+					Status:        types.StatusActive,
+				}}, n.SecondaryCIDRs)
+			},
+		},
+		{
+			name: "provider failure",
+			body: `{"subnetSize":20,"poolID":"` + poolID.String() + `"}`,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(active(), nil)
+				db.On("GetPool", mock.Anything, poolID.String()).Return(pool, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{WebhookURL: failingServer.URL}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{{CIDR: "10.0.0.0/20"}}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				db.On("ReleaseNetwork", mock.Anything, "10.0.16.0/20").Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "PutNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusInternalServerError, w.Code)
+				assert.Contains(t, w.Body.String(), "error adding CIDR: 500 Internal Server Error")
+			},
+		},
+		{
+			name: "pool policy violated",
+			body: `{"subnetSize":22,"poolID":"` + poolID.String() + `"}`,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				n := active()
+				n.Account = "1234"
+				db.On("GetNetwork", mock.Anything, "1234").Return(n, nil)
+				db.On("GetPool", mock.Anything, poolID.String()).Return(&types.Pool{
+					ID:         poolID,
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(16),
+					Policy: &types.PoolPolicy{
+						PrefixLengths: []int{20},
+						Quotas:        []*types.Quota{{Scope: types.QuotaAccount, MaxNetworks: 1}},
+					},
+				}, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{WebhookURL: server.URL}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{}, "", nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{n}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				e := &types.ErrorResponse{}
+				err := json.NewDecoder(w.Body).Decode(e)
+				require.NoError(t, err)
+				assert.Equal(t, types.ErrorPolicy, e.Code)
+				// the network already counts against the quota of its account
+				assert.Equal(t, []string{"prefix length /22 not one of /20"}, e.Violations)
+			},
+		},
+		{
+			name: "rules turn the CIDR down",
+			body: `{"subnetSize":24}`,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				n := active()
+				n.Environment = "prod"
+				db.On("GetNetwork", mock.Anything, "1234").Return(n, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{pool}, nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{Name: "aws", WebhookURL: server.URL}, nil)
+				s.On("GetAPIToken", mock.Anything, "aws").Return("token", nil)
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{{
+					Name:    "prod-sizes",
+					When:    []*types.RuleCondition{{Field: "environment", Operator: types.RuleIn, Values: []string{"prod"}}},
+					Require: []*types.RuleCondition{{Field: "subnetSize", Operator: types.RuleMax, Limit: 20}},
+				}}, "", nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				e := &types.ErrorResponse{}
+				err := json.NewDecoder(w.Body).Decode(e)
+				require.NoError(t, err)
+				assert.Equal(t, types.ErrorRule, e.Code)
+				assert.Equal(t, []*types.RuleResult{
+					{Rule: "prod-sizes", Outcome: types.RuleFail, Reason: "subnetSize 24 above 20"},
+				}, e.Rules)
+			},
+		},
+		{
+			name: "network not active",
+			body: `{"subnetSize":20}`,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				n := active()
+				n.Status = types.StatusProvisioning
+				db.On("GetNetwork", mock.Anything, "1234").Return(n, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusConflict, w.Code)
+				assert.Contains(t, w.Body.String(), "is provisioning, only active networks take new CIDRs")
+			},
+		},
+		{
+			name: "outside every pool",
+			body: `{"subnetSize":20}`,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				n := active()
+				n.CIDR = "172.16.0.0/20"
+				db.On("GetNetwork", mock.Anything, "1234").Return(n, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{pool}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), "no pool holds network 172.16.0.0/20, set poolID")
+			},
+		},
+		{
+			name: "IPv6 pool",
+			body: `{"subnetSize":20,"poolID":"ipv6"}`,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetNetwork", mock.Anything, "1234").Return(active(), nil)
+				db.On("GetPool", mock.Anything, "ipv6").Return(&types.Pool{
+					SubnetIP:   "2600:1f18::",
+					SubnetMask: types.Int(40),
+				}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), "pool ipv6 is an IPv6 pool, secondary CIDRs are IPv4")
+			},
+		},
+		{
+			name:    "invalid size",
+			body:    `{"subnetSize":12}`,
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "GetNetwork", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, `{"code":"invalid","errors":{"subnetSize":"failed on the 'min=16' tag"}}`+"\n", w.Body.String())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			s := &fakeSecrets.Secrets{}
+			tt.prepare(t, db, s)
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req = mux.SetURLVars(req, map[string]string{"id": "1234"})
+			w := httptest.NewRecorder()
+			api := New(db, s)
+
+			api.AddNetworkCIDR(w, req)
 
 			tt.assert(t, db, w)
 		})
@@ -1,24 +1,39 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/netip"
+	"strconv"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
+	"github.com/olxbr/network-api/pkg/db"
+	"github.com/olxbr/network-api/pkg/net"
 	"github.com/olxbr/network-api/pkg/types"
+	"go4.org/netipx"
 )
 
 func (a *api) ListPools(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	pools, err := a.DB.ScanPools(ctx)
+	o, err := listOptions(r.URL.Query(), poolSorts)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
 
+	pools, next, err := a.DB.ListPools(ctx, o)
 	if err != nil {
 		writeError(w, err, http.StatusBadRequest)
 		return
 	}
+	sortItems(pools, o, poolSorts)
 
 	writeJson(w, types.PoolListResponse{
-		Items: pools,
+		Items:     pools,
+		NextToken: next,
 	}, http.StatusOK)
 }
 
@@ -28,7 +43,7 @@ func (a *api) CreatePool(w http.ResponseWriter, r *http.Request) {
 	pr := &types.PoolRequest{}
 	err := json.NewDecoder(r.Body).Decode(pr)
 	if err != nil {
-		writeError(w, err, http.StatusInternalServerError)
+		writeError(w, err, http.StatusBadRequest)
 		return
 	}
 
@@ -43,6 +58,14 @@ func (a *api) CreatePool(w http.ResponseWriter, r *http.Request) {
 		Name:     pr.Name,
 		Region:   pr.Region,
 		SubnetIP: pr.SubnetIP,
+		Strategy: pr.Strategy,
+		ParentID: pr.ParentID,
+		Admins:   pr.Admins,
+
+		RoutingDomain: pr.RoutingDomain,
+		Labels:        pr.Labels,
+		Priority:      pr.Priority,
+		Policy:        pr.Policy,
 	}
 
 	if pr.SubnetMask != nil {
@@ -51,6 +74,24 @@ func (a *api) CreatePool(w http.ResponseWriter, r *http.Request) {
 		p.SubnetMaxIP = pr.SubnetMaxIP
 	}
 
+	err = checkPoolPolicy(p, p.Policy)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	p.Exclusions, err = poolExclusions(p, pr.Exclusions)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = a.checkPoolRange(ctx, p)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
 	err = a.DB.PutPool(ctx, p)
 	if err != nil {
 		writeError(w, err, http.StatusInternalServerError)
@@ -70,19 +111,340 @@ func (a *api) DetailPool(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	p.QuotaUsage, err = net.New(a.DB).QuotaUsage(ctx, p)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, p, http.StatusOK)
+}
+
+// UpdatePool renames a pool or changes its range, keeping it inside its
+// parent, around the pools delegated from it and the networks allocated from
+// it, and apart from every other pool. Changes are kept in the pool history.
+func (a *api) UpdatePool(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	ur := &types.PoolUpdateRequest{}
+	err := json.NewDecoder(r.Body).Decode(ur)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = validate.Struct(ur)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	p, err := a.DB.GetPool(ctx, params["id"])
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	previousName, previousRange := p.Name, p.Range()
+
+	if ur.Name != "" {
+		p.Name = ur.Name
+	}
+
+	if ur.SubnetIP != "" {
+		if netip.MustParseAddr(ur.SubnetIP).Is6() != p.IsIPv6() {
+			writeError(w, fmt.Errorf("pool %s can't change address family", p.Name), http.StatusBadRequest)
+			return
+		}
+		p.SubnetIP = ur.SubnetIP
+		p.SubnetMask, p.SubnetMaxIP = ur.SubnetMask, ur.SubnetMaxIP
+	}
+
+	if p.Name == previousName && p.Range() == previousRange {
+		writeJson(w, p, http.StatusOK)
+		return
+	}
+
+	if p.Range() != previousRange {
+		err = a.checkPoolResize(ctx, p, previousRange)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	p.RecordChange(previousName, previousRange)
+	err = a.DB.UpdatePool(ctx, p)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
 	writeJson(w, p, http.StatusOK)
 }
 
+// checkPoolResize makes sure the new range of p still holds its exclusions,
+// child pools and networks, and overlaps no other pool.
+func (a *api) checkPoolResize(ctx context.Context, p *types.Pool, previous netipx.IPRange) error {
+	_, err := poolExclusions(p, p.Exclusions)
+	if err != nil {
+		return err
+	}
+
+	err = a.checkPoolRange(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	return net.New(a.DB).CheckPoolResize(ctx, p, previous)
+}
+
+// checkPoolRange makes sure the range of p fits in the pool tree and overlaps
+// no pool of its routing domain outside its own branch. A pool without a
+// routing domain joins the one of its parent.
+func (a *api) checkPoolRange(ctx context.Context, p *types.Pool) error {
+	var parent *types.Pool
+	if p.ParentID != "" {
+		var err error
+		parent, err = a.DB.GetPool(ctx, p.ParentID)
+		if err != nil {
+			return err
+		}
+		if p.RoutingDomain == "" {
+			p.RoutingDomain = parent.RoutingDomain
+		}
+		if parent.IsIPv6() != p.IsIPv6() || parent.RoutingDomain != p.RoutingDomain {
+			return net.PoolNotInParentError{Pool: p, Parent: parent}
+		}
+	}
+
+	pools, err := a.DB.ScanPools(ctx)
+	if err != nil {
+		return err
+	}
+	err = net.CheckPoolRange(p, parent, pools)
+	if err != nil {
+		return err
+	}
+	return net.CheckPoolOverlap(p, pools)
+}
+
+// PoolTree lists pools along with the pools delegated from them, starting
+// from the pool given as root or from every top-level pool.
+func (a *api) PoolTree(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	pools, err := a.DB.ScanPools(ctx)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	root := r.URL.Query().Get("root")
+	items := net.BuildPoolTree(pools, root)
+	if root != "" && len(items) == 0 {
+		writeError(w, db.NotFoundError{Kind: "pool", ID: root}, http.StatusNotFound)
+		return
+	}
+
+	writeJson(w, types.PoolTreeResponse{Items: items}, http.StatusOK)
+}
+
+// DeletePool removes a pool without child pools nor networks. With
+// cascade=reassign its networks are moved to the target pool, or to its
+// parent when none is given, as it is removed.
 func (a *api) DeletePool(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	params := mux.Vars(r)
+	q := r.URL.Query()
+
+	cascade := q.Get("cascade")
+	if cascade != "" && cascade != cascadeReassign {
+		writeError(w, fmt.Errorf("unknown cascade mode %q, use %q", cascade, cascadeReassign), http.StatusBadRequest)
+		return
+	}
+
+	p, err := a.DB.GetPool(ctx, params["id"])
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	pools, err := a.DB.ScanPools(ctx)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	if children := net.Children(p, pools); len(children) > 0 {
+		writeError(w, fmt.Errorf("pool %s has %d child pools: %w", p.Name, len(children), db.ErrConflict), http.StatusConflict)
+		return
+	}
+
+	nm := net.New(a.DB)
+	nets, err := nm.PoolNetworks(ctx, p)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	var target *types.Pool
+	if len(nets) > 0 {
+		if cascade != cascadeReassign {
+			writeError(w, net.PoolInUseError{Pool: p, Networks: nets}, http.StatusConflict)
+			return
+		}
+		target, err = a.reassignTarget(ctx, p, q.Get("target"))
+		if err != nil {
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = nm.DeletePool(ctx, p, target)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, p, http.StatusOK)
+}
+
+// cascadeReassign moves the networks of a deleted pool to another pool.
+const cascadeReassign = "reassign"
+
+// reassignTarget returns the pool taking the networks of p, the one with
+// targetID or the parent of p when empty.
+func (a *api) reassignTarget(ctx context.Context, p *types.Pool, targetID string) (*types.Pool, error) {
+	if targetID == "" {
+		targetID = p.ParentID
+	}
+	if targetID == "" {
+		return nil, fmt.Errorf("pool %s has no parent, give a target pool for its networks", p.Name)
+	}
+	if targetID == p.ID.String() {
+		return nil, fmt.Errorf("pool %s can't take its own networks", p.Name)
+	}
+
+	target, err := a.DB.GetPool(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target.RoutingDomain != p.RoutingDomain {
+		return nil, fmt.Errorf("pool %s is in another routing domain than pool %s", target.Name, p.Name)
+	}
+	return target, nil
+}
+
+// UpdatePoolExclusions replaces the ranges excluded from a pool. Networks
+// already in an excluded range are kept, the exclusions only stop new ones.
+func (a *api) UpdatePoolExclusions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	er := &types.ExclusionsRequest{}
+	err := json.NewDecoder(r.Body).Decode(er)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = validate.Struct(er)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	p, err := a.DB.GetPool(ctx, params["id"])
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	p.Exclusions, err = poolExclusions(p, er.Exclusions)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = a.DB.UpdatePool(ctx, p)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, p, http.StatusOK)
+}
+
+// UpdatePoolLabels replaces the labels and priority networks pick the pool
+// by.
+func (a *api) UpdatePoolLabels(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	lr := &types.PoolLabelsRequest{}
+	err := json.NewDecoder(r.Body).Decode(lr)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = validate.Struct(lr)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	p, err := a.DB.GetPool(ctx, params["id"])
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	p.Labels, p.Priority = lr.Labels, lr.Priority
+	err = a.DB.UpdatePool(ctx, p)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, p, http.StatusOK)
+}
+
+// UpdatePoolPolicy replaces the policy of a pool, an empty policy lifts
+// every rule. Networks already allocated are kept even when they break it.
+func (a *api) UpdatePoolPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	pol := &types.PoolPolicy{}
+	err := json.NewDecoder(r.Body).Decode(pol)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = validate.Struct(pol)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
 
 	p, err := a.DB.GetPool(ctx, params["id"])
 	if err != nil {
 		writeError(w, err, http.StatusInternalServerError)
+		return
 	}
 
-	err = a.DB.DeletePool(ctx, p.ID.String())
+	err = checkPoolPolicy(p, pol)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	p.Policy = pol
+	if len(pol.PrefixLengths) == 0 && len(pol.Environments) == 0 && len(pol.Quotas) == 0 {
+		p.Policy = nil
+	}
+	err = a.DB.UpdatePool(ctx, p)
 	if err != nil {
 		writeError(w, err, http.StatusInternalServerError)
 		return
@@ -90,3 +452,104 @@ func (a *api) DeletePool(w http.ResponseWriter, r *http.Request) {
 
 	writeJson(w, p, http.StatusOK)
 }
+
+// checkPoolPolicy makes sure the prefix lengths of a policy fit in the pool.
+func checkPoolPolicy(p *types.Pool, pol *types.PoolPolicy) error {
+	if pol == nil {
+		return nil
+	}
+	bits := 32
+	if p.IsIPv6() {
+		bits = 128
+	}
+	for _, l := range pol.PrefixLengths {
+		if l > bits {
+			return fmt.Errorf("prefix length /%d too long for pool %s", l, p.Name)
+		}
+	}
+	return nil
+}
+
+// poolExclusions makes sure every exclusion lies within the pool range,
+// returning them with their CIDRs masked.
+func poolExclusions(p *types.Pool, exclusions []*types.Exclusion) ([]*types.Exclusion, error) {
+	masked := []*types.Exclusion{}
+	for _, e := range exclusions {
+		prefix, err := netip.ParsePrefix(e.CIDR)
+		if err != nil {
+			return nil, err
+		}
+		prefix = prefix.Masked()
+		if err := net.CheckInPool(p, prefix); err != nil {
+			return nil, err
+		}
+		masked = append(masked, &types.Exclusion{
+			CIDR:   prefix.String(),
+			Reason: e.Reason,
+			Owner:  e.Owner,
+		})
+	}
+	return masked, nil
+}
+
+func (a *api) PoolUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	nm := net.New(a.DB)
+	u, err := nm.PoolUsage(ctx, params["id"])
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, u, http.StatusOK)
+}
+
+// poolRequestValidation bounds the pool size by address family, IPv4 pools
+// span /8 to /24 and IPv6 pools /20 to /56, and requires SubnetMaxIP to be
+// of the same family as SubnetIP.
+func poolRequestValidation(sl validator.StructLevel) {
+	switch pr := sl.Current().Interface().(type) {
+	case types.PoolRequest:
+		poolRangeValidation(sl, pr.SubnetIP, pr.SubnetMask, pr.SubnetMaxIP)
+	case types.PoolUpdateRequest:
+		if pr.SubnetIP == "" {
+			if pr.SubnetMask != nil || pr.SubnetMaxIP != nil {
+				sl.ReportError(pr.SubnetIP, "subnetIP", "SubnetIP", "required_with", "SubnetMask SubnetMaxIP")
+			}
+			return
+		}
+		if pr.SubnetMask == nil && pr.SubnetMaxIP == nil {
+			sl.ReportError(pr.SubnetMaxIP, "subnetMaxIP", "SubnetMaxIP", "required_without", "SubnetMask")
+		}
+		poolRangeValidation(sl, pr.SubnetIP, pr.SubnetMask, pr.SubnetMaxIP)
+	}
+}
+
+func poolRangeValidation(sl validator.StructLevel, subnetIP string, subnetMask *int, subnetMaxIP *string) {
+	ip, err := netip.ParseAddr(subnetIP)
+	if err != nil {
+		return
+	}
+
+	if subnetMask != nil {
+		min, max := 8, 24
+		if ip.Is6() {
+			min, max = 20, 56
+		}
+		if *subnetMask < min {
+			sl.ReportError(subnetMask, "subnetMask", "SubnetMask", "min", strconv.Itoa(min))
+		}
+		if *subnetMask > max {
+			sl.ReportError(subnetMask, "subnetMask", "SubnetMask", "max", strconv.Itoa(max))
+		}
+	}
+
+	if subnetMaxIP != nil {
+		maxIP, err := netip.ParseAddr(*subnetMaxIP)
+		if err == nil && maxIP.Is6() != ip.Is6() {
+			sl.ReportError(subnetMaxIP, "subnetMaxIP", "SubnetMaxIP", "samefamily", "subnetIP")
+		}
+	}
+}
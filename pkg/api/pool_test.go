@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/gorilla/mux"
+	dbpkg "github.com/olxbr/network-api/pkg/db"
 	fakeDb "github.com/olxbr/network-api/pkg/db/fake"
 	fakeSecrets "github.com/olxbr/network-api/pkg/secret/fake"
 	"github.com/olxbr/network-api/pkg/types"
@@ -21,13 +22,14 @@ import (
 func TestCanListPools(t *testing.T) {
 	tests := []struct {
 		name    string
+		query   string
 		prepare func(t *testing.T, db *fakeDb.Database)
 		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
 	}{
 		{
 			name: "empty response",
 			prepare: func(t *testing.T, db *fakeDb.Database) {
-				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("ListPools", mock.Anything, mock.Anything).Return([]*types.Pool{}, "", nil)
 			},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
@@ -38,7 +40,7 @@ func TestCanListPools(t *testing.T) {
 		{
 			name: "fail to read from database",
 			prepare: func(t *testing.T, db *fakeDb.Database) {
-				db.On("ScanPools", mock.Anything).Return(nil, fmt.Errorf("error"))
+				db.On("ListPools", mock.Anything, mock.Anything).Return(nil, "", fmt.Errorf("error"))
 			},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
@@ -49,7 +51,7 @@ func TestCanListPools(t *testing.T) {
 		{
 			name: "pools",
 			prepare: func(t *testing.T, db *fakeDb.Database) {
-				db.On("ScanPools", mock.Anything).Return([]*types.Pool{
+				db.On("ListPools", mock.Anything, mock.Anything).Return([]*types.Pool{
 					{
 						ID:         types.NewUUID(),
 						Name:       "pool-us",
@@ -64,7 +66,7 @@ func TestCanListPools(t *testing.T) {
 						SubnetIP:    "10.0.0.0",
 						SubnetMaxIP: types.String("10.2.255.255"),
 					},
-				}, nil)
+				}, "", nil)
 			},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
@@ -77,13 +79,37 @@ func TestCanListPools(t *testing.T) {
 				assert.Equal(t, "10.0.0.0", n.Items[1].SubnetIP)
 			},
 		},
+		{
+			name:  "sorted page",
+			query: "sort=cidr&limit=2&nextToken=abc",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("ListPools", mock.Anything, &types.ListOptions{
+					Limit:     2,
+					NextToken: "abc",
+					Sort:      "cidr",
+				}).Return([]*types.Pool{
+					{Name: "pool-b", SubnetIP: "10.2.0.0", SubnetMask: types.Int(16)},
+					{Name: "pool-a", SubnetIP: "10.0.0.0", SubnetMask: types.Int(16)},
+				}, "def", nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				n := &types.PoolListResponse{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				assert.Equal(t, "def", n.NextToken)
+				assert.Equal(t, "pool-a", n.Items[0].Name)
+				assert.Equal(t, "pool-b", n.Items[1].Name)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			db := &fakeDb.Database{}
 			tt.prepare(t, db)
 
-			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
 			w := httptest.NewRecorder()
 			api := New(db, nil)
 
@@ -95,6 +121,12 @@ func TestCanListPools(t *testing.T) {
 }
 
 func TestCanCreatePool(t *testing.T) {
+	corpID := types.NewUUID()
+	corp := &types.Pool{ID: corpID, Name: "corp", SubnetIP: "10.0.0.0", SubnetMask: types.Int(8)}
+	west := &types.Pool{ID: types.NewUUID(), Name: "us-west", SubnetIP: "10.16.0.0", SubnetMask: types.Int(12), ParentID: corpID.String()}
+	sandboxID := types.NewUUID()
+	sandbox := &types.Pool{ID: sandboxID, Name: "sandbox", SubnetIP: "10.0.0.0", SubnetMask: types.Int(8), RoutingDomain: "sandbox"}
+
 	tests := []struct {
 		name    string
 		payload interface{}
@@ -107,7 +139,7 @@ func TestCanCreatePool(t *testing.T) {
 			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
-				assert.Equal(t, http.StatusInternalServerError, w.Code)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
 				assert.Equal(t, "{\"errors\":{\"_all\":\"json: cannot unmarshal string into Go value of type types.PoolRequest\"}}\n", w.Body.String())
 			},
 		},
@@ -122,9 +154,335 @@ func TestCanCreatePool(t *testing.T) {
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
 				assert.Equal(t, http.StatusBadRequest, w.Code)
-				assert.True(t, strings.Contains(w.Body.String(), "Key: 'PoolRequest.Name' Error:Field validation for 'Name' failed on the 'required' tag"))
-				assert.True(t, strings.Contains(w.Body.String(), "Key: 'PoolRequest.Region' Error:Field validation for 'Region' failed on the 'required' tag"))
-				assert.True(t, strings.Contains(w.Body.String(), "Key: 'PoolRequest.SubnetIP' Error:Field validation for 'SubnetIP' failed on the 'required' tag"))
+				assert.Contains(t, w.Body.String(), `"name":"failed on the 'required' tag"`)
+				assert.Contains(t, w.Body.String(), `"region":"failed on the 'required' tag"`)
+				assert.Contains(t, w.Body.String(), `"subnetIP":"failed on the 'required' tag"`)
+			},
+		},
+		{
+			name: "unknown strategy",
+			payload: types.PoolRequest{
+				Name:       "pool-us",
+				Region:     "us-east-1",
+				SubnetIP:   "10.2.0.0",
+				SubnetMask: types.Int(16),
+				Strategy:   "random",
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"strategy":"failed on the 'oneof=first-fit best-fit sparse' tag"`)
+			},
+		},
+		{
+			name: "valid payload with strategy",
+			payload: types.PoolRequest{
+				Name:       "pool-us",
+				Region:     "us-east-1",
+				SubnetIP:   "10.2.0.0",
+				SubnetMask: types.Int(16),
+				Strategy:   types.BestFit,
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("PutPool", mock.Anything, mock.MatchedBy(func(n *types.Pool) bool {
+					return n.Name == "pool-us" && n.Strategy == types.BestFit
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusCreated, w.Code)
+				n := &types.Pool{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				assert.Equal(t, types.BestFit, n.Strategy)
+			},
+		},
+		{
+			name: "valid payload with exclusions",
+			payload: types.PoolRequest{
+				Name:       "pool-us",
+				Region:     "us-east-1",
+				SubnetIP:   "10.0.0.0",
+				SubnetMask: types.Int(8),
+				Exclusions: []*types.Exclusion{
+					{CIDR: "10.100.7.0/16", Reason: "on-prem datacenter", Owner: "infra"},
+				},
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("PutPool", mock.Anything, mock.MatchedBy(func(n *types.Pool) bool {
+					return len(n.Exclusions) == 1 && n.Exclusions[0].CIDR == "10.100.0.0/16"
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusCreated, w.Code)
+				n := &types.Pool{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				require.Len(t, n.Exclusions, 1)
+				assert.Equal(t, "infra", n.Exclusions[0].Owner)
+				assert.Equal(t, "on-prem datacenter", n.Exclusions[0].Reason)
+			},
+		},
+		{
+			name: "exclusion without a reason",
+			payload: types.PoolRequest{
+				Name:       "pool-us",
+				Region:     "us-east-1",
+				SubnetIP:   "10.0.0.0",
+				SubnetMask: types.Int(8),
+				Exclusions: []*types.Exclusion{{CIDR: "10.100.0.0/16", Owner: "infra"}},
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutPool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, `{"code":"invalid","errors":{"exclusions[0].reason":"failed on the 'required' tag"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name: "exclusion outside the pool",
+			payload: types.PoolRequest{
+				Name:       "pool-us",
+				Region:     "us-east-1",
+				SubnetIP:   "10.0.0.0",
+				SubnetMask: types.Int(8),
+				Exclusions: []*types.Exclusion{{CIDR: "192.168.0.0/16", Reason: "office", Owner: "it"}},
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutPool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Equal(t, `{"code":"not_in_pool","errors":{"_all":"network 192.168.0.0/16 not in pool range 10.0.0.0-10.255.255.255"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name: "child pool",
+			payload: types.PoolRequest{
+				Name:       "us-east",
+				Region:     "us-east-1",
+				SubnetIP:   "10.0.0.0",
+				SubnetMask: types.Int(12),
+				ParentID:   corpID.String(),
+				Admins:     []string{"network-team"},
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetPool", mock.Anything, corpID.String()).Return(corp, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{corp, west}, nil)
+				db.On("PutPool", mock.Anything, mock.MatchedBy(func(p *types.Pool) bool {
+					return p.ParentID == corpID.String() && p.Admins[0] == "network-team"
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusCreated, w.Code)
+				p := &types.Pool{}
+				err := json.NewDecoder(w.Body).Decode(p)
+				require.NoError(t, err)
+				assert.Equal(t, corpID.String(), p.ParentID)
+			},
+		},
+		{
+			name: "overlapping another pool",
+			payload: types.PoolRequest{
+				Name:       "pool-us",
+				Region:     "us-east-1",
+				SubnetIP:   "10.2.0.0",
+				SubnetMask: types.Int(16),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{corp, west}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutPool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusConflict, w.Code)
+				assert.Equal(t, `{"code":"conflict","errors":{"_all":"pool pool-us range 10.2.0.0-10.2.255.255 overlaps pool corp range 10.0.0.0-10.255.255.255"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name: "overlapping a pool of another routing domain",
+			payload: types.PoolRequest{
+				Name:          "sandbox",
+				Region:        "us-east-1",
+				SubnetIP:      "10.0.0.0",
+				SubnetMask:    types.Int(8),
+				RoutingDomain: "sandbox",
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{corp, west}, nil)
+				db.On("PutPool", mock.Anything, mock.MatchedBy(func(p *types.Pool) bool {
+					return p.RoutingDomain == "sandbox"
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusCreated, w.Code)
+			},
+		},
+		{
+			name: "child pool joins the routing domain of its parent",
+			payload: types.PoolRequest{
+				Name:       "lab-east",
+				Region:     "us-east-1",
+				SubnetIP:   "10.0.0.0",
+				SubnetMask: types.Int(12),
+				ParentID:   sandboxID.String(),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetPool", mock.Anything, sandboxID.String()).Return(sandbox, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{corp, west, sandbox}, nil)
+				db.On("PutPool", mock.Anything, mock.MatchedBy(func(p *types.Pool) bool {
+					return p.RoutingDomain == "sandbox"
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusCreated, w.Code)
+			},
+		},
+		{
+			name: "child pool of another routing domain",
+			payload: types.PoolRequest{
+				Name:          "us-east",
+				Region:        "us-east-1",
+				SubnetIP:      "10.0.0.0",
+				SubnetMask:    types.Int(12),
+				ParentID:      corpID.String(),
+				RoutingDomain: "sandbox",
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetPool", mock.Anything, corpID.String()).Return(corp, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutPool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Equal(t, `{"code":"not_in_pool","errors":{"_all":"pool us-east routing domain \"sandbox\" not the one of parent pool corp \"\""}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name: "child pool outside its parent",
+			payload: types.PoolRequest{
+				Name:       "us-east",
+				Region:     "us-east-1",
+				SubnetIP:   "11.0.0.0",
+				SubnetMask: types.Int(12),
+				ParentID:   corpID.String(),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetPool", mock.Anything, corpID.String()).Return(corp, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{corp, west}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutPool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Equal(t, `{"code":"not_in_pool","errors":{"_all":"pool us-east range 11.0.0.0-11.15.255.255 not in parent pool corp range 10.0.0.0-10.255.255.255"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name: "child pool overlapping a sibling",
+			payload: types.PoolRequest{
+				Name:       "us-east",
+				Region:     "us-east-1",
+				SubnetIP:   "10.16.0.0",
+				SubnetMask: types.Int(16),
+				ParentID:   corpID.String(),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetPool", mock.Anything, corpID.String()).Return(corp, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{corp, west}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutPool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusConflict, w.Code)
+				assert.Equal(t, `{"code":"conflict","errors":{"_all":"pool us-east range 10.16.0.0-10.16.255.255 overlaps pool us-west range 10.16.0.0-10.31.255.255"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name: "unknown parent pool",
+			payload: types.PoolRequest{
+				Name:       "us-east",
+				Region:     "us-east-1",
+				SubnetIP:   "10.0.0.0",
+				SubnetMask: types.Int(12),
+				ParentID:   "missing",
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("GetPool", mock.Anything, "missing").Return(nil, dbpkg.NotFoundError{Kind: "pool", ID: "missing"})
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutPool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			name: "IPv4 mask out of range",
+			payload: types.PoolRequest{
+				Name:       "pool-us",
+				Region:     "us-east-1",
+				SubnetIP:   "10.2.0.0",
+				SubnetMask: types.Int(40),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"subnetMask":"failed on the 'max=24' tag"`)
+			},
+		},
+		{
+			name: "IPv6 mask out of range",
+			payload: types.PoolRequest{
+				Name:       "pool-us-v6",
+				Region:     "us-east-1",
+				SubnetIP:   "2600:1f18:1000::",
+				SubnetMask: types.Int(64),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"subnetMask":"failed on the 'max=56' tag"`)
+			},
+		},
+		{
+			name: "mixed address families",
+			payload: types.PoolRequest{
+				Name:        "pool-us",
+				Region:      "us-east-1",
+				SubnetIP:    "10.2.0.0",
+				SubnetMaxIP: types.String("2600:1f18:1000::"),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"subnetMaxIP":"failed on the 'samefamily=subnetIP' tag"`)
+			},
+		},
+		{
+			name: "valid IPv6 pool",
+			payload: types.PoolRequest{
+				Name:       "pool-us-v6",
+				Region:     "us-east-1",
+				SubnetIP:   "2600:1f18:1000::",
+				SubnetMask: types.Int(40),
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("PutPool", mock.Anything, mock.MatchedBy(func(n *types.Pool) bool {
+					return n.SubnetIP == "2600:1f18:1000::" && types.ToInt(n.SubnetMask) == 40
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusCreated, w.Code)
+				n := &types.Pool{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				assert.True(t, n.IsIPv6())
 			},
 		},
 		{
@@ -136,6 +494,7 @@ func TestCanCreatePool(t *testing.T) {
 				SubnetMask: types.Int(16),
 			},
 			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
 				db.On("PutPool", mock.Anything, mock.MatchedBy(func(n *types.Pool) bool {
 					return (n.Name == "pool-us" &&
 						n.Region == "us-east-1" &&
@@ -209,6 +568,40 @@ func TestCanDetailPool(t *testing.T) {
 				assert.Equal(t, "us-east-1", n.Region)
 				assert.Equal(t, "10.2.0.0", n.SubnetIP)
 				assert.Equal(t, 16, types.ToInt(n.SubnetMask))
+				assert.Empty(t, n.QuotaUsage)
+			},
+		},
+		{
+			name: "pool with quotas",
+			id:   poolId.String(),
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, poolId.String()).Return(&types.Pool{
+					ID:         poolId,
+					Name:       "pool-us",
+					Region:     "us-east-1",
+					SubnetIP:   "10.2.0.0",
+					SubnetMask: types.Int(16),
+					Policy: &types.PoolPolicy{Quotas: []*types.Quota{
+						{Scope: types.QuotaAccount, MaxNetworks: 4},
+					}},
+				}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.2.0.0/20", Account: "123"},
+					{CIDR: "10.2.16.0/20", Account: "123"},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				n := &types.Pool{}
+				err := json.NewDecoder(w.Body).Decode(n)
+				require.NoError(t, err)
+				require.Len(t, n.QuotaUsage, 1)
+				assert.Equal(t, "123", n.QuotaUsage[0].Value)
+				assert.Equal(t, 2, n.QuotaUsage[0].Networks)
+				assert.Equal(t, int64(8192), n.QuotaUsage[0].Addresses.Int64())
+				assert.Equal(t, 4, n.QuotaUsage[0].MaxNetworks)
 			},
 		},
 	}
@@ -231,10 +624,33 @@ func TestCanDetailPool(t *testing.T) {
 
 func TestCanDeletePool(t *testing.T) {
 	poolId := types.NewUUID()
+	parentID := types.NewUUID()
+	parent := &types.Pool{ID: parentID, Name: "corp", SubnetIP: "10.0.0.0", SubnetMask: types.Int(8)}
+	child := func() *types.Pool {
+		return &types.Pool{
+			ID:         poolId,
+			Name:       "pool-us",
+			Region:     "us-east-1",
+			SubnetIP:   "10.2.0.0",
+			SubnetMask: types.Int(16),
+			ParentID:   parentID.String(),
+		}
+	}
+	prepareNetworks := func(db *fakeDb.Database) {
+		db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+			{ID: types.NewUUID(), CIDR: "10.2.0.0/24", Account: "123", Status: types.StatusActive},
+			{ID: types.NewUUID(), CIDR: "10.3.0.0/24", Account: "456", Status: types.StatusActive},
+		}, nil)
+		db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+			{CIDR: "10.2.0.0/24", NetworkID: "n1", PoolID: poolId.String()},
+			{CIDR: "10.3.0.0/24", NetworkID: "n2", PoolID: parentID.String()},
+		}, nil)
+	}
 
 	tests := []struct {
 		name    string
 		id      string
+		query   string
 		prepare func(t *testing.T, db *fakeDb.Database)
 		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
 	}{
@@ -249,7 +665,12 @@ func TestCanDeletePool(t *testing.T) {
 					SubnetIP:   "10.2.0.0",
 					SubnetMask: types.Int(16),
 				}, nil)
-				db.On("DeletePool", mock.Anything, poolId.String()).Return(nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("DeletePool", mock.Anything, mock.MatchedBy(func(p *types.Pool) bool {
+					return p.ID.String() == poolId.String()
+				}), []*types.Reservation(nil)).Return(nil)
 			},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
@@ -264,18 +685,763 @@ func TestCanDeletePool(t *testing.T) {
 				assert.Equal(t, 16, types.ToInt(n.SubnetMask))
 			},
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			db := &fakeDb.Database{}
-			tt.prepare(t, db)
-
-			req := httptest.NewRequest(http.MethodGet, "/", nil)
-			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
-			w := httptest.NewRecorder()
-			api := New(db, nil)
-
-			api.DeletePool(w, req)
+		{
+			name: "pool with child pools",
+			id:   poolId.String(),
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				p := &types.Pool{
+					ID:         poolId,
+					Name:       "pool-us",
+					Region:     "us-east-1",
+					SubnetIP:   "10.2.0.0",
+					SubnetMask: types.Int(16),
+				}
+				db.On("GetPool", mock.Anything, poolId.String()).Return(p, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{
+					p,
+					{ID: types.NewUUID(), Name: "payments", SubnetIP: "10.2.0.0", SubnetMask: types.Int(20), ParentID: poolId.String()},
+				}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "DeletePool", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusConflict, w.Code)
+				assert.Equal(t, `{"code":"conflict","errors":{"_all":"pool pool-us has 1 child pools: conflicting write"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name: "pool with networks",
+			id:   poolId.String(),
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, poolId.String()).Return(child(), nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{parent, child()}, nil)
+				prepareNetworks(db)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "DeletePool", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusConflict, w.Code)
+				resp := &types.ErrorResponse{}
+				err := json.NewDecoder(w.Body).Decode(resp)
+				require.NoError(t, err)
+				assert.Equal(t, types.ErrorConflict, resp.Code)
+				assert.Contains(t, resp.Errors["_all"], "pool pool-us still holds 10.2.0.0/24")
+				require.Len(t, resp.Conflicts, 1)
+				assert.Equal(t, "10.2.0.0/24", resp.Conflicts[0].CIDR)
+			},
+		},
+		{
+			name:  "reassign networks to the parent",
+			id:    poolId.String(),
+			query: "cascade=reassign",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, poolId.String()).Return(child(), nil)
+				db.On("GetPool", mock.Anything, parentID.String()).Return(parent, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{parent, child()}, nil)
+				prepareNetworks(db)
+				db.On("DeletePool", mock.Anything, mock.MatchedBy(func(p *types.Pool) bool {
+					return p.ID.String() == poolId.String()
+				}), mock.MatchedBy(func(rs []*types.Reservation) bool {
+					return len(rs) == 1 && rs[0].CIDR == "10.2.0.0/24" && rs[0].PoolID == parentID.String()
+				})).Return(nil).Once()
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "UpdateReservation", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name:  "reassign networks without a target",
+			id:    poolId.String(),
+			query: "cascade=reassign",
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				p := child()
+				p.ParentID = ""
+				db.On("GetPool", mock.Anything, poolId.String()).Return(p, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{p}, nil)
+				prepareNetworks(db)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "DeletePool", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, `{"errors":{"_all":"pool pool-us has no parent, give a target pool for its networks"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name:  "reassign networks to a pool not holding them",
+			id:    poolId.String(),
+			query: "cascade=reassign&target=" + parentID.String(),
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, poolId.String()).Return(child(), nil)
+				db.On("GetPool", mock.Anything, parentID.String()).Return(&types.Pool{
+					ID: parentID, Name: "lab", SubnetIP: "172.16.0.0", SubnetMask: types.Int(12),
+				}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{child()}, nil)
+				prepareNetworks(db)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "UpdateReservation", mock.Anything, mock.Anything)
+				db.AssertNotCalled(t, "DeletePool", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Equal(t, `{"code":"not_in_pool","errors":{"_all":"network 10.2.0.0/24 not in pool range 172.16.0.0-172.31.255.255"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name:    "unknown cascade mode",
+			id:      poolId.String(),
+			query:   "cascade=delete",
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "DeletePool", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, `{"errors":{"_all":"unknown cascade mode \"delete\", use \"reassign\""}}`+"\n", w.Body.String())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			tt.prepare(t, db)
+
+			req := httptest.NewRequest(http.MethodDelete, "/?"+tt.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+			w := httptest.NewRecorder()
+			api := New(db, nil)
+
+			api.DeletePool(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
+
+func TestCanUpdatePoolExclusions(t *testing.T) {
+	poolId := types.NewUUID()
+	pool := func() *types.Pool {
+		return &types.Pool{
+			ID:         poolId,
+			Name:       "pool-us",
+			Region:     "us-east-1",
+			SubnetIP:   "10.0.0.0",
+			SubnetMask: types.Int(8),
+			Exclusions: []*types.Exclusion{{CIDR: "10.100.0.0/16", Reason: "on-prem datacenter", Owner: "infra"}},
+			Version:    2,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		prepare func(t *testing.T, db *fakeDb.Database)
+		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "replace exclusions",
+			body: `{"exclusions":[{"cidr":"10.200.0.0/13","reason":"partner VPN","owner":"network-team"}]}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, poolId.String()).Return(pool(), nil)
+				db.On("UpdatePool", mock.Anything, mock.MatchedBy(func(p *types.Pool) bool {
+					return len(p.Exclusions) == 1 && p.Exclusions[0].CIDR == "10.200.0.0/13" && p.Version == 2
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				p := &types.Pool{}
+				err := json.NewDecoder(w.Body).Decode(p)
+				require.NoError(t, err)
+				require.Len(t, p.Exclusions, 1)
+				assert.Equal(t, "network-team", p.Exclusions[0].Owner)
+			},
+		},
+		{
+			name: "clear exclusions",
+			body: `{"exclusions":[]}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, poolId.String()).Return(pool(), nil)
+				db.On("UpdatePool", mock.Anything, mock.MatchedBy(func(p *types.Pool) bool {
+					return len(p.Exclusions) == 0
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.NotContains(t, w.Body.String(), "exclusions")
+			},
+		},
+		{
+			name:    "invalid cidr",
+			body:    `{"exclusions":[{"cidr":"10.200.0.0","reason":"partner VPN","owner":"network-team"}]}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, `{"code":"invalid","errors":{"exclusions[0].cidr":"failed on the 'cidr' tag"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name: "pool changed meanwhile",
+			body: `{"exclusions":[{"cidr":"10.200.0.0/13","reason":"partner VPN","owner":"network-team"}]}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, poolId.String()).Return(pool(), nil)
+				db.On("UpdatePool", mock.Anything, mock.Anything).Return(fmt.Errorf("%w: pool %s changed meanwhile", dbpkg.ErrConflict, poolId))
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusConflict, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"conflict"`)
+			},
+		},
+		{
+			name: "unknown pool",
+			body: `{"exclusions":[]}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, poolId.String()).Return(nil, dbpkg.NotFoundError{Kind: "pool", ID: poolId.String()})
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "UpdatePool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			tt.prepare(t, db)
+
+			req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(tt.body))
+			req = mux.SetURLVars(req, map[string]string{"id": poolId.String()})
+			w := httptest.NewRecorder()
+			api := New(db, nil)
+
+			api.UpdatePoolExclusions(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
+
+func TestCanUpdatePoolLabels(t *testing.T) {
+	poolId := types.NewUUID()
+	pool := func() *types.Pool {
+		return &types.Pool{
+			ID:         poolId,
+			Name:       "pool-us",
+			Region:     "us-east-1",
+			SubnetIP:   "10.0.0.0",
+			SubnetMask: types.Int(8),
+			Labels:     map[string]string{"environment": "prod"},
+			Priority:   1,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		prepare func(t *testing.T, db *fakeDb.Database)
+		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "replace labels",
+			body: `{"labels":{"purpose":"shared"},"priority":10}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, poolId.String()).Return(pool(), nil)
+				db.On("UpdatePool", mock.Anything, mock.MatchedBy(func(p *types.Pool) bool {
+					return len(p.Labels) == 1 && p.Labels["purpose"] == "shared" && p.Priority == 10
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				p := &types.Pool{}
+				err := json.NewDecoder(w.Body).Decode(p)
+				require.NoError(t, err)
+				assert.Equal(t, map[string]string{"purpose": "shared"}, p.Labels)
+				assert.Equal(t, 10, p.Priority)
+			},
+		},
+		{
+			name:    "empty label value",
+			body:    `{"labels":{"purpose":""}}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "UpdatePool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"invalid"`)
+			},
+		},
+		{
+			name: "unknown pool",
+			body: `{"labels":{}}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, poolId.String()).Return(nil, dbpkg.NotFoundError{Kind: "pool", ID: poolId.String()})
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "UpdatePool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			tt.prepare(t, db)
+
+			req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(tt.body))
+			req = mux.SetURLVars(req, map[string]string{"id": poolId.String()})
+			w := httptest.NewRecorder()
+			api := New(db, nil)
+
+			api.UpdatePoolLabels(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
+
+func TestCanUpdatePoolPolicy(t *testing.T) {
+	poolId := types.NewUUID()
+	pool := func() *types.Pool {
+		return &types.Pool{
+			ID:         poolId,
+			Name:       "pool-us",
+			Region:     "us-east-1",
+			SubnetIP:   "10.0.0.0",
+			SubnetMask: types.Int(8),
+			Policy:     &types.PoolPolicy{Environments: []string{"prod"}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		prepare func(t *testing.T, db *fakeDb.Database)
+		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "replace policy",
+			body: `{"prefixLengths":[20,22],"quotas":[{"scope":"account","maxNetworks":3}]}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, poolId.String()).Return(pool(), nil)
+				db.On("UpdatePool", mock.Anything, mock.MatchedBy(func(p *types.Pool) bool {
+					return len(p.Policy.PrefixLengths) == 2 && len(p.Policy.Environments) == 0 &&
+						len(p.Policy.Quotas) == 1 && p.Policy.Quotas[0].MaxNetworks == 3
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "lift policy",
+			body: `{}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, poolId.String()).Return(pool(), nil)
+				db.On("UpdatePool", mock.Anything, mock.MatchedBy(func(p *types.Pool) bool {
+					return p.Policy == nil
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.NotContains(t, w.Body.String(), "policy")
+			},
+		},
+		{
+			name:    "quota without a limit",
+			body:    `{"quotas":[{"scope":"account"}]}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, `{"code":"invalid","errors":{"quotas[0].maxNetworks":"failed on the 'required_without=MaxAddresses' tag"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name:    "unknown quota scope",
+			body:    `{"quotas":[{"scope":"team","maxNetworks":1}]}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"quotas[0].scope":"failed on the 'oneof=account environment' tag"`)
+			},
+		},
+		{
+			name: "prefix length too long",
+			body: `{"prefixLengths":[33]}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, poolId.String()).Return(pool(), nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "UpdatePool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), "prefix length /33 too long for pool pool-us")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			tt.prepare(t, db)
+
+			req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(tt.body))
+			req = mux.SetURLVars(req, map[string]string{"id": poolId.String()})
+			w := httptest.NewRecorder()
+			api := New(db, nil)
+
+			api.UpdatePoolPolicy(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
+
+func TestCanUpdatePool(t *testing.T) {
+	corpID := types.NewUUID()
+	eastID := types.NewUUID()
+	corp := &types.Pool{ID: corpID, Name: "corp", SubnetIP: "10.0.0.0", SubnetMask: types.Int(8)}
+	west := &types.Pool{ID: types.NewUUID(), Name: "us-west", SubnetIP: "10.16.0.0", SubnetMask: types.Int(12), ParentID: corpID.String()}
+	payments := &types.Pool{ID: types.NewUUID(), Name: "payments", SubnetIP: "10.1.0.0", SubnetMask: types.Int(16), ParentID: eastID.String()}
+	partner := &types.Pool{ID: types.NewUUID(), Name: "partner", SubnetIP: "172.16.0.0", SubnetMask: types.Int(12)}
+	east := func() *types.Pool {
+		return &types.Pool{
+			ID:         eastID,
+			Name:       "us-east",
+			Region:     "us-east-1",
+			SubnetIP:   "10.0.0.0",
+			SubnetMask: types.Int(13),
+			ParentID:   corpID.String(),
+			Version:    1,
+			SortKey:    "us-east-1#10.0.0.0#us-east",
+		}
+	}
+	prepareTree := func(db *fakeDb.Database) {
+		db.On("GetPool", mock.Anything, eastID.String()).Return(east(), nil)
+		db.On("GetPool", mock.Anything, corpID.String()).Return(corp, nil)
+		db.On("ScanPools", mock.Anything).Return([]*types.Pool{corp, east(), west, payments, partner}, nil)
+	}
+	prepareNetworks := func(db *fakeDb.Database, nets ...*types.Network) {
+		db.On("ScanNetworks", mock.Anything).Return(nets, nil)
+		db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		prepare func(t *testing.T, db *fakeDb.Database)
+		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "grow within the parent",
+			body: `{"subnetIP":"10.0.0.0","subnetMask":12}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				prepareTree(db)
+				prepareNetworks(db)
+				db.On("UpdatePool", mock.Anything, mock.MatchedBy(func(p *types.Pool) bool {
+					return types.ToInt(p.SubnetMask) == 12 && p.Version == 1
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				p := &types.Pool{}
+				err := json.NewDecoder(w.Body).Decode(p)
+				require.NoError(t, err)
+				assert.Equal(t, 12, types.ToInt(p.SubnetMask))
+				require.Len(t, p.History, 1)
+				assert.Equal(t, "10.0.0.0-10.7.255.255", p.History[0].PreviousRange)
+				assert.Equal(t, "10.0.0.0-10.15.255.255", p.History[0].Range)
+			},
+		},
+		{
+			name: "rename",
+			body: `{"name":"us-east-prod"}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, eastID.String()).Return(east(), nil)
+				db.On("UpdatePool", mock.Anything, mock.MatchedBy(func(p *types.Pool) bool {
+					return p.Name == "us-east-prod" && types.ToInt(p.SubnetMask) == 13
+				})).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "ScanPools", mock.Anything)
+				assert.Equal(t, http.StatusOK, w.Code)
+				p := &types.Pool{}
+				err := json.NewDecoder(w.Body).Decode(p)
+				require.NoError(t, err)
+				require.Len(t, p.History, 1)
+				assert.Equal(t, "us-east", p.History[0].PreviousName)
+				assert.Equal(t, "us-east-prod", p.History[0].Name)
+				assert.Equal(t, p.History[0].PreviousRange, p.History[0].Range)
+			},
+		},
+		{
+			name: "nothing changed",
+			body: `{"name":"us-east","subnetIP":"10.0.0.0","subnetMask":13}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, eastID.String()).Return(east(), nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "UpdatePool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusOK, w.Code)
+				p := &types.Pool{}
+				err := json.NewDecoder(w.Body).Decode(p)
+				require.NoError(t, err)
+				assert.Empty(t, p.History)
+			},
+		},
+		{
+			name: "shrink past a network",
+			body: `{"subnetIP":"10.0.0.0","subnetMask":14}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				prepareTree(db)
+				prepareNetworks(db,
+					&types.Network{CIDR: "10.2.0.0/16", Account: "111", Status: types.StatusActive},
+					&types.Network{CIDR: "10.5.0.0/16", Account: "222", Status: types.StatusActive},
+					&types.Network{CIDR: "192.168.0.0/16", Account: "333", Status: types.StatusActive},
+				)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "UpdatePool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusConflict, w.Code)
+				resp := &types.ErrorResponse{}
+				err := json.NewDecoder(w.Body).Decode(resp)
+				require.NoError(t, err)
+				assert.Equal(t, types.ErrorConflict, resp.Code)
+				assert.Equal(t, "pool us-east range 10.0.0.0-10.3.255.255 leaves out 10.5.0.0/16 (account 222)", resp.Errors["_all"])
+				require.Len(t, resp.Conflicts, 1)
+				assert.Equal(t, "10.5.0.0/16", resp.Conflicts[0].CIDR)
+			},
+		},
+		{
+			name: "move over an unrelated pool",
+			body: `{"subnetIP":"172.16.0.0","subnetMask":13}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, eastID.String()).Return(&types.Pool{
+					ID: eastID, Name: "us-east", SubnetIP: "10.0.0.0", SubnetMask: types.Int(13),
+				}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{partner}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "UpdatePool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusConflict, w.Code)
+				assert.Contains(t, w.Body.String(), "overlaps pool partner")
+			},
+		},
+		{
+			name: "grow into a sibling",
+			body: `{"subnetIP":"10.0.0.0","subnetMask":11}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				prepareTree(db)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "UpdatePool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusConflict, w.Code)
+				assert.Contains(t, w.Body.String(), "overlaps pool us-west")
+			},
+		},
+		{
+			name: "shrink past a child",
+			body: `{"subnetIP":"10.0.0.0","subnetMask":16}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				prepareTree(db)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "UpdatePool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Equal(t, `{"code":"not_in_pool","errors":{"_all":"pool payments range 10.1.0.0-10.1.255.255 not in parent pool us-east range 10.0.0.0-10.0.255.255"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name: "switch address family",
+			body: `{"subnetIP":"2600:1f18::","subnetMask":40}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, eastID.String()).Return(east(), nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "UpdatePool", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, `{"errors":{"_all":"pool us-east can't change address family"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name:    "mask out of range",
+			body:    `{"subnetIP":"10.0.0.0","subnetMask":30}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, `{"code":"invalid","errors":{"subnetMask":"failed on the 'max=24' tag"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name:    "empty update",
+			body:    `{}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, `{"code":"invalid","errors":{"name":"failed on the 'required_without=SubnetIP' tag","subnetIP":"failed on the 'required_without=Name' tag"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name:    "range without a size",
+			body:    `{"subnetIP":"10.0.0.0"}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, `{"code":"invalid","errors":{"subnetMaxIP":"failed on the 'required_without=SubnetMask' tag"}}`+"\n", w.Body.String())
+			},
+		},
+		{
+			name:    "size without a range",
+			body:    `{"name":"us-east-prod","subnetMask":12}`,
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, `{"code":"invalid","errors":{"subnetIP":"failed on the 'required_with=SubnetMask SubnetMaxIP' tag"}}`+"\n", w.Body.String())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			tt.prepare(t, db)
+
+			req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(tt.body))
+			req = mux.SetURLVars(req, map[string]string{"id": eastID.String()})
+			w := httptest.NewRecorder()
+			api := New(db, nil)
+
+			api.UpdatePool(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
+
+func TestCanGetPoolTree(t *testing.T) {
+	corp := &types.Pool{ID: types.NewUUID(), Name: "corp", SubnetIP: "10.0.0.0", SubnetMask: types.Int(8)}
+	east := &types.Pool{ID: types.NewUUID(), Name: "us-east", SubnetIP: "10.0.0.0", SubnetMask: types.Int(12), ParentID: corp.ID.String()}
+	lab := &types.Pool{ID: types.NewUUID(), Name: "lab", SubnetIP: "172.16.0.0", SubnetMask: types.Int(12)}
+
+	tests := []struct {
+		name   string
+		query  string
+		assert func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "every tree",
+			assert: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				tr := &types.PoolTreeResponse{}
+				err := json.NewDecoder(w.Body).Decode(tr)
+				require.NoError(t, err)
+				require.Len(t, tr.Items, 2)
+				assert.Equal(t, "corp", tr.Items[0].Name)
+				require.Len(t, tr.Items[0].Children, 1)
+				assert.Equal(t, "us-east", tr.Items[0].Children[0].Name)
+				assert.Equal(t, corp.ID.String(), tr.Items[0].Children[0].ParentID)
+				assert.Equal(t, "lab", tr.Items[1].Name)
+			},
+		},
+		{
+			name:  "subtree",
+			query: "?root=" + east.ID.String(),
+			assert: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				tr := &types.PoolTreeResponse{}
+				err := json.NewDecoder(w.Body).Decode(tr)
+				require.NoError(t, err)
+				require.Len(t, tr.Items, 1)
+				assert.Equal(t, "us-east", tr.Items[0].Name)
+				assert.Empty(t, tr.Items[0].Children)
+			},
+		},
+		{
+			name:  "unknown root",
+			query: "?root=missing",
+			assert: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, w.Code)
+				assert.Equal(t, `{"code":"not_found","errors":{"_all":"pool missing not found"}}`+"\n", w.Body.String())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			db.On("ScanPools", mock.Anything).Return([]*types.Pool{lab, east, corp}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			w := httptest.NewRecorder()
+			api := New(db, nil)
+
+			api.PoolTree(w, req)
+
+			tt.assert(t, w)
+		})
+	}
+}
+
+func TestCanGetPoolUsage(t *testing.T) {
+	poolId := types.NewUUID()
+
+	tests := []struct {
+		name    string
+		id      string
+		prepare func(t *testing.T, db *fakeDb.Database)
+		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "pool with networks",
+			id:   poolId.String(),
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, poolId.String()).Return(&types.Pool{
+					ID:         poolId,
+					Name:       "pool-us",
+					Region:     "us-east-1",
+					SubnetIP:   "10.2.0.0",
+					SubnetMask: types.Int(16),
+				}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.2.0.0/17"},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				u := &types.PoolUsage{}
+				err := json.NewDecoder(w.Body).Decode(u)
+				require.NoError(t, err)
+				assert.Equal(t, poolId.String(), u.PoolID)
+				assert.Equal(t, "32768", u.Allocated.String())
+				assert.Equal(t, "32768", u.Free.String())
+				assert.Equal(t, "10.2.128.0/17", u.LargestFreePrefix)
+				assert.Equal(t, uint64(128), u.Available["/24"])
+			},
+		},
+		{
+			name: "pool not found",
+			id:   poolId.String(),
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetPool", mock.Anything, poolId.String()).Return(nil, dbpkg.NotFoundError{Kind: "pool", ID: poolId.String()})
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusNotFound, w.Code)
+				assert.Equal(t, fmt.Sprintf("{\"code\":\"not_found\",\"errors\":{\"_all\":\"pool %s not found\"}}\n", poolId.String()), w.Body.String())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			tt.prepare(t, db)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+			w := httptest.NewRecorder()
+			api := New(db, nil)
+
+			api.PoolUsage(w, req)
 
 			tt.assert(t, db, w)
 		})
@@ -10,15 +10,22 @@ import (
 
 func (a *api) ListProviders(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	providers, err := a.DB.ScanProviders(ctx)
+	o, err := listOptions(r.URL.Query(), providerSorts)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
 
+	providers, next, err := a.DB.ListProviders(ctx, o)
 	if err != nil {
 		writeError(w, err, http.StatusBadRequest)
 		return
 	}
+	sortItems(providers, o, providerSorts)
 
 	writeJson(w, types.ProviderListResponse{
-		Items: providers,
+		Items:     providers,
+		NextToken: next,
 	}, http.StatusOK)
 }
 
@@ -28,7 +35,7 @@ func (a *api) CreateProvider(w http.ResponseWriter, r *http.Request) {
 	pr := &types.ProviderRequest{}
 	err := json.NewDecoder(r.Body).Decode(pr)
 	if err != nil {
-		writeError(w, err, http.StatusInternalServerError)
+		writeError(w, err, http.StatusBadRequest)
 		return
 	}
 
@@ -79,12 +86,14 @@ func (a *api) UpdateProvider(w http.ResponseWriter, r *http.Request) {
 	p, err := a.DB.GetProvider(ctx, params["name"])
 	if err != nil {
 		writeError(w, err, http.StatusInternalServerError)
+		return
 	}
 
 	pr := &types.ProviderUpdateRequest{}
 	err = json.NewDecoder(r.Body).Decode(pr)
 	if err != nil {
-		writeError(w, err, http.StatusInternalServerError)
+		writeError(w, err, http.StatusBadRequest)
+		return
 	}
 
 	if pr.WebhookURL != nil {
@@ -115,6 +124,7 @@ func (a *api) DeleteProvider(w http.ResponseWriter, r *http.Request) {
 	p, err := a.DB.GetProvider(ctx, params["name"])
 	if err != nil {
 		writeError(w, err, http.StatusInternalServerError)
+		return
 	}
 
 	err = a.DB.DeleteProvider(ctx, p.Name)
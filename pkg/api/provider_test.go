@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"strings"
 	"testing"
 
 	"github.com/gorilla/mux"
@@ -27,7 +26,7 @@ func TestCanListProviders(t *testing.T) {
 		{
 			name: "empty response",
 			prepare: func(t *testing.T, db *fakeDb.Database) {
-				db.On("ScanProviders", mock.Anything).Return([]*types.Provider{}, nil)
+				db.On("ListProviders", mock.Anything, mock.Anything).Return([]*types.Provider{}, "", nil)
 			},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
@@ -38,7 +37,7 @@ func TestCanListProviders(t *testing.T) {
 		{
 			name: "fail to read from database",
 			prepare: func(t *testing.T, db *fakeDb.Database) {
-				db.On("ScanProviders", mock.Anything).Return(nil, fmt.Errorf("error"))
+				db.On("ListProviders", mock.Anything, mock.Anything).Return(nil, "", fmt.Errorf("error"))
 			},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
@@ -49,7 +48,7 @@ func TestCanListProviders(t *testing.T) {
 		{
 			name: "providers",
 			prepare: func(t *testing.T, db *fakeDb.Database) {
-				db.On("ScanProviders", mock.Anything).Return([]*types.Provider{
+				db.On("ListProviders", mock.Anything, mock.Anything).Return([]*types.Provider{
 					{
 						ID:         types.NewUUID(),
 						Name:       "aws",
@@ -62,7 +61,7 @@ func TestCanListProviders(t *testing.T) {
 						WebhookURL: "https://gcp-napi.provider",
 						APIToken:   "gcpapitoken",
 					},
-				}, nil)
+				}, "", nil)
 			},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
@@ -105,7 +104,7 @@ func TestCanCreateProvider(t *testing.T) {
 			prepare: func(t *testing.T, db *fakeDb.Database, s *fakeSecrets.Secrets) {},
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
-				assert.Equal(t, http.StatusInternalServerError, w.Code)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
 				assert.Equal(t, "{\"errors\":{\"_all\":\"json: cannot unmarshal string into Go value of type types.ProviderRequest\"}}\n", w.Body.String())
 			},
 		},
@@ -120,9 +119,9 @@ func TestCanCreateProvider(t *testing.T) {
 			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
 				db.AssertExpectations(t)
 				assert.Equal(t, http.StatusBadRequest, w.Code)
-				assert.True(t, strings.Contains(w.Body.String(), "Key: 'ProviderRequest.Name' Error:Field validation for 'Name' failed on the 'required' tag"))
-				assert.True(t, strings.Contains(w.Body.String(), "Key: 'ProviderRequest.WebhookURL' Error:Field validation for 'WebhookURL' failed on the 'required' tag"))
-				assert.True(t, strings.Contains(w.Body.String(), "Key: 'ProviderRequest.APIToken' Error:Field validation for 'APIToken' failed on the 'required' tag"))
+				assert.Contains(t, w.Body.String(), `"name":"failed on the 'required' tag"`)
+				assert.Contains(t, w.Body.String(), `"webhookURL":"failed on the 'required' tag"`)
+				assert.Contains(t, w.Body.String(), `"apiToken":"failed on the 'required' tag"`)
 			},
 		},
 		{
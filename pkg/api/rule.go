@@ -0,0 +1,270 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/olxbr/network-api/pkg/db"
+	"github.com/olxbr/network-api/pkg/net"
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+func (a *api) ListRules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	o, err := listOptions(r.URL.Query(), ruleSorts)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	rules, next, err := a.DB.ListRules(ctx, o)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	sortItems(rules, o, ruleSorts)
+
+	writeJson(w, types.RuleListResponse{
+		Items:     rules,
+		NextToken: next,
+	}, http.StatusOK)
+}
+
+func (a *api) CreateRule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	rr := &types.RuleRequest{}
+	err := json.NewDecoder(r.Body).Decode(rr)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	rule, err := newRule(rr)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	_, err = a.DB.GetRule(ctx, rule.Name)
+	if err == nil {
+		writeError(w, fmt.Errorf("rule %s already exists: %w", rule.Name, db.ErrConflict), http.StatusConflict)
+		return
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = a.DB.PutRule(ctx, rule)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, rule, http.StatusCreated)
+}
+
+func (a *api) DetailRule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+	rule, err := a.DB.GetRule(ctx, params["name"])
+
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, rule, http.StatusOK)
+}
+
+// UpdateRule replaces the rule conditions, applying to the networks created
+// from then on.
+func (a *api) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	rule, err := a.DB.GetRule(ctx, params["name"])
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	rr := &types.RuleRequest{}
+	err = json.NewDecoder(r.Body).Decode(rr)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	rr.Name = rule.Name
+
+	rule, err = newRule(rr)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = a.DB.PutRule(ctx, rule)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, rule, http.StatusOK)
+}
+
+func (a *api) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	rule, err := a.DB.GetRule(ctx, params["name"])
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = a.DB.DeleteRule(ctx, rule.Name)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, rule, http.StatusOK)
+}
+
+// EvaluateRules is a dry run of the rules for a network request, nothing is
+// allocated. Without a pool ID the rules see the first pool matching the
+// selector they let the network use, its free space left unchecked.
+func (a *api) EvaluateRules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	er := &types.RuleEvaluateRequest{}
+	err := json.NewDecoder(r.Body).Decode(er)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = validate.Struct(er)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	nr := er.Network
+
+	rules := []*types.Rule{}
+	for _, rr := range er.Rules {
+		rule, err := newRule(rr)
+		if err != nil {
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
+		rules = append(rules, rule)
+	}
+	if len(er.Rules) == 0 {
+		rules, _, err = a.DB.ListRules(ctx, nil)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	in := net.RuleInput{Request: nr}
+	in.Provider, err = a.DB.GetProvider(ctx, nr.Provider)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	if nr.Layout != "" {
+		in.Layout, err = a.DB.GetLayout(ctx, nr.Layout)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	var results []*types.RuleResult
+	if nr.PoolID != "" {
+		in.Pool, err = a.DB.GetPool(ctx, nr.PoolID)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+		results = net.EvaluateRules(rules, in)
+	} else {
+		in.Pool, results, err = a.evaluateSelected(ctx, rules, in)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	resp := &types.RuleEvaluateResponse{
+		Allowed: net.RulesPassed(results),
+		Results: results,
+	}
+	if in.Pool != nil {
+		resp.PoolID, resp.Pool = in.Pool.ID.String(), in.Pool.Name
+	}
+	writeJson(w, resp, http.StatusOK)
+}
+
+// evaluateSelected evaluates the rules against each pool matching the
+// selector of the request, up to the first one they pass. When none does the
+// rules are reported for the first pool, or without a pool when none
+// matches.
+func (a *api) evaluateSelected(ctx context.Context, rules []*types.Rule, in net.RuleInput) (*types.Pool, []*types.RuleResult, error) {
+	var p6 *types.Pool
+	if in.Request.IPv6PoolID != "" {
+		var err error
+		p6, err = a.DB.GetPool(ctx, in.Request.IPv6PoolID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	pools, err := a.DB.ScanPools(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	selected := poolSelector(in.Request, p6).Select(pools)
+	if len(selected) == 0 {
+		return nil, net.EvaluateRules(rules, in), nil
+	}
+
+	var first []*types.RuleResult
+	for _, p := range selected {
+		in.Pool = p
+		results := net.EvaluateRules(rules, in)
+		if net.RulesPassed(results) {
+			return p, results, nil
+		}
+		if first == nil {
+			first = results
+		}
+	}
+	return selected[0], first, nil
+}
+
+// newRule validates a rule request into the rule it describes.
+func newRule(rr *types.RuleRequest) (*types.Rule, error) {
+	err := validate.Struct(rr)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &types.Rule{
+		Name:        rr.Name,
+		Description: rr.Description,
+		When:        rr.When,
+		Require:     rr.Require,
+	}
+	if err := net.CheckRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
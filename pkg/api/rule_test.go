@@ -0,0 +1,276 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	dbpkg "github.com/olxbr/network-api/pkg/db"
+	fakeDb "github.com/olxbr/network-api/pkg/db/fake"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func prodTGWRule() *types.Rule {
+	return &types.Rule{
+		Name:        "prod-tgw",
+		Description: "prod networks must attach the transit gateway",
+		When:        []*types.RuleCondition{{Field: "environment", Operator: types.RuleIn, Values: []string{"prod"}}},
+		Require:     []*types.RuleCondition{{Field: "attachTGW", Operator: types.RuleIn, Values: []string{"true"}}},
+	}
+}
+
+func TestCanCreateRule(t *testing.T) {
+	rule := prodTGWRule()
+	valid := types.RuleRequest{Name: rule.Name, Description: rule.Description, When: rule.When, Require: rule.Require}
+
+	tests := []struct {
+		name    string
+		payload interface{}
+		prepare func(t *testing.T, db *fakeDb.Database)
+		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "missing payload data",
+			payload: types.RuleRequest{},
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"name":"failed on the 'required' tag"`)
+				assert.Contains(t, w.Body.String(), `"require":"failed on the 'required' tag"`)
+			},
+		},
+		{
+			name: "invalid conditions",
+			payload: types.RuleRequest{
+				Name: "broken",
+				When: []*types.RuleCondition{{Field: "environment", Operator: types.RuleIn}},
+				Require: []*types.RuleCondition{
+					{Field: "subnetSize", Operator: "atLeast", Limit: 20},
+				},
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"when[0].values":"failed on the 'required_if=Operator in' tag"`)
+				assert.Contains(t, w.Body.String(), `"require[0].operator":"failed on the 'oneof=in notIn present min max' tag"`)
+			},
+		},
+		{
+			name: "unknown field",
+			payload: types.RuleRequest{
+				Name:    "typo",
+				Require: []*types.RuleCondition{{Field: "enviroment", Operator: types.RulePresent}},
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), "rule typo: unknown field enviroment")
+			},
+		},
+		{
+			name:    "rule already exists",
+			payload: valid,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetRule", mock.Anything, "prod-tgw").Return(prodTGWRule(), nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertNotCalled(t, "PutRule", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusConflict, w.Code)
+				assert.Contains(t, w.Body.String(), "rule prod-tgw already exists")
+			},
+		},
+		{
+			name:    "valid payload data",
+			payload: valid,
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetRule", mock.Anything, "prod-tgw").Return(nil, dbpkg.NotFoundError{Kind: "rule", ID: "prod-tgw"})
+				db.On("PutRule", mock.Anything, prodTGWRule()).Return(nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusCreated, w.Code)
+				r := &types.Rule{}
+				err := json.NewDecoder(w.Body).Decode(r)
+				require.NoError(t, err)
+				assert.Equal(t, prodTGWRule(), r)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			tt.prepare(t, db)
+
+			payload := &bytes.Buffer{}
+			err := json.NewEncoder(payload).Encode(tt.payload)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/", payload)
+			w := httptest.NewRecorder()
+			api := New(db, nil)
+
+			api.CreateRule(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
+
+func TestCanUpdateRule(t *testing.T) {
+	db := &fakeDb.Database{}
+	db.On("GetRule", mock.Anything, "prod-tgw").Return(prodTGWRule(), nil)
+	db.On("PutRule", mock.Anything, mock.MatchedBy(func(r *types.Rule) bool {
+		return r.Name == "prod-tgw" && r.When[0].Values[0] == "prod" && r.When[1].Values[0] == "aws"
+	})).Return(nil)
+
+	rule := prodTGWRule()
+	payload := &bytes.Buffer{}
+	err := json.NewEncoder(payload).Encode(types.RuleRequest{
+		When: append(rule.When,
+			&types.RuleCondition{Field: "provider", Operator: types.RuleIn, Values: []string{"aws"}},
+		),
+		Require: rule.Require,
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPut, "/", payload)
+	req = mux.SetURLVars(req, map[string]string{"name": "prod-tgw"})
+	w := httptest.NewRecorder()
+	api := New(db, nil)
+
+	api.UpdateRule(w, req)
+
+	db.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCanEvaluateRules(t *testing.T) {
+	small := &types.Pool{ID: types.NewUUID(), Name: "small", Region: "us-east-1", SubnetIP: "10.0.0.0", SubnetMask: types.Int(24), Priority: 10}
+	large := &types.Pool{ID: types.NewUUID(), Name: "large", Region: "us-east-1", SubnetIP: "10.1.0.0", SubnetMask: types.Int(16)}
+	request := func(poolID string) *types.NetworkRequest {
+		nr := &types.NetworkRequest{
+			Account:       "123",
+			Provider:      "aws",
+			Environment:   "prod",
+			SubnetSize:    22,
+			AttachTGW:     types.Bool(false),
+			PrivateSubnet: types.Bool(true),
+			PublicSubnet:  types.Bool(false),
+		}
+		if poolID == "" {
+			nr.Region = "us-east-1"
+		}
+		nr.PoolID = poolID
+		return nr
+	}
+
+	tests := []struct {
+		name    string
+		payload interface{}
+		prepare func(t *testing.T, db *fakeDb.Database)
+		assert  func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "missing network",
+			payload: types.RuleEvaluateRequest{},
+			prepare: func(t *testing.T, db *fakeDb.Database) {},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"network":"failed on the 'required' tag"`)
+			},
+		},
+		{
+			name:    "stored rules",
+			payload: types.RuleEvaluateRequest{Network: request(large.ID.String())},
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("ListRules", mock.Anything, mock.Anything).Return([]*types.Rule{prodTGWRule()}, "", nil)
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{Name: "aws"}, nil)
+				db.On("GetPool", mock.Anything, large.ID.String()).Return(large, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "ReserveNetwork", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusOK, w.Code)
+				resp := &types.RuleEvaluateResponse{}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(resp))
+				assert.Equal(t, &types.RuleEvaluateResponse{
+					PoolID: large.ID.String(),
+					Pool:   "large",
+					Results: []*types.RuleResult{
+						{Rule: "prod-tgw", Outcome: types.RuleFail, Reason: "attachTGW false not one of [true]"},
+					},
+				}, resp)
+			},
+		},
+		{
+			name: "draft rules",
+			payload: types.RuleEvaluateRequest{
+				Network: request(large.ID.String()),
+				Rules: []*types.RuleRequest{{
+					Name:    "prod-size",
+					When:    []*types.RuleCondition{{Field: "environment", Operator: types.RuleIn, Values: []string{"prod"}}},
+					Require: []*types.RuleCondition{{Field: "subnetSize", Operator: types.RuleMax, Limit: 22}},
+				}},
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{Name: "aws"}, nil)
+				db.On("GetPool", mock.Anything, large.ID.String()).Return(large, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				db.AssertNotCalled(t, "ListRules", mock.Anything, mock.Anything)
+				assert.Equal(t, http.StatusOK, w.Code)
+				resp := &types.RuleEvaluateResponse{}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(resp))
+				assert.True(t, resp.Allowed)
+				assert.Equal(t, []*types.RuleResult{{Rule: "prod-size", Outcome: types.RulePass}}, resp.Results)
+			},
+		},
+		{
+			name: "pool picked by selector",
+			payload: types.RuleEvaluateRequest{
+				Network: request(""),
+				Rules: []*types.RuleRequest{{
+					Name:    "large-pools",
+					Require: []*types.RuleCondition{{Field: "pool", Operator: types.RuleNotIn, Values: []string{"small"}}},
+				}},
+			},
+			prepare: func(t *testing.T, db *fakeDb.Database) {
+				db.On("GetProvider", mock.Anything, "aws").Return(&types.Provider{Name: "aws"}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{small, large}, nil)
+			},
+			assert: func(t *testing.T, db *fakeDb.Database, w *httptest.ResponseRecorder) {
+				db.AssertExpectations(t)
+				assert.Equal(t, http.StatusOK, w.Code)
+				resp := &types.RuleEvaluateResponse{}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(resp))
+				assert.True(t, resp.Allowed)
+				assert.Equal(t, "large", resp.Pool)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDb.Database{}
+			tt.prepare(t, db)
+
+			payload := &bytes.Buffer{}
+			err := json.NewEncoder(payload).Encode(tt.payload)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/", payload)
+			w := httptest.NewRecorder()
+			api := New(db, nil)
+
+			api.EvaluateRules(w, req)
+
+			tt.assert(t, db, w)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olxbr/network-api/pkg/client"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+func renderLayouts(w io.Writer, ls *types.LayoutListResponse) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Layout", "AZs", "Tier", "Type", "Size", "Name Pattern"})
+	for _, l := range ls.Items {
+		for _, t := range l.Tiers {
+			size := fmt.Sprintf("weight %d", t.Weight)
+			if t.PrefixLength != 0 {
+				size = fmt.Sprintf("/%d", t.PrefixLength)
+			}
+			if err := table.Append([]string{
+				l.Name,
+				strconv.Itoa(l.AZCount),
+				t.Name,
+				string(t.Type),
+				size,
+				t.NamePattern,
+			}); err != nil {
+				log.Printf("error appending to table: %v", err)
+			}
+		}
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+}
+
+// parseTier reads a tier flag, name:type:size[:pattern], where size is a
+// prefix length such as /28 or a weight.
+func parseTier(s string) (*types.LayoutTier, error) {
+	parts := strings.SplitN(s, ":", 4)
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("invalid tier %q, use name:type:size[:pattern]", s)
+	}
+	t := &types.LayoutTier{
+		Name: parts[0],
+		Type: types.SubnetType(parts[1]),
+	}
+	if len(parts) == 4 {
+		t.NamePattern = parts[3]
+	}
+
+	size, isPrefix := strings.CutPrefix(parts[2], "/")
+	n, err := strconv.Atoi(size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size %q for tier %s", parts[2], t.Name)
+	}
+	if isPrefix {
+		t.PrefixLength = n
+	} else {
+		t.Weight = n
+	}
+	return t, nil
+}
+
+func newLayoutCommand() *cobra.Command {
+	layoutCmd := &cobra.Command{
+		Use:   "layout",
+		Short: "Subnet layout operations",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := LoadConfig()
+			if err != nil {
+				log.Printf("Is your config file correctly created?")
+				return err
+			}
+			ctx := cmd.Context()
+			ctx, err = SetupClientContext(WithConfig(ctx, cfg), cfg)
+			if err != nil {
+				return err
+			}
+			cmd.SetContext(ctx)
+			return nil
+		},
+	}
+
+	layoutCmd.AddCommand(layoutAddCmd())
+	layoutCmd.AddCommand(layoutListCmd)
+	layoutCmd.AddCommand(layoutRemoveCmd)
+
+	return layoutCmd
+}
+
+func layoutAddCmd() *cobra.Command {
+	req := &types.LayoutRequest{}
+	var tiers []string
+
+	c := &cobra.Command{
+		Use:   "add",
+		Short: "Adds a new subnet layout",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+
+			req.Name = args[0]
+			for _, s := range tiers {
+				t, err := parseTier(s)
+				if err != nil {
+					log.Printf("Error: %s", err)
+					return
+				}
+				req.Tiers = append(req.Tiers, t)
+			}
+
+			l, err := cli.CreateLayout(ctx, req)
+			if err != nil {
+				log.Printf("error creating layout: %+v", err)
+				return
+			}
+
+			log.Println("Layout:")
+			renderLayouts(cmd.OutOrStdout(), &types.LayoutListResponse{
+				Items: []*types.Layout{l},
+			})
+		},
+	}
+
+	f := c.Flags()
+	f.IntVar(&req.AZCount, "az-count", 3, "Number of availability zones")
+	f.StringArrayVar(&tiers, "tier", nil, "Subnet tier as name:type:size[:pattern], size is a weight or a prefix length such as /28")
+
+	_ = c.MarkFlagRequired("tier")
+
+	return c
+}
+
+var layoutListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available subnet layouts",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		cli, ok := client.ClientFromContext(ctx)
+		if !ok {
+			log.Printf("error retriving client")
+			return
+		}
+		ls := &types.LayoutListResponse{Items: []*types.Layout{}}
+		for l, err := range cli.Layouts(ctx, nil) {
+			if err != nil {
+				log.Printf("Error: %s", err)
+				return
+			}
+			ls.Items = append(ls.Items, l)
+		}
+		renderLayouts(cmd.OutOrStdout(), ls)
+	},
+}
+
+var layoutRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Removes a subnet layout",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		cli, ok := client.ClientFromContext(ctx)
+		if !ok {
+			log.Printf("error retriving client")
+			return
+		}
+
+		name := args[0]
+
+		err := cli.DeleteLayout(ctx, name)
+		if err != nil {
+			log.Printf("Error: %s", err)
+			return
+		}
+
+		log.Printf("Layout removed: %s", name)
+	},
+}
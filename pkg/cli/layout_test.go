@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/olxbr/network-api/pkg/client"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutAddCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   []string
+		prepare func(t *testing.T, w http.ResponseWriter, r *http.Request)
+		assert  func(t *testing.T, out string, e error)
+	}{
+		{
+			name:    "without tier flags",
+			flags:   []string{"default"},
+			prepare: func(t *testing.T, w http.ResponseWriter, r *http.Request) {},
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, e.Error(), `required flag(s) "tier" not set`)
+			},
+		},
+		{
+			name:  "invalid tier",
+			flags: []string{"default", "--tier", "private:private"},
+			prepare: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				t.Error("unexpected request")
+			},
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, `invalid tier "private:private", use name:type:size[:pattern]`)
+			},
+		},
+		{
+			name: "add layout",
+			flags: []string{
+				"default",
+				"--az-count", "2",
+				"--tier", "app:private:2:{tier}-{zone}",
+				"--tier", "tgw:transitGateway:/28",
+			},
+			prepare: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				lr := &types.LayoutRequest{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(lr))
+				assert.Equal(t, &types.LayoutRequest{
+					Name:    "default",
+					AZCount: 2,
+					Tiers: []*types.LayoutTier{
+						{Name: "app", Type: types.Private, Weight: 2, NamePattern: "{tier}-{zone}"},
+						{Name: "tgw", Type: types.TransitGateway, PrefixLength: 28},
+					},
+				}, lr)
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(201)
+				_ = json.NewEncoder(w).Encode(&types.Layout{
+					Name:    lr.Name,
+					AZCount: lr.AZCount,
+					Tiers:   lr.Tiers,
+				})
+			},
+			assert: func(t *testing.T, out string, e error) {
+				assert.NoError(t, e)
+				assert.Contains(t, out, "weight 2")
+				assert.Contains(t, out, "/28")
+				assert.Contains(t, out, "{tier}-{zone}")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.prepare(t, w, r)
+			}))
+			defer s.Close()
+			ctx := context.TODO()
+			ctx = client.WithNewClient(ctx, &client.ClientOptions{
+				Endpoint: s.URL,
+				Client:   &http.Client{},
+			})
+			cmd := layoutAddCmd()
+			var b bytes.Buffer
+			cmd.SetOut(&b)
+			log.SetOutput(&b)
+			cmd.SetArgs(tt.flags)
+			e := cmd.ExecuteContext(ctx)
+			out, err := io.ReadAll(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.assert(t, string(out), e)
+			log.SetOutput(os.Stderr)
+			cmd.SetOut(os.Stdout)
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/olxbr/network-api/pkg/client"
@@ -12,16 +16,22 @@ import (
 
 func renderNetworks(w io.Writer, ns *types.NetworkListResponse) {
 	table := tablewriter.NewWriter(w)
-	table.Header([]string{"ID", "Provider", "Account", "Region", "Environment", "CIDR", "VpcID", "Info"})
+	table.Header([]string{"ID", "Provider", "Account", "Region", "Environment", "CIDR", "IPv6 CIDR", "VpcID", "Status", "Info"})
 	for _, n := range ns.Items {
+		id := ""
+		if n.ID != nil {
+			id = n.ID.String()
+		}
 		if err := table.Append([]string{
-			n.ID.String(),
+			id,
 			n.Provider,
 			n.Account,
 			n.Region,
 			n.Environment,
 			n.CIDR,
+			n.IPv6CIDR,
 			n.VpcID,
+			string(n.Status),
 			n.Info,
 		}); err != nil {
 			log.Printf("error appending to table: %v", err)
@@ -53,9 +63,14 @@ func newNetworkCommand() *cobra.Command {
 	}
 
 	networkCmd.AddCommand(networkAddCmd())
-	networkCmd.AddCommand(networkRemoveCmd)
-	networkCmd.AddCommand(networkListCmd)
+	networkCmd.AddCommand(networkRemoveCmd())
+	networkCmd.AddCommand(networkListCmd())
 	networkCmd.AddCommand(networkInfoCmd)
+	networkCmd.AddCommand(networkStatusCmd())
+	networkCmd.AddCommand(networkCheckCmd())
+	networkCmd.AddCommand(networkImportCmd())
+	networkCmd.AddCommand(networkValidateCmd())
+	networkCmd.AddCommand(networkAddCIDRCmd())
 
 	return networkCmd
 }
@@ -70,6 +85,10 @@ func networkAddCmd() *cobra.Command {
 	var Reserved bool
 	var CIDR string
 	var SubnetSize int
+	var Strategy string
+	var IPv6CIDR string
+	var IPv6SubnetSize int
+	var Subnets []string
 
 	c := &cobra.Command{
 		Use:   "add",
@@ -88,17 +107,57 @@ func networkAddCmd() *cobra.Command {
 					return
 				}
 				req.CIDR = CIDR
+				req.IPv6CIDR = IPv6CIDR
 			} else {
 				req.SubnetSize = SubnetSize
+				req.Strategy = types.AllocationStrategy(Strategy)
+				if req.IPv6PoolID != "" {
+					req.IPv6SubnetSize = IPv6SubnetSize
+				}
 			}
 
-			req.AttachTGW = types.Bool(AttachTGW)
-			req.PrivateSubnet = types.Bool(PrivateSubnet)
-			req.PublicSubnet = types.Bool(PublicSubnet)
+			for _, sub := range Subnets {
+				sr, err := parseSubnet(sub)
+				if err != nil {
+					log.Printf("Error: %s", err)
+					return
+				}
+				req.Subnets = append(req.Subnets, sr)
+			}
+
+			// a layout or a subnet plan replaces the subnet flags
+			if req.Layout == "" && len(req.Subnets) == 0 {
+				req.AttachTGW = types.Bool(AttachTGW)
+				req.PrivateSubnet = types.Bool(PrivateSubnet)
+				req.PublicSubnet = types.Bool(PublicSubnet)
+			}
+
+			// with --dry only the rules are evaluated, nothing is allocated
+			if dryRun {
+				er, err := cli.EvaluateRules(ctx, &types.RuleEvaluateRequest{Network: req})
+				if err != nil {
+					log.Printf("Error: %s", err)
+					return
+				}
+				if er.Pool != "" {
+					log.Printf("Pool: %s (%s)", er.Pool, er.PoolID)
+				}
+				if er.Allowed {
+					log.Printf("The rules allow the network")
+				} else {
+					log.Printf("The network fails the rules")
+				}
+				renderRuleResults(cmd.OutOrStdout(), er.Results)
+				return
+			}
 
 			nr, err := cli.CreateNetwork(ctx, req)
 			if err != nil {
 				log.Printf("error creating network: %+v", err)
+				var ce *client.Error
+				if errors.As(err, &ce) && len(ce.Response.Rules) > 0 {
+					renderRuleResults(cmd.OutOrStdout(), ce.Response.Rules)
+				}
 				return
 			}
 
@@ -106,6 +165,9 @@ func networkAddCmd() *cobra.Command {
 			renderNetworks(cmd.OutOrStdout(), &types.NetworkListResponse{
 				Items: []*types.Network{nr.Network},
 			})
+			if nr.Selection != nil {
+				renderSelection(nr.Selection)
+			}
 		},
 	}
 
@@ -113,18 +175,26 @@ func networkAddCmd() *cobra.Command {
 	f.StringVar(&req.Provider, "provider", "", "Provider")
 	f.StringVar(&req.Account, "account", "", "Account")
 	f.StringVar(&req.PoolID, "pool-id", "", "Pool ID")
+	f.StringVar(&req.Region, "region", "", "Region to pick the pool in, instead of --pool-id")
+	f.StringToStringVar(&req.Selector, "selector", nil, "Labels the picked pool must carry as key=value, along with --region")
 	f.StringVarP(&req.Environment, "environment", "e", "", "Environment")
 	f.IntVar(&SubnetSize, "subnet-size", 0, "subnet")
+	f.StringVar(&req.IPv6PoolID, "ipv6-pool-id", "", "IPv6 Pool ID, allocates a dual-stack network")
+	f.IntVar(&IPv6SubnetSize, "ipv6-subnet-size", 0, "IPv6 subnet, defaults to 56")
+	f.StringVar(&Strategy, "strategy", "", "Allocation strategy, overrides the pool default: first-fit, best-fit or sparse")
 
 	f.StringVar(&req.Info, "info", "", "Extra information about the VPC")
 
 	f.BoolVar(&AttachTGW, "transit-gateway", true, "Attach transit gateway")
 	f.BoolVar(&PrivateSubnet, "private", true, "Private subnet")
 	f.BoolVar(&PublicSubnet, "public", true, "Public subnet")
+	f.StringVar(&req.Layout, "layout", "", "Subnet layout, replaces the subnet flags")
+	f.StringArrayVar(&Subnets, "subnet", nil, "Subnet as name:type:cidr, repeat for each subnet of an explicit plan, replaces the subnet flags")
 
 	f.BoolVar(&Legacy, "legacy", false, "Legacy network - requires CIDR")
 	f.BoolVar(&Reserved, "reserved", false, "Reserverd network - requires CIDR")
 	f.StringVar(&CIDR, "cidr", "", "CIDR")
+	f.StringVar(&IPv6CIDR, "ipv6-cidr", "", "IPv6 CIDR for a dual-stack legacy or reserved network")
 
 	err := c.MarkFlagRequired("provider")
 	if err != nil {
@@ -138,11 +208,9 @@ func networkAddCmd() *cobra.Command {
 		return nil
 	}
 
-	err = c.MarkFlagRequired("pool-id")
-	if err != nil {
-		log.Printf("error marking pool-id flag required: %+v", err)
-		return nil
-	}
+	c.MarkFlagsOneRequired("pool-id", "region")
+	c.MarkFlagsMutuallyExclusive("pool-id", "region")
+	c.MarkFlagsMutuallyExclusive("pool-id", "selector")
 
 	err = c.MarkFlagRequired("environment")
 	if err != nil {
@@ -153,10 +221,176 @@ func networkAddCmd() *cobra.Command {
 	return c
 }
 
-var networkRemoveCmd = &cobra.Command{
-	Use:   "remove",
-	Short: "Removes a network",
-	Run:   func(cmd *cobra.Command, args []string) {},
+// renderSelection tells which pool was picked for a network and the matching
+// pools passed over before it.
+func renderSelection(s *types.PoolSelection) {
+	log.Printf("Pool: %s (%s), %s", s.Pool, s.PoolID, s.Reason)
+	for _, sk := range s.Skipped {
+		log.Printf("Skipped pool %s (%s): %s", sk.Pool, sk.PoolID, sk.Reason)
+	}
+}
+
+// parseSubnet reads a subnet of an explicit plan given as name:type:cidr.
+func parseSubnet(s string) (*types.SubnetRequest, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid subnet %q, use name:type:cidr", s)
+	}
+	return &types.SubnetRequest{
+		Name: parts[0],
+		Type: types.SubnetType(parts[1]),
+		CIDR: parts[2],
+	}, nil
+}
+
+func renderImport(w io.Writer, ir *types.NetworkImportResponse) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"VpcID", "Name", "CIDR", "IPv6 CIDR", "Action", "Reason", "Network ID"})
+	for _, i := range ir.Items {
+		networkID := ""
+		if i.NetworkID != nil {
+			networkID = i.NetworkID.String()
+		}
+		if err := table.Append([]string{
+			i.VpcID,
+			i.Name,
+			i.CIDR,
+			i.IPv6CIDR,
+			string(i.Action),
+			i.Reason,
+			networkID,
+		}); err != nil {
+			log.Printf("error appending to table: %v", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+}
+
+func networkImportCmd() *cobra.Command {
+	req := &types.NetworkImportRequest{}
+
+	var yes bool
+
+	c := &cobra.Command{
+		Use:   "import",
+		Short: "Imports existing provider networks as legacy networks",
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+
+			req.DryRun = true
+			report, err := cli.ImportNetworks(ctx, req)
+			if err != nil {
+				log.Printf("Error: %s", err)
+				return
+			}
+			renderImport(cmd.OutOrStdout(), report)
+
+			// import exactly what the report shows
+			vpcIDs := []string{}
+			for _, i := range report.Items {
+				if i.Action == types.ImportCreate {
+					vpcIDs = append(vpcIDs, i.VpcID)
+				}
+			}
+			if dryRun {
+				return
+			}
+			if len(vpcIDs) == 0 {
+				log.Println("Nothing to import")
+				return
+			}
+			if !yes && !confirm(cmd, fmt.Sprintf("Import %d networks?", len(vpcIDs))) {
+				log.Println("Aborted")
+				return
+			}
+
+			req.DryRun = false
+			req.VpcIDs = vpcIDs
+			ir, err := cli.ImportNetworks(ctx, req)
+			if err != nil {
+				log.Printf("Error: %s", err)
+				return
+			}
+			log.Println("Imported:")
+			renderImport(cmd.OutOrStdout(), ir)
+		},
+	}
+
+	f := c.Flags()
+	f.StringVar(&req.Provider, "provider", "", "Provider")
+	f.StringVar(&req.Account, "account", "", "Account")
+	f.StringVar(&req.PoolID, "pool-id", "", "Pool ID, its region is the one queried")
+	f.StringVar(&req.IPv6PoolID, "ipv6-pool-id", "", "IPv6 Pool ID, imports the IPv6 CIDRs too")
+	f.StringVarP(&req.Environment, "environment", "e", "", "Environment")
+	f.StringSliceVar(&req.VpcIDs, "vpc-id", nil, "Only import these networks, default ones included")
+	f.BoolVarP(&yes, "yes", "y", false, "Skip the confirmation prompt")
+
+	for _, name := range []string{"provider", "account", "pool-id", "environment"} {
+		err := c.MarkFlagRequired(name)
+		if err != nil {
+			log.Printf("error marking %s flag required: %+v", name, err)
+			return nil
+		}
+	}
+
+	return c
+}
+
+func networkRemoveCmd() *cobra.Command {
+	var yes bool
+
+	c := &cobra.Command{
+		Use:   "remove <network_id>",
+		Short: "Removes a network",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+
+			networkID := args[0]
+			if !yes {
+				n, err := cli.DetailNetwork(ctx, networkID)
+				if err != nil {
+					log.Printf("Error: %s", err)
+					return
+				}
+
+				renderNetworks(cmd.OutOrStdout(), &types.NetworkListResponse{
+					Items: []*types.Network{n},
+				})
+				if !confirm(cmd, fmt.Sprintf("Remove network %s (%s)?", networkID, n.CIDR)) {
+					log.Println("Aborted")
+					return
+				}
+			}
+
+			n, err := cli.DeleteNetwork(ctx, networkID)
+			if err != nil {
+				log.Printf("Error: %s", err)
+				return
+			}
+
+			if n.Status == types.StatusDeleted {
+				log.Printf("Network removed: %s", n.ID.String())
+			} else {
+				log.Printf("Network removal requested: %s, follow it with: network status --wait %s", n.ID.String(), n.ID.String())
+			}
+		},
+	}
+
+	c.Flags().BoolVarP(&yes, "yes", "y", false, "Skip the confirmation prompt")
+	return c
 }
 
 var networkInfoCmd = &cobra.Command{
@@ -173,6 +407,10 @@ var networkInfoCmd = &cobra.Command{
 
 		networkID := args[0]
 		n, err := cli.DetailNetwork(ctx, networkID)
+		if errors.Is(err, client.ErrNotFound) {
+			log.Printf("Network not found: %s", networkID)
+			return
+		}
 		if err != nil {
 			log.Printf("Error: %s", err)
 			return
@@ -182,24 +420,320 @@ var networkInfoCmd = &cobra.Command{
 		renderNetworks(cmd.OutOrStdout(), &types.NetworkListResponse{
 			Items: []*types.Network{n},
 		})
+
+		if len(n.SecondaryCIDRs) > 0 {
+			log.Println("Secondary CIDRs:")
+			renderSecondaryCIDRs(cmd.OutOrStdout(), n.SecondaryCIDRs)
+		}
+
+		if len(n.Subnets) > 0 {
+			log.Println("Subnets:")
+			renderSubnets(cmd.OutOrStdout(), n.Subnets)
+		}
+
+		if n.Drift != nil {
+			log.Println("Drift:")
+			renderDrift(cmd.OutOrStdout(), []*types.NetworkCheckResult{
+				{ID: n.ID, Drift: n.Drift},
+			})
+		}
 	},
 }
 
-var networkListCmd = &cobra.Command{
-	Use:   "list",
-	Short: "List networks",
-	Run: func(cmd *cobra.Command, args []string) {
-		ctx := cmd.Context()
-		cli, ok := client.ClientFromContext(ctx)
-		if !ok {
-			log.Printf("error retriving client")
-			return
+func renderSecondaryCIDRs(w io.Writer, cidrs []*types.SecondaryCIDR) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"CIDR", "Pool ID", "Association ID", "Status"})
+	for _, sc := range cidrs {
+		if err := table.Append([]string{
+			sc.CIDR,
+			sc.PoolID,
+			sc.AssociationID,
+			string(sc.Status),
+		}); err != nil {
+			log.Printf("error appending to table: %v", err)
 		}
-		ns, err := cli.ListNetworks(ctx)
-		if err != nil {
-			log.Printf("Error: %s", err)
-			return
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+}
+
+func renderSubnets(w io.Writer, subnets []*types.Subnet) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Name", "Type", "Tier", "CIDR", "IPv6 CIDR", "AZ", "ID"})
+	for _, s := range subnets {
+		if err := table.Append([]string{
+			s.Name,
+			string(s.Type),
+			s.Tier,
+			s.CIDR,
+			s.IPv6CIDR,
+			s.AZ,
+			s.ID,
+		}); err != nil {
+			log.Printf("error appending to table: %v", err)
 		}
-		renderNetworks(cmd.OutOrStdout(), ns)
-	},
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+}
+
+func renderDrift(w io.Writer, results []*types.NetworkCheckResult) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"ID", "Drift", "Checked At", "Differences"})
+	for _, r := range results {
+		row := []string{r.ID.String(), "", "", r.Error}
+		if r.Drift != nil {
+			row[1] = string(r.Drift.Status)
+			row[2] = r.Drift.CheckedAt.Format(time.RFC3339)
+			row[3] = strings.Join(r.Drift.Differences, "\n")
+		}
+		if err := table.Append(row); err != nil {
+			log.Printf("error appending to table: %v", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+}
+
+func networkCheckCmd() *cobra.Command {
+	var all bool
+
+	c := &cobra.Command{
+		Use:   "check [<network_id>]",
+		Short: "Checks networks for drift against their provider",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if all {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+
+			if all {
+				cr, err := cli.CheckNetworks(ctx)
+				if err != nil {
+					log.Printf("Error: %s", err)
+					return
+				}
+				renderDrift(cmd.OutOrStdout(), cr.Items)
+				return
+			}
+
+			n, err := cli.CheckNetwork(ctx, args[0])
+			if err != nil {
+				log.Printf("Error: %s", err)
+				return
+			}
+			if n.Drift == nil {
+				log.Printf("Network %s is %s, drift is only checked on active networks", n.ID.String(), n.Status)
+				return
+			}
+			renderDrift(cmd.OutOrStdout(), []*types.NetworkCheckResult{
+				{ID: n.ID, Drift: n.Drift},
+			})
+		},
+	}
+
+	c.Flags().BoolVar(&all, "all", false, "Check every network managed by a provider")
+	return c
+}
+
+func networkValidateCmd() *cobra.Command {
+	req := &types.NetworkValidateRequest{}
+
+	c := &cobra.Command{
+		Use:   "validate",
+		Short: "Checks whether a CIDR is free and fits in its pool",
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+
+			vr, err := cli.ValidateNetwork(ctx, req)
+			if err != nil {
+				log.Printf("Error: %s", err)
+				return
+			}
+			for _, e := range vr.Errors {
+				log.Printf("Error: %s", e)
+			}
+			if len(vr.Conflicts) > 0 {
+				log.Println("Overlapping networks:")
+				renderNetworks(cmd.OutOrStdout(), &types.NetworkListResponse{
+					Items: vr.Conflicts,
+				})
+			}
+			if vr.Valid {
+				log.Printf("CIDR is available")
+			}
+		},
+	}
+
+	f := c.Flags()
+	f.StringVar(&req.CIDR, "cidr", "", "IPv4 CIDR")
+	f.StringVar(&req.IPv6CIDR, "ipv6-cidr", "", "IPv6 CIDR")
+	f.StringVar(&req.PoolID, "pool-id", "", "Pool the CIDR must fit in")
+	f.StringVar(&req.IPv6PoolID, "ipv6-pool-id", "", "Pool the IPv6 CIDR must fit in")
+	f.StringVar(&req.RoutingDomain, "routing-domain", "", "Routing domain to check for overlaps, the pool's one by default")
+	return c
+}
+
+func renderNetworkStatus(w io.Writer, sr *types.NetworkStatusResponse) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Status", "Reason", "Timestamp"})
+	for _, c := range sr.History {
+		if err := table.Append([]string{
+			string(c.Status),
+			c.Reason,
+			c.Timestamp.Format(time.RFC3339),
+		}); err != nil {
+			log.Printf("error appending to table: %v", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+}
+
+func networkAddCIDRCmd() *cobra.Command {
+	req := &types.NetworkCIDRRequest{}
+	var Strategy string
+
+	c := &cobra.Command{
+		Use:   "add-cidr <network_id>",
+		Short: "Attaches a secondary CIDR to a network",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+
+			req.Strategy = types.AllocationStrategy(Strategy)
+			n, err := cli.AddNetworkCIDR(ctx, args[0], req)
+			if err != nil {
+				log.Printf("Error: %s", err)
+				return
+			}
+
+			log.Println("Secondary CIDRs:")
+			renderSecondaryCIDRs(cmd.OutOrStdout(), n.SecondaryCIDRs)
+		},
+	}
+
+	f := c.Flags()
+	f.IntVar(&req.SubnetSize, "subnet-size", 0, "Size of the new block, from 16 to 28")
+	f.StringVar(&req.PoolID, "pool-id", "", "Pool ID, defaults to the pool holding the network")
+	f.StringVar(&Strategy, "strategy", "", "Allocation strategy, overrides the pool default: first-fit, best-fit or sparse")
+
+	err := c.MarkFlagRequired("subnet-size")
+	if err != nil {
+		log.Printf("error marking subnet-size flag required: %+v", err)
+		return nil
+	}
+	return c
+}
+
+func networkStatusCmd() *cobra.Command {
+	var wait bool
+	var interval time.Duration
+
+	c := &cobra.Command{
+		Use:   "status <network_id>",
+		Short: "Show network status history",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+
+			networkID := args[0]
+			for {
+				sr, err := cli.NetworkStatus(ctx, networkID)
+				if err != nil {
+					log.Printf("Error: %s", err)
+					return
+				}
+
+				if !wait || sr.Status.Settled() {
+					log.Printf("Network %s is %s", networkID, sr.Status)
+					renderNetworkStatus(cmd.OutOrStdout(), sr)
+					return
+				}
+
+				log.Printf("Network %s is %s, checking again in %s", networkID, sr.Status, interval)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(interval):
+				}
+			}
+		},
+	}
+
+	f := c.Flags()
+	f.BoolVarP(&wait, "wait", "w", false, "Poll until the network is active, failed or deleted")
+	f.DurationVar(&interval, "interval", 15*time.Second, "Polling interval used with --wait")
+	return c
+}
+
+func networkListCmd() *cobra.Command {
+	f := &types.NetworkFilter{}
+	o := &types.ListOptions{}
+	var desc bool
+
+	c := &cobra.Command{
+		Use:   "list",
+		Short: "List networks",
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+			if desc {
+				o.Order = types.SortDesc
+			}
+
+			ns := &types.NetworkListResponse{Items: []*types.Network{}}
+			for n, err := range cli.Networks(ctx, f, o) {
+				if err != nil {
+					log.Printf("Error: %s", err)
+					return
+				}
+				ns.Items = append(ns.Items, n)
+			}
+			renderNetworks(cmd.OutOrStdout(), ns)
+		},
+	}
+
+	flags := c.Flags()
+	flags.StringVar(&f.Provider, "provider", "", "Only networks of this provider")
+	flags.StringVar(&f.Region, "region", "", "Only networks in this region")
+	flags.StringVar(&f.Account, "account", "", "Only networks in this account")
+	flags.StringVarP(&f.Environment, "environment", "e", "", "Only networks in this environment")
+	flags.StringVar(&f.PoolID, "pool-id", "", "Only networks allocated from this pool")
+	flags.StringVar(&f.Contains, "contains", "", "Only networks containing this IP or CIDR")
+	flags.StringVar(&o.Sort, "sort", "", "Sort by provider, region, account, environment, status or cidr")
+	flags.BoolVar(&desc, "desc", false, "Sort in descending order")
+	flags.IntVar(&o.Limit, "page-size", 0, "Networks fetched per request")
+	return c
 }
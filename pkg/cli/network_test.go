@@ -9,7 +9,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/olxbr/network-api/pkg/client"
 	"github.com/olxbr/network-api/pkg/types"
@@ -37,7 +39,7 @@ func TestNetworkAddCommand(t *testing.T) {
 			flags:   []string{},
 			prepare: func(w http.ResponseWriter, r *http.Request) {},
 			assert: func(t *testing.T, out string, e error) {
-				assert.Contains(t, e.Error(), `"account", "environment", "pool-id", "provider" not set`)
+				assert.Contains(t, e.Error(), `"account", "environment", "provider" not set`)
 			},
 		},
 		{
@@ -79,6 +81,136 @@ func TestNetworkAddCommand(t *testing.T) {
 				assert.Contains(t, out, "TestAccount")
 			},
 		},
+		{
+			name:  "pool picked by selector",
+			flags: []string{"--region", "us-east-1", "--selector", "purpose=shared", "--account", "TestAccount", "--provider", "TestProvider", "--environment", "TestEnv", "--subnet-size", "16"},
+			prepare: func(w http.ResponseWriter, r *http.Request) {
+				nr := &types.NetworkRequest{}
+				_ = json.NewDecoder(r.Body).Decode(nr)
+				w.Header().Set("Content-Type", "application/json")
+				if nr.PoolID != "" || nr.Region != "us-east-1" || nr.Selector["purpose"] != "shared" {
+					w.WriteHeader(400)
+					_ = json.NewEncoder(w).Encode(&types.ErrorResponse{Errors: map[string]string{"selector": "unexpected selector"}})
+					return
+				}
+				w.WriteHeader(202)
+				_ = json.NewEncoder(w).Encode(&types.NetworkResponse{
+					Network: &types.Network{ID: uuid, CIDR: "10.2.0.0/16"},
+					Selection: &types.PoolSelection{
+						PoolID:  "spare-id",
+						Pool:    "spare",
+						Reason:  "priority 1 pool matching region us-east-1, environment TestEnv, purpose=shared with a free /16",
+						Skipped: []*types.SkippedPool{{PoolID: "full-id", Pool: "full", Reason: "no more networks available"}},
+					},
+				})
+			},
+			assert: func(t *testing.T, out string, e error) {
+				assert.NotContains(t, out, "unexpected selector")
+				assert.Contains(t, out, "Pool: spare (spare-id), priority 1 pool matching region us-east-1")
+				assert.Contains(t, out, "Skipped pool full (full-id): no more networks available")
+			},
+		},
+		{
+			name:    "pool id and region",
+			flags:   append(params, "--region", "us-east-1"),
+			prepare: func(w http.ResponseWriter, r *http.Request) {},
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, e.Error(), "[pool-id region] were all set")
+			},
+		},
+		{
+			name:  "explicit subnets",
+			flags: append(params, "--subnet", "private01:private:0.0.0.0/17", "--subnet", "public01:public:0.0.128.0/26"),
+			prepare: func(w http.ResponseWriter, r *http.Request) {
+				nr := &types.NetworkRequest{}
+				_ = json.NewDecoder(r.Body).Decode(nr)
+				w.Header().Set("Content-Type", "application/json")
+				if len(nr.Subnets) != 2 || nr.Subnets[1].CIDR != "0.0.128.0/26" || nr.PrivateSubnet != nil {
+					w.WriteHeader(400)
+					_ = json.NewEncoder(w).Encode(&types.ErrorResponse{Errors: map[string]string{"subnets": "unexpected plan"}})
+					return
+				}
+				w.WriteHeader(201)
+				_ = json.NewEncoder(w).Encode(&types.NetworkResponse{
+					Network: &types.Network{ID: uuid, CIDR: "10.2.0.0/16"},
+				})
+			},
+			assert: func(t *testing.T, out string, e error) {
+				assert.NotContains(t, out, "unexpected plan")
+				assert.Contains(t, out, uuid.String())
+			},
+		},
+		{
+			name:    "invalid subnet",
+			flags:   append(params, "--subnet", "private01:private"),
+			prepare: func(w http.ResponseWriter, r *http.Request) {},
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, `invalid subnet "private01:private", use name:type:cidr`)
+			},
+		},
+		{
+			name:  "rejected request",
+			flags: params,
+			prepare: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(400)
+				_ = json.NewEncoder(w).Encode(&types.ErrorResponse{
+					Code: types.ErrorInvalid,
+					Errors: map[string]string{
+						"subnetSize": "failed on the 'max=28' tag",
+						"account":    "failed on the 'required' tag",
+					},
+				})
+			},
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, "error creating network: request failed 400: account failed on the 'required' tag; subnetSize failed on the 'max=28' tag")
+			},
+		},
+		{
+			name:  "rules turn the network down",
+			flags: params,
+			prepare: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(422)
+				_ = json.NewEncoder(w).Encode(&types.ErrorResponse{
+					Code:   types.ErrorRule,
+					Errors: map[string]string{"_all": "network request fails rules: prod-tgw: attachTGW false not one of [true]"},
+					Rules: []*types.RuleResult{
+						{Rule: "prod-tgw", Outcome: types.RuleFail, Reason: "attachTGW false not one of [true]"},
+						{Rule: "qa-size", Outcome: types.RuleSkip, Reason: "when environment TestEnv not one of [qa]"},
+					},
+				})
+			},
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, "error creating network: request failed 422: network request fails rules")
+				assert.Contains(t, out, "when environment TestEnv not one of [qa]")
+			},
+		},
+		{
+			name:  "dry run",
+			flags: append(params, "--dry"),
+			prepare: func(w http.ResponseWriter, r *http.Request) {
+				er := &types.RuleEvaluateRequest{}
+				_ = json.NewDecoder(r.Body).Decode(er)
+				if r.URL.Path != "/api/v1/rules/evaluate" || er.Network.PoolID != "PoolID" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(&types.RuleEvaluateResponse{
+					Allowed: true,
+					PoolID:  "PoolID",
+					Pool:    "shared",
+					Results: []*types.RuleResult{{Rule: "prod-tgw", Outcome: types.RulePass}},
+				})
+			},
+			assert: func(t *testing.T, out string, e error) {
+				assert.NoError(t, e)
+				assert.Contains(t, out, "Pool: shared (PoolID)")
+				assert.Contains(t, out, "The rules allow the network")
+				assert.Contains(t, out, "prod-tgw")
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -90,6 +222,9 @@ func TestNetworkAddCommand(t *testing.T) {
 				Client:   &http.Client{},
 			})
 			cmd := networkAddCmd()
+			// --dry is a flag of the root command
+			cmd.Flags().AddFlagSet(newRootCmd().PersistentFlags())
+			defer func() { dryRun = false }()
 			var b bytes.Buffer
 			cmd.SetOut(&b)
 			log.SetOutput(&b)
@@ -123,6 +258,21 @@ func TestNetworkInfoCommand(t *testing.T) {
 				assert.Contains(t, e.Error(), "accepts 1 arg(s), received 0")
 			},
 		},
+		{
+			name:  "network not found",
+			flags: []string{uuid.String()},
+			prepare: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(404)
+				_ = json.NewEncoder(w).Encode(&types.ErrorResponse{
+					Code:   types.ErrorNotFound,
+					Errors: map[string]string{"_all": "network not found"},
+				})
+			},
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, "Network not found: "+uuid.String())
+			},
+		},
 		{
 			name:  "valid network-id",
 			flags: []string{uuid.String()},
@@ -143,6 +293,55 @@ func TestNetworkInfoCommand(t *testing.T) {
 				assert.Contains(t, out, "TestAccount")
 			},
 		},
+		{
+			name:  "with subnets",
+			flags: []string{uuid.String()},
+			prepare: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(200)
+				_ = json.NewEncoder(w).Encode(&types.Network{
+					ID:   uuid,
+					CIDR: "10.2.0.0/20",
+					Subnets: []*types.Subnet{
+						{ID: "subnet-1", Name: "private01", Type: types.Private, CIDR: "10.2.0.0/22", AZ: "us-east-1a"},
+						{Name: "private02", Type: types.Private, CIDR: "10.2.4.0/22", AZIndex: 1},
+					},
+				})
+			},
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, "Subnets:")
+				assert.Contains(t, out, "subnet-1")
+				assert.Contains(t, out, "us-east-1a")
+				assert.Contains(t, out, "10.2.4.0/22")
+			},
+		},
+		{
+			name:  "with drift",
+			flags: []string{uuid.String()},
+			prepare: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(200)
+				_ = json.NewEncoder(w).Encode(&types.Network{
+					ID:          uuid,
+					Provider:    "TestProvider",
+					Region:      "us-east-1",
+					Account:     "TestAccount",
+					Environment: "TestEnv",
+					CIDR:        "10.2.0.0",
+					Drift: &types.DriftCheck{
+						Status:      types.DriftDrifted,
+						Differences: []string{"stack resources drifted from the template"},
+						CheckedAt:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					},
+				})
+			},
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, "Drift:")
+				assert.Contains(t, out, "drifted")
+				assert.Contains(t, out, "stack resources drifted from the template")
+				assert.Contains(t, out, "2024-01-01T00:00:00Z")
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -204,7 +403,7 @@ func TestNetworkListCommand(t *testing.T) {
 		Endpoint: s.URL,
 		Client:   &http.Client{},
 	})
-	cmd := networkListCmd
+	cmd := networkListCmd()
 	var b bytes.Buffer
 	cmd.SetOut(&b)
 	log.SetOutput(&b)
@@ -224,3 +423,554 @@ func TestNetworkListCommand(t *testing.T) {
 	log.SetOutput(os.Stderr)
 	cmd.SetOut(os.Stdout)
 }
+
+func TestNetworkListCommandPages(t *testing.T) {
+	id01 := types.NewUUID()
+	id02 := types.NewUUID()
+	requests := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		q := r.URL.Query()
+		assert.Equal(t, "aws", q.Get("provider"))
+		assert.Equal(t, "prod", q.Get("environment"))
+		assert.Equal(t, "1", q.Get("limit"))
+
+		nr := &types.NetworkListResponse{
+			Items:     []*types.Network{{ID: id01, Provider: "aws", Account: "TestAccount01", CIDR: "10.1.0.0/16"}},
+			NextToken: "page2",
+		}
+		if q.Get("nextToken") == "page2" {
+			nr = &types.NetworkListResponse{
+				Items: []*types.Network{{ID: id02, Provider: "aws", Account: "TestAccount02", CIDR: "10.2.0.0/16"}},
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_ = json.NewEncoder(w).Encode(nr)
+	}))
+	defer s.Close()
+	ctx := context.TODO()
+	ctx = client.WithNewClient(ctx, &client.ClientOptions{
+		Endpoint: s.URL,
+		Client:   &http.Client{},
+	})
+	cmd := networkListCmd()
+	var b bytes.Buffer
+	cmd.SetOut(&b)
+	log.SetOutput(&b)
+	cmd.SetArgs([]string{"--provider", "aws", "-e", "prod", "--page-size", "1"})
+	err := cmd.ExecuteContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(out)
+	assert.Equal(t, 2, requests)
+	assert.Contains(t, result, id01.String())
+	assert.Contains(t, result, id02.String())
+	log.SetOutput(os.Stderr)
+	cmd.SetOut(os.Stdout)
+}
+
+func TestNetworkRemoveCommand(t *testing.T) {
+	uuid := types.NewUUID()
+	network := &types.Network{
+		ID:          uuid,
+		Provider:    "TestProvider",
+		Region:      "us-east-1",
+		Account:     "TestAccount",
+		Environment: "TestEnv",
+		CIDR:        "10.2.0.0/16",
+	}
+
+	tests := []struct {
+		name    string
+		flags   []string
+		input   string
+		status  types.NetworkStatus
+		deleted bool
+		assert  func(t *testing.T, out string, e error)
+	}{
+		{
+			name:    "confirmed",
+			flags:   []string{uuid.String()},
+			input:   "y\n",
+			status:  types.StatusDeleted,
+			deleted: true,
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, "TestAccount")
+				assert.Contains(t, out, "Remove network "+uuid.String()+" (10.2.0.0/16)? [y/N]:")
+				assert.Contains(t, out, "Network removed: "+uuid.String())
+			},
+		},
+		{
+			name:  "aborted",
+			flags: []string{uuid.String()},
+			input: "\n",
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, "Aborted")
+				assert.NotContains(t, out, "Network removed")
+			},
+		},
+		{
+			name:    "skip confirmation",
+			flags:   []string{uuid.String(), "--yes"},
+			status:  types.StatusDeleted,
+			deleted: true,
+			assert: func(t *testing.T, out string, e error) {
+				assert.NotContains(t, out, "[y/N]")
+				assert.Contains(t, out, "Network removed: "+uuid.String())
+			},
+		},
+		{
+			name:    "removal in progress",
+			flags:   []string{uuid.String(), "--yes"},
+			status:  types.StatusDeleting,
+			deleted: true,
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, "Network removal requested: "+uuid.String())
+				assert.NotContains(t, out, "Network removed")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted := false
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.Method == http.MethodDelete {
+					deleted = true
+					n := *network
+					n.Status = tt.status
+					w.WriteHeader(http.StatusAccepted)
+					_ = json.NewEncoder(w).Encode(&n)
+					return
+				}
+				w.WriteHeader(200)
+				_ = json.NewEncoder(w).Encode(network)
+			}))
+			defer s.Close()
+			ctx := context.TODO()
+			ctx = client.WithNewClient(ctx, &client.ClientOptions{
+				Endpoint: s.URL,
+				Client:   &http.Client{},
+			})
+			cmd := networkRemoveCmd()
+			var b bytes.Buffer
+			cmd.SetOut(&b)
+			cmd.SetIn(strings.NewReader(tt.input))
+			log.SetOutput(&b)
+			cmd.SetArgs(tt.flags)
+			e := cmd.ExecuteContext(ctx)
+			out, err := io.ReadAll(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.assert(t, string(out), e)
+			assert.Equal(t, tt.deleted, deleted)
+			log.SetOutput(os.Stderr)
+			cmd.SetOut(os.Stdout)
+		})
+	}
+}
+
+func TestNetworkStatusCommand(t *testing.T) {
+	uuid := types.NewUUID()
+	history := []*types.StatusChange{
+		{Status: types.StatusPending, Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Status: types.StatusProvisioning, Timestamp: time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		name     string
+		flags    []string
+		statuses []types.NetworkStatus
+		calls    int
+		assert   func(t *testing.T, out string)
+	}{
+		{
+			name:     "current status",
+			flags:    []string{uuid.String()},
+			statuses: []types.NetworkStatus{types.StatusProvisioning},
+			calls:    1,
+			assert: func(t *testing.T, out string) {
+				assert.Contains(t, out, "Network "+uuid.String()+" is provisioning")
+				assert.Contains(t, out, "2024-01-01T00:00:01Z")
+			},
+		},
+		{
+			name:     "wait until settled",
+			flags:    []string{uuid.String(), "--wait", "--interval", "1ms"},
+			statuses: []types.NetworkStatus{types.StatusProvisioning, types.StatusProvisioning, types.StatusActive},
+			calls:    3,
+			assert: func(t *testing.T, out string) {
+				assert.Contains(t, out, "checking again in 1ms")
+				assert.Contains(t, out, "Network "+uuid.String()+" is active")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/v1/networks/"+uuid.String()+"/status", r.URL.Path)
+				st := tt.statuses[calls]
+				calls++
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(200)
+				_ = json.NewEncoder(w).Encode(&types.NetworkStatusResponse{
+					ID:      uuid,
+					Status:  st,
+					History: history,
+				})
+			}))
+			defer s.Close()
+			ctx := context.TODO()
+			ctx = client.WithNewClient(ctx, &client.ClientOptions{
+				Endpoint: s.URL,
+				Client:   &http.Client{},
+			})
+			cmd := networkStatusCmd()
+			var b bytes.Buffer
+			cmd.SetOut(&b)
+			log.SetOutput(&b)
+			cmd.SetArgs(tt.flags)
+			if err := cmd.ExecuteContext(ctx); err != nil {
+				t.Fatal(err)
+			}
+			out, err := io.ReadAll(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.assert(t, string(out))
+			assert.Equal(t, tt.calls, calls)
+			log.SetOutput(os.Stderr)
+			cmd.SetOut(os.Stdout)
+		})
+	}
+}
+
+func TestNetworkCheckCommand(t *testing.T) {
+	id01 := types.NewUUID()
+	id02 := types.NewUUID()
+
+	tests := []struct {
+		name   string
+		flags  []string
+		path   string
+		body   interface{}
+		assert func(t *testing.T, out string, e error)
+	}{
+		{
+			name:  "without network id",
+			flags: []string{},
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, e.Error(), "accepts 1 arg(s), received 0")
+			},
+		},
+		{
+			name:  "single network",
+			flags: []string{id01.String()},
+			path:  "/api/v1/networks/" + id01.String() + "/check",
+			body: &types.Network{
+				ID:     id01,
+				Status: types.StatusActive,
+				Drift:  &types.DriftCheck{Status: types.DriftInSync},
+			},
+			assert: func(t *testing.T, out string, e error) {
+				assert.NoError(t, e)
+				assert.Contains(t, out, id01.String())
+				assert.Contains(t, out, "in_sync")
+			},
+		},
+		{
+			name:  "network not active",
+			flags: []string{id01.String()},
+			path:  "/api/v1/networks/" + id01.String() + "/check",
+			body: &types.Network{
+				ID:     id01,
+				Status: types.StatusProvisioning,
+			},
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, "drift is only checked on active networks")
+			},
+		},
+		{
+			name:  "all networks",
+			flags: []string{"--all"},
+			path:  "/api/v1/networks/check",
+			body: &types.NetworkCheckResponse{
+				Items: []*types.NetworkCheckResult{
+					{ID: id01, Drift: &types.DriftCheck{Status: types.DriftMissing}},
+					{ID: id02, Error: "error checking network: 500 Internal Server Error"},
+				},
+			},
+			assert: func(t *testing.T, out string, e error) {
+				assert.NoError(t, e)
+				assert.Contains(t, out, "missing")
+				assert.Contains(t, out, id02.String())
+				assert.Contains(t, out, "500 Internal Server Error")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, tt.path, r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(200)
+				_ = json.NewEncoder(w).Encode(tt.body)
+			}))
+			defer s.Close()
+			ctx := context.TODO()
+			ctx = client.WithNewClient(ctx, &client.ClientOptions{
+				Endpoint: s.URL,
+				Client:   &http.Client{},
+			})
+			cmd := networkCheckCmd()
+			var b bytes.Buffer
+			cmd.SetOut(&b)
+			log.SetOutput(&b)
+			cmd.SetArgs(tt.flags)
+			e := cmd.ExecuteContext(ctx)
+			out, err := io.ReadAll(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.assert(t, string(out), e)
+			log.SetOutput(os.Stderr)
+			cmd.SetOut(os.Stdout)
+		})
+	}
+}
+
+func TestNetworkValidateCommand(t *testing.T) {
+	id := types.NewUUID()
+
+	tests := []struct {
+		name   string
+		flags  []string
+		body   *types.NetworkValidateResponse
+		assert func(t *testing.T, out string, req *types.NetworkValidateRequest)
+	}{
+		{
+			name:  "available cidr",
+			flags: []string{"--cidr", "10.0.8.0/22", "--pool-id", "poolid"},
+			body:  &types.NetworkValidateResponse{Valid: true, Conflicts: []*types.Network{}},
+			assert: func(t *testing.T, out string, req *types.NetworkValidateRequest) {
+				assert.Equal(t, "10.0.8.0/22", req.CIDR)
+				assert.Equal(t, "poolid", req.PoolID)
+				assert.Contains(t, out, "CIDR is available")
+			},
+		},
+		{
+			name:  "overlapping cidr",
+			flags: []string{"--cidr", "10.0.0.0/15"},
+			body: &types.NetworkValidateResponse{
+				Conflicts: []*types.Network{
+					{ID: id, CIDR: "10.0.4.0/24", Account: "1234"},
+					{CIDR: "10.0.5.0/24", Status: types.StatusPending},
+				},
+				Errors: []string{"network 10.0.0.0/15 not in pool range 10.0.0.0-10.0.255.255"},
+			},
+			assert: func(t *testing.T, out string, req *types.NetworkValidateRequest) {
+				assert.Contains(t, out, "not in pool range")
+				assert.Contains(t, out, "Overlapping networks:")
+				assert.Contains(t, out, id.String())
+				assert.Contains(t, out, "10.0.5.0/24")
+				assert.NotContains(t, out, "CIDR is available")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &types.NetworkValidateRequest{}
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "/api/v1/networks/validate", r.URL.Path)
+				_ = json.NewDecoder(r.Body).Decode(req)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(200)
+				_ = json.NewEncoder(w).Encode(tt.body)
+			}))
+			defer s.Close()
+			ctx := context.TODO()
+			ctx = client.WithNewClient(ctx, &client.ClientOptions{
+				Endpoint: s.URL,
+				Client:   &http.Client{},
+			})
+			cmd := networkValidateCmd()
+			var b bytes.Buffer
+			cmd.SetOut(&b)
+			log.SetOutput(&b)
+			cmd.SetArgs(tt.flags)
+			err := cmd.ExecuteContext(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := io.ReadAll(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.assert(t, string(out), req)
+			log.SetOutput(os.Stderr)
+			cmd.SetOut(os.Stdout)
+		})
+	}
+}
+
+func TestNetworkImportCommand(t *testing.T) {
+	uuid := types.NewUUID()
+	required := []string{"--provider", "aws", "--account", "123", "--pool-id", "pool", "--environment", "prod"}
+	report := []*types.NetworkImportResult{
+		{VpcID: "vpc-1", CIDR: "10.50.0.0/16", Action: types.ImportCreate},
+		{VpcID: "vpc-2", CIDR: "10.10.0.0/16", Action: types.ImportSkip, Reason: "already registered"},
+	}
+
+	tests := []struct {
+		name     string
+		flags    []string
+		input    string
+		report   []*types.NetworkImportResult
+		requests int
+		assert   func(t *testing.T, out string, e error)
+	}{
+		{
+			name:  "missing flags",
+			flags: []string{"--dry"},
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, e.Error(), `required flag(s) "account", "environment", "pool-id", "provider" not set`)
+			},
+		},
+		{
+			name:     "dry run",
+			flags:    append([]string{"--dry"}, required...),
+			report:   report,
+			requests: 1,
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, "vpc-1")
+				assert.Contains(t, out, "already registered")
+				assert.NotContains(t, out, "Import 1 networks?")
+			},
+		},
+		{
+			name:     "confirmed",
+			flags:    required,
+			input:    "y\n",
+			report:   report,
+			requests: 2,
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, "Import 1 networks? [y/N]:")
+				assert.Contains(t, out, "Imported:")
+				assert.Contains(t, out, uuid.String())
+			},
+		},
+		{
+			name:     "aborted",
+			flags:    required,
+			input:    "n\n",
+			report:   report,
+			requests: 1,
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, "Aborted")
+			},
+		},
+		{
+			name:     "nothing to import",
+			flags:    append([]string{"--yes"}, required...),
+			report:   report[1:],
+			requests: 1,
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, "Nothing to import")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				ir := &types.NetworkImportRequest{}
+				_ = json.NewDecoder(r.Body).Decode(ir)
+				assert.Equal(t, "aws", ir.Provider)
+
+				resp := &types.NetworkImportResponse{DryRun: ir.DryRun, Items: tt.report}
+				if !ir.DryRun {
+					assert.Equal(t, []string{"vpc-1"}, ir.VpcIDs)
+					resp.Items = []*types.NetworkImportResult{
+						{VpcID: "vpc-1", CIDR: "10.50.0.0/16", Action: types.ImportCreate, NetworkID: uuid},
+					}
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(200)
+				_ = json.NewEncoder(w).Encode(resp)
+			}))
+			defer s.Close()
+			ctx := context.TODO()
+			ctx = client.WithNewClient(ctx, &client.ClientOptions{
+				Endpoint: s.URL,
+				Client:   &http.Client{},
+			})
+			cmd := networkImportCmd()
+			cmd.Flags().AddFlagSet(newRootCmd().PersistentFlags())
+			defer func() { dryRun = false }()
+			var b bytes.Buffer
+			cmd.SetOut(&b)
+			cmd.SetIn(strings.NewReader(tt.input))
+			log.SetOutput(&b)
+			cmd.SetArgs(tt.flags)
+			e := cmd.ExecuteContext(ctx)
+			out, err := io.ReadAll(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.assert(t, string(out), e)
+			assert.Equal(t, tt.requests, requests)
+			log.SetOutput(os.Stderr)
+			cmd.SetOut(os.Stdout)
+		})
+	}
+}
+
+func TestNetworkAddCIDRCommand(t *testing.T) {
+	uuid := types.NewUUID()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/networks/"+uuid.String()+"/cidrs", r.URL.Path)
+		cr := &types.NetworkCIDRRequest{}
+		_ = json.NewDecoder(r.Body).Decode(cr)
+		assert.Equal(t, 20, cr.SubnetSize)
+		assert.Equal(t, "pool-1", cr.PoolID)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(201)
+		_ = json.NewEncoder(w).Encode(&types.Network{
+			ID:   uuid,
+			CIDR: "10.0.0.0/20",
+			SecondaryCIDRs: []*types.SecondaryCIDR{
+				{CIDR: "10.0.16.0/20", PoolID: "pool-1", AssociationID: "vpc-cidr-assoc-1234", Status: types.StatusActive},
+			},
+		})
+	}))
+	defer s.Close()
+	ctx := context.TODO()
+	ctx = client.WithNewClient(ctx, &client.ClientOptions{
+		Endpoint: s.URL,
+		Client:   &http.Client{},
+	})
+	cmd := networkAddCIDRCmd()
+	var b bytes.Buffer
+	cmd.SetOut(&b)
+	log.SetOutput(&b)
+	cmd.SetArgs([]string{uuid.String(), "--subnet-size", "20", "--pool-id", "pool-1"})
+	err := cmd.ExecuteContext(ctx)
+	assert.NoError(t, err)
+	out := b.String()
+	assert.Contains(t, out, "10.0.16.0/20")
+	assert.Contains(t, out, "vpc-cidr-assoc-1234")
+	log.SetOutput(os.Stderr)
+	cmd.SetOut(os.Stdout)
+}
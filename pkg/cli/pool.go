@@ -1,9 +1,14 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/olxbr/network-api/pkg/client"
@@ -11,21 +16,25 @@ import (
 	"github.com/spf13/cobra"
 )
 
+func poolRange(p *types.Pool) string {
+	if p.SubnetMask != nil {
+		return fmt.Sprintf("%s/%d", p.SubnetIP, types.ToInt(p.SubnetMask))
+	} else if p.SubnetMaxIP != nil {
+		return fmt.Sprintf("%s - %s", p.SubnetIP, types.ToString(p.SubnetMaxIP))
+	}
+	return ""
+}
+
 func renderPools(w io.Writer, ps *types.PoolListResponse) {
 	table := tablewriter.NewWriter(w)
-	table.Header([]string{"ID", "Name", "Region", "Range"})
+	table.Header([]string{"ID", "Name", "Region", "Range", "Strategy"})
 	for _, p := range ps.Items {
-		var r string
-		if p.SubnetMask != nil {
-			r = fmt.Sprintf("%s/%d", p.SubnetIP, types.ToInt(p.SubnetMask))
-		} else if p.SubnetMaxIP != nil {
-			r = fmt.Sprintf("%s - %s", p.SubnetIP, types.ToString(p.SubnetMaxIP))
-		}
 		if err := table.Append([]string{
 			p.ID.String(),
 			p.Name,
 			p.Region,
-			r,
+			poolRange(p),
+			string(p.Strategy),
 		}); err != nil {
 			log.Printf("error appending to table: %v", err)
 		}
@@ -35,6 +44,235 @@ func renderPools(w io.Writer, ps *types.PoolListResponse) {
 	}
 }
 
+// renderPoolTree lists pools under their parents, indenting each level.
+func renderPoolTree(w io.Writer, tr *types.PoolTreeResponse) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Name", "ID", "Region", "Range", "Admins"})
+	var appendNodes func(nodes []*types.PoolTree, depth int)
+	appendNodes = func(nodes []*types.PoolTree, depth int) {
+		for _, n := range nodes {
+			name := n.Name
+			if depth > 0 {
+				// tables trim leading spaces, so deeper levels grow the branch
+				name = "└" + strings.Repeat("─", depth-1) + " " + name
+			}
+			if err := table.Append([]string{
+				name,
+				n.ID.String(),
+				n.Region,
+				poolRange(n.Pool),
+				strings.Join(n.Admins, ", "),
+			}); err != nil {
+				log.Printf("error appending to table: %v", err)
+			}
+			appendNodes(n.Children, depth+1)
+		}
+	}
+	appendNodes(tr.Items, 0)
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+}
+
+func renderExclusions(w io.Writer, exclusions []*types.Exclusion) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"CIDR", "Owner", "Reason"})
+	for _, e := range exclusions {
+		if err := table.Append([]string{
+			e.CIDR,
+			e.Owner,
+			e.Reason,
+		}); err != nil {
+			log.Printf("error appending to table: %v", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+}
+
+// renderPoolHistory lists the renames and resizes of a pool, oldest first.
+func renderPoolHistory(w io.Writer, history []*types.PoolChange) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Timestamp", "Name", "Range", "Previous Name", "Previous Range"})
+	for _, c := range history {
+		if err := table.Append([]string{
+			c.Timestamp.Format(time.RFC3339),
+			c.Name,
+			c.Range,
+			c.PreviousName,
+			c.PreviousRange,
+		}); err != nil {
+			log.Printf("error appending to table: %v", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+}
+
+// parseExclusion reads an exclusion given as cidr:owner:reason, the reason
+// may hold colons of its own.
+func parseExclusion(s string) (*types.Exclusion, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid exclusion %q, use cidr:owner:reason", s)
+	}
+	return &types.Exclusion{
+		CIDR:   parts[0],
+		Owner:  parts[1],
+		Reason: parts[2],
+	}, nil
+}
+
+// parseQuota reads a quota given as scope[=value]:networks=N or
+// scope[=value]:addresses=N.
+func parseQuota(s string) (*types.Quota, error) {
+	owner, limit, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid quota %q, use scope[=value]:networks=N or scope[=value]:addresses=N", s)
+	}
+	scope, value, _ := strings.Cut(owner, "=")
+	q := &types.Quota{Scope: types.QuotaScope(scope), Value: value}
+
+	kind, max, ok := strings.Cut(limit, "=")
+	if !ok {
+		return nil, fmt.Errorf("invalid quota limit %q, use networks=N or addresses=N", limit)
+	}
+	switch kind {
+	case "networks":
+		n, err := strconv.Atoi(max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quota limit %q: %w", limit, err)
+		}
+		q.MaxNetworks = n
+	case "addresses":
+		n, err := strconv.ParseUint(max, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quota limit %q: %w", limit, err)
+		}
+		q.MaxAddresses = n
+	default:
+		return nil, fmt.Errorf("invalid quota limit %q, use networks=N or addresses=N", limit)
+	}
+	return q, nil
+}
+
+func renderPolicy(w io.Writer, pol *types.PoolPolicy) {
+	lengths := []string{}
+	for _, l := range pol.PrefixLengths {
+		lengths = append(lengths, "/"+strconv.Itoa(l))
+	}
+	quotas := []string{}
+	for _, q := range pol.Quotas {
+		owner := string(q.Scope)
+		if q.Value != "" {
+			owner += "=" + q.Value
+		}
+		if q.MaxNetworks > 0 {
+			quotas = append(quotas, fmt.Sprintf("%s: %d networks", owner, q.MaxNetworks))
+		}
+		if q.MaxAddresses > 0 {
+			quotas = append(quotas, fmt.Sprintf("%s: %d addresses", owner, q.MaxAddresses))
+		}
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Prefix Lengths", "Environments", "Quotas"})
+	if err := table.Append([]string{
+		strings.Join(lengths, ", "),
+		strings.Join(pol.Environments, ", "),
+		strings.Join(quotas, ", "),
+	}); err != nil {
+		log.Printf("error appending to table: %v", err)
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+}
+
+func renderQuotaUsage(w io.Writer, usage []*types.QuotaUsage) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Scope", "Value", "Networks", "Addresses"})
+	for _, u := range usage {
+		networks := strconv.Itoa(u.Networks)
+		if u.MaxNetworks > 0 {
+			networks += "/" + strconv.Itoa(u.MaxNetworks)
+		}
+		addresses := "0"
+		if u.Addresses != nil {
+			addresses = u.Addresses.String()
+		}
+		if u.MaxAddresses > 0 {
+			addresses += "/" + strconv.FormatUint(u.MaxAddresses, 10)
+		}
+		if err := table.Append([]string{string(u.Scope), u.Value, networks, addresses}); err != nil {
+			log.Printf("error appending to table: %v", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+}
+
+func renderPoolUsage(w io.Writer, u *types.PoolUsage) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Pool", "Range", "Total", "Allocated", "Reserved", "Excluded", "Delegated", "Free", "Largest Free"})
+	excluded, delegated := "0", "0"
+	if u.Excluded != nil {
+		excluded = u.Excluded.String()
+	}
+	if u.Delegated != nil {
+		delegated = u.Delegated.String()
+	}
+	if err := table.Append([]string{
+		u.PoolID,
+		u.Range,
+		u.Total.String(),
+		u.Allocated.String(),
+		u.Reserved.String(),
+		excluded,
+		delegated,
+		u.Free.String(),
+		u.LargestFreePrefix,
+	}); err != nil {
+		log.Printf("error appending to table: %v", err)
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+
+	sizes := make([]string, 0, len(u.Available))
+	for s := range u.Available {
+		sizes = append(sizes, s)
+	}
+	sort.Slice(sizes, func(i, j int) bool {
+		return len(sizes[i]) < len(sizes[j]) || (len(sizes[i]) == len(sizes[j]) && sizes[i] < sizes[j])
+	})
+
+	table = tablewriter.NewWriter(w)
+	table.Header([]string{"Size", "Available"})
+	for _, s := range sizes {
+		if err := table.Append([]string{s, strconv.FormatUint(u.Available[s], 10)}); err != nil {
+			log.Printf("error appending to table: %v", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+
+	table = tablewriter.NewWriter(w)
+	table.Header([]string{"Free Ranges"})
+	for _, r := range u.FreeRanges {
+		if err := table.Append([]string{r}); err != nil {
+			log.Printf("error appending to table: %v", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+}
+
 func newPoolCommand() *cobra.Command {
 	poolCmd := &cobra.Command{
 		Use:   "pool",
@@ -56,8 +294,15 @@ func newPoolCommand() *cobra.Command {
 	}
 
 	poolCmd.AddCommand(poolAddCmd())
-	poolCmd.AddCommand(poolRemoveCmd)
+	poolCmd.AddCommand(poolRemoveCmd())
 	poolCmd.AddCommand(poolListCmd)
+	poolCmd.AddCommand(poolUsageCmd)
+	poolCmd.AddCommand(poolExclusionsCmd())
+	poolCmd.AddCommand(poolLabelsCmd())
+	poolCmd.AddCommand(poolPolicyCmd())
+	poolCmd.AddCommand(poolInfoCmd())
+	poolCmd.AddCommand(poolUpdateCmd())
+	poolCmd.AddCommand(poolTreeCmd())
 
 	return poolCmd
 }
@@ -66,6 +311,8 @@ func poolAddCmd() *cobra.Command {
 	req := &types.PoolRequest{}
 	var subnetMask int
 	var subnetMaxIP string
+	var strategy string
+	var exclusions []string
 	c := &cobra.Command{
 		Use:   "add <name>",
 		Short: "Adds a new pool",
@@ -79,6 +326,7 @@ func poolAddCmd() *cobra.Command {
 			}
 
 			req.Name = args[0]
+			req.Strategy = types.AllocationStrategy(strategy)
 
 			if subnetMask != -1 {
 				req.SubnetMask = types.Int(subnetMask)
@@ -89,6 +337,15 @@ func poolAddCmd() *cobra.Command {
 				return
 			}
 
+			for _, s := range exclusions {
+				e, err := parseExclusion(s)
+				if err != nil {
+					log.Printf("Error: %s", err)
+					return
+				}
+				req.Exclusions = append(req.Exclusions, e)
+			}
+
 			p, err := cli.CreatePool(ctx, req)
 			if err != nil {
 				log.Printf("error creating pool: %+v", err)
@@ -99,6 +356,10 @@ func poolAddCmd() *cobra.Command {
 			renderPools(cmd.OutOrStdout(), &types.PoolListResponse{
 				Items: []*types.Pool{p},
 			})
+			if len(p.Exclusions) > 0 {
+				log.Println("Exclusions:")
+				renderExclusions(cmd.OutOrStdout(), p.Exclusions)
+			}
 		},
 	}
 
@@ -107,6 +368,13 @@ func poolAddCmd() *cobra.Command {
 	f.StringVar(&req.SubnetIP, "subnet-ip", "", "Subnet IP Address")
 	f.IntVar(&subnetMask, "subnet-mask", -1, "Subnet Mask")
 	f.StringVar(&subnetMaxIP, "subnet-maxip", "", "Subnet Maximum IP Address")
+	f.StringVar(&strategy, "strategy", "", "Default allocation strategy: first-fit, best-fit or sparse")
+	f.StringArrayVar(&exclusions, "exclude", nil, "Range never allocated as cidr:owner:reason, repeat for each range")
+	f.StringVar(&req.ParentID, "parent-id", "", "Pool the new pool is delegated from")
+	f.StringSliceVar(&req.Admins, "admin", nil, "Team administering the pool, repeat for each team")
+	f.StringVar(&req.RoutingDomain, "routing-domain", "", "Routing domain of the pool, pools in different domains may overlap")
+	f.StringToStringVar(&req.Labels, "label", nil, "Label networks select the pool by as key=value, e.g. environment=prod or purpose=shared")
+	f.IntVar(&req.Priority, "priority", 0, "Priority among the pools matching a selector, highest first")
 
 	c.MarkFlagsMutuallyExclusive("subnet-mask", "subnet-maxip")
 	_ = c.MarkFlagRequired("region")
@@ -115,10 +383,319 @@ func poolAddCmd() *cobra.Command {
 	return c
 }
 
-var poolRemoveCmd = &cobra.Command{
-	Use:   "remove",
-	Short: "Remove a pool",
-	Run:   func(cmd *cobra.Command, args []string) {},
+func poolExclusionsCmd() *cobra.Command {
+	var exclusions []string
+	var clear bool
+	c := &cobra.Command{
+		Use:   "exclusions <pool_id>",
+		Short: "Replaces the ranges excluded from a pool",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+
+			if len(exclusions) == 0 && !clear {
+				log.Printf("Exclusions or --clear are required")
+				return
+			}
+
+			req := &types.ExclusionsRequest{Exclusions: []*types.Exclusion{}}
+			for _, s := range exclusions {
+				e, err := parseExclusion(s)
+				if err != nil {
+					log.Printf("Error: %s", err)
+					return
+				}
+				req.Exclusions = append(req.Exclusions, e)
+			}
+
+			p, err := cli.UpdatePoolExclusions(ctx, args[0], req)
+			if err != nil {
+				log.Printf("Error: %s", err)
+				return
+			}
+			renderExclusions(cmd.OutOrStdout(), p.Exclusions)
+		},
+	}
+
+	f := c.Flags()
+	f.StringArrayVar(&exclusions, "exclude", nil, "Range never allocated as cidr:owner:reason, repeat for each range")
+	f.BoolVar(&clear, "clear", false, "Remove every exclusion")
+	c.MarkFlagsMutuallyExclusive("exclude", "clear")
+
+	return c
+}
+
+func poolLabelsCmd() *cobra.Command {
+	req := &types.PoolLabelsRequest{}
+	c := &cobra.Command{
+		Use:   "labels <pool_id>",
+		Short: "Replaces the labels and priority of a pool",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+
+			p, err := cli.UpdatePoolLabels(ctx, args[0], req)
+			if err != nil {
+				log.Printf("Error: %s", err)
+				return
+			}
+			renderLabels(cmd.OutOrStdout(), p)
+		},
+	}
+
+	f := c.Flags()
+	f.StringToStringVar(&req.Labels, "label", nil, "Label as key=value, labels left out are removed")
+	f.IntVar(&req.Priority, "priority", 0, "Priority among the pools matching a selector, highest first")
+
+	return c
+}
+
+func renderLabels(w io.Writer, p *types.Pool) {
+	keys := make([]string, 0, len(p.Labels))
+	for k := range p.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Label", "Value"})
+	for _, k := range keys {
+		if err := table.Append([]string{k, p.Labels[k]}); err != nil {
+			log.Printf("error appending to table: %v", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+	log.Printf("Priority: %d", p.Priority)
+}
+
+func poolPolicyCmd() *cobra.Command {
+	pol := &types.PoolPolicy{}
+	var quotas []string
+	var clear bool
+	c := &cobra.Command{
+		Use:   "policy <pool_id>",
+		Short: "Replaces the allocation policy of a pool",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+
+			if len(pol.PrefixLengths) == 0 && len(pol.Environments) == 0 && len(quotas) == 0 && !clear {
+				log.Printf("A rule or --clear are required")
+				return
+			}
+
+			for _, s := range quotas {
+				q, err := parseQuota(s)
+				if err != nil {
+					log.Printf("Error: %s", err)
+					return
+				}
+				pol.Quotas = append(pol.Quotas, q)
+			}
+
+			p, err := cli.UpdatePoolPolicy(ctx, args[0], pol)
+			if err != nil {
+				log.Printf("Error: %s", err)
+				return
+			}
+			if p.Policy == nil {
+				log.Printf("Pool %s has no policy", p.Name)
+				return
+			}
+			renderPolicy(cmd.OutOrStdout(), p.Policy)
+		},
+	}
+
+	f := c.Flags()
+	f.IntSliceVar(&pol.PrefixLengths, "prefix-length", nil, "Network size handed out by the pool, repeat for each size")
+	f.StringSliceVar(&pol.Environments, "environment", nil, "Environment allowed to allocate from the pool, repeat for each environment")
+	f.StringArrayVar(&quotas, "quota", nil, "Quota as scope[=value]:networks=N or scope[=value]:addresses=N, scope being account or environment")
+	f.BoolVar(&clear, "clear", false, "Remove every rule")
+	c.MarkFlagsMutuallyExclusive("prefix-length", "clear")
+	c.MarkFlagsMutuallyExclusive("environment", "clear")
+	c.MarkFlagsMutuallyExclusive("quota", "clear")
+
+	return c
+}
+
+func poolInfoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "info <pool_id>",
+		Short: "Shows a pool along with its policy and quota consumption",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+
+			p, err := cli.DetailPool(ctx, args[0])
+			if err != nil {
+				log.Printf("Error: %s", err)
+				return
+			}
+
+			renderPools(cmd.OutOrStdout(), &types.PoolListResponse{
+				Items: []*types.Pool{p},
+			})
+			if p.Policy != nil {
+				log.Println("Policy:")
+				renderPolicy(cmd.OutOrStdout(), p.Policy)
+			}
+			if len(p.QuotaUsage) > 0 {
+				log.Println("Quota usage:")
+				renderQuotaUsage(cmd.OutOrStdout(), p.QuotaUsage)
+			}
+		},
+	}
+}
+
+func poolUpdateCmd() *cobra.Command {
+	req := &types.PoolUpdateRequest{}
+	var subnetMask int
+	var subnetMaxIP string
+	c := &cobra.Command{
+		Use:   "update <pool_id>",
+		Short: "Renames or resizes a pool",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+
+			if req.Name == "" && req.SubnetIP == "" {
+				log.Printf("A new name or subnet IP address are required")
+				return
+			}
+
+			if req.SubnetIP != "" {
+				if subnetMask != -1 {
+					req.SubnetMask = types.Int(subnetMask)
+				} else if subnetMaxIP != "" {
+					req.SubnetMaxIP = types.String(subnetMaxIP)
+				} else {
+					log.Printf("A subnet mask or a maximum IP address are required")
+					return
+				}
+			}
+
+			p, err := cli.UpdatePool(ctx, args[0], req)
+			if err != nil {
+				log.Printf("Error: %s", err)
+				renderConflicts(cmd.OutOrStdout(), err)
+				return
+			}
+
+			renderPools(cmd.OutOrStdout(), &types.PoolListResponse{
+				Items: []*types.Pool{p},
+			})
+			if len(p.History) > 0 {
+				renderPoolHistory(cmd.OutOrStdout(), p.History)
+			}
+		},
+	}
+
+	f := c.Flags()
+	f.StringVar(&req.Name, "name", "", "New pool name")
+	f.StringVar(&req.SubnetIP, "subnet-ip", "", "Subnet IP Address")
+	f.IntVar(&subnetMask, "subnet-mask", -1, "Subnet Mask")
+	f.StringVar(&subnetMaxIP, "subnet-maxip", "", "Subnet Maximum IP Address")
+
+	c.MarkFlagsMutuallyExclusive("subnet-mask", "subnet-maxip")
+
+	return c
+}
+
+func poolTreeCmd() *cobra.Command {
+	var root string
+	c := &cobra.Command{
+		Use:   "tree",
+		Short: "Show pools under the pools they were delegated from",
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+
+			tr, err := cli.PoolTree(ctx, root)
+			if err != nil {
+				log.Printf("Error: %s", err)
+				return
+			}
+			renderPoolTree(cmd.OutOrStdout(), tr)
+		},
+	}
+
+	c.Flags().StringVar(&root, "root", "", "Only show the tree under this pool")
+
+	return c
+}
+
+func poolRemoveCmd() *cobra.Command {
+	var cascade, target string
+	c := &cobra.Command{
+		Use:   "remove <pool_id>",
+		Short: "Remove a pool",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+
+			p, err := cli.DeletePool(ctx, args[0], cascade, target)
+			if err != nil {
+				log.Printf("Error: %s", err)
+				renderConflicts(cmd.OutOrStdout(), err)
+				return
+			}
+			log.Printf("Pool removed: %s", p.ID.String())
+		},
+	}
+
+	f := c.Flags()
+	f.StringVar(&cascade, "cascade", "", "What to do with the networks of the pool, reassign moves them to another pool")
+	f.StringVar(&target, "target", "", "Pool taking the reassigned networks, defaults to the parent pool")
+
+	return c
+}
+
+// renderConflicts lists the networks an API error was caused by, if any.
+func renderConflicts(w io.Writer, err error) {
+	var ce *client.Error
+	if !errors.As(err, &ce) || len(ce.Response.Conflicts) == 0 {
+		return
+	}
+	log.Println("Conflicting networks:")
+	renderNetworks(w, &types.NetworkListResponse{
+		Items: ce.Response.Conflicts,
+	})
 }
 
 var poolListCmd = &cobra.Command{
@@ -131,11 +708,34 @@ var poolListCmd = &cobra.Command{
 			log.Printf("error retriving client")
 			return
 		}
-		ps, err := cli.ListPools(ctx)
+		ps := &types.PoolListResponse{Items: []*types.Pool{}}
+		for p, err := range cli.Pools(ctx, nil) {
+			if err != nil {
+				log.Printf("Error: %s", err)
+				return
+			}
+			ps.Items = append(ps.Items, p)
+		}
+		renderPools(cmd.OutOrStdout(), ps)
+	},
+}
+
+var poolUsageCmd = &cobra.Command{
+	Use:   "usage <pool_id>",
+	Short: "Show pool utilization and remaining capacity",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		cli, ok := client.ClientFromContext(ctx)
+		if !ok {
+			log.Printf("error retriving client")
+			return
+		}
+		u, err := cli.PoolUsage(ctx, args[0])
 		if err != nil {
 			log.Printf("Error: %s", err)
 			return
 		}
-		renderPools(cmd.OutOrStdout(), ps)
+		renderPoolUsage(cmd.OutOrStdout(), u)
 	},
 }
@@ -6,10 +6,12 @@ import (
 	"encoding/json"
 	"io"
 	"log"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/olxbr/network-api/pkg/client"
 	"github.com/olxbr/network-api/pkg/types"
@@ -77,6 +79,48 @@ func TestPoolAddCommand(t *testing.T) {
 				assert.Contains(t, out, "pool-01")
 			},
 		},
+		{
+			name: "add pool with exclusions",
+			flags: []string{
+				"pool-01",
+				"--region", "us-east-1",
+				"--subnet-ip", "10.0.0.0",
+				"--subnet-mask", "8",
+				"--exclude", "10.100.0.0/16:infra:on-prem datacenter",
+			},
+			prepare: func(w http.ResponseWriter, r *http.Request) {
+				pr := &types.PoolRequest{}
+				_ = json.NewDecoder(r.Body).Decode(pr)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(201)
+				_ = json.NewEncoder(w).Encode(&types.Pool{
+					ID:         uuid,
+					Name:       "pool-01",
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					Exclusions: pr.Exclusions,
+				})
+			},
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, "10.100.0.0/16")
+				assert.Contains(t, out, "infra")
+				assert.Contains(t, out, "on-prem datacenter")
+			},
+		},
+		{
+			name: "add pool with an invalid exclusion",
+			flags: []string{
+				"pool-01",
+				"--region", "us-east-1",
+				"--subnet-ip", "10.0.0.0",
+				"--subnet-mask", "8",
+				"--exclude", "10.100.0.0/16",
+			},
+			prepare: func(w http.ResponseWriter, r *http.Request) {},
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, `invalid exclusion "10.100.0.0/16", use cidr:owner:reason`)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -154,3 +198,529 @@ func TestPoolListCommand(t *testing.T) {
 	log.SetOutput(os.Stderr)
 	cmd.SetOut(os.Stdout)
 }
+
+func TestPoolUsageCommand(t *testing.T) {
+	id := types.NewUUID()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/pools/"+id.String()+"/usage", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_ = json.NewEncoder(w).Encode(&types.PoolUsage{
+			PoolID:            id.String(),
+			Range:             "10.2.0.0-10.2.255.255",
+			Total:             big.NewInt(65536),
+			Allocated:         big.NewInt(32768),
+			Reserved:          big.NewInt(0),
+			Excluded:          big.NewInt(4096),
+			Free:              big.NewInt(28672),
+			LargestFreePrefix: "10.2.128.0/17",
+			Available:         map[string]uint64{"/17": 1, "/24": 128},
+			FreeRanges:        []string{"10.2.128.0-10.2.255.255"},
+		})
+	}))
+	defer s.Close()
+	ctx := context.TODO()
+	ctx = client.WithNewClient(ctx, &client.ClientOptions{
+		Endpoint: s.URL,
+		Client:   &http.Client{},
+	})
+	cmd := poolUsageCmd
+	var b bytes.Buffer
+	cmd.SetOut(&b)
+	log.SetOutput(&b)
+	cmd.SetArgs([]string{id.String()})
+	err := cmd.ExecuteContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(out)
+	assert.Contains(t, result, id.String())
+	assert.Contains(t, result, "10.2.128.0/17")
+	assert.Contains(t, result, "10.2.128.0-10.2.255.255")
+	assert.Contains(t, result, "128")
+	assert.Contains(t, result, "4096")
+	log.SetOutput(os.Stderr)
+	cmd.SetOut(os.Stdout)
+}
+
+func TestPoolExclusionsCommand(t *testing.T) {
+	id := types.NewUUID()
+
+	tests := []struct {
+		name   string
+		flags  []string
+		assert func(t *testing.T, out string, body *types.ExclusionsRequest)
+	}{
+		{
+			name:  "replace exclusions",
+			flags: []string{id.String(), "--exclude", "10.100.0.0/16:infra:on-prem datacenter", "--exclude", "10.200.0.0/13:network-team:partner VPN"},
+			assert: func(t *testing.T, out string, body *types.ExclusionsRequest) {
+				assert.Len(t, body.Exclusions, 2)
+				assert.Contains(t, out, "10.100.0.0/16")
+				assert.Contains(t, out, "partner VPN")
+			},
+		},
+		{
+			name:  "clear exclusions",
+			flags: []string{id.String(), "--clear"},
+			assert: func(t *testing.T, out string, body *types.ExclusionsRequest) {
+				assert.NotNil(t, body.Exclusions)
+				assert.Empty(t, body.Exclusions)
+			},
+		},
+		{
+			name:  "nothing to set",
+			flags: []string{id.String()},
+			assert: func(t *testing.T, out string, body *types.ExclusionsRequest) {
+				assert.Nil(t, body)
+				assert.Contains(t, out, "Exclusions or --clear are required")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body *types.ExclusionsRequest
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPut, r.Method)
+				assert.Equal(t, "/api/v1/pools/"+id.String()+"/exclusions", r.URL.Path)
+				body = &types.ExclusionsRequest{}
+				_ = json.NewDecoder(r.Body).Decode(body)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(200)
+				_ = json.NewEncoder(w).Encode(&types.Pool{
+					ID:         id,
+					Name:       "pool-01",
+					Exclusions: body.Exclusions,
+				})
+			}))
+			defer s.Close()
+			ctx := context.TODO()
+			ctx = client.WithNewClient(ctx, &client.ClientOptions{
+				Endpoint: s.URL,
+				Client:   &http.Client{},
+			})
+			cmd := poolExclusionsCmd()
+			var b bytes.Buffer
+			cmd.SetOut(&b)
+			log.SetOutput(&b)
+			cmd.SetArgs(tt.flags)
+			err := cmd.ExecuteContext(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := io.ReadAll(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.assert(t, string(out), body)
+			log.SetOutput(os.Stderr)
+			cmd.SetOut(os.Stdout)
+		})
+	}
+}
+
+func TestPoolLabelsCommand(t *testing.T) {
+	id := types.NewUUID()
+
+	var body *types.PoolLabelsRequest
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/api/v1/pools/"+id.String()+"/labels", r.URL.Path)
+		body = &types.PoolLabelsRequest{}
+		_ = json.NewDecoder(r.Body).Decode(body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_ = json.NewEncoder(w).Encode(&types.Pool{
+			ID:       id,
+			Name:     "pool-01",
+			Labels:   body.Labels,
+			Priority: body.Priority,
+		})
+	}))
+	defer s.Close()
+	ctx := context.TODO()
+	ctx = client.WithNewClient(ctx, &client.ClientOptions{
+		Endpoint: s.URL,
+		Client:   &http.Client{},
+	})
+	cmd := poolLabelsCmd()
+	var b bytes.Buffer
+	cmd.SetOut(&b)
+	log.SetOutput(&b)
+	cmd.SetArgs([]string{id.String(), "--label", "purpose=shared", "--label", "environment=prod", "--priority", "10"})
+	err := cmd.ExecuteContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]string{"purpose": "shared", "environment": "prod"}, body.Labels)
+	assert.Equal(t, 10, body.Priority)
+	assert.Contains(t, string(out), "shared")
+	assert.Contains(t, string(out), "Priority: 10")
+	log.SetOutput(os.Stderr)
+	cmd.SetOut(os.Stdout)
+}
+
+func TestPoolPolicyCommand(t *testing.T) {
+	id := types.NewUUID()
+
+	tests := []struct {
+		name   string
+		flags  []string
+		assert func(t *testing.T, out string, body *types.PoolPolicy)
+	}{
+		{
+			name: "replace policy",
+			flags: []string{id.String(), "--prefix-length", "20", "--prefix-length", "22", "--environment", "prod",
+				"--quota", "account:networks=3", "--quota", "environment=dev:addresses=65536"},
+			assert: func(t *testing.T, out string, body *types.PoolPolicy) {
+				assert.Equal(t, []int{20, 22}, body.PrefixLengths)
+				assert.Equal(t, []string{"prod"}, body.Environments)
+				assert.Equal(t, []*types.Quota{
+					{Scope: types.QuotaAccount, MaxNetworks: 3},
+					{Scope: types.QuotaEnvironment, Value: "dev", MaxAddresses: 65536},
+				}, body.Quotas)
+				assert.Contains(t, out, "/20, /22")
+				assert.Contains(t, out, "environment=dev: 65536 addresses")
+			},
+		},
+		{
+			name:  "clear policy",
+			flags: []string{id.String(), "--clear"},
+			assert: func(t *testing.T, out string, body *types.PoolPolicy) {
+				assert.Empty(t, body.PrefixLengths)
+				assert.Contains(t, out, "Pool pool-01 has no policy")
+			},
+		},
+		{
+			name:  "invalid quota",
+			flags: []string{id.String(), "--quota", "account:networks"},
+			assert: func(t *testing.T, out string, body *types.PoolPolicy) {
+				assert.Nil(t, body)
+				assert.Contains(t, out, `invalid quota limit "networks", use networks=N or addresses=N`)
+			},
+		},
+		{
+			name:  "nothing to set",
+			flags: []string{id.String()},
+			assert: func(t *testing.T, out string, body *types.PoolPolicy) {
+				assert.Nil(t, body)
+				assert.Contains(t, out, "A rule or --clear are required")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body *types.PoolPolicy
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPut, r.Method)
+				assert.Equal(t, "/api/v1/pools/"+id.String()+"/policy", r.URL.Path)
+				body = &types.PoolPolicy{}
+				_ = json.NewDecoder(r.Body).Decode(body)
+				p := &types.Pool{ID: id, Name: "pool-01"}
+				if len(body.PrefixLengths) > 0 || len(body.Environments) > 0 || len(body.Quotas) > 0 {
+					p.Policy = body
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(200)
+				_ = json.NewEncoder(w).Encode(p)
+			}))
+			defer s.Close()
+			ctx := context.TODO()
+			ctx = client.WithNewClient(ctx, &client.ClientOptions{
+				Endpoint: s.URL,
+				Client:   &http.Client{},
+			})
+			cmd := poolPolicyCmd()
+			var b bytes.Buffer
+			cmd.SetOut(&b)
+			log.SetOutput(&b)
+			cmd.SetArgs(tt.flags)
+			err := cmd.ExecuteContext(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := io.ReadAll(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.assert(t, string(out), body)
+			log.SetOutput(os.Stderr)
+			cmd.SetOut(os.Stdout)
+		})
+	}
+}
+
+func TestPoolInfoCommand(t *testing.T) {
+	id := types.NewUUID()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/pools/"+id.String(), r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_ = json.NewEncoder(w).Encode(&types.Pool{
+			ID:         id,
+			Name:       "pool-01",
+			Region:     "us-east-1",
+			SubnetIP:   "10.0.0.0",
+			SubnetMask: types.Int(16),
+			Policy: &types.PoolPolicy{Quotas: []*types.Quota{
+				{Scope: types.QuotaAccount, MaxNetworks: 3},
+			}},
+			QuotaUsage: []*types.QuotaUsage{
+				{Scope: types.QuotaAccount, Value: "123", Networks: 2, Addresses: big.NewInt(8192), MaxNetworks: 3},
+			},
+		})
+	}))
+	defer s.Close()
+	ctx := context.TODO()
+	ctx = client.WithNewClient(ctx, &client.ClientOptions{
+		Endpoint: s.URL,
+		Client:   &http.Client{},
+	})
+	cmd := poolInfoCmd()
+	var b bytes.Buffer
+	cmd.SetOut(&b)
+	log.SetOutput(&b)
+	cmd.SetArgs([]string{id.String()})
+	err := cmd.ExecuteContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(out), "pool-01")
+	assert.Contains(t, string(out), "account: 3 networks")
+	assert.Contains(t, string(out), "Quota usage:")
+	assert.Contains(t, string(out), "2/3")
+	assert.Contains(t, string(out), "8192")
+	log.SetOutput(os.Stderr)
+	cmd.SetOut(os.Stdout)
+}
+
+func TestPoolTreeCommand(t *testing.T) {
+	corp := &types.Pool{ID: types.NewUUID(), Name: "corp", Region: "us-east-1", SubnetIP: "10.0.0.0", SubnetMask: types.Int(8)}
+	east := &types.Pool{ID: types.NewUUID(), Name: "us-east", Region: "us-east-1", SubnetIP: "10.0.0.0", SubnetMask: types.Int(12), ParentID: corp.ID.String()}
+	payments := &types.Pool{ID: types.NewUUID(), Name: "payments", Region: "us-east-1", SubnetIP: "10.1.0.0", SubnetMask: types.Int(16), ParentID: east.ID.String(), Admins: []string{"payments-team"}}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/pools/tree", r.URL.Path)
+		assert.Equal(t, corp.ID.String(), r.URL.Query().Get("root"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_ = json.NewEncoder(w).Encode(&types.PoolTreeResponse{
+			Items: []*types.PoolTree{
+				{Pool: corp, Children: []*types.PoolTree{
+					{Pool: east, Children: []*types.PoolTree{{Pool: payments}}},
+				}},
+			},
+		})
+	}))
+	defer s.Close()
+	ctx := context.TODO()
+	ctx = client.WithNewClient(ctx, &client.ClientOptions{
+		Endpoint: s.URL,
+		Client:   &http.Client{},
+	})
+	cmd := poolTreeCmd()
+	var b bytes.Buffer
+	cmd.SetOut(&b)
+	log.SetOutput(&b)
+	cmd.SetArgs([]string{"--root", corp.ID.String()})
+	err := cmd.ExecuteContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(out)
+	assert.Contains(t, result, "corp")
+	assert.Contains(t, result, "└ us-east")
+	assert.Contains(t, result, "└─ payments")
+	assert.Contains(t, result, "10.1.0.0/16")
+	assert.Contains(t, result, "payments-team")
+	log.SetOutput(os.Stderr)
+	cmd.SetOut(os.Stdout)
+}
+
+func TestPoolUpdateCommand(t *testing.T) {
+	id := types.NewUUID()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/api/v1/pools/"+id.String(), r.URL.Path)
+		ur := &types.PoolUpdateRequest{}
+		_ = json.NewDecoder(r.Body).Decode(ur)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_ = json.NewEncoder(w).Encode(&types.Pool{
+			ID:         id,
+			Name:       "pool-01",
+			Region:     "us-east-1",
+			SubnetIP:   ur.SubnetIP,
+			SubnetMask: ur.SubnetMask,
+		})
+	}))
+	defer s.Close()
+	ctx := context.TODO()
+	ctx = client.WithNewClient(ctx, &client.ClientOptions{
+		Endpoint: s.URL,
+		Client:   &http.Client{},
+	})
+	cmd := poolUpdateCmd()
+	var b bytes.Buffer
+	cmd.SetOut(&b)
+	log.SetOutput(&b)
+	cmd.SetArgs([]string{id.String(), "--subnet-ip", "10.2.0.0", "--subnet-mask", "15"})
+	err := cmd.ExecuteContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(out), "10.2.0.0/15")
+	log.SetOutput(os.Stderr)
+	cmd.SetOut(os.Stdout)
+}
+
+func TestPoolRenameCommand(t *testing.T) {
+	id := types.NewUUID()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		ur := &types.PoolUpdateRequest{}
+		_ = json.NewDecoder(r.Body).Decode(ur)
+		assert.Equal(t, "pool-02", ur.Name)
+		assert.Empty(t, ur.SubnetIP)
+		assert.Nil(t, ur.SubnetMask)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_ = json.NewEncoder(w).Encode(&types.Pool{
+			ID:         id,
+			Name:       ur.Name,
+			Region:     "us-east-1",
+			SubnetIP:   "10.0.0.0",
+			SubnetMask: types.Int(16),
+			History: []*types.PoolChange{{
+				Name:          ur.Name,
+				PreviousName:  "pool-01",
+				Range:         "10.0.0.0-10.0.255.255",
+				PreviousRange: "10.0.0.0-10.0.255.255",
+				Timestamp:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+			}},
+		})
+	}))
+	defer s.Close()
+	ctx := context.TODO()
+	ctx = client.WithNewClient(ctx, &client.ClientOptions{
+		Endpoint: s.URL,
+		Client:   &http.Client{},
+	})
+	cmd := poolUpdateCmd()
+	var b bytes.Buffer
+	cmd.SetOut(&b)
+	log.SetOutput(&b)
+	cmd.SetArgs([]string{id.String(), "--name", "pool-02"})
+	err := cmd.ExecuteContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(out)
+	assert.Contains(t, result, "pool-02")
+	assert.Contains(t, result, "pool-01")
+	assert.Contains(t, result, "2024-05-01T12:00:00Z")
+	log.SetOutput(os.Stderr)
+	cmd.SetOut(os.Stdout)
+}
+
+func TestPoolRemoveCommand(t *testing.T) {
+	id := types.NewUUID()
+	tests := []struct {
+		name   string
+		args   []string
+		assert func(t *testing.T, r *http.Request, w http.ResponseWriter)
+		out    []string
+	}{
+		{
+			name: "pool removed",
+			args: []string{id.String()},
+			assert: func(t *testing.T, r *http.Request, w http.ResponseWriter) {
+				assert.Empty(t, r.URL.RawQuery)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_ = json.NewEncoder(w).Encode(&types.Pool{ID: id, Name: "pool-01"})
+			},
+			out: []string{"Pool removed: " + id.String()},
+		},
+		{
+			name: "pool still holding networks",
+			args: []string{id.String()},
+			assert: func(t *testing.T, r *http.Request, w http.ResponseWriter) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusConflict)
+				_ = json.NewEncoder(w).Encode(&types.ErrorResponse{
+					Code:   types.ErrorConflict,
+					Errors: map[string]string{"_all": "pool pool-01 still holds 10.2.0.0/24 (account 123)"},
+					Conflicts: []*types.Network{
+						{ID: types.NewUUID(), Account: "123", CIDR: "10.2.0.0/24"},
+					},
+				})
+			},
+			out: []string{"pool pool-01 still holds", "Conflicting networks:", "10.2.0.0/24"},
+		},
+		{
+			name: "networks reassigned",
+			args: []string{id.String(), "--cascade", "reassign", "--target", "corp-id"},
+			assert: func(t *testing.T, r *http.Request, w http.ResponseWriter) {
+				assert.Equal(t, "reassign", r.URL.Query().Get("cascade"))
+				assert.Equal(t, "corp-id", r.URL.Query().Get("target"))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_ = json.NewEncoder(w).Encode(&types.Pool{ID: id, Name: "pool-01"})
+			},
+			out: []string{"Pool removed: " + id.String()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodDelete, r.Method)
+				assert.Equal(t, "/api/v1/pools/"+id.String(), r.URL.Path)
+				tt.assert(t, r, w)
+			}))
+			defer s.Close()
+			ctx := client.WithNewClient(context.TODO(), &client.ClientOptions{
+				Endpoint: s.URL,
+				Client:   &http.Client{},
+			})
+			cmd := poolRemoveCmd()
+			var b bytes.Buffer
+			cmd.SetOut(&b)
+			log.SetOutput(&b)
+			cmd.SetArgs(tt.args)
+			err := cmd.ExecuteContext(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := b.String()
+			for _, o := range tt.out {
+				assert.Contains(t, out, o)
+			}
+			log.SetOutput(os.Stderr)
+			cmd.SetOut(os.Stdout)
+		})
+	}
+}
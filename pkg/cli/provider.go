@@ -104,10 +104,13 @@ var providerListCmd = &cobra.Command{
 			log.Printf("error retriving client")
 			return
 		}
-		ps, err := cli.ListProviders(ctx)
-		if err != nil {
-			log.Printf("Error: %s", err)
-			return
+		ps := &types.ProviderListResponse{Items: []*types.Provider{}}
+		for p, err := range cli.Providers(ctx, nil) {
+			if err != nil {
+				log.Printf("Error: %s", err)
+				return
+			}
+			ps.Items = append(ps.Items, p)
 		}
 		renderProviders(cmd.OutOrStdout(), ps)
 	},
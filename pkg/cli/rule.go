@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olxbr/network-api/pkg/client"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// parseCondition reads a condition flag: field=a,b for one of the values,
+// field!=a,b for none of them, field>=N and field<=N for numeric limits and a
+// bare field for a field that must be set.
+func parseCondition(s string) (*types.RuleCondition, error) {
+	for _, op := range []struct {
+		sep      string
+		operator types.RuleOperator
+	}{
+		{"!=", types.RuleNotIn},
+		{">=", types.RuleMin},
+		{"<=", types.RuleMax},
+		{"=", types.RuleIn},
+	} {
+		field, value, ok := strings.Cut(s, op.sep)
+		if !ok {
+			continue
+		}
+		c := &types.RuleCondition{Field: field, Operator: op.operator}
+		if op.operator == types.RuleMin || op.operator == types.RuleMax {
+			limit, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid limit %q in condition %q", value, s)
+			}
+			c.Limit = limit
+		} else {
+			c.Values = strings.Split(value, ",")
+		}
+		return c, nil
+	}
+	if s == "" || strings.ContainsAny(s, "<>!") {
+		return nil, fmt.Errorf("invalid condition %q, use field=a,b, field!=a,b, field>=N, field<=N or field", s)
+	}
+	return &types.RuleCondition{Field: s, Operator: types.RulePresent}, nil
+}
+
+// formatCondition writes a condition back as its flag.
+func formatCondition(c *types.RuleCondition) string {
+	switch c.Operator {
+	case types.RuleIn:
+		return c.Field + "=" + strings.Join(c.Values, ",")
+	case types.RuleNotIn:
+		return c.Field + "!=" + strings.Join(c.Values, ",")
+	case types.RuleMin:
+		return fmt.Sprintf("%s>=%d", c.Field, c.Limit)
+	case types.RuleMax:
+		return fmt.Sprintf("%s<=%d", c.Field, c.Limit)
+	}
+	return c.Field
+}
+
+func formatConditions(cs []*types.RuleCondition) string {
+	parts := []string{}
+	for _, c := range cs {
+		parts = append(parts, formatCondition(c))
+	}
+	return strings.Join(parts, " ")
+}
+
+func renderRules(w io.Writer, rs *types.RuleListResponse) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Rule", "When", "Require", "Description"})
+	for _, r := range rs.Items {
+		if err := table.Append([]string{
+			r.Name,
+			formatConditions(r.When),
+			formatConditions(r.Require),
+			r.Description,
+		}); err != nil {
+			log.Printf("error appending to table: %v", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+}
+
+func renderRuleResults(w io.Writer, results []*types.RuleResult) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Rule", "Outcome", "Reason"})
+	for _, r := range results {
+		if err := table.Append([]string{
+			r.Rule,
+			string(r.Outcome),
+			r.Reason,
+		}); err != nil {
+			log.Printf("error appending to table: %v", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+}
+
+func newRuleCommand() *cobra.Command {
+	ruleCmd := &cobra.Command{
+		Use:   "rule",
+		Short: "Network request rule operations",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := LoadConfig()
+			if err != nil {
+				log.Printf("Is your config file correctly created?")
+				return err
+			}
+			ctx := cmd.Context()
+			ctx, err = SetupClientContext(WithConfig(ctx, cfg), cfg)
+			if err != nil {
+				return err
+			}
+			cmd.SetContext(ctx)
+			return nil
+		},
+	}
+
+	ruleCmd.AddCommand(ruleAddCmd())
+	ruleCmd.AddCommand(ruleListCmd)
+	ruleCmd.AddCommand(ruleRemoveCmd)
+
+	return ruleCmd
+}
+
+func ruleAddCmd() *cobra.Command {
+	req := &types.RuleRequest{}
+	var when, require []string
+
+	c := &cobra.Command{
+		Use:   "add",
+		Short: "Adds a new network request rule",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+
+			req.Name = args[0]
+			for _, s := range when {
+				cond, err := parseCondition(s)
+				if err != nil {
+					log.Printf("Error: %s", err)
+					return
+				}
+				req.When = append(req.When, cond)
+			}
+			for _, s := range require {
+				cond, err := parseCondition(s)
+				if err != nil {
+					log.Printf("Error: %s", err)
+					return
+				}
+				req.Require = append(req.Require, cond)
+			}
+
+			r, err := cli.CreateRule(ctx, req)
+			if err != nil {
+				log.Printf("error creating rule: %+v", err)
+				return
+			}
+
+			log.Println("Rule:")
+			renderRules(cmd.OutOrStdout(), &types.RuleListResponse{
+				Items: []*types.Rule{r},
+			})
+		},
+	}
+
+	f := c.Flags()
+	f.StringVar(&req.Description, "description", "", "What the rule is for")
+	f.StringArrayVar(&when, "when", nil, "Condition limiting the requests the rule applies to, as field=a,b, field!=a,b, field>=N, field<=N or field")
+	f.StringArrayVar(&require, "require", nil, "Condition the requests must meet, as field=a,b, field!=a,b, field>=N, field<=N or field")
+
+	_ = c.MarkFlagRequired("require")
+
+	return c
+}
+
+var ruleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List network request rules",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		cli, ok := client.ClientFromContext(ctx)
+		if !ok {
+			log.Printf("error retriving client")
+			return
+		}
+		rs := &types.RuleListResponse{Items: []*types.Rule{}}
+		for r, err := range cli.Rules(ctx, nil) {
+			if err != nil {
+				log.Printf("Error: %s", err)
+				return
+			}
+			rs.Items = append(rs.Items, r)
+		}
+		renderRules(cmd.OutOrStdout(), rs)
+	},
+}
+
+var ruleRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Removes a network request rule",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		cli, ok := client.ClientFromContext(ctx)
+		if !ok {
+			log.Printf("error retriving client")
+			return
+		}
+
+		name := args[0]
+
+		err := cli.DeleteRule(ctx, name)
+		if err != nil {
+			log.Printf("Error: %s", err)
+			return
+		}
+
+		log.Printf("Rule removed: %s", name)
+	},
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/olxbr/network-api/pkg/client"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleAddCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   []string
+		prepare func(t *testing.T, w http.ResponseWriter, r *http.Request)
+		assert  func(t *testing.T, out string, e error)
+	}{
+		{
+			name:    "without require flags",
+			flags:   []string{"prod-tgw"},
+			prepare: func(t *testing.T, w http.ResponseWriter, r *http.Request) {},
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, e.Error(), `required flag(s) "require" not set`)
+			},
+		},
+		{
+			name:  "invalid condition",
+			flags: []string{"qa-size", "--require", "subnetSize>=big"},
+			prepare: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				t.Error("unexpected request")
+			},
+			assert: func(t *testing.T, out string, e error) {
+				assert.Contains(t, out, `invalid limit "big" in condition "subnetSize>=big"`)
+			},
+		},
+		{
+			name: "add rule",
+			flags: []string{
+				"public-justified",
+				"--description", "public subnets need a justification",
+				"--when", "publicSubnet=true",
+				"--when", "environment!=sandbox,dev",
+				"--require", "info",
+				"--require", "subnetSize<=24",
+			},
+			prepare: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				rr := &types.RuleRequest{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(rr))
+				assert.Equal(t, &types.RuleRequest{
+					Name:        "public-justified",
+					Description: "public subnets need a justification",
+					When: []*types.RuleCondition{
+						{Field: "publicSubnet", Operator: types.RuleIn, Values: []string{"true"}},
+						{Field: "environment", Operator: types.RuleNotIn, Values: []string{"sandbox", "dev"}},
+					},
+					Require: []*types.RuleCondition{
+						{Field: "info", Operator: types.RulePresent},
+						{Field: "subnetSize", Operator: types.RuleMax, Limit: 24},
+					},
+				}, rr)
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(201)
+				_ = json.NewEncoder(w).Encode(&types.Rule{
+					Name:        rr.Name,
+					Description: rr.Description,
+					When:        rr.When,
+					Require:     rr.Require,
+				})
+			},
+			assert: func(t *testing.T, out string, e error) {
+				assert.NoError(t, e)
+				assert.Contains(t, out, "publicSubnet=true environment!=sandbox,dev")
+				assert.Contains(t, out, "info subnetSize<=24")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.prepare(t, w, r)
+			}))
+			defer s.Close()
+			ctx := context.TODO()
+			ctx = client.WithNewClient(ctx, &client.ClientOptions{
+				Endpoint: s.URL,
+				Client:   &http.Client{},
+			})
+			cmd := ruleAddCmd()
+			var b bytes.Buffer
+			cmd.SetOut(&b)
+			log.SetOutput(&b)
+			cmd.SetArgs(tt.flags)
+			e := cmd.ExecuteContext(ctx)
+			out, err := io.ReadAll(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.assert(t, string(out), e)
+			log.SetOutput(os.Stderr)
+			cmd.SetOut(os.Stdout)
+		})
+	}
+}
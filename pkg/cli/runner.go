@@ -1,11 +1,13 @@
 package cli
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/spf13/cobra"
@@ -50,6 +52,9 @@ func NewRunner() *Runner {
 	rootCmd.AddCommand(newNetworkCommand())
 	rootCmd.AddCommand(newProviderCommand())
 	rootCmd.AddCommand(newPoolCommand())
+	rootCmd.AddCommand(newLayoutCommand())
+	rootCmd.AddCommand(newRuleCommand())
+	rootCmd.AddCommand(newWhoisCommand())
 	rootCmd.AddCommand(newConfigCommand())
 	return &Runner{
 		root: rootCmd,
@@ -70,3 +75,14 @@ func run(command *cobra.Command) error {
 
 	return command.ExecuteContext(ctx)
 }
+
+// confirm asks a yes/no question on the command input, defaulting to no.
+func confirm(cmd *cobra.Command, question string) bool {
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s [y/N]: ", question)
+	answer, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
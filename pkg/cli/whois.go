@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"io"
+	"log"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olxbr/network-api/pkg/client"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+func renderLookup(w io.Writer, lr *types.LookupListResponse) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Query", "Routing Domain", "Status", "Pool", "Network", "Account", "Environment", "VpcID", "Subnet", "Subnet CIDR", "AZ", "Free Range"})
+	for _, l := range lr.Items {
+		row := make([]string, 12)
+		row[0] = l.Query
+		row[1] = l.RoutingDomain
+		row[2] = string(l.Status)
+		if l.Pool != nil {
+			row[3] = l.Pool.Name
+		}
+		if l.Network != nil {
+			row[4] = l.Network.ID.String()
+			row[5] = l.Network.Account
+			row[6] = l.Network.Environment
+			row[7] = l.Network.VpcID
+		}
+		if l.Subnet != nil {
+			row[8] = l.Subnet.Name
+			row[9] = l.Subnet.CIDR
+		}
+		if l.AZIndex != nil {
+			row[10] = strconv.Itoa(*l.AZIndex)
+		}
+		row[11] = l.FreeRange
+		if err := table.Append(row); err != nil {
+			log.Printf("error appending to table: %v", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		log.Printf("error rendering table: %v", err)
+	}
+}
+
+func newWhoisCommand() *cobra.Command {
+	c := whoisCmd()
+	c.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		cfg, err := LoadConfig()
+		if err != nil {
+			log.Printf("Is your config file correctly created?")
+			return err
+		}
+		ctx := cmd.Context()
+		ctx, err = SetupClientContext(WithConfig(ctx, cfg), cfg)
+		if err != nil {
+			return err
+		}
+		cmd.SetContext(ctx)
+		return nil
+	}
+	return c
+}
+
+func whoisCmd() *cobra.Command {
+	var domain string
+
+	c := &cobra.Command{
+		Use:   "whois <ip|cidr>",
+		Short: "Shows the pool, network and subnet holding an IP or CIDR",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			cli, ok := client.ClientFromContext(ctx)
+			if !ok {
+				log.Printf("error retriving client")
+				return
+			}
+			lr, err := cli.Lookup(ctx, args[0], domain)
+			if err != nil {
+				log.Printf("Error: %s", err)
+				return
+			}
+			renderLookup(cmd.OutOrStdout(), lr)
+		},
+	}
+
+	c.Flags().StringVar(&domain, "routing-domain", "", "Routing domain to look in, every one when empty")
+	return c
+}
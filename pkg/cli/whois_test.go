@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/olxbr/network-api/pkg/client"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhoisCommand(t *testing.T) {
+	id := types.NewUUID()
+	tests := []struct {
+		name     string
+		args     []string
+		query    string
+		response *types.LookupListResponse
+		expected []string
+	}{
+		{
+			name:  "allocated ip",
+			args:  []string{"10.0.6.10"},
+			query: "ip=10.0.6.10",
+			response: &types.LookupListResponse{Items: []*types.LookupResponse{{
+				Query:   "10.0.6.10/32",
+				Status:  types.LookupAllocated,
+				Pool:    &types.Pool{Name: "prod"},
+				Network: &types.Network{ID: id, Account: "123", Environment: "prod", VpcID: "vpc-1"},
+				Subnet:  &types.Subnet{Name: "public02", CIDR: "10.0.6.0/24"},
+				AZIndex: types.Int(1),
+			}}},
+			expected: []string{"allocated", "prod", id.String(), "vpc-1", "public02", "10.0.6.0/24"},
+		},
+		{
+			name:  "unallocated cidr",
+			args:  []string{"10.0.64.0/24"},
+			query: "cidr=10.0.64.0%2F24",
+			response: &types.LookupListResponse{Items: []*types.LookupResponse{{
+				Query:     "10.0.64.0/24",
+				Status:    types.LookupUnallocated,
+				Pool:      &types.Pool{Name: "prod"},
+				FreeRange: "10.0.33.0-10.0.255.255",
+			}}},
+			expected: []string{"unallocated", "10.0.33.0-10.0.255.255"},
+		},
+		{
+			name:  "ip in a routing domain",
+			args:  []string{"10.0.6.10", "--routing-domain", "sandbox"},
+			query: "ip=10.0.6.10&routingDomain=sandbox",
+			response: &types.LookupListResponse{Items: []*types.LookupResponse{{
+				Query:         "10.0.6.10/32",
+				Status:        types.LookupAllocated,
+				Pool:          &types.Pool{Name: "lab"},
+				Network:       &types.Network{ID: id, Account: "789", Environment: "sandbox"},
+				RoutingDomain: "sandbox",
+			}}},
+			expected: []string{"allocated", "lab", "789", "sandbox"},
+		},
+		{
+			name:  "ip in several routing domains",
+			args:  []string{"10.0.6.10"},
+			query: "ip=10.0.6.10",
+			response: &types.LookupListResponse{Items: []*types.LookupResponse{
+				{Query: "10.0.6.10/32", Status: types.LookupUnallocated, Pool: &types.Pool{Name: "prod"}, FreeRange: "10.0.0.0-10.0.255.255"},
+				{Query: "10.0.6.10/32", Status: types.LookupAllocated, Pool: &types.Pool{Name: "lab"}, RoutingDomain: "sandbox"},
+			}},
+			expected: []string{"unallocated", "prod", "allocated", "lab", "sandbox"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/v1/lookup", r.URL.Path)
+				assert.Equal(t, tt.query, r.URL.RawQuery)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(200)
+				_ = json.NewEncoder(w).Encode(tt.response)
+			}))
+			defer s.Close()
+			ctx := context.TODO()
+			ctx = client.WithNewClient(ctx, &client.ClientOptions{
+				Endpoint: s.URL,
+				Client:   &http.Client{},
+			})
+			cmd := whoisCmd()
+			var b bytes.Buffer
+			cmd.SetOut(&b)
+			log.SetOutput(&b)
+			cmd.SetArgs(tt.args)
+			err := cmd.ExecuteContext(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := io.ReadAll(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := string(out)
+			for _, e := range tt.expected {
+				assert.Contains(t, result, e)
+			}
+			log.SetOutput(os.Stderr)
+			cmd.SetOut(os.Stdout)
+		})
+	}
+}
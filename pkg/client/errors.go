@@ -0,0 +1,95 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("conflict")
+	ErrOverlap       = errors.New("network overlaps with existing network")
+	ErrPoolExhausted = errors.New("pool exhausted")
+	ErrNotInPool     = errors.New("network not in pool range")
+	ErrExcluded      = errors.New("network excluded from pool")
+	ErrInvalid       = errors.New("invalid request")
+	ErrNoPoolMatch   = errors.New("no pool matches")
+	ErrPolicy        = errors.New("pool policy violation")
+	ErrRule          = errors.New("network request fails rules")
+)
+
+// Error is returned for the requests the API rejects, it matches the error
+// of its code so callers can check it with errors.Is.
+type Error struct {
+	StatusCode int
+	Response   *types.ErrorResponse
+}
+
+func (e *Error) Error() string {
+	messages := []string{}
+	for field, message := range e.Response.Errors {
+		if field != "_all" {
+			message = field + " " + message
+		}
+		messages = append(messages, message)
+	}
+	sort.Strings(messages)
+	return fmt.Sprintf("request failed %d: %s", e.StatusCode, strings.Join(messages, "; "))
+}
+
+func (e *Error) Is(target error) bool {
+	switch e.Response.Code {
+	case types.ErrorNotFound:
+		return target == ErrNotFound
+	case types.ErrorOverlap:
+		return target == ErrOverlap || target == ErrConflict
+	case types.ErrorConflict:
+		return target == ErrConflict
+	case types.ErrorPoolExhausted:
+		return target == ErrPoolExhausted
+	case types.ErrorNotInPool:
+		return target == ErrNotInPool
+	case types.ErrorExcluded:
+		return target == ErrExcluded
+	case types.ErrorInvalid:
+		return target == ErrInvalid
+	case types.ErrorNoPoolMatch:
+		return target == ErrNoPoolMatch
+	case types.ErrorPolicy:
+		return target == ErrPolicy
+	case types.ErrorRule:
+		return target == ErrRule
+	}
+
+	// responses without a code only tell the status
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusConflict:
+		return target == ErrConflict
+	}
+	return false
+}
+
+// Fields returns the validation error of each field, keyed by its path in
+// the request body.
+func (e *Error) Fields() map[string]string {
+	if e.Response.Code != types.ErrorInvalid {
+		return nil
+	}
+	return e.Response.Errors
+}
+
+func decodeError(code int, d *json.Decoder) error {
+	e := &types.ErrorResponse{}
+	if err := d.Decode(e); err != nil {
+		return err
+	}
+	return &Error{StatusCode: code, Response: e}
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// ListLayouts reads a page of layouts, or all of them when o carries no limit.
+func (c *Client) ListLayouts(ctx context.Context, o *types.ListOptions) (*types.LayoutListResponse, error) {
+	v := url.Values{}
+	o.Values(v)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.listUrl("api/v1/layouts", v), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	l := &types.LayoutListResponse{}
+	if err := d.Decode(l); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (c *Client) CreateLayout(ctx context.Context, r *types.LayoutRequest) (*types.Layout, error) {
+	buf := &bytes.Buffer{}
+	e := json.NewEncoder(buf)
+	if err := e.Encode(r); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseUrl("api/v1/layouts"), buf)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusCreated {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	l := &types.Layout{}
+	if err := d.Decode(l); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (c *Client) UpdateLayout(ctx context.Context, name string, r *types.LayoutRequest) (*types.Layout, error) {
+	buf := &bytes.Buffer{}
+	e := json.NewEncoder(buf)
+	if err := e.Encode(r); err != nil {
+		return nil, err
+	}
+
+	url := c.baseUrl("api/v1/layouts/" + name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	l := &types.Layout{}
+	if err := d.Decode(l); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (c *Client) DeleteLayout(ctx context.Context, name string) error {
+	url := c.baseUrl("api/v1/layouts/" + name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return decodeError(resp.StatusCode, d)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/url"
+
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// defaultPageSize is used by the iterators when the options carry neither a
+// limit nor a sort.
+const defaultPageSize = 100
+
+// Networks iterates over every network matching f, one page at a time.
+func (c *Client) Networks(ctx context.Context, f *types.NetworkFilter, o *types.ListOptions) iter.Seq2[*types.Network, error] {
+	return pages(o, func(o *types.ListOptions) ([]*types.Network, string, error) {
+		ns, err := c.ListNetworks(ctx, f, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return ns.Items, ns.NextToken, nil
+	})
+}
+
+// Pools iterates over every pool, one page at a time.
+func (c *Client) Pools(ctx context.Context, o *types.ListOptions) iter.Seq2[*types.Pool, error] {
+	return pages(o, func(o *types.ListOptions) ([]*types.Pool, string, error) {
+		ps, err := c.ListPools(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return ps.Items, ps.NextToken, nil
+	})
+}
+
+// Providers iterates over every provider, one page at a time.
+func (c *Client) Providers(ctx context.Context, o *types.ListOptions) iter.Seq2[*types.Provider, error] {
+	return pages(o, func(o *types.ListOptions) ([]*types.Provider, string, error) {
+		ps, err := c.ListProviders(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return ps.Items, ps.NextToken, nil
+	})
+}
+
+// Layouts iterates over every layout, one page at a time.
+func (c *Client) Layouts(ctx context.Context, o *types.ListOptions) iter.Seq2[*types.Layout, error] {
+	return pages(o, func(o *types.ListOptions) ([]*types.Layout, string, error) {
+		ls, err := c.ListLayouts(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return ls.Items, ls.NextToken, nil
+	})
+}
+
+// Rules iterates over every rule, one page at a time.
+func (c *Client) Rules(ctx context.Context, o *types.ListOptions) iter.Seq2[*types.Rule, error] {
+	return pages(o, func(o *types.ListOptions) ([]*types.Rule, string, error) {
+		rs, err := c.ListRules(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return rs.Items, rs.NextToken, nil
+	})
+}
+
+// pages follows the next token of each page until the last one, stopping at
+// the first error.
+func pages[T any](o *types.ListOptions, list func(o *types.ListOptions) ([]T, string, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		opts := types.ListOptions{}
+		if o != nil {
+			opts = *o
+		}
+		// the API sorts each page on its own, sorted lists without a limit
+		// are read at once to keep the order across every item
+		if opts.Limit == 0 && opts.Sort == "" {
+			opts.Limit = defaultPageSize
+		}
+
+		for {
+			items, next, err := list(&opts)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, i := range items {
+				if !yield(i, nil) {
+					return
+				}
+			}
+			if next == "" {
+				return
+			}
+			opts.NextToken = next
+		}
+	}
+}
+
+func (c *Client) listUrl(path string, v url.Values) string {
+	if len(v) == 0 {
+		return c.baseUrl(path)
+	}
+	return c.baseUrl(path) + "?" + v.Encode()
+}
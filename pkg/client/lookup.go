@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// Lookup asks who owns an IP or, when query holds a mask, a CIDR, within
+// the given routing domain or in every one when empty.
+func (c *Client) Lookup(ctx context.Context, query, domain string) (*types.LookupListResponse, error) {
+	v := url.Values{}
+	if strings.Contains(query, "/") {
+		v.Set("cidr", query)
+	} else {
+		v.Set("ip", query)
+	}
+	if domain != "" {
+		v.Set("routingDomain", domain)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.listUrl("api/v1/lookup", v), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	lr := &types.LookupListResponse{}
+	if err := d.Decode(lr); err != nil {
+		return nil, err
+	}
+
+	return lr, nil
+}
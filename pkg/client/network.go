@@ -7,12 +7,18 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 
 	"github.com/olxbr/network-api/pkg/types"
 )
 
-func (c *Client) ListNetworks(ctx context.Context) (*types.NetworkListResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseUrl("api/v1/networks"), nil)
+// ListNetworks reads a page of the networks matching f, or all of them when
+// o carries no limit.
+func (c *Client) ListNetworks(ctx context.Context, f *types.NetworkFilter, o *types.ListOptions) (*types.NetworkListResponse, error) {
+	v := url.Values{}
+	f.Values(v)
+	o.Values(v)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.listUrl("api/v1/networks", v), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -28,11 +34,7 @@ func (c *Client) ListNetworks(ctx context.Context) (*types.NetworkListResponse,
 	}()
 	d := json.NewDecoder(resp.Body)
 	if resp.StatusCode != http.StatusOK {
-		e := &types.ErrorResponse{}
-		if err := d.Decode(e); err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("request failed %d: %+v", resp.StatusCode, e)
+		return nil, decodeError(resp.StatusCode, d)
 	}
 
 	ns := &types.NetworkListResponse{}
@@ -61,11 +63,7 @@ func (c *Client) DetailNetwork(ctx context.Context, id string) (*types.Network,
 	}()
 	d := json.NewDecoder(resp.Body)
 	if resp.StatusCode != http.StatusOK {
-		e := &types.ErrorResponse{}
-		if err := d.Decode(e); err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("request failed %d: %+v", resp.StatusCode, e)
+		return nil, decodeError(resp.StatusCode, d)
 	}
 
 	n := &types.Network{}
@@ -97,19 +95,331 @@ func (c *Client) CreateNetwork(ctx context.Context, r *types.NetworkRequest) (*t
 		}
 	}()
 
+	d := json.NewDecoder(resp.Body)
+	// provisioned networks are accepted, reserved and legacy ones created
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	n := &types.NetworkResponse{}
+	if err := d.Decode(n); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// AddNetworkCIDR attaches a secondary block to the network.
+func (c *Client) AddNetworkCIDR(ctx context.Context, id string, r *types.NetworkCIDRRequest) (*types.Network, error) {
+	buf := &bytes.Buffer{}
+	e := json.NewEncoder(buf)
+	if err := e.Encode(r); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseUrl("api/v1/networks/"+id+"/cidrs"), buf)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
 	d := json.NewDecoder(resp.Body)
 	if resp.StatusCode != http.StatusCreated {
-		e := &types.ErrorResponse{}
-		if err := d.Decode(e); err != nil {
-			return nil, err
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	n := &types.Network{}
+	if err := d.Decode(n); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (c *Client) DeleteNetwork(ctx context.Context, id string) (*types.Network, error) {
+	url := c.baseUrl("api/v1/networks/" + id)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
 		}
-		return nil, fmt.Errorf("request failed %d: %+v", resp.StatusCode, e)
+	}()
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return nil, decodeError(resp.StatusCode, d)
 	}
 
-	n := &types.NetworkResponse{}
+	n := &types.Network{}
 	if err := d.Decode(n); err != nil {
 		return nil, err
 	}
 
 	return n, nil
 }
+
+func (c *Client) NetworkStatus(ctx context.Context, id string) (*types.NetworkStatusResponse, error) {
+	url := c.baseUrl("api/v1/networks/" + id + "/status")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	sr := &types.NetworkStatusResponse{}
+	if err := d.Decode(sr); err != nil {
+		return nil, err
+	}
+
+	return sr, nil
+}
+
+func (c *Client) ListSubnets(ctx context.Context, id string) (*types.SubnetResponse, error) {
+	url := c.baseUrl("api/v1/networks/" + id + "/subnets")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	sr := &types.SubnetResponse{}
+	if err := d.Decode(sr); err != nil {
+		return nil, err
+	}
+
+	return sr, nil
+}
+
+func (c *Client) DetailSubnet(ctx context.Context, id, name string) (*types.Subnet, error) {
+	url := c.baseUrl("api/v1/networks/" + id + "/subnets/" + name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	s := &types.Subnet{}
+	if err := d.Decode(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// SendProviderEvent reports the outcome of a network event on behalf of a
+// provider, authenticated with the API token registered for that provider.
+func (c *Client) SendProviderEvent(ctx context.Context, id, token string, pe *types.ProviderEvent) (*types.Network, error) {
+	buf := &bytes.Buffer{}
+	e := json.NewEncoder(buf)
+	if err := e.Encode(pe); err != nil {
+		return nil, err
+	}
+
+	url := c.baseUrl("api/v1/networks/" + id + "/provider-events")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, buf)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	n := &types.Network{}
+	if err := d.Decode(n); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (c *Client) CheckNetwork(ctx context.Context, id string) (*types.Network, error) {
+	url := c.baseUrl("api/v1/networks/" + id + "/check")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	n := &types.Network{}
+	if err := d.Decode(n); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (c *Client) CheckNetworks(ctx context.Context) (*types.NetworkCheckResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseUrl("api/v1/networks/check"), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	cr := &types.NetworkCheckResponse{}
+	if err := d.Decode(cr); err != nil {
+		return nil, err
+	}
+
+	return cr, nil
+}
+
+func (c *Client) ImportNetworks(ctx context.Context, r *types.NetworkImportRequest) (*types.NetworkImportResponse, error) {
+	buf := &bytes.Buffer{}
+	e := json.NewEncoder(buf)
+	if err := e.Encode(r); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseUrl("api/v1/networks/import"), buf)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	ir := &types.NetworkImportResponse{}
+	if err := d.Decode(ir); err != nil {
+		return nil, err
+	}
+
+	return ir, nil
+}
+
+func (c *Client) ValidateNetwork(ctx context.Context, r *types.NetworkValidateRequest) (*types.NetworkValidateResponse, error) {
+	buf := &bytes.Buffer{}
+	e := json.NewEncoder(buf)
+	if err := e.Encode(r); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseUrl("api/v1/networks/validate"), buf)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	vr := &types.NetworkValidateResponse{}
+	if err := d.Decode(vr); err != nil {
+		return nil, err
+	}
+
+	return vr, nil
+}
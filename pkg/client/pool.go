@@ -4,15 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 
 	"github.com/olxbr/network-api/pkg/types"
 )
 
-func (c *Client) ListPools(ctx context.Context) (*types.PoolListResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseUrl("api/v1/pools"), nil)
+// ListPools reads a page of pools, or all of them when o carries no limit.
+func (c *Client) ListPools(ctx context.Context, o *types.ListOptions) (*types.PoolListResponse, error) {
+	v := url.Values{}
+	o.Values(v)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.listUrl("api/v1/pools", v), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -27,6 +30,9 @@ func (c *Client) ListPools(ctx context.Context) (*types.PoolListResponse, error)
 		}
 	}()
 	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
 
 	p := &types.PoolListResponse{}
 	if err := d.Decode(p); err != nil {
@@ -59,11 +65,134 @@ func (c *Client) CreatePool(ctx context.Context, r *types.PoolRequest) (*types.P
 
 	d := json.NewDecoder(resp.Body)
 	if resp.StatusCode != http.StatusCreated {
-		e := &types.ErrorResponse{}
-		if err := d.Decode(e); err != nil {
-			return nil, err
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	p := &types.Pool{}
+	if err := d.Decode(p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// DetailPool returns a pool along with the consumption of its quotas.
+func (c *Client) DetailPool(ctx context.Context, id string) (*types.Pool, error) {
+	url := c.baseUrl("api/v1/pools/" + id)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	p := &types.Pool{}
+	if err := d.Decode(p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (c *Client) PoolUsage(ctx context.Context, id string) (*types.PoolUsage, error) {
+	url := c.baseUrl("api/v1/pools/" + id + "/usage")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	u := &types.PoolUsage{}
+	if err := d.Decode(u); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (c *Client) UpdatePoolExclusions(ctx context.Context, id string, r *types.ExclusionsRequest) (*types.Pool, error) {
+	buf := &bytes.Buffer{}
+	e := json.NewEncoder(buf)
+	if err := e.Encode(r); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.baseUrl("api/v1/pools/"+id+"/exclusions"), buf)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	p := &types.Pool{}
+	if err := d.Decode(p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (c *Client) UpdatePoolLabels(ctx context.Context, id string, r *types.PoolLabelsRequest) (*types.Pool, error) {
+	buf := &bytes.Buffer{}
+	e := json.NewEncoder(buf)
+	if err := e.Encode(r); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.baseUrl("api/v1/pools/"+id+"/labels"), buf)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
 		}
-		return nil, fmt.Errorf("request failed %d: %+v", resp.StatusCode, e)
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
 	}
 
 	p := &types.Pool{}
@@ -73,3 +202,144 @@ func (c *Client) CreatePool(ctx context.Context, r *types.PoolRequest) (*types.P
 
 	return p, nil
 }
+
+func (c *Client) UpdatePoolPolicy(ctx context.Context, id string, r *types.PoolPolicy) (*types.Pool, error) {
+	buf := &bytes.Buffer{}
+	e := json.NewEncoder(buf)
+	if err := e.Encode(r); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.baseUrl("api/v1/pools/"+id+"/policy"), buf)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	p := &types.Pool{}
+	if err := d.Decode(p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// DeletePool removes a pool. A pool still holding networks is only removed
+// with cascade "reassign", moving its networks to the target pool or, when
+// empty, to its parent.
+func (c *Client) DeletePool(ctx context.Context, id, cascade, target string) (*types.Pool, error) {
+	v := url.Values{}
+	if cascade != "" {
+		v.Set("cascade", cascade)
+	}
+	if target != "" {
+		v.Set("target", target)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.listUrl("api/v1/pools/"+id, v), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	p := &types.Pool{}
+	if err := d.Decode(p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (c *Client) UpdatePool(ctx context.Context, id string, r *types.PoolUpdateRequest) (*types.Pool, error) {
+	buf := &bytes.Buffer{}
+	e := json.NewEncoder(buf)
+	if err := e.Encode(r); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.baseUrl("api/v1/pools/"+id), buf)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	p := &types.Pool{}
+	if err := d.Decode(p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// PoolTree returns the pool trees, starting from root when given.
+func (c *Client) PoolTree(ctx context.Context, root string) (*types.PoolTreeResponse, error) {
+	v := url.Values{}
+	if root != "" {
+		v.Set("root", root)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.listUrl("api/v1/pools/tree", v), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	tr := &types.PoolTreeResponse{}
+	if err := d.Decode(tr); err != nil {
+		return nil, err
+	}
+
+	return tr, nil
+}
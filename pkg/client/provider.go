@@ -4,15 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 
 	"github.com/olxbr/network-api/pkg/types"
 )
 
-func (c *Client) ListProviders(ctx context.Context) (*types.ProviderListResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseUrl("api/v1/providers"), nil)
+// ListProviders reads a page of providers, or all of them when o carries no limit.
+func (c *Client) ListProviders(ctx context.Context, o *types.ListOptions) (*types.ProviderListResponse, error) {
+	v := url.Values{}
+	o.Values(v)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.listUrl("api/v1/providers", v), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -27,6 +30,9 @@ func (c *Client) ListProviders(ctx context.Context) (*types.ProviderListResponse
 		}
 	}()
 	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
 
 	p := &types.ProviderListResponse{}
 	if err := d.Decode(p); err != nil {
@@ -59,11 +65,7 @@ func (c *Client) CreateProvider(ctx context.Context, r *types.ProviderRequest) (
 
 	d := json.NewDecoder(resp.Body)
 	if resp.StatusCode != http.StatusCreated {
-		e := &types.ErrorResponse{}
-		if err := d.Decode(e); err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("request failed %d: %+v", resp.StatusCode, e)
+		return nil, decodeError(resp.StatusCode, d)
 	}
 
 	p := &types.Provider{}
@@ -99,11 +101,7 @@ func (c *Client) UpdateProvider(ctx context.Context, name string, r *types.Provi
 
 	d := json.NewDecoder(resp.Body)
 	if resp.StatusCode != http.StatusOK {
-		e := &types.ErrorResponse{}
-		if err := d.Decode(e); err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("request failed %d: %+v", resp.StatusCode, e)
+		return nil, decodeError(resp.StatusCode, d)
 	}
 
 	p := &types.Provider{}
@@ -133,11 +131,7 @@ func (c *Client) DeleteProvider(ctx context.Context, name string) error {
 
 	d := json.NewDecoder(resp.Body)
 	if resp.StatusCode != http.StatusOK {
-		e := &types.ErrorResponse{}
-		if err := d.Decode(e); err != nil {
-			return err
-		}
-		return fmt.Errorf("request failed %d: %+v", resp.StatusCode, e)
+		return decodeError(resp.StatusCode, d)
 	}
 
 	return nil
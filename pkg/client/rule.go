@@ -0,0 +1,174 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// ListRules reads a page of rules, or all of them when o carries no limit.
+func (c *Client) ListRules(ctx context.Context, o *types.ListOptions) (*types.RuleListResponse, error) {
+	v := url.Values{}
+	o.Values(v)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.listUrl("api/v1/rules", v), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	rule := &types.RuleListResponse{}
+	if err := d.Decode(rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (c *Client) CreateRule(ctx context.Context, r *types.RuleRequest) (*types.Rule, error) {
+	buf := &bytes.Buffer{}
+	e := json.NewEncoder(buf)
+	if err := e.Encode(r); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseUrl("api/v1/rules"), buf)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusCreated {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	rule := &types.Rule{}
+	if err := d.Decode(rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// EvaluateRules evaluates the rules for a network request without creating
+// the network.
+func (c *Client) EvaluateRules(ctx context.Context, r *types.RuleEvaluateRequest) (*types.RuleEvaluateResponse, error) {
+	buf := &bytes.Buffer{}
+	e := json.NewEncoder(buf)
+	if err := e.Encode(r); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseUrl("api/v1/rules/evaluate"), buf)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	er := &types.RuleEvaluateResponse{}
+	if err := d.Decode(er); err != nil {
+		return nil, err
+	}
+
+	return er, nil
+}
+
+func (c *Client) UpdateRule(ctx context.Context, name string, r *types.RuleRequest) (*types.Rule, error) {
+	buf := &bytes.Buffer{}
+	e := json.NewEncoder(buf)
+	if err := e.Encode(r); err != nil {
+		return nil, err
+	}
+
+	url := c.baseUrl("api/v1/rules/" + name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, d)
+	}
+
+	rule := &types.Rule{}
+	if err := d.Decode(rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (c *Client) DeleteRule(ctx context.Context, name string) error {
+	url := c.baseUrl("api/v1/rules/" + name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
+
+	d := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return decodeError(resp.StatusCode, d)
+	}
+
+	return nil
+}
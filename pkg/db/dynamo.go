@@ -9,19 +9,39 @@ import (
 
 type Database interface {
 	ScanNetworks(ctx context.Context) ([]*types.Network, error)
+	ListNetworks(ctx context.Context, f *types.NetworkFilter, o *types.ListOptions) ([]*types.Network, string, error)
 	GetNetwork(ctx context.Context, id string) (*types.Network, error)
 	PutNetwork(ctx context.Context, n *types.Network) error
 	DeleteNetwork(ctx context.Context, id string) error
 
 	ScanPools(ctx context.Context) ([]*types.Pool, error)
+	ListPools(ctx context.Context, o *types.ListOptions) ([]*types.Pool, string, error)
 	GetPool(ctx context.Context, id string) (*types.Pool, error)
 	PutPool(ctx context.Context, p *types.Pool) error
-	DeletePool(ctx context.Context, id string) error
+	UpdatePool(ctx context.Context, p *types.Pool) error
+	DeletePool(ctx context.Context, p *types.Pool, reassigned []*types.Reservation) error
 
 	ScanProviders(ctx context.Context) ([]*types.Provider, error)
+	ListProviders(ctx context.Context, o *types.ListOptions) ([]*types.Provider, string, error)
 	GetProvider(ctx context.Context, name string) (*types.Provider, error)
 	PutProvider(ctx context.Context, p *types.Provider) error
 	DeleteProvider(ctx context.Context, name string) error
+
+	ListLayouts(ctx context.Context, o *types.ListOptions) ([]*types.Layout, string, error)
+	GetLayout(ctx context.Context, name string) (*types.Layout, error)
+	PutLayout(ctx context.Context, l *types.Layout) error
+	DeleteLayout(ctx context.Context, name string) error
+
+	ListRules(ctx context.Context, o *types.ListOptions) ([]*types.Rule, string, error)
+	GetRule(ctx context.Context, name string) (*types.Rule, error)
+	PutRule(ctx context.Context, r *types.Rule) error
+	DeleteRule(ctx context.Context, name string) error
+
+	ScanReservations(ctx context.Context) ([]*types.Reservation, error)
+	ReserveNetwork(ctx context.Context, r *types.Reservation, p *types.Pool) error
+	DomainVersion(ctx context.Context, domain string) (int, error)
+	UpdateReservation(ctx context.Context, r *types.Reservation) error
+	ReleaseNetwork(ctx context.Context, key string) error
 }
 
 type DynamoClient interface {
@@ -30,6 +50,7 @@ type DynamoClient interface {
 	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
 	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
 	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
 }
 
 type database struct {
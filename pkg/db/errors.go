@@ -0,0 +1,26 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrConflict = errors.New("conflicting write")
+	ErrNotFound = errors.New("not found")
+)
+
+// NotFoundError is returned when an item is missing from its table, it
+// matches ErrNotFound.
+type NotFoundError struct {
+	Kind string
+	ID   string
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("%s %s not found", e.Kind, e.ID)
+}
+
+func (e NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
@@ -15,6 +15,20 @@ type Database struct {
 	mock.Mock
 }
 
+// DeleteLayout provides a mock function with given fields: ctx, name
+func (_m *Database) DeleteLayout(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteNetwork provides a mock function with given fields: ctx, id
 func (_m *Database) DeleteNetwork(ctx context.Context, id string) error {
 	ret := _m.Called(ctx, id)
@@ -29,13 +43,13 @@ func (_m *Database) DeleteNetwork(ctx context.Context, id string) error {
 	return r0
 }
 
-// DeletePool provides a mock function with given fields: ctx, id
-func (_m *Database) DeletePool(ctx context.Context, id string) error {
-	ret := _m.Called(ctx, id)
+// DeletePool provides a mock function with given fields: ctx, p, reassigned
+func (_m *Database) DeletePool(ctx context.Context, p *types.Pool, reassigned []*types.Reservation) error {
+	ret := _m.Called(ctx, p, reassigned)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Pool, []*types.Reservation) error); ok {
+		r0 = rf(ctx, p, reassigned)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -57,6 +71,64 @@ func (_m *Database) DeleteProvider(ctx context.Context, name string) error {
 	return r0
 }
 
+// DeleteRule provides a mock function with given fields: ctx, name
+func (_m *Database) DeleteRule(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DomainVersion provides a mock function with given fields: ctx, domain
+func (_m *Database) DomainVersion(ctx context.Context, domain string) (int, error) {
+	ret := _m.Called(ctx, domain)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, domain)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, domain)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLayout provides a mock function with given fields: ctx, name
+func (_m *Database) GetLayout(ctx context.Context, name string) (*types.Layout, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 *types.Layout
+	if rf, ok := ret.Get(0).(func(context.Context, string) *types.Layout); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Layout)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetNetwork provides a mock function with given fields: ctx, id
 func (_m *Database) GetNetwork(ctx context.Context, id string) (*types.Network, error) {
 	ret := _m.Called(ctx, id)
@@ -126,6 +198,193 @@ func (_m *Database) GetProvider(ctx context.Context, name string) (*types.Provid
 	return r0, r1
 }
 
+// GetRule provides a mock function with given fields: ctx, name
+func (_m *Database) GetRule(ctx context.Context, name string) (*types.Rule, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 *types.Rule
+	if rf, ok := ret.Get(0).(func(context.Context, string) *types.Rule); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Rule)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListLayouts provides a mock function with given fields: ctx, o
+func (_m *Database) ListLayouts(ctx context.Context, o *types.ListOptions) ([]*types.Layout, string, error) {
+	ret := _m.Called(ctx, o)
+
+	var r0 []*types.Layout
+	if rf, ok := ret.Get(0).(func(context.Context, *types.ListOptions) []*types.Layout); ok {
+		r0 = rf(ctx, o)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Layout)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(context.Context, *types.ListOptions) string); ok {
+		r1 = rf(ctx, o)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *types.ListOptions) error); ok {
+		r2 = rf(ctx, o)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListNetworks provides a mock function with given fields: ctx, f, o
+func (_m *Database) ListNetworks(ctx context.Context, f *types.NetworkFilter, o *types.ListOptions) ([]*types.Network, string, error) {
+	ret := _m.Called(ctx, f, o)
+
+	var r0 []*types.Network
+	if rf, ok := ret.Get(0).(func(context.Context, *types.NetworkFilter, *types.ListOptions) []*types.Network); ok {
+		r0 = rf(ctx, f, o)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Network)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(context.Context, *types.NetworkFilter, *types.ListOptions) string); ok {
+		r1 = rf(ctx, f, o)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *types.NetworkFilter, *types.ListOptions) error); ok {
+		r2 = rf(ctx, f, o)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListPools provides a mock function with given fields: ctx, o
+func (_m *Database) ListPools(ctx context.Context, o *types.ListOptions) ([]*types.Pool, string, error) {
+	ret := _m.Called(ctx, o)
+
+	var r0 []*types.Pool
+	if rf, ok := ret.Get(0).(func(context.Context, *types.ListOptions) []*types.Pool); ok {
+		r0 = rf(ctx, o)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Pool)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(context.Context, *types.ListOptions) string); ok {
+		r1 = rf(ctx, o)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *types.ListOptions) error); ok {
+		r2 = rf(ctx, o)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListProviders provides a mock function with given fields: ctx, o
+func (_m *Database) ListProviders(ctx context.Context, o *types.ListOptions) ([]*types.Provider, string, error) {
+	ret := _m.Called(ctx, o)
+
+	var r0 []*types.Provider
+	if rf, ok := ret.Get(0).(func(context.Context, *types.ListOptions) []*types.Provider); ok {
+		r0 = rf(ctx, o)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Provider)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(context.Context, *types.ListOptions) string); ok {
+		r1 = rf(ctx, o)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *types.ListOptions) error); ok {
+		r2 = rf(ctx, o)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListRules provides a mock function with given fields: ctx, o
+func (_m *Database) ListRules(ctx context.Context, o *types.ListOptions) ([]*types.Rule, string, error) {
+	ret := _m.Called(ctx, o)
+
+	var r0 []*types.Rule
+	if rf, ok := ret.Get(0).(func(context.Context, *types.ListOptions) []*types.Rule); ok {
+		r0 = rf(ctx, o)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Rule)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(context.Context, *types.ListOptions) string); ok {
+		r1 = rf(ctx, o)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *types.ListOptions) error); ok {
+		r2 = rf(ctx, o)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// PutLayout provides a mock function with given fields: ctx, l
+func (_m *Database) PutLayout(ctx context.Context, l *types.Layout) error {
+	ret := _m.Called(ctx, l)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Layout) error); ok {
+		r0 = rf(ctx, l)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // PutNetwork provides a mock function with given fields: ctx, n
 func (_m *Database) PutNetwork(ctx context.Context, n *types.Network) error {
 	ret := _m.Called(ctx, n)
@@ -168,6 +427,48 @@ func (_m *Database) PutProvider(ctx context.Context, p *types.Provider) error {
 	return r0
 }
 
+// PutRule provides a mock function with given fields: ctx, r
+func (_m *Database) PutRule(ctx context.Context, r *types.Rule) error {
+	ret := _m.Called(ctx, r)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Rule) error); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReleaseNetwork provides a mock function with given fields: ctx, key
+func (_m *Database) ReleaseNetwork(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReserveNetwork provides a mock function with given fields: ctx, r, p
+func (_m *Database) ReserveNetwork(ctx context.Context, r *types.Reservation, p *types.Pool) error {
+	ret := _m.Called(ctx, r, p)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Reservation, *types.Pool) error); ok {
+		r0 = rf(ctx, r, p)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ScanNetworks provides a mock function with given fields: ctx
 func (_m *Database) ScanNetworks(ctx context.Context) ([]*types.Network, error) {
 	ret := _m.Called(ctx)
@@ -237,6 +538,57 @@ func (_m *Database) ScanProviders(ctx context.Context) ([]*types.Provider, error
 	return r0, r1
 }
 
+// ScanReservations provides a mock function with given fields: ctx
+func (_m *Database) ScanReservations(ctx context.Context) ([]*types.Reservation, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []*types.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context) []*types.Reservation); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdatePool provides a mock function with given fields: ctx, p
+func (_m *Database) UpdatePool(ctx context.Context, p *types.Pool) error {
+	ret := _m.Called(ctx, p)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Pool) error); ok {
+		r0 = rf(ctx, p)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateReservation provides a mock function with given fields: ctx, r
+func (_m *Database) UpdateReservation(ctx context.Context, r *types.Reservation) error {
+	ret := _m.Called(ctx, r)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Reservation) error); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type NewDatabaseT interface {
 	mock.TestingT
 	Cleanup(func())
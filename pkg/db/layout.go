@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynatypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// ListLayouts reads one page of layouts, or all of them without a limit.
+func (d *database) ListLayouts(ctx context.Context, o *types.ListOptions) ([]*types.Layout, string, error) {
+	if o == nil {
+		o = &types.ListOptions{}
+	}
+	var limit *int32
+	if o.Limit > 0 {
+		limit = aws.Int32(int32(o.Limit))
+	}
+
+	items, next, err := readPages(limit != nil, o.NextToken, func(start item) ([]item, item, error) {
+		so, err := d.Client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:         aws.String("napi_layouts"),
+			ExclusiveStartKey: start,
+			Limit:             limit,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return so.Items, so.LastEvaluatedKey, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	layouts := []*types.Layout{}
+	err = attributevalue.UnmarshalListOfMaps(items, &layouts)
+	if err != nil {
+		return nil, "", err
+	}
+	return layouts, next, nil
+}
+
+func (d *database) GetLayout(ctx context.Context, name string) (*types.Layout, error) {
+	so, err := d.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("napi_layouts"),
+		Key: map[string]dynatypes.AttributeValue{
+			"name": &dynatypes.AttributeValueMemberS{Value: name},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(so.Item) == 0 {
+		return nil, NotFoundError{Kind: "layout", ID: name}
+	}
+
+	l := &types.Layout{}
+	err = attributevalue.UnmarshalMap(so.Item, l)
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (d *database) PutLayout(ctx context.Context, l *types.Layout) error {
+	item, err := attributevalue.MarshalMap(l)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String("napi_layouts"),
+		Item:      item,
+	})
+	return err
+}
+
+func (d *database) DeleteLayout(ctx context.Context, name string) error {
+	_, err := d.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String("napi_layouts"),
+		Key: map[string]dynatypes.AttributeValue{
+			"name": &dynatypes.AttributeValueMemberS{Value: name},
+		},
+	})
+	return err
+}
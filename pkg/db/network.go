@@ -2,8 +2,8 @@ package db
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -35,6 +35,109 @@ func (d *database) ScanNetworks(ctx context.Context) ([]*types.Network, error) {
 	return networks, nil
 }
 
+// networksByProvider indexes the networks by provider and sort key, letting
+// lists filtered by provider query instead of scanning the table.
+const networksByProvider = "provider-sk-index"
+
+// ListNetworks reads the networks matching f. With a provider it queries the
+// provider index, narrowed by the longest sort key prefix the filter gives,
+// the remaining fields are matched by a filter expression.
+func (d *database) ListNetworks(ctx context.Context, f *types.NetworkFilter, o *types.ListOptions) ([]*types.Network, string, error) {
+	if f == nil {
+		f = &types.NetworkFilter{}
+	}
+	if o == nil {
+		o = &types.ListOptions{}
+	}
+
+	names := map[string]string{}
+	values := item{}
+	conditions := []string{}
+	prefix := f.Provider + "#"
+	inPrefix := f.Provider != ""
+	for _, field := range []struct{ name, value string }{
+		{"region", f.Region},
+		{"account", f.Account},
+		{"environment", f.Environment},
+	} {
+		if field.value == "" {
+			inPrefix = false
+			continue
+		}
+		if inPrefix {
+			prefix += field.value + "#"
+			continue
+		}
+		names["#"+field.name] = field.name
+		values[":"+field.name] = &dynatypes.AttributeValueMemberS{Value: field.value}
+		conditions = append(conditions, fmt.Sprintf("#%s = :%s", field.name, field.name))
+	}
+
+	var filter *string
+	if len(conditions) > 0 {
+		filter = aws.String(strings.Join(conditions, " AND "))
+	}
+	var limit *int32
+	if o.Limit > 0 {
+		limit = aws.Int32(int32(o.Limit))
+	}
+
+	var fetch fetchPage
+	if f.Provider != "" {
+		names["#provider"] = "provider"
+		values[":provider"] = &dynatypes.AttributeValueMemberS{Value: f.Provider}
+		values[":prefix"] = &dynatypes.AttributeValueMemberS{Value: prefix}
+		fetch = func(start item) ([]item, item, error) {
+			qo, err := d.Client.Query(ctx, &dynamodb.QueryInput{
+				TableName:                 aws.String("napi_networks"),
+				IndexName:                 aws.String(networksByProvider),
+				KeyConditionExpression:    aws.String("#provider = :provider AND begins_with(sk, :prefix)"),
+				FilterExpression:          filter,
+				ExpressionAttributeNames:  names,
+				ExpressionAttributeValues: values,
+				ExclusiveStartKey:         start,
+				Limit:                     limit,
+				ScanIndexForward:          aws.Bool(o.Order != types.SortDesc),
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			return qo.Items, qo.LastEvaluatedKey, nil
+		}
+	} else {
+		// empty expression maps are rejected
+		if len(conditions) == 0 {
+			names, values = nil, nil
+		}
+		fetch = func(start item) ([]item, item, error) {
+			so, err := d.Client.Scan(ctx, &dynamodb.ScanInput{
+				TableName:                 aws.String("napi_networks"),
+				FilterExpression:          filter,
+				ExpressionAttributeNames:  names,
+				ExpressionAttributeValues: values,
+				ExclusiveStartKey:         start,
+				Limit:                     limit,
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			return so.Items, so.LastEvaluatedKey, nil
+		}
+	}
+
+	items, next, err := readPages(limit != nil, o.NextToken, fetch)
+	if err != nil {
+		return nil, "", err
+	}
+
+	networks := []*types.Network{}
+	err = attributevalue.UnmarshalListOfMaps(items, &networks)
+	if err != nil {
+		return nil, "", err
+	}
+	return networks, next, nil
+}
+
 func (d *database) GetNetwork(ctx context.Context, id string) (*types.Network, error) {
 	qo, err := d.Client.Query(ctx, &dynamodb.QueryInput{
 		TableName:              aws.String("napi_networks"),
@@ -49,7 +152,7 @@ func (d *database) GetNetwork(ctx context.Context, id string) (*types.Network, e
 	}
 
 	if qo.Count <= 0 {
-		return nil, errors.New("network not found")
+		return nil, NotFoundError{Kind: "network", ID: id}
 	}
 
 	network := &types.Network{}
@@ -78,12 +181,36 @@ func (d *database) PutNetwork(ctx context.Context, n *types.Network) error {
 	return err
 }
 
+// DeleteNetwork removes every item stored under the network id, looking up
+// the sort keys first as the table is keyed by both.
 func (d *database) DeleteNetwork(ctx context.Context, id string) error {
-	_, err := d.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
-		TableName: aws.String("napi_networks"),
-		Key: map[string]dynatypes.AttributeValue{
-			"id": &dynatypes.AttributeValueMemberS{Value: id},
+	qo, err := d.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String("napi_networks"),
+		KeyConditionExpression: aws.String("id = :hashKey"),
+		ProjectionExpression:   aws.String("sk"),
+		ExpressionAttributeValues: map[string]dynatypes.AttributeValue{
+			":hashKey": &dynatypes.AttributeValueMemberS{Value: id},
 		},
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	if qo.Count <= 0 {
+		return NotFoundError{Kind: "network", ID: id}
+	}
+
+	for _, item := range qo.Items {
+		_, err = d.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String("napi_networks"),
+			Key: map[string]dynatypes.AttributeValue{
+				"id": &dynatypes.AttributeValueMemberS{Value: id},
+				"sk": item["sk"],
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
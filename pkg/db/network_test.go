@@ -2,12 +2,14 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynatypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/google/uuid"
 
 	"github.com/stretchr/testify/assert"
@@ -34,3 +36,143 @@ func TestCanCreateNetwork(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func TestGetNetworkNotFound(t *testing.T) {
+	cli := &fake.DynamoClient{}
+	cli.On("Query", mock.Anything, mock.Anything).Return(&dynamodb.QueryOutput{Count: 0}, nil)
+
+	d := New(cli)
+	_, err := d.GetNetwork(context.TODO(), "f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0")
+
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.EqualError(t, err, "network f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0 not found")
+}
+
+func TestGetProviderNotFound(t *testing.T) {
+	cli := &fake.DynamoClient{}
+	cli.On("GetItem", mock.Anything, mock.Anything).Return(&dynamodb.GetItemOutput{}, nil)
+
+	d := New(cli)
+	_, err := d.GetProvider(context.TODO(), "aws")
+
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.EqualError(t, err, "provider aws not found")
+}
+
+func TestGetLayoutNotFound(t *testing.T) {
+	cli := &fake.DynamoClient{}
+	cli.On("GetItem", mock.Anything, mock.MatchedBy(func(params *dynamodb.GetItemInput) bool {
+		return aws.ToString(params.TableName) == "napi_layouts"
+	})).Return(&dynamodb.GetItemOutput{}, nil)
+
+	d := New(cli)
+	_, err := d.GetLayout(context.TODO(), "default")
+
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.EqualError(t, err, "layout default not found")
+}
+
+func TestCanDeleteNetwork(t *testing.T) {
+	cli := &fake.DynamoClient{}
+
+	sk := &dynatypes.AttributeValueMemberS{Value: "aws#us-east-1#1234#prod#10.0.0.0/24"}
+	cli.On("Query", mock.Anything, mock.Anything).Return(&dynamodb.QueryOutput{
+		Count: 1,
+		Items: []map[string]dynatypes.AttributeValue{{"sk": sk}},
+	}, nil)
+	cli.On("DeleteItem", mock.Anything, mock.MatchedBy(func(params *dynamodb.DeleteItemInput) bool {
+		return aws.ToString(params.TableName) == "napi_networks" &&
+			params.Key["sk"] == sk
+	})).Return(&dynamodb.DeleteItemOutput{}, nil)
+
+	d := New(cli)
+	err := d.DeleteNetwork(context.TODO(), "f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0")
+
+	assert.NoError(t, err)
+	cli.AssertExpectations(t)
+}
+
+func TestListNetworks(t *testing.T) {
+	item := func(id, sk string) map[string]dynatypes.AttributeValue {
+		return map[string]dynatypes.AttributeValue{
+			"id": &dynatypes.AttributeValueMemberS{Value: id},
+			"sk": &dynatypes.AttributeValueMemberS{Value: sk},
+		}
+	}
+	last := map[string]dynatypes.AttributeValue{
+		"id":       &dynatypes.AttributeValueMemberS{Value: "f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0"},
+		"sk":       &dynatypes.AttributeValueMemberS{Value: "aws#us-east-1#1234#prod#10.0.0.0/24"},
+		"provider": &dynatypes.AttributeValueMemberS{Value: "aws"},
+	}
+
+	t.Run("query by provider with a page limit", func(t *testing.T) {
+		cli := &fake.DynamoClient{}
+		cli.On("Query", mock.Anything, mock.MatchedBy(func(params *dynamodb.QueryInput) bool {
+			return aws.ToString(params.IndexName) == networksByProvider &&
+				aws.ToInt32(params.Limit) == 1 &&
+				params.ExclusiveStartKey == nil &&
+				aws.ToString(params.FilterExpression) == "#environment = :environment" &&
+				params.ExpressionAttributeValues[":prefix"].(*dynatypes.AttributeValueMemberS).Value == "aws#us-east-1#"
+		})).Return(&dynamodb.QueryOutput{
+			Items:            []map[string]dynatypes.AttributeValue{item("f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0", "aws#us-east-1#1234#prod#10.0.0.0/24")},
+			LastEvaluatedKey: last,
+		}, nil).Once()
+
+		d := New(cli)
+		ns, next, err := d.ListNetworks(context.TODO(), &types.NetworkFilter{
+			Provider:    "aws",
+			Region:      "us-east-1",
+			Environment: "prod",
+		}, &types.ListOptions{Limit: 1})
+
+		assert.NoError(t, err)
+		assert.Len(t, ns, 1)
+		assert.NotEmpty(t, next)
+		cli.AssertExpectations(t)
+
+		cli.On("Query", mock.Anything, mock.MatchedBy(func(params *dynamodb.QueryInput) bool {
+			return assert.ObjectsAreEqual(last, params.ExclusiveStartKey)
+		})).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+		ns, next, err = d.ListNetworks(context.TODO(), &types.NetworkFilter{
+			Provider:    "aws",
+			Region:      "us-east-1",
+			Environment: "prod",
+		}, &types.ListOptions{Limit: 1, NextToken: next})
+
+		assert.NoError(t, err)
+		assert.Empty(t, ns)
+		assert.Empty(t, next)
+		cli.AssertExpectations(t)
+	})
+
+	t.Run("scan every page without a limit", func(t *testing.T) {
+		cli := &fake.DynamoClient{}
+		cli.On("Scan", mock.Anything, mock.MatchedBy(func(params *dynamodb.ScanInput) bool {
+			return params.ExclusiveStartKey == nil && params.ExpressionAttributeNames == nil
+		})).Return(&dynamodb.ScanOutput{
+			Items:            []map[string]dynatypes.AttributeValue{item("f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0", "a")},
+			LastEvaluatedKey: last,
+		}, nil).Once()
+		cli.On("Scan", mock.Anything, mock.MatchedBy(func(params *dynamodb.ScanInput) bool {
+			return params.ExclusiveStartKey != nil
+		})).Return(&dynamodb.ScanOutput{
+			Items: []map[string]dynatypes.AttributeValue{item("f1f1f1f1-f1f1-f1f1-f1f1-f1f1f1f1f1f1", "b")},
+		}, nil).Once()
+
+		d := New(cli)
+		ns, next, err := d.ListNetworks(context.TODO(), nil, nil)
+
+		assert.NoError(t, err)
+		assert.Len(t, ns, 2)
+		assert.Empty(t, next)
+		cli.AssertExpectations(t)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		d := New(&fake.DynamoClient{})
+		_, _, err := d.ListNetworks(context.TODO(), nil, &types.ListOptions{Limit: 1, NextToken: "not a token"})
+
+		assert.True(t, errors.Is(err, ErrInvalidToken))
+	})
+}
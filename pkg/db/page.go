@@ -0,0 +1,84 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	dynatypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var ErrInvalidToken = errors.New("invalid page token")
+
+type item = map[string]dynatypes.AttributeValue
+
+// fetchPage reads the items after start, returning the key to resume from
+// when more are left.
+type fetchPage func(start item) ([]item, item, error)
+
+// readPages reads a single page when limited, handing back a token for the
+// next one, or every page otherwise.
+func readPages(limited bool, token string, fetch fetchPage) ([]item, string, error) {
+	start, err := decodeToken(token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	items := []item{}
+	for {
+		page, last, err := fetch(start)
+		if err != nil {
+			return nil, "", err
+		}
+		items = append(items, page...)
+
+		if len(last) == 0 {
+			return items, "", nil
+		}
+		if limited {
+			next, err := encodeToken(last)
+			return items, next, err
+		}
+		start = last
+	}
+}
+
+// encodeToken wraps a LastEvaluatedKey into an opaque token, the keys of
+// every table are strings.
+func encodeToken(key item) (string, error) {
+	values := map[string]string{}
+	for k, v := range key {
+		s, ok := v.(*dynatypes.AttributeValueMemberS)
+		if !ok {
+			return "", errors.New("unsupported key attribute " + k)
+		}
+		values[k] = s.Value
+	}
+
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeToken(token string) (item, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	values := map[string]string{}
+	if err := json.Unmarshal(b, &values); err != nil || len(values) == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	key := item{}
+	for k, v := range values {
+		key[k] = &dynatypes.AttributeValueMemberS{Value: v}
+	}
+	return key, nil
+}
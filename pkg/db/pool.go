@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -35,6 +36,39 @@ func (d *database) ScanPools(ctx context.Context) ([]*types.Pool, error) {
 	return pools, nil
 }
 
+// ListPools reads one page of pools, or all of them without a limit.
+func (d *database) ListPools(ctx context.Context, o *types.ListOptions) ([]*types.Pool, string, error) {
+	if o == nil {
+		o = &types.ListOptions{}
+	}
+	var limit *int32
+	if o.Limit > 0 {
+		limit = aws.Int32(int32(o.Limit))
+	}
+
+	items, next, err := readPages(limit != nil, o.NextToken, func(start item) ([]item, item, error) {
+		so, err := d.Client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:         aws.String("napi_pools"),
+			ExclusiveStartKey: start,
+			Limit:             limit,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return so.Items, so.LastEvaluatedKey, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	pools := []*types.Pool{}
+	err = attributevalue.UnmarshalListOfMaps(items, &pools)
+	if err != nil {
+		return nil, "", err
+	}
+	return pools, next, nil
+}
+
 func (d *database) GetPool(ctx context.Context, id string) (*types.Pool, error) {
 	qo, err := d.Client.Query(ctx, &dynamodb.QueryInput{
 		TableName:              aws.String("napi_pools"),
@@ -48,7 +82,7 @@ func (d *database) GetPool(ctx context.Context, id string) (*types.Pool, error)
 	}
 
 	if qo.Count <= 0 {
-		return nil, errors.New("pool not found")
+		return nil, NotFoundError{Kind: "pool", ID: id}
 	}
 
 	pool := &types.Pool{}
@@ -67,7 +101,7 @@ func (d *database) PutPool(ctx context.Context, p *types.Pool) error {
 	}
 
 	item["sk"] = &dynatypes.AttributeValueMemberS{
-		Value: fmt.Sprintf("%s#%s#%s", p.Region, p.SubnetIP, p.Name),
+		Value: poolSortKey(p),
 	}
 
 	_, err = d.Client.PutItem(ctx, &dynamodb.PutItemInput{
@@ -77,12 +111,135 @@ func (d *database) PutPool(ctx context.Context, p *types.Pool) error {
 	return err
 }
 
-func (d *database) DeletePool(ctx context.Context, id string) error {
-	_, err := d.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
-		TableName: aws.String("napi_pools"),
-		Key: map[string]dynatypes.AttributeValue{
-			"id": &dynatypes.AttributeValueMemberS{Value: id},
+// UpdatePool stores changes to an existing pool, failing with ErrConflict
+// when the pool was updated or allocated from since it was read. A pool whose
+// sort key changed is moved to the new key.
+func (d *database) UpdatePool(ctx context.Context, p *types.Pool) error {
+	current := p.Version
+	previousKey := p.SortKey
+	p.Version++
+	p.SortKey = poolSortKey(p)
+	item, err := attributevalue.MarshalMap(p)
+	if err != nil {
+		p.Version, p.SortKey = current, previousKey
+		return err
+	}
+
+	condition := aws.String("attribute_not_exists(version) OR version = :current")
+	values := map[string]dynatypes.AttributeValue{
+		":current": &dynatypes.AttributeValueMemberN{Value: strconv.Itoa(current)},
+	}
+
+	if previousKey == "" || previousKey == p.SortKey {
+		_, err = d.Client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:                 aws.String("napi_pools"),
+			Item:                      item,
+			ConditionExpression:       condition,
+			ExpressionAttributeValues: values,
+		})
+	} else {
+		_, err = d.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: []dynatypes.TransactWriteItem{
+				{
+					Delete: &dynatypes.Delete{
+						TableName: aws.String("napi_pools"),
+						Key: map[string]dynatypes.AttributeValue{
+							"id": &dynatypes.AttributeValueMemberS{Value: p.ID.String()},
+							"sk": &dynatypes.AttributeValueMemberS{Value: previousKey},
+						},
+						ConditionExpression:       condition,
+						ExpressionAttributeValues: values,
+					},
+				},
+				{
+					Put: &dynatypes.Put{
+						TableName:           aws.String("napi_pools"),
+						Item:                item,
+						ConditionExpression: aws.String("attribute_not_exists(id)"),
+					},
+				},
+			},
+		})
+	}
+	if err != nil {
+		p.Version, p.SortKey = current, previousKey
+		var ccf *dynatypes.ConditionalCheckFailedException
+		var tce *dynatypes.TransactionCanceledException
+		if errors.As(err, &ccf) || errors.As(err, &tce) {
+			return fmt.Errorf("%w: pool %s changed meanwhile", ErrConflict, p.ID.String())
+		}
+		return err
+	}
+	return nil
+}
+
+// maxTransactItems is the most items DynamoDB writes in one transaction.
+const maxTransactItems = 100
+
+// DeletePool removes p, stored under the key it was read with, storing the
+// reservations reassigned to other pools in the same transaction. It fails
+// with ErrConflict when p was allocated from or any of the reservations
+// changed meanwhile, leaving everything as it was.
+func (d *database) DeletePool(ctx context.Context, p *types.Pool, reassigned []*types.Reservation) error {
+	if len(reassigned) >= maxTransactItems {
+		return fmt.Errorf("pool %s has %d reservations to reassign, at most %d can be moved with it",
+			p.Name, len(reassigned), maxTransactItems-1)
+	}
+
+	items := []dynatypes.TransactWriteItem{
+		{
+			Delete: &dynatypes.Delete{
+				TableName: aws.String("napi_pools"),
+				Key: map[string]dynatypes.AttributeValue{
+					"id": &dynatypes.AttributeValueMemberS{Value: p.ID.String()},
+					"sk": &dynatypes.AttributeValueMemberS{Value: storedSortKey(p)},
+				},
+				ConditionExpression: aws.String("attribute_exists(id) AND (attribute_not_exists(version) OR version = :current)"),
+				ExpressionAttributeValues: map[string]dynatypes.AttributeValue{
+					":current": &dynatypes.AttributeValueMemberN{Value: strconv.Itoa(p.Version)},
+				},
+			},
 		},
+	}
+	for _, r := range reassigned {
+		item, err := reservationItem(r)
+		if err != nil {
+			return err
+		}
+		items = append(items, dynatypes.TransactWriteItem{
+			Put: &dynatypes.Put{
+				TableName:           aws.String("napi_reservations"),
+				Item:                item,
+				ConditionExpression: aws.String("networkID = :networkID"),
+				ExpressionAttributeValues: map[string]dynatypes.AttributeValue{
+					":networkID": &dynatypes.AttributeValueMemberS{Value: r.NetworkID},
+				},
+			},
+		})
+	}
+
+	_, err := d.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
 	})
-	return err
+	if err != nil {
+		var tce *dynatypes.TransactionCanceledException
+		if errors.As(err, &tce) {
+			return fmt.Errorf("%w: pool %s changed meanwhile", ErrConflict, p.ID.String())
+		}
+		return err
+	}
+	return nil
+}
+
+func poolSortKey(p *types.Pool) string {
+	return fmt.Sprintf("%s#%s#%s", p.Region, p.SubnetIP, p.Name)
+}
+
+// storedSortKey is the key p was read with, pools not read back from the
+// table yet are stored under the key of their current fields.
+func storedSortKey(p *types.Pool) string {
+	if p.SortKey != "" {
+		return p.SortKey
+	}
+	return poolSortKey(p)
 }
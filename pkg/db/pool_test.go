@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynatypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/olxbr/network-api/pkg/db/fake"
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+func TestCanUpdatePool(t *testing.T) {
+	cli := &fake.DynamoClient{}
+
+	cli.On("PutItem", mock.Anything, mock.MatchedBy(func(params *dynamodb.PutItemInput) bool {
+		current := params.ExpressionAttributeValues[":current"].(*dynatypes.AttributeValueMemberN)
+		version := params.Item["version"].(*dynatypes.AttributeValueMemberN)
+		return aws.ToString(params.TableName) == "napi_pools" &&
+			params.ConditionExpression != nil &&
+			current.Value == "3" && version.Value == "4"
+	})).Return(&dynamodb.PutItemOutput{}, nil)
+
+	p := &types.Pool{
+		ID:         types.NewUUID(),
+		Region:     "us-east-1",
+		SubnetIP:   "10.0.0.0",
+		SubnetMask: types.Int(8),
+		Version:    3,
+	}
+
+	d := New(cli)
+	err := d.UpdatePool(context.TODO(), p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, p.Version)
+}
+
+func TestUpdatePoolConflict(t *testing.T) {
+	cli := &fake.DynamoClient{}
+
+	cli.On("PutItem", mock.Anything, mock.Anything).
+		Return(nil, &dynatypes.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")})
+
+	p := &types.Pool{
+		ID:         types.NewUUID(),
+		Region:     "us-east-1",
+		SubnetIP:   "10.0.0.0",
+		SubnetMask: types.Int(8),
+		Version:    3,
+	}
+
+	d := New(cli)
+	err := d.UpdatePool(context.TODO(), p)
+
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, 3, p.Version)
+}
+
+func TestUpdatePoolMovesSortKey(t *testing.T) {
+	cli := &fake.DynamoClient{}
+
+	cli.On("TransactWriteItems", mock.Anything, mock.MatchedBy(func(params *dynamodb.TransactWriteItemsInput) bool {
+		if len(params.TransactItems) != 2 {
+			return false
+		}
+		del := params.TransactItems[0].Delete
+		put := params.TransactItems[1].Put
+		sk := put.Item["sk"].(*dynatypes.AttributeValueMemberS)
+		return del.Key["sk"].(*dynatypes.AttributeValueMemberS).Value == "us-east-1#10.0.0.0#main" &&
+			sk.Value == "us-east-1#10.16.0.0#main"
+	})).Return(&dynamodb.TransactWriteItemsOutput{}, nil)
+
+	p := &types.Pool{
+		ID:         types.NewUUID(),
+		Name:       "main",
+		Region:     "us-east-1",
+		SubnetIP:   "10.16.0.0",
+		SubnetMask: types.Int(12),
+		Version:    3,
+		SortKey:    "us-east-1#10.0.0.0#main",
+	}
+
+	d := New(cli)
+	err := d.UpdatePool(context.TODO(), p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, p.Version)
+	assert.Equal(t, "us-east-1#10.16.0.0#main", p.SortKey)
+}
+
+func TestDeletePoolReassignsReservations(t *testing.T) {
+	cli := &fake.DynamoClient{}
+
+	cli.On("TransactWriteItems", mock.Anything, mock.MatchedBy(func(params *dynamodb.TransactWriteItemsInput) bool {
+		if len(params.TransactItems) != 2 {
+			return false
+		}
+		del := params.TransactItems[0].Delete
+		put := params.TransactItems[1].Put
+		poolID := put.Item["poolID"].(*dynatypes.AttributeValueMemberS)
+		return aws.ToString(del.TableName) == "napi_pools" &&
+			del.Key["sk"].(*dynatypes.AttributeValueMemberS).Value == "us-east-1#10.0.0.0#main" &&
+			aws.ToString(put.TableName) == "napi_reservations" &&
+			poolID.Value == "parent"
+	})).Return(&dynamodb.TransactWriteItemsOutput{}, nil)
+
+	p := &types.Pool{
+		ID:         types.NewUUID(),
+		Name:       "main",
+		Region:     "us-east-1",
+		SubnetIP:   "10.0.0.0",
+		SubnetMask: types.Int(16),
+		SortKey:    "us-east-1#10.0.0.0#main",
+	}
+
+	d := New(cli)
+	err := d.DeletePool(context.TODO(), p, []*types.Reservation{
+		{CIDR: "10.0.1.0/24", NetworkID: "n1", PoolID: "parent"},
+	})
+
+	assert.NoError(t, err)
+	cli.AssertExpectations(t)
+}
@@ -33,6 +33,39 @@ func (d *database) ScanProviders(ctx context.Context) ([]*types.Provider, error)
 	return pools, nil
 }
 
+// ListProviders reads one page of providers, or all of them without a limit.
+func (d *database) ListProviders(ctx context.Context, o *types.ListOptions) ([]*types.Provider, string, error) {
+	if o == nil {
+		o = &types.ListOptions{}
+	}
+	var limit *int32
+	if o.Limit > 0 {
+		limit = aws.Int32(int32(o.Limit))
+	}
+
+	items, next, err := readPages(limit != nil, o.NextToken, func(start item) ([]item, item, error) {
+		so, err := d.Client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:         aws.String("napi_providers"),
+			ExclusiveStartKey: start,
+			Limit:             limit,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return so.Items, so.LastEvaluatedKey, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	providers := []*types.Provider{}
+	err = attributevalue.UnmarshalListOfMaps(items, &providers)
+	if err != nil {
+		return nil, "", err
+	}
+	return providers, next, nil
+}
+
 func (d *database) GetProvider(ctx context.Context, region string) (*types.Provider, error) {
 	so, err := d.Client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: aws.String("napi_providers"),
@@ -43,6 +76,9 @@ func (d *database) GetProvider(ctx context.Context, region string) (*types.Provi
 	if err != nil {
 		return nil, err
 	}
+	if len(so.Item) == 0 {
+		return nil, NotFoundError{Kind: "provider", ID: region}
+	}
 
 	pool := &types.Provider{}
 	err = attributevalue.UnmarshalMap(so.Item, pool)
@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynatypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// domainVersionCIDR stands in for the CIDR in the key of the item versioning
+// the reservations of a routing domain, see DomainVersion.
+const domainVersionCIDR = "#version"
+
+// ScanReservations reads every reservation with a consistent scan, so a check
+// made after reading the domain version sees all reservations counted by it.
+func (d *database) ScanReservations(ctx context.Context) ([]*types.Reservation, error) {
+	paginator := dynamodb.NewScanPaginator(d.Client, &dynamodb.ScanInput{
+		TableName:      aws.String("napi_reservations"),
+		ConsistentRead: aws.Bool(true),
+	})
+
+	reservations := []*types.Reservation{}
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return reservations, err
+		}
+
+		var rs []*types.Reservation
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &rs)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rs {
+			if strings.HasSuffix(r.CIDR, domainVersionCIDR) {
+				continue
+			}
+			r.CIDR = strings.TrimPrefix(r.CIDR, types.ReservationKey(r.RoutingDomain, ""))
+			reservations = append(reservations, r)
+		}
+	}
+
+	return reservations, nil
+}
+
+// DomainVersion returns the version of the reservations of the routing
+// domain, bumped by every ReserveNetwork in it. A domain without reservations
+// yet is at version 0.
+func (d *database) DomainVersion(ctx context.Context, domain string) (int, error) {
+	so, err := d.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("napi_reservations"),
+		Key: map[string]dynatypes.AttributeValue{
+			"cidr": &dynatypes.AttributeValueMemberS{Value: types.ReservationKey(domain, domainVersionCIDR)},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	v := struct {
+		Version int `dynamodbav:"version"`
+	}{}
+	err = attributevalue.UnmarshalMap(so.Item, &v)
+	if err != nil {
+		return 0, err
+	}
+	return v.Version, nil
+}
+
+// ReserveNetwork writes the reservation only if its CIDR is not taken yet in
+// its routing domain and no other reservation was made in the domain since
+// r.DomainVersion was read, so overlapping networks checked against the same
+// reservations can't both be reserved. When a pool is given its version is
+// bumped in the same transaction too. The pool must still be stored under the
+// key it was read with, allocations from a pool deleted or moved meanwhile
+// fail.
+func (d *database) ReserveNetwork(ctx context.Context, r *types.Reservation, p *types.Pool) error {
+	item, err := reservationItem(r)
+	if err != nil {
+		return err
+	}
+
+	items := []dynatypes.TransactWriteItem{
+		{
+			Put: &dynatypes.Put{
+				TableName:           aws.String("napi_reservations"),
+				Item:                item,
+				ConditionExpression: aws.String("attribute_not_exists(cidr)"),
+			},
+		},
+		{
+			Update: &dynatypes.Update{
+				TableName: aws.String("napi_reservations"),
+				Key: map[string]dynatypes.AttributeValue{
+					"cidr": &dynatypes.AttributeValueMemberS{Value: types.ReservationKey(r.RoutingDomain, domainVersionCIDR)},
+				},
+				UpdateExpression:    aws.String("SET version = :next"),
+				ConditionExpression: aws.String("attribute_not_exists(version) OR version = :current"),
+				ExpressionAttributeValues: map[string]dynatypes.AttributeValue{
+					":current": &dynatypes.AttributeValueMemberN{Value: strconv.Itoa(r.DomainVersion)},
+					":next":    &dynatypes.AttributeValueMemberN{Value: strconv.Itoa(r.DomainVersion + 1)},
+				},
+			},
+		},
+	}
+
+	if p != nil {
+		items = append(items, dynatypes.TransactWriteItem{
+			Update: &dynatypes.Update{
+				TableName: aws.String("napi_pools"),
+				Key: map[string]dynatypes.AttributeValue{
+					"id": &dynatypes.AttributeValueMemberS{Value: p.ID.String()},
+					"sk": &dynatypes.AttributeValueMemberS{Value: storedSortKey(p)},
+				},
+				UpdateExpression:    aws.String("SET version = :next"),
+				ConditionExpression: aws.String("attribute_exists(id) AND (attribute_not_exists(version) OR version = :current)"),
+				ExpressionAttributeValues: map[string]dynatypes.AttributeValue{
+					":current": &dynatypes.AttributeValueMemberN{Value: strconv.Itoa(p.Version)},
+					":next":    &dynatypes.AttributeValueMemberN{Value: strconv.Itoa(p.Version + 1)},
+				},
+			},
+		})
+	}
+
+	_, err = d.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+	if err != nil {
+		var tce *dynatypes.TransactionCanceledException
+		if errors.As(err, &tce) {
+			return fmt.Errorf("%w: network %s: %s", ErrConflict, r.CIDR, aws.ToString(tce.Message))
+		}
+		return err
+	}
+
+	if p != nil {
+		p.Version++
+	}
+	return nil
+}
+
+// UpdateReservation stores changes to the reservation of r.CIDR, such as the
+// pool it counts against, failing with ErrConflict when the CIDR was released
+// or claimed by another network meanwhile.
+func (d *database) UpdateReservation(ctx context.Context, r *types.Reservation) error {
+	item, err := reservationItem(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String("napi_reservations"),
+		Item:                item,
+		ConditionExpression: aws.String("networkID = :networkID"),
+		ExpressionAttributeValues: map[string]dynatypes.AttributeValue{
+			":networkID": &dynatypes.AttributeValueMemberS{Value: r.NetworkID},
+		},
+	})
+	if err != nil {
+		var ccf *dynatypes.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return fmt.Errorf("%w: network %s reservation changed meanwhile", ErrConflict, r.CIDR)
+		}
+		return err
+	}
+	return nil
+}
+
+// ReleaseNetwork removes the reservation with key, see types.ReservationKey.
+func (d *database) ReleaseNetwork(ctx context.Context, key string) error {
+	_, err := d.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String("napi_reservations"),
+		Key: map[string]dynatypes.AttributeValue{
+			"cidr": &dynatypes.AttributeValueMemberS{Value: key},
+		},
+	})
+	return err
+}
+
+// reservationItem stores r under its key, which only differs from its CIDR
+// outside the default routing domain.
+func reservationItem(r *types.Reservation) (map[string]dynatypes.AttributeValue, error) {
+	item, err := attributevalue.MarshalMap(r)
+	if err != nil {
+		return nil, err
+	}
+	item["cidr"] = &dynatypes.AttributeValueMemberS{Value: r.Key()}
+	return item, nil
+}
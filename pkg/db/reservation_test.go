@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynatypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/olxbr/network-api/pkg/db/fake"
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+func TestCanReserveNetwork(t *testing.T) {
+	cli := &fake.DynamoClient{}
+
+	cli.On("TransactWriteItems", mock.Anything, mock.MatchedBy(func(params *dynamodb.TransactWriteItemsInput) bool {
+		if len(params.TransactItems) != 3 {
+			return false
+		}
+		update := params.TransactItems[2].Update
+		// the pool moved from the key it was read with fails the transaction
+		// instead of writing a new item
+		sk := update.Key["sk"].(*dynatypes.AttributeValueMemberS)
+		return aws.ToString(params.TransactItems[0].Put.TableName) == "napi_reservations" &&
+			aws.ToString(update.TableName) == "napi_pools" &&
+			sk.Value == "us-east-1#10.0.0.0#previous" &&
+			strings.HasPrefix(aws.ToString(update.ConditionExpression), "attribute_exists(id) AND ")
+	})).Return(&dynamodb.TransactWriteItemsOutput{}, nil)
+
+	p := &types.Pool{
+		ID:         types.NewUUID(),
+		Name:       "renamed",
+		Region:     "us-east-1",
+		SubnetIP:   "10.0.0.0",
+		SubnetMask: types.Int(8),
+		Version:    3,
+		SortKey:    "us-east-1#10.0.0.0#previous",
+	}
+
+	d := New(cli)
+	err := d.ReserveNetwork(context.TODO(), &types.Reservation{CIDR: "10.0.0.0/24", NetworkID: "id"}, p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, p.Version)
+}
+
+func TestReserveNetworkInRoutingDomain(t *testing.T) {
+	cli := &fake.DynamoClient{}
+
+	cli.On("TransactWriteItems", mock.Anything, mock.MatchedBy(func(params *dynamodb.TransactWriteItemsInput) bool {
+		cidr := params.TransactItems[0].Put.Item["cidr"].(*dynatypes.AttributeValueMemberS)
+		// the reservation only commits while the domain is at the version
+		// it was checked against
+		update := params.TransactItems[1].Update
+		key := update.Key["cidr"].(*dynatypes.AttributeValueMemberS)
+		current := update.ExpressionAttributeValues[":current"].(*dynatypes.AttributeValueMemberN)
+		return cidr.Value == "sandbox#10.0.0.0/24" &&
+			key.Value == "sandbox##version" && current.Value == "7"
+	})).Return(&dynamodb.TransactWriteItemsOutput{}, nil)
+
+	d := New(cli)
+	err := d.ReserveNetwork(context.TODO(), &types.Reservation{CIDR: "10.0.0.0/24", NetworkID: "id", RoutingDomain: "sandbox", DomainVersion: 7}, nil)
+
+	assert.NoError(t, err)
+}
+
+func TestReserveNetworkConflict(t *testing.T) {
+	cli := &fake.DynamoClient{}
+
+	cli.On("TransactWriteItems", mock.Anything, mock.Anything).
+		Return(nil, &dynatypes.TransactionCanceledException{Message: aws.String("ConditionalCheckFailed")})
+
+	d := New(cli)
+	err := d.ReserveNetwork(context.TODO(), &types.Reservation{CIDR: "10.0.0.0/24", NetworkID: "id"}, nil)
+
+	assert.ErrorIs(t, err, ErrConflict)
+}
+
+func TestDomainVersion(t *testing.T) {
+	cli := &fake.DynamoClient{}
+
+	cli.On("GetItem", mock.Anything, mock.MatchedBy(func(params *dynamodb.GetItemInput) bool {
+		key := params.Key["cidr"].(*dynatypes.AttributeValueMemberS)
+		return key.Value == "sandbox##version" && aws.ToBool(params.ConsistentRead)
+	})).Return(&dynamodb.GetItemOutput{Item: map[string]dynatypes.AttributeValue{
+		"cidr":    &dynatypes.AttributeValueMemberS{Value: "sandbox##version"},
+		"version": &dynatypes.AttributeValueMemberN{Value: "7"},
+	}}, nil)
+	cli.On("GetItem", mock.Anything, mock.Anything).Return(&dynamodb.GetItemOutput{}, nil)
+
+	d := New(cli)
+	v, err := d.DomainVersion(context.TODO(), "sandbox")
+	assert.NoError(t, err)
+	assert.Equal(t, 7, v)
+
+	v, err = d.DomainVersion(context.TODO(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, v)
+}
+
+func TestScanReservationsSkipsDomainVersions(t *testing.T) {
+	cli := &fake.DynamoClient{}
+
+	cli.On("Scan", mock.Anything, mock.MatchedBy(func(params *dynamodb.ScanInput) bool {
+		return aws.ToBool(params.ConsistentRead)
+	}), mock.Anything).Return(&dynamodb.ScanOutput{Items: []map[string]dynatypes.AttributeValue{
+		{
+			"cidr":          &dynatypes.AttributeValueMemberS{Value: "sandbox#10.0.0.0/24"},
+			"networkID":     &dynatypes.AttributeValueMemberS{Value: "id"},
+			"routingDomain": &dynatypes.AttributeValueMemberS{Value: "sandbox"},
+		},
+		{
+			"cidr":    &dynatypes.AttributeValueMemberS{Value: "sandbox##version"},
+			"version": &dynatypes.AttributeValueMemberN{Value: "1"},
+		},
+		{
+			"cidr":    &dynatypes.AttributeValueMemberS{Value: "#version"},
+			"version": &dynatypes.AttributeValueMemberN{Value: "3"},
+		},
+	}}, nil)
+
+	d := New(cli)
+	rs, err := d.ScanReservations(context.TODO())
+
+	assert.NoError(t, err)
+	if assert.Len(t, rs, 1) {
+		assert.Equal(t, "10.0.0.0/24", rs[0].CIDR)
+	}
+}
+
+func TestCanUpdateReservation(t *testing.T) {
+	cli := &fake.DynamoClient{}
+
+	cli.On("PutItem", mock.Anything, mock.MatchedBy(func(params *dynamodb.PutItemInput) bool {
+		networkID := params.ExpressionAttributeValues[":networkID"].(*dynatypes.AttributeValueMemberS)
+		poolID := params.Item["poolID"].(*dynatypes.AttributeValueMemberS)
+		return aws.ToString(params.TableName) == "napi_reservations" &&
+			networkID.Value == "id" && poolID.Value == "pool"
+	})).Return(&dynamodb.PutItemOutput{}, nil)
+
+	d := New(cli)
+	err := d.UpdateReservation(context.TODO(), &types.Reservation{CIDR: "10.0.0.0/24", NetworkID: "id", PoolID: "pool"})
+
+	assert.NoError(t, err)
+}
+
+func TestUpdateReservationConflict(t *testing.T) {
+	cli := &fake.DynamoClient{}
+
+	cli.On("PutItem", mock.Anything, mock.Anything).
+		Return(nil, &dynatypes.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")})
+
+	d := New(cli)
+	err := d.UpdateReservation(context.TODO(), &types.Reservation{CIDR: "10.0.0.0/24", NetworkID: "id", PoolID: "pool"})
+
+	assert.ErrorIs(t, err, ErrConflict)
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynatypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// ListRules reads one page of rules, or all of them without a limit.
+func (d *database) ListRules(ctx context.Context, o *types.ListOptions) ([]*types.Rule, string, error) {
+	if o == nil {
+		o = &types.ListOptions{}
+	}
+	var limit *int32
+	if o.Limit > 0 {
+		limit = aws.Int32(int32(o.Limit))
+	}
+
+	items, next, err := readPages(limit != nil, o.NextToken, func(start item) ([]item, item, error) {
+		so, err := d.Client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:         aws.String("napi_rules"),
+			ExclusiveStartKey: start,
+			Limit:             limit,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return so.Items, so.LastEvaluatedKey, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	rules := []*types.Rule{}
+	err = attributevalue.UnmarshalListOfMaps(items, &rules)
+	if err != nil {
+		return nil, "", err
+	}
+	return rules, next, nil
+}
+
+func (d *database) GetRule(ctx context.Context, name string) (*types.Rule, error) {
+	so, err := d.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("napi_rules"),
+		Key: map[string]dynatypes.AttributeValue{
+			"name": &dynatypes.AttributeValueMemberS{Value: name},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(so.Item) == 0 {
+		return nil, NotFoundError{Kind: "rule", ID: name}
+	}
+
+	r := &types.Rule{}
+	err = attributevalue.UnmarshalMap(so.Item, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (d *database) PutRule(ctx context.Context, r *types.Rule) error {
+	item, err := attributevalue.MarshalMap(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String("napi_rules"),
+		Item:      item,
+	})
+	return err
+}
+
+func (d *database) DeleteRule(ctx context.Context, name string) error {
+	_, err := d.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String("napi_rules"),
+		Key: map[string]dynatypes.AttributeValue{
+			"name": &dynatypes.AttributeValueMemberS{Value: name},
+		},
+	})
+	return err
+}
@@ -3,7 +3,9 @@ package net
 import (
 	"fmt"
 	"net/netip"
+	"strings"
 
+	"github.com/olxbr/network-api/pkg/db"
 	"github.com/olxbr/network-api/pkg/types"
 )
 
@@ -15,3 +17,201 @@ type NetworkNotInPoolError struct {
 func (e NetworkNotInPoolError) Error() string {
 	return fmt.Sprintf("network %s not in pool range %s", e.Network.String(), e.Pool.Range().String())
 }
+
+// ExcludedError is returned when a network hits a range excluded from a pool.
+type ExcludedError struct {
+	Network   netip.Prefix
+	Pool      *types.Pool
+	Exclusion *types.Exclusion
+}
+
+func (e ExcludedError) Error() string {
+	return fmt.Sprintf("network %s overlaps %s excluded from pool %s by %s: %s",
+		e.Network.String(), e.Exclusion.CIDR, e.Pool.Name, e.Exclusion.Owner, e.Exclusion.Reason)
+}
+
+// PoolNotInParentError is returned when a pool range leaves the range, or the
+// routing domain, of the pool it was delegated from.
+type PoolNotInParentError struct {
+	Pool   *types.Pool
+	Parent *types.Pool
+}
+
+func (e PoolNotInParentError) Error() string {
+	if e.Pool.RoutingDomain != e.Parent.RoutingDomain {
+		return fmt.Sprintf("pool %s routing domain %q not the one of parent pool %s %q",
+			e.Pool.Name, e.Pool.RoutingDomain, e.Parent.Name, e.Parent.RoutingDomain)
+	}
+	return fmt.Sprintf("pool %s range %s not in parent pool %s range %s",
+		e.Pool.Name, e.Pool.Range().String(), e.Parent.Name, e.Parent.Range().String())
+}
+
+// PoolOverlapError is returned when a pool range overlaps another pool, it
+// matches db.ErrConflict.
+type PoolOverlapError struct {
+	Pool  *types.Pool
+	Other *types.Pool
+}
+
+func (e PoolOverlapError) Error() string {
+	return fmt.Sprintf("pool %s range %s overlaps pool %s range %s",
+		e.Pool.Name, e.Pool.Range().String(), e.Other.Name, e.Other.Range().String())
+}
+
+func (e PoolOverlapError) Is(target error) bool {
+	return target == db.ErrConflict
+}
+
+// NetworksOutsidePoolError is returned when a new pool range would leave out
+// networks allocated from the old one, listed in Networks, it matches
+// db.ErrConflict.
+type NetworksOutsidePoolError struct {
+	Pool     *types.Pool
+	Networks []*types.Network
+}
+
+func (e NetworksOutsidePoolError) Error() string {
+	networks := make([]string, 0, len(e.Networks))
+	for _, n := range e.Networks {
+		networks = append(networks, describeConflict(n))
+	}
+	return fmt.Sprintf("pool %s range %s leaves out %s", e.Pool.Name, e.Pool.Range().String(), strings.Join(networks, ", "))
+}
+
+func (e NetworksOutsidePoolError) Is(target error) bool {
+	return target == db.ErrConflict
+}
+
+// PoolInUseError is returned when a pool to be deleted still holds the
+// networks listed in Networks, it matches db.ErrConflict.
+type PoolInUseError struct {
+	Pool     *types.Pool
+	Networks []*types.Network
+}
+
+func (e PoolInUseError) Error() string {
+	networks := make([]string, 0, len(e.Networks))
+	for _, n := range e.Networks {
+		networks = append(networks, describeConflict(n))
+	}
+	return fmt.Sprintf("pool %s still holds %s", e.Pool.Name, strings.Join(networks, ", "))
+}
+
+func (e PoolInUseError) Is(target error) bool {
+	return target == db.ErrConflict
+}
+
+// OverlapError is returned when a network overlaps with the ones in use,
+// listed in Conflicts, it matches db.ErrConflict.
+type OverlapError struct {
+	Network   netip.Prefix
+	Conflicts []*types.Network
+}
+
+func (e OverlapError) Error() string {
+	if len(e.Conflicts) == 0 {
+		return fmt.Sprintf("network %s overlaps with existing network", e.Network.String())
+	}
+
+	conflicts := make([]string, 0, len(e.Conflicts))
+	for _, n := range e.Conflicts {
+		conflicts = append(conflicts, describeConflict(n))
+	}
+	return fmt.Sprintf("network %s overlaps with %s", e.Network.String(), strings.Join(conflicts, ", "))
+}
+
+// describeConflict names the blocks of a conflicting network and who owns it.
+func describeConflict(n *types.Network) string {
+	cidr := n.CIDR
+	if n.IPv6CIDR != "" {
+		cidr += " " + n.IPv6CIDR
+	}
+	for _, sc := range n.SecondaryCIDRs {
+		cidr += " " + sc.CIDR
+	}
+
+	owner := []string{}
+	if n.ID != nil {
+		owner = append(owner, "network "+n.ID.String())
+	}
+	if n.Account != "" {
+		owner = append(owner, "account "+n.Account)
+	}
+	if n.Environment != "" {
+		owner = append(owner, "environment "+n.Environment)
+	}
+	if n.Status == types.StatusPending {
+		owner = append(owner, string(n.Status))
+	}
+	if len(owner) == 0 {
+		return cidr
+	}
+	return fmt.Sprintf("%s (%s)", cidr, strings.Join(owner, ", "))
+}
+
+func (e OverlapError) Is(target error) bool {
+	return target == db.ErrConflict
+}
+
+// PoolExhaustedError is returned when a pool has no free network of the
+// requested size left.
+type PoolExhaustedError struct {
+	Pool       *types.Pool
+	SubnetSize int
+}
+
+func (e PoolExhaustedError) Error() string {
+	return fmt.Sprintf("no more networks available: no free /%d in pool range %s", e.SubnetSize, e.Pool.Range().String())
+}
+
+// NoPoolMatchError is returned when no pool matching a selector can take a
+// network. Err is the reason the last matching pool was skipped, nil when no
+// pool matched at all.
+type NoPoolMatchError struct {
+	Selector PoolSelector
+	Skipped  []*types.SkippedPool
+	Err      error
+}
+
+func (e NoPoolMatchError) Error() string {
+	if len(e.Skipped) == 0 {
+		return fmt.Sprintf("no pool matches %s", e.Selector)
+	}
+
+	reasons := make([]string, 0, len(e.Skipped))
+	for _, s := range e.Skipped {
+		reasons = append(reasons, fmt.Sprintf("pool %s: %s", s.Pool, s.Reason))
+	}
+	return fmt.Sprintf("no pool matching %s can take the network: %s", e.Selector, strings.Join(reasons, "; "))
+}
+
+func (e NoPoolMatchError) Unwrap() error {
+	return e.Err
+}
+
+// PolicyViolationError is returned when a network breaks the rules of the
+// policy of the pool it would be allocated from, listed in Violations.
+type PolicyViolationError struct {
+	Pool       *types.Pool
+	Violations []string
+}
+
+func (e PolicyViolationError) Error() string {
+	return fmt.Sprintf("network violates the policy of pool %s: %s", e.Pool.Name, strings.Join(e.Violations, "; "))
+}
+
+// RuleViolationError is returned when a network request fails some rule,
+// Results holds the outcome of every rule evaluated.
+type RuleViolationError struct {
+	Results []*types.RuleResult
+}
+
+func (e RuleViolationError) Error() string {
+	failed := []string{}
+	for _, r := range e.Results {
+		if r.Outcome == types.RuleFail {
+			failed = append(failed, r.Rule+": "+r.Reason)
+		}
+	}
+	return fmt.Sprintf("network request fails rules: %s", strings.Join(failed, "; "))
+}
@@ -0,0 +1,185 @@
+package net
+
+import (
+	"context"
+	"log"
+	"net/netip"
+	"sort"
+
+	"go4.org/netipx"
+
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// Lookup finds what owns query, an address or a block, in the routing
+// domain: the narrowest pool holding it, the network it belongs to and the
+// generated subnet within that network. When no network holds it, the free
+// range of the pool around it is reported instead.
+func (nm *NetworkManager) Lookup(ctx context.Context, domain string, query netip.Prefix) (*types.LookupResponse, error) {
+	pools, nets, reservations, err := nm.scanLookup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return lookupIn(domain, pools, nets, reservations, query)
+}
+
+// LookupAll runs Lookup in every routing domain, returning the ones where a
+// pool, a network or an allocation in flight holds query, sorted by domain.
+// When none does the default domain is reported alone.
+func (nm *NetworkManager) LookupAll(ctx context.Context, query netip.Prefix) ([]*types.LookupResponse, error) {
+	pools, nets, reservations, err := nm.scanLookup(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{"": true}
+	for _, p := range pools {
+		seen[p.RoutingDomain] = true
+	}
+	for _, n := range nets {
+		seen[n.RoutingDomain] = true
+	}
+	for _, r := range reservations {
+		seen[r.RoutingDomain] = true
+	}
+	domains := make([]string, 0, len(seen))
+	for d := range seen {
+		domains = append(domains, d)
+	}
+	sort.Strings(domains)
+
+	found := []*types.LookupResponse{}
+	var fallback *types.LookupResponse
+	for _, d := range domains {
+		res, err := lookupIn(d, pools, nets, reservations, query)
+		if err != nil {
+			return nil, err
+		}
+		if res.Pool != nil || res.Status != types.LookupUnallocated {
+			found = append(found, res)
+		} else if d == "" {
+			fallback = res
+		}
+	}
+	if len(found) == 0 {
+		found = append(found, fallback)
+	}
+	return found, nil
+}
+
+func (nm *NetworkManager) scanLookup(ctx context.Context) ([]*types.Pool, []*types.Network, []*types.Reservation, error) {
+	pools, err := nm.DB.ScanPools(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	nets, err := nm.DB.ScanNetworks(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	reservations, err := nm.DB.ScanReservations(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return pools, nets, reservations, nil
+}
+
+// lookupIn is Lookup over the scanned pools, networks and reservations.
+func lookupIn(domain string, pools []*types.Pool, nets []*types.Network, reservations []*types.Reservation, query netip.Prefix) (*types.LookupResponse, error) {
+	pools = domainPools(pools, domain)
+	nets = domainNetworks(nets, domain)
+	reservations = domainReservations(reservations, domain)
+
+	res := &types.LookupResponse{
+		Query:         query.String(),
+		RoutingDomain: domain,
+		Status:        types.LookupUnallocated,
+		Pool:          containingPool(pools, query),
+	}
+
+	for _, n := range nets {
+		if !n.HoldsAddresses() {
+			continue
+		}
+		for _, prefix := range n.Prefixes() {
+			if prefix.Bits() <= query.Bits() && prefix.Contains(query.Addr()) {
+				res.Status = types.LookupAllocated
+				res.Network = n
+				res.Subnet, res.AZIndex = containingSubnet(n, query)
+				return res, nil
+			}
+		}
+	}
+
+	used, err := usedSetOf(nets, reservations)
+	if err != nil {
+		return nil, err
+	}
+	// reservations of networks still being created hold addresses too
+	if used.ContainsPrefix(query) {
+		res.Status = types.LookupAllocated
+		return res, nil
+	}
+	if used.OverlapsPrefix(query) {
+		res.Status = types.LookupPartial
+		return res, nil
+	}
+
+	if res.Pool != nil {
+		free, err := freeSet(res.Pool, pools, used)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range free.Ranges() {
+			if r.Contains(query.Addr()) {
+				res.FreeRange = r.String()
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// containingPool returns the smallest pool whose range holds query.
+func containingPool(pools []*types.Pool, query netip.Prefix) *types.Pool {
+	qr := netipx.RangeOfPrefix(query)
+	var found *types.Pool
+	for _, p := range pools {
+		pr := p.Range()
+		if !pr.Contains(qr.From()) || !pr.Contains(qr.To()) {
+			continue
+		}
+		if found == nil || rangeSize(pr).Cmp(rangeSize(found.Range())) < 0 {
+			found = p
+		}
+	}
+	return found
+}
+
+// containingSubnet returns the subnet of n holding query and the index of
+// its availability zone.
+func containingSubnet(n *types.Network, query netip.Prefix) (*types.Subnet, *int) {
+	if n.Reserved || n.Legacy {
+		return nil, nil
+	}
+
+	snets, err := NetworkSubnets(n)
+	if err != nil {
+		log.Printf("failed to generate subnets for network %s: %v", n.CIDR, err)
+		return nil, nil
+	}
+
+	for _, s := range snets {
+		for _, c := range []string{s.CIDR, s.IPv6CIDR} {
+			prefix, err := netip.ParsePrefix(c)
+			if err != nil {
+				continue
+			}
+			if prefix.Bits() <= query.Bits() && prefix.Contains(query.Addr()) {
+				return s, types.Int(s.AZIndex)
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,205 @@
+package net
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/olxbr/network-api/pkg/db/fake"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	pool := &types.Pool{
+		ID:         types.NewUUID(),
+		Name:       "prod",
+		SubnetIP:   "10.0.0.0",
+		SubnetMask: types.Int(16),
+	}
+	wide := &types.Pool{
+		ID:         types.NewUUID(),
+		Name:       "all",
+		SubnetIP:   "10.0.0.0",
+		SubnetMask: types.Int(8),
+	}
+	network := &types.Network{
+		ID:            types.NewUUID(),
+		CIDR:          "10.0.0.0/20",
+		IPv6CIDR:      "2600:1f18::/56",
+		PrivateSubnet: true,
+		PublicSubnet:  true,
+		AttachTGW:     true,
+		SubnetIDs:     map[string]string{"10.0.6.0/24": "subnet-public02"},
+		Status:        types.StatusActive,
+	}
+	layered := &types.Network{
+		ID:     types.NewUUID(),
+		CIDR:   "10.5.0.0/20",
+		Status: types.StatusActive,
+		Layout: &types.Layout{
+			Name:    "app-data",
+			AZCount: 2,
+			Tiers: []*types.LayoutTier{
+				{Name: "app", Type: types.Private, Weight: 1},
+				{Name: "data", Type: types.Private, Weight: 1},
+			},
+		},
+	}
+	prepare := func(db *fake.Database) {
+		db.On("ScanPools", mock.Anything).Return([]*types.Pool{wide, pool}, nil)
+		db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+			network,
+			layered,
+			{CIDR: "10.0.64.0/20", Status: types.StatusDeleted},
+		}, nil)
+		db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+			{CIDR: "10.0.0.0/20"},
+			{CIDR: "10.0.32.0/24"},
+		}, nil)
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		assert func(t *testing.T, lr *types.LookupResponse)
+	}{
+		{
+			name:  "address in a subnet",
+			query: "10.0.6.10/32",
+			assert: func(t *testing.T, lr *types.LookupResponse) {
+				assert.Equal(t, types.LookupAllocated, lr.Status)
+				assert.Equal(t, pool, lr.Pool)
+				assert.Equal(t, network, lr.Network)
+				require.NotNil(t, lr.Subnet)
+				assert.Equal(t, "public02", lr.Subnet.Name)
+				assert.Equal(t, types.Public, lr.Subnet.Type)
+				assert.Equal(t, "10.0.6.0/24", lr.Subnet.CIDR)
+				assert.Equal(t, "subnet-public02", lr.Subnet.ID)
+				assert.Equal(t, types.Int(1), lr.AZIndex)
+				assert.Empty(t, lr.FreeRange)
+			},
+		},
+		{
+			name:  "address in an IPv6 subnet",
+			query: "2600:1f18:0:6::1/128",
+			assert: func(t *testing.T, lr *types.LookupResponse) {
+				assert.Equal(t, types.LookupAllocated, lr.Status)
+				assert.Nil(t, lr.Pool)
+				assert.Equal(t, network, lr.Network)
+				require.NotNil(t, lr.Subnet)
+				assert.Equal(t, "tgw01", lr.Subnet.Name)
+				assert.Equal(t, "10.0.3.0/28", lr.Subnet.CIDR)
+				assert.Equal(t, types.Int(0), lr.AZIndex)
+			},
+		},
+		{
+			name:  "address in a layout tier",
+			query: "10.5.12.9/32",
+			assert: func(t *testing.T, lr *types.LookupResponse) {
+				assert.Equal(t, types.LookupAllocated, lr.Status)
+				assert.Equal(t, wide, lr.Pool)
+				assert.Equal(t, layered, lr.Network)
+				require.NotNil(t, lr.Subnet)
+				assert.Equal(t, "data02", lr.Subnet.Name)
+				assert.Equal(t, "data", lr.Subnet.Tier)
+				assert.Equal(t, "10.5.12.0/22", lr.Subnet.CIDR)
+				assert.Equal(t, types.Int(1), lr.AZIndex)
+			},
+		},
+		{
+			name:  "allocation in flight",
+			query: "10.0.32.5/32",
+			assert: func(t *testing.T, lr *types.LookupResponse) {
+				assert.Equal(t, types.LookupAllocated, lr.Status)
+				assert.Equal(t, pool, lr.Pool)
+				assert.Nil(t, lr.Network)
+				assert.Empty(t, lr.FreeRange)
+			},
+		},
+		{
+			name:  "block partly allocated",
+			query: "10.0.0.0/18",
+			assert: func(t *testing.T, lr *types.LookupResponse) {
+				assert.Equal(t, types.LookupPartial, lr.Status)
+				assert.Nil(t, lr.Network)
+				assert.Empty(t, lr.FreeRange)
+			},
+		},
+		{
+			name:  "unallocated address",
+			query: "10.0.64.1/32",
+			assert: func(t *testing.T, lr *types.LookupResponse) {
+				assert.Equal(t, types.LookupUnallocated, lr.Status)
+				assert.Equal(t, pool, lr.Pool)
+				assert.Nil(t, lr.Network)
+				assert.Equal(t, "10.0.33.0-10.0.255.255", lr.FreeRange)
+			},
+		},
+		{
+			name:  "outside every pool",
+			query: "192.168.0.1/32",
+			assert: func(t *testing.T, lr *types.LookupResponse) {
+				assert.Equal(t, types.LookupUnallocated, lr.Status)
+				assert.Nil(t, lr.Pool)
+				assert.Empty(t, lr.FreeRange)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fake.Database{}
+			nm := New(db)
+			prepare(db)
+
+			lr, err := nm.Lookup(context.Background(), "", netip.MustParsePrefix(tt.query))
+			require.NoError(t, err)
+			assert.Equal(t, tt.query, lr.Query)
+			tt.assert(t, lr)
+		})
+	}
+}
+
+func TestLookupAll(t *testing.T) {
+	prod := &types.Pool{ID: types.NewUUID(), Name: "prod", SubnetIP: "10.0.0.0", SubnetMask: types.Int(16)}
+	lab := &types.Pool{ID: types.NewUUID(), Name: "lab", SubnetIP: "10.0.0.0", SubnetMask: types.Int(16), RoutingDomain: "sandbox"}
+	network := &types.Network{ID: types.NewUUID(), CIDR: "10.0.0.0/20", RoutingDomain: "sandbox", Reserved: true, Status: types.StatusActive}
+
+	db := &fake.Database{}
+	db.On("ScanPools", mock.Anything).Return([]*types.Pool{prod, lab}, nil)
+	db.On("ScanNetworks", mock.Anything).Return([]*types.Network{network}, nil)
+	db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+		{CIDR: "172.16.0.0/24", RoutingDomain: "partners"},
+	}, nil)
+	nm := New(db)
+
+	// the same address is looked up in every domain holding it
+	items, err := nm.LookupAll(context.Background(), netip.MustParsePrefix("10.0.1.1/32"))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "", items[0].RoutingDomain)
+	assert.Equal(t, types.LookupUnallocated, items[0].Status)
+	assert.Equal(t, prod, items[0].Pool)
+	assert.Equal(t, "sandbox", items[1].RoutingDomain)
+	assert.Equal(t, types.LookupAllocated, items[1].Status)
+	assert.Equal(t, network, items[1].Network)
+
+	// allocations in flight count even outside every pool
+	items, err = nm.LookupAll(context.Background(), netip.MustParsePrefix("172.16.0.9/32"))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "partners", items[0].RoutingDomain)
+	assert.Equal(t, types.LookupAllocated, items[0].Status)
+
+	items, err = nm.LookupAll(context.Background(), netip.MustParsePrefix("192.168.0.1/32"))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "", items[0].RoutingDomain)
+	assert.Equal(t, types.LookupUnallocated, items[0].Status)
+	assert.Nil(t, items[0].Pool)
+
+	db.AssertNumberOfCalls(t, "ScanPools", 3)
+}
@@ -0,0 +1,41 @@
+package net
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// BackfillSubnets stores the subnet plan of every network created before
+// subnets were persisted, generating it the way those networks were
+// provisioned along with the IDs the provider reported. Reserved, legacy and
+// deleted networks are skipped. It returns the networks it filled in and,
+// when dryRun is set, leaves the database untouched.
+func (nm *NetworkManager) BackfillSubnets(ctx context.Context, dryRun bool) ([]*types.Network, error) {
+	nets, err := nm.DB.ScanNetworks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filled := []*types.Network{}
+	for _, n := range nets {
+		if n.Reserved || n.Legacy || n.Status == types.StatusDeleted || len(n.Subnets) > 0 {
+			continue
+		}
+
+		snets, err := NetworkSubnets(n)
+		if err != nil {
+			return filled, fmt.Errorf("network %s: %w", n.ID, err)
+		}
+		n.Subnets = snets
+
+		if !dryRun {
+			if err := nm.DB.PutNetwork(ctx, n); err != nil {
+				return filled, fmt.Errorf("network %s: %w", n.ID, err)
+			}
+		}
+		filled = append(filled, n)
+	}
+	return filled, nil
+}
@@ -0,0 +1,72 @@
+package net
+
+import (
+	"context"
+	"testing"
+
+	"github.com/olxbr/network-api/pkg/db/fake"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfillSubnets(t *testing.T) {
+	id := types.NewUUID()
+	scan := func() []*types.Network {
+		return []*types.Network{
+			{
+				ID:            id,
+				CIDR:          "10.0.0.0/20",
+				PrivateSubnet: true,
+				PublicSubnet:  true,
+				SubnetIDs:     map[string]string{"10.0.0.0/23": "subnet-private01"},
+				Status:        types.StatusActive,
+			},
+			{
+				ID:      types.NewUUID(),
+				CIDR:    "10.0.16.0/20",
+				Subnets: []*types.Subnet{{Name: "private01", Type: types.Private, CIDR: "10.0.16.0/24"}},
+				Status:  types.StatusActive,
+			},
+			{ID: types.NewUUID(), CIDR: "10.0.32.0/20", Reserved: true},
+			{ID: types.NewUUID(), CIDR: "10.0.48.0/20", Legacy: true},
+			{ID: types.NewUUID(), CIDR: "10.0.64.0/20", PrivateSubnet: true, Status: types.StatusDeleted},
+		}
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		db := &fake.Database{}
+		db.On("ScanNetworks", mock.Anything).Return(scan(), nil)
+		nm := New(db)
+
+		filled, err := nm.BackfillSubnets(context.Background(), true)
+		require.NoError(t, err)
+		require.Len(t, filled, 1)
+		assert.Equal(t, id, filled[0].ID)
+		db.AssertNotCalled(t, "PutNetwork", mock.Anything, mock.Anything)
+	})
+
+	t.Run("stores subnets", func(t *testing.T) {
+		db := &fake.Database{}
+		db.On("ScanNetworks", mock.Anything).Return(scan(), nil)
+		db.On("PutNetwork", mock.Anything, mock.MatchedBy(func(n *types.Network) bool {
+			return n.ID == id
+		})).Return(nil).Once()
+		nm := New(db)
+
+		filled, err := nm.BackfillSubnets(context.Background(), false)
+		require.NoError(t, err)
+		require.Len(t, filled, 1)
+		db.AssertExpectations(t)
+
+		snets := filled[0].Subnets
+		require.Len(t, snets, 6)
+		assert.Equal(t, "private01", snets[0].Name)
+		assert.Equal(t, "10.0.0.0/23", snets[0].CIDR)
+		assert.Equal(t, "subnet-private01", snets[0].ID)
+		assert.Equal(t, 0, snets[0].AZIndex)
+		assert.Equal(t, "public03", snets[5].Name)
+		assert.Equal(t, 2, snets[5].AZIndex)
+	})
+}
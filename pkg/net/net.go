@@ -2,15 +2,22 @@ package net
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/netip"
 
+	"github.com/google/uuid"
 	"go4.org/netipx"
 
 	"github.com/olxbr/network-api/pkg/db"
+	"github.com/olxbr/network-api/pkg/types"
 )
 
+// maxAllocationAttempts bounds how many times AllocateNetwork retries when
+// a concurrent allocation wins the reservation race.
+const maxAllocationAttempts = 5
+
 type NetworkManager struct {
 	DB db.Database
 }
@@ -19,67 +26,436 @@ func New(database db.Database) *NetworkManager {
 	return &NetworkManager{DB: database}
 }
 
-func (nm *NetworkManager) CheckNetwork(ctx context.Context, network netip.Prefix) error {
+// usedSet returns every address of the routing domain taken either by a
+// stored network that still holds its CIDRs or by a reservation whose network
+// has not been written yet.
+func (nm *NetworkManager) usedSet(ctx context.Context, domain string) (*netipx.IPSet, error) {
 	nets, err := nm.DB.ScanNetworks(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	ipSetBuilder := &netipx.IPSetBuilder{}
+	reservations, err := nm.DB.ScanReservations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return usedSetOf(domainNetworks(nets, domain), domainReservations(reservations, domain))
+}
+
+// domainNetworks returns the networks in the routing domain.
+func domainNetworks(nets []*types.Network, domain string) []*types.Network {
+	in := []*types.Network{}
+	for _, n := range nets {
+		if n.RoutingDomain == domain {
+			in = append(in, n)
+		}
+	}
+	return in
+}
+
+// domainReservations returns the reservations in the routing domain.
+func domainReservations(reservations []*types.Reservation, domain string) []*types.Reservation {
+	in := []*types.Reservation{}
+	for _, r := range reservations {
+		if r.RoutingDomain == domain {
+			in = append(in, r)
+		}
+	}
+	return in
+}
+
+// domainPools returns the pools in the routing domain.
+func domainPools(pools []*types.Pool, domain string) []*types.Pool {
+	in := []*types.Pool{}
+	for _, p := range pools {
+		if p.RoutingDomain == domain {
+			in = append(in, p)
+		}
+	}
+	return in
+}
 
+func usedSetOf(nets []*types.Network, reservations []*types.Reservation) (*netipx.IPSet, error) {
+	ipSetBuilder := &netipx.IPSetBuilder{}
 	for _, n := range nets {
-		ipSetBuilder.AddPrefix(n.IPPrefix())
+		if !n.HoldsAddresses() {
+			continue
+		}
+		for _, prefix := range n.Prefixes() {
+			ipSetBuilder.AddPrefix(prefix)
+		}
+	}
+	for _, r := range reservations {
+		ipSetBuilder.AddPrefix(r.IPPrefix())
 	}
 
 	ipset, err := ipSetBuilder.IPSet()
 	if err != nil {
-		return fmt.Errorf("error building ipset: %+v", err)
+		return nil, fmt.Errorf("error building ipset: %+v", err)
+	}
+	return ipset, nil
+}
+
+// CheckNetwork makes sure no address of network is in use in the routing
+// domain nor excluded from one of its pools, reporting the networks or
+// exclusion holding them otherwise.
+func (nm *NetworkManager) CheckNetwork(ctx context.Context, domain string, network netip.Prefix) error {
+	conflicts, err := nm.Overlaps(ctx, domain, network)
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) > 0 {
+		return OverlapError{Network: network, Conflicts: conflicts}
 	}
 
-	if ipset.ContainsPrefix(network) {
-		return fmt.Errorf("network %s overlaps with existing network", network.String())
+	pools, err := nm.DB.ScanPools(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range domainPools(pools, domain) {
+		if err := CheckExclusions(p, network); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func (nm *NetworkManager) AllocateNetwork(ctx context.Context, poolID string, subnetSize int) (netip.Prefix, error) {
-	p, err := nm.DB.GetPool(ctx, poolID)
+// Overlaps returns the networks of the routing domain holding any address of
+// the given prefixes. Reservations whose network is still being created are
+// reported as pending networks with only their ID and CIDR.
+func (nm *NetworkManager) Overlaps(ctx context.Context, domain string, prefixes ...netip.Prefix) ([]*types.Network, error) {
+	nets, err := nm.DB.ScanNetworks(ctx)
 	if err != nil {
-		return netip.Prefix{}, fmt.Errorf("error getting pool: %+v", err)
+		return nil, err
 	}
-	pn := p.Network()
+	nets = domainNetworks(nets, domain)
 
+	reservations, err := nm.DB.ScanReservations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reservations = domainReservations(reservations, domain)
+
+	return overlapping(nets, reservations, prefixes), nil
+}
+
+// overlapping returns the networks and pending reservations holding any
+// address of the given prefixes.
+func overlapping(nets []*types.Network, reservations []*types.Reservation, prefixes []netip.Prefix) []*types.Network {
+	overlaps := func(blocks ...netip.Prefix) bool {
+		for _, b := range blocks {
+			for _, p := range prefixes {
+				if b.Overlaps(p) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	conflicts := []*types.Network{}
+	stored := map[string]bool{}
+	for _, n := range nets {
+		if !n.HoldsAddresses() {
+			continue
+		}
+		blocks := n.Prefixes()
+		for _, b := range blocks {
+			stored[b.String()] = true
+		}
+		if overlaps(blocks...) {
+			conflicts = append(conflicts, n)
+		}
+	}
+	for _, r := range reservations {
+		if stored[r.CIDR] || !overlaps(r.IPPrefix()) {
+			continue
+		}
+		n := &types.Network{
+			CIDR:          r.CIDR,
+			RoutingDomain: r.RoutingDomain,
+			Account:       r.Account,
+			Environment:   r.Environment,
+			Status:        types.StatusPending,
+		}
+		if id, err := uuid.Parse(r.NetworkID); err == nil {
+			n.ID = &types.DynamoUUID{UUID: id}
+		}
+		conflicts = append(conflicts, n)
+	}
+	return conflicts
+}
+
+// NetworkCheck runs CheckNetwork on many networks of a routing domain
+// against a single scan of its networks, reservations and pools.
+type NetworkCheck struct {
+	nets         []*types.Network
+	reservations []*types.Reservation
+	pools        []*types.Pool
+	used         *netipx.IPSet
+}
+
+// NewNetworkCheck scans the routing domain once for the networks to check,
+// networks created afterwards are not seen by it.
+func (nm *NetworkManager) NewNetworkCheck(ctx context.Context, domain string) (*NetworkCheck, error) {
 	nets, err := nm.DB.ScanNetworks(ctx)
 	if err != nil {
-		return netip.Prefix{}, err
+		return nil, err
 	}
 
-	ipSetBuilder := &netipx.IPSetBuilder{}
+	reservations, err := nm.DB.ScanReservations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pools, err := nm.DB.ScanPools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &NetworkCheck{
+		nets:         domainNetworks(nets, domain),
+		reservations: domainReservations(reservations, domain),
+		pools:        domainPools(pools, domain),
+	}
+	c.used, err = usedSetOf(c.nets, c.reservations)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Check is CheckNetwork against the scan of the routing domain.
+func (c *NetworkCheck) Check(network netip.Prefix) error {
+	if c.used.OverlapsPrefix(network) {
+		return OverlapError{Network: network, Conflicts: overlapping(c.nets, c.reservations, []netip.Prefix{network})}
+	}
+
+	for _, p := range c.pools {
+		if err := CheckExclusions(p, network); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckInPool makes sure network is within the pool range.
+func CheckInPool(p *types.Pool, network netip.Prefix) error {
+	pr := p.Range()
+	nr := netipx.RangeOfPrefix(network)
+	if !pr.Contains(nr.From()) || !pr.Contains(nr.To()) {
+		return NetworkNotInPoolError{Network: &network, Pool: p}
+	}
+	return nil
+}
+
+// CheckPoolResize makes sure the range of p still holds every network, or
+// allocation in flight, that had any address in its previous range.
+func (nm *NetworkManager) CheckPoolResize(ctx context.Context, p *types.Pool, previous netipx.IPRange) error {
+	nets, err := nm.Overlaps(ctx, p.RoutingDomain, previous.Prefixes()...)
+	if err != nil {
+		return err
+	}
+
+	pr := p.Range()
+	outside := []*types.Network{}
 	for _, n := range nets {
-		ipSetBuilder.AddPrefix(n.IPPrefix())
+		for _, prefix := range n.Prefixes() {
+			nr := netipx.RangeOfPrefix(prefix)
+			if nr.Overlaps(previous) && (!pr.Contains(nr.From()) || !pr.Contains(nr.To())) {
+				outside = append(outside, n)
+				break
+			}
+		}
+	}
+	if len(outside) > 0 {
+		return NetworksOutsidePoolError{Pool: p, Networks: outside}
+	}
+	return nil
+}
+
+// PoolNetworks returns the networks, or allocations in flight, holding any
+// address of the pool range.
+func (nm *NetworkManager) PoolNetworks(ctx context.Context, p *types.Pool) ([]*types.Network, error) {
+	return nm.Overlaps(ctx, p.RoutingDomain, p.Range().Prefixes()...)
+}
+
+// DeletePool removes pool from, moving its networks to pool to when given,
+// which must hold every address they have in from. Their reservations count
+// against to afterwards, they are moved in the same write as the pool is
+// removed.
+func (nm *NetworkManager) DeletePool(ctx context.Context, from, to *types.Pool) error {
+	if to == nil {
+		return nm.DB.DeletePool(ctx, from, nil)
 	}
 
-	ipset, err := ipSetBuilder.IPSet()
+	nets, err := nm.PoolNetworks(ctx, from)
+	if err != nil {
+		return err
+	}
+
+	fr := from.Range()
+	for _, n := range nets {
+		for _, prefix := range n.Prefixes() {
+			if !netipx.RangeOfPrefix(prefix).Overlaps(fr) {
+				continue
+			}
+			if err := CheckInPool(to, prefix); err != nil {
+				return err
+			}
+		}
+	}
+
+	reservations, err := nm.DB.ScanReservations(ctx)
+	if err != nil {
+		return err
+	}
+	reassigned := []*types.Reservation{}
+	for _, r := range domainReservations(reservations, from.RoutingDomain) {
+		if !netipx.RangeOfPrefix(r.IPPrefix()).Overlaps(fr) {
+			continue
+		}
+		r.PoolID = to.ID.String()
+		reassigned = append(reassigned, r)
+	}
+	return nm.DB.DeletePool(ctx, from, reassigned)
+}
+
+// CheckExclusions makes sure network stays clear of the pool exclusions.
+func CheckExclusions(p *types.Pool, network netip.Prefix) error {
+	for _, e := range p.Exclusions {
+		excluded, err := netip.ParsePrefix(e.CIDR)
+		if err != nil {
+			continue
+		}
+		if excluded.Overlaps(network) {
+			return ExcludedError{Network: network, Pool: p, Exclusion: e}
+		}
+	}
+	return nil
+}
+
+// PoolOf returns the smallest pool of the routing domain holding network,
+// nil when none does.
+func (nm *NetworkManager) PoolOf(ctx context.Context, domain string, network netip.Prefix) (*types.Pool, error) {
+	pools, err := nm.DB.ScanPools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return containingPool(domainPools(pools, domain), network), nil
+}
+
+// ReserveNetwork atomically claims network in the routing domain of n once
+// CheckNetwork passes, and for reserved networks in pool p, once CheckPolicy
+// passes. It fails with db.ErrConflict when another reservation was made in
+// the domain since the checks or, if p is given, when the pool changed since
+// it was read.
+func (nm *NetworkManager) ReserveNetwork(ctx context.Context, n *types.Network, p *types.Pool, network netip.Prefix) error {
+	version, err := nm.DB.DomainVersion(ctx, n.RoutingDomain)
+	if err != nil {
+		return err
+	}
+
+	err = nm.CheckNetwork(ctx, n.RoutingDomain, network)
+	if err == nil && n.Reserved && p != nil {
+		err = nm.CheckPolicy(ctx, p, n, network.Bits())
+	}
 	if err != nil {
-		return netip.Prefix{}, fmt.Errorf("error building ipset: %+v", err)
+		return err
+	}
+	return nm.reserve(ctx, n.RoutingDomain, n, p, network, version)
+}
+
+// reserve claims network in domain for n, failing unless the domain is still
+// at version.
+func (nm *NetworkManager) reserve(ctx context.Context, domain string, n *types.Network, p *types.Pool, network netip.Prefix, version int) error {
+	r := &types.Reservation{
+		CIDR:          network.String(),
+		RoutingDomain: domain,
+		Account:       n.Account,
+		Environment:   n.Environment,
+		DomainVersion: version,
 	}
+	if n.ID != nil {
+		r.NetworkID = n.ID.String()
+	}
+	if p != nil && p.ID != nil {
+		r.PoolID = p.ID.String()
+	}
+	return nm.DB.ReserveNetwork(ctx, r, p)
+}
+
+// ReleaseNetwork frees a CIDR previously claimed with ReserveNetwork.
+func (nm *NetworkManager) ReleaseNetwork(ctx context.Context, domain string, network netip.Prefix) error {
+	return nm.DB.ReleaseNetwork(ctx, types.ReservationKey(domain, network.String()))
+}
 
-	newNet := netip.PrefixFrom(pn, subnetSize)
+// AllocateNetwork finds a free prefix of subnetSize in the pool using the
+// given strategy, or the pool default when empty, and reserves it for n once
+// CheckPolicy passes, retrying with a fresh view of the pool when a
+// concurrent allocation got there first. The policy is checked again on each
+// attempt, so concurrent allocations cannot both pass a quota.
+func (nm *NetworkManager) AllocateNetwork(ctx context.Context, poolID string, n *types.Network, subnetSize int, strategy types.AllocationStrategy) (netip.Prefix, error) {
+	for attempt := 1; ; attempt++ {
+		p, err := nm.DB.GetPool(ctx, poolID)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("error getting pool: %w", err)
+		}
+
+		version, err := nm.DB.DomainVersion(ctx, p.RoutingDomain)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+
+		err = nm.CheckPolicy(ctx, p, n, subnetSize)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
 
-	for ipset.OverlapsPrefix(newNet) {
-		var valid bool
-		newNet, valid = NextSubnet(newNet, subnetSize)
-		if !valid {
-			return netip.Prefix{}, fmt.Errorf("no more networks available")
+		newNet, err := nm.nextFreeNetwork(ctx, p, subnetSize, strategy)
+		if err != nil {
+			return netip.Prefix{}, err
 		}
 
-		newRange := netipx.RangeOfPrefix(newNet)
-		if !p.Range().Overlaps(newRange) {
-			return netip.Prefix{}, NetworkNotInPoolError{Network: &newNet, Pool: p}
+		err = nm.reserve(ctx, p.RoutingDomain, n, p, newNet, version)
+		if err == nil {
+			log.Printf("allocated network: %+v", newNet.String())
+			return newNet, nil
 		}
+		if !errors.Is(err, db.ErrConflict) || attempt >= maxAllocationAttempts {
+			return netip.Prefix{}, err
+		}
+		log.Printf("allocation of %s conflicted, retrying (attempt %d): %v", newNet.String(), attempt, err)
+	}
+}
+
+func (nm *NetworkManager) nextFreeNetwork(ctx context.Context, p *types.Pool, subnetSize int, strategy types.AllocationStrategy) (netip.Prefix, error) {
+	allocate, err := resolveStrategy(p, strategy)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	used, err := nm.usedSet(ctx, p.RoutingDomain)
+	if err != nil {
+		return netip.Prefix{}, err
 	}
 
-	log.Printf("allocated network: %+v", newNet.String())
+	pools, err := nm.DB.ScanPools(ctx)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	free, err := freeSet(p, domainPools(pools, p.RoutingDomain), used)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	newNet, ok := allocate(free.Prefixes(), subnetSize)
+	if !ok {
+		return netip.Prefix{}, PoolExhaustedError{Pool: p, SubnetSize: subnetSize}
+	}
 	return newNet, nil
 }
 
@@ -2,21 +2,28 @@ package net
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/netip"
+	"sync"
 	"testing"
 
+	"github.com/olxbr/network-api/pkg/db"
 	"github.com/olxbr/network-api/pkg/db/fake"
 	"github.com/olxbr/network-api/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go4.org/netipx"
 )
 
 func TestAllocateNetwork(t *testing.T) {
+	network := &types.Network{ID: types.NewUUID(), Account: "123", Environment: "prod"}
 	tests := []struct {
 		name       string
 		poolID     string
 		subnetSize int
+		strategy   types.AllocationStrategy
 		prepare    func(t *testing.T, db *fake.Database)
 		assert     func(t *testing.T, db *fake.Database, n netip.Prefix, err error)
 	}{
@@ -26,11 +33,15 @@ func TestAllocateNetwork(t *testing.T) {
 			subnetSize: 24,
 			prepare: func(t *testing.T, db *fake.Database) {
 				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
 				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
 					Region:     "us-east-1",
 					SubnetIP:   "10.0.0.0",
 					SubnetMask: types.Int(8),
 				}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			},
 			assert: func(t *testing.T, db *fake.Database, n netip.Prefix, err error) {
 				db.AssertExpectations(t)
@@ -38,6 +49,62 @@ func TestAllocateNetwork(t *testing.T) {
 				assert.Equal(t, "10.0.0.0/24", n.String())
 			},
 		},
+		{
+			name:       "skips exclusions",
+			poolID:     "poolid",
+			subnetSize: 24,
+			prepare: func(t *testing.T, db *fake.Database) {
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.0.1.0/24"},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+					Exclusions: []*types.Exclusion{
+						{CIDR: "10.0.0.0/24", Reason: "on-prem datacenter", Owner: "infra"},
+						{CIDR: "10.0.2.0/23", Reason: "partner VPN", Owner: "network-team"},
+					},
+				}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			},
+			assert: func(t *testing.T, db *fake.Database, n netip.Prefix, err error) {
+				db.AssertExpectations(t)
+				assert.NoError(t, err)
+				assert.Equal(t, "10.0.4.0/24", n.String())
+			},
+		},
+		{
+			name:       "skips child pools",
+			poolID:     "poolid",
+			subnetSize: 16,
+			prepare: func(t *testing.T, db *fake.Database) {
+				parent := &types.Pool{
+					ID:         types.NewUUID(),
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{
+					parent,
+					{ID: types.NewUUID(), SubnetIP: "10.0.0.0", SubnetMask: types.Int(12), ParentID: parent.ID.String()},
+					{ID: types.NewUUID(), SubnetIP: "10.64.0.0", SubnetMask: types.Int(10)},
+				}, nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(parent, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			},
+			assert: func(t *testing.T, db *fake.Database, n netip.Prefix, err error) {
+				db.AssertExpectations(t)
+				assert.NoError(t, err)
+				assert.Equal(t, "10.16.0.0/16", n.String())
+			},
+		},
 		{
 			name:       "with existing networks",
 			poolID:     "poolid",
@@ -47,11 +114,15 @@ func TestAllocateNetwork(t *testing.T) {
 					{CIDR: "10.0.0.0/24"},
 					{CIDR: "10.0.1.0/24"},
 				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
 				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
 					Region:     "us-east-1",
 					SubnetIP:   "10.0.0.0",
 					SubnetMask: types.Int(8),
 				}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			},
 			assert: func(t *testing.T, db *fake.Database, n netip.Prefix, err error) {
 				db.AssertExpectations(t)
@@ -68,11 +139,15 @@ func TestAllocateNetwork(t *testing.T) {
 					{CIDR: "10.0.0.0/16"},
 					{CIDR: "10.1.0.0/24"},
 				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
 				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
 					Region:     "us-east-1",
 					SubnetIP:   "10.0.0.0",
 					SubnetMask: types.Int(8),
 				}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			},
 			assert: func(t *testing.T, db *fake.Database, n netip.Prefix, err error) {
 				db.AssertExpectations(t)
@@ -89,11 +164,15 @@ func TestAllocateNetwork(t *testing.T) {
 					{CIDR: "10.0.0.0/24"},
 					{CIDR: "10.0.2.0/24"},
 				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
 				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
 					Region:     "us-east-1",
 					SubnetIP:   "10.0.0.0",
 					SubnetMask: types.Int(8),
 				}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			},
 			assert: func(t *testing.T, db *fake.Database, n netip.Prefix, err error) {
 				db.AssertExpectations(t)
@@ -102,16 +181,296 @@ func TestAllocateNetwork(t *testing.T) {
 			},
 		},
 		{
-			name:       "result network is not in pool",
+			name:       "ipv6 pool with dual-stack networks",
+			poolID:     "poolid6",
+			subnetSize: 56,
+			prepare: func(t *testing.T, db *fake.Database) {
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.0.0.0/16", IPv6CIDR: "2600:1f18:1000::/56"},
+					{CIDR: "10.1.0.0/16", IPv6CIDR: "2600:1f18:1000:100::/56"},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("GetPool", mock.Anything, "poolid6").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "2600:1f18:1000::",
+					SubnetMask: types.Int(40),
+				}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			},
+			assert: func(t *testing.T, db *fake.Database, n netip.Prefix, err error) {
+				db.AssertExpectations(t)
+				assert.NoError(t, err)
+				assert.Equal(t, "2600:1f18:1000:200::/56", n.String())
+			},
+		},
+		{
+			name:       "with failed and deleted networks",
+			poolID:     "poolid",
+			subnetSize: 23,
+			prepare: func(t *testing.T, db *fake.Database) {
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.0.0.0/24", Status: types.StatusFailed},
+					{CIDR: "10.0.1.0/24", Status: types.StatusDeleted},
+					{CIDR: "10.0.2.0/24", Status: types.StatusDeleting},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			},
+			assert: func(t *testing.T, db *fake.Database, n netip.Prefix, err error) {
+				db.AssertExpectations(t)
+				assert.NoError(t, err)
+				assert.Equal(t, "10.0.0.0/23", n.String())
+			},
+		},
+		{
+			name:       "with existing reservations",
+			poolID:     "poolid",
+			subnetSize: 24,
+			prepare: func(t *testing.T, db *fake.Database) {
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.0.0.0/24"},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+					{CIDR: "10.0.1.0/24", NetworkID: "other"},
+				}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.MatchedBy(func(r *types.Reservation) bool {
+					return r.CIDR == "10.0.2.0/24" && r.NetworkID == network.ID.String() && r.Account == "123" && r.Environment == "prod"
+				}), mock.Anything).Return(nil)
+			},
+			assert: func(t *testing.T, db *fake.Database, n netip.Prefix, err error) {
+				db.AssertExpectations(t)
+				assert.NoError(t, err)
+				assert.Equal(t, "10.0.2.0/24", n.String())
+			},
+		},
+		{
+			name:       "retries when reservation conflicts",
+			poolID:     "poolid",
+			subnetSize: 24,
+			prepare: func(t *testing.T, d *fake.Database) {
+				d.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				d.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				d.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				d.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+				d.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				d.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(db.ErrConflict).Once()
+				d.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			assert: func(t *testing.T, d *fake.Database, n netip.Prefix, err error) {
+				d.AssertExpectations(t)
+				d.AssertNumberOfCalls(t, "ReserveNetwork", 2)
+				assert.NoError(t, err)
+				assert.Equal(t, "10.0.0.0/24", n.String())
+			},
+		},
+		{
+			name:       "checks the pool quota again after a conflict",
+			poolID:     "poolid",
+			subnetSize: 24,
+			prepare: func(t *testing.T, d *fake.Database) {
+				d.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				// the policy and the allocation scan once each before the conflict
+				d.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil).Times(2)
+				d.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+					{CIDR: "10.0.0.0/24", NetworkID: "other", Account: "123"},
+				}, nil)
+				d.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				d.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Name:       "shared",
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+					Policy: &types.PoolPolicy{Quotas: []*types.Quota{
+						{Scope: types.QuotaAccount, MaxNetworks: 1},
+					}},
+				}, nil)
+				d.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				d.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(db.ErrConflict).Once()
+			},
+			assert: func(t *testing.T, d *fake.Database, n netip.Prefix, err error) {
+				d.AssertNumberOfCalls(t, "ReserveNetwork", 1)
+				assert.EqualError(t, err, "network violates the policy of pool shared: account 123 already holds 1 of 1 networks")
+			},
+		},
+		{
+			name:       "gives up after too many conflicts",
+			poolID:     "poolid",
+			subnetSize: 24,
+			prepare: func(t *testing.T, d *fake.Database) {
+				d.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				d.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				d.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				d.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(8),
+				}, nil)
+				d.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				d.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(db.ErrConflict)
+			},
+			assert: func(t *testing.T, d *fake.Database, n netip.Prefix, err error) {
+				d.AssertExpectations(t)
+				d.AssertNumberOfCalls(t, "ReserveNetwork", maxAllocationAttempts)
+				assert.ErrorIs(t, err, db.ErrConflict)
+			},
+		},
+		{
+			name:       "best fit takes the smallest gap",
+			poolID:     "poolid",
+			subnetSize: 24,
+			strategy:   types.BestFit,
+			prepare: func(t *testing.T, db *fake.Database) {
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.0.0.0/23"},
+					{CIDR: "10.0.4.0/24"},
+					{CIDR: "10.0.6.0/23"},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(16),
+				}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			},
+			assert: func(t *testing.T, db *fake.Database, n netip.Prefix, err error) {
+				db.AssertExpectations(t)
+				assert.NoError(t, err)
+				assert.Equal(t, "10.0.5.0/24", n.String())
+			},
+		},
+		{
+			name:       "first fit leaves fragments behind",
+			poolID:     "poolid",
+			subnetSize: 24,
+			strategy:   types.FirstFit,
+			prepare: func(t *testing.T, db *fake.Database) {
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.0.0.0/23"},
+					{CIDR: "10.0.4.0/24"},
+					{CIDR: "10.0.6.0/23"},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(16),
+				}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			},
+			assert: func(t *testing.T, db *fake.Database, n netip.Prefix, err error) {
+				db.AssertExpectations(t)
+				assert.NoError(t, err)
+				assert.Equal(t, "10.0.2.0/24", n.String())
+			},
+		},
+		{
+			name:       "sparse uses the pool default",
+			poolID:     "poolid",
+			subnetSize: 24,
+			prepare: func(t *testing.T, db *fake.Database) {
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.0.0.0/24"},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(16),
+					Strategy:   types.Sparse,
+				}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			},
+			assert: func(t *testing.T, db *fake.Database, n netip.Prefix, err error) {
+				db.AssertExpectations(t)
+				assert.NoError(t, err)
+				assert.Equal(t, "10.0.128.0/24", n.String())
+			},
+		},
+		{
+			name:       "request overrides pool strategy",
+			poolID:     "poolid",
+			subnetSize: 24,
+			strategy:   types.FirstFit,
+			prepare: func(t *testing.T, db *fake.Database) {
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.0.0.0/24"},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(16),
+					Strategy:   types.Sparse,
+				}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+				db.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			},
+			assert: func(t *testing.T, db *fake.Database, n netip.Prefix, err error) {
+				db.AssertExpectations(t)
+				assert.NoError(t, err)
+				assert.Equal(t, "10.0.1.0/24", n.String())
+			},
+		},
+		{
+			name:       "unknown strategy",
+			poolID:     "poolid",
+			subnetSize: 24,
+			strategy:   "random",
+			prepare: func(t *testing.T, db *fake.Database) {
+				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
+					Region:     "us-east-1",
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(16),
+				}, nil)
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+			},
+			assert: func(t *testing.T, db *fake.Database, n netip.Prefix, err error) {
+				db.AssertExpectations(t)
+				assert.ErrorContains(t, err, "unknown allocation strategy: random")
+			},
+		},
+		{
+			name:       "pool exhausted",
 			poolID:     "poolid",
 			subnetSize: 10,
 			prepare: func(t *testing.T, db *fake.Database) {
+				db.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
 				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
 					{CIDR: "10.0.0.0/24"},
 					{CIDR: "10.64.0.0/10"},
 					{CIDR: "10.128.0.0/24"},
 					{CIDR: "10.192.0.0/23"},
 				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
 				db.On("GetPool", mock.Anything, "poolid").Return(&types.Pool{
 					Region:     "us-east-1",
 					SubnetIP:   "10.0.0.0",
@@ -120,7 +479,8 @@ func TestAllocateNetwork(t *testing.T) {
 			},
 			assert: func(t *testing.T, db *fake.Database, n netip.Prefix, err error) {
 				db.AssertExpectations(t)
-				assert.ErrorContains(t, err, "network 11.0.0.0/10 not in pool range 10.0.0.0-10.255.255.255")
+				assert.ErrorContains(t, err, "no more networks available")
+				assert.ErrorAs(t, err, &PoolExhaustedError{})
 			},
 		},
 	}
@@ -133,13 +493,160 @@ func TestAllocateNetwork(t *testing.T) {
 			ctx := context.Background()
 
 			tt.prepare(t, db)
-			net, err := nm.AllocateNetwork(ctx, tt.poolID, tt.subnetSize)
+			net, err := nm.AllocateNetwork(ctx, tt.poolID, network, tt.subnetSize, tt.strategy)
 			tt.assert(t, db, net, err)
 		})
 	}
 
 }
 
+func TestAllocateNetworkConcurrently(t *testing.T) {
+	var mu sync.Mutex
+	pool := &types.Pool{
+		ID:         types.NewUUID(),
+		Region:     "us-east-1",
+		SubnetIP:   "10.0.0.0",
+		SubnetMask: types.Int(16),
+	}
+	reservations := map[string]*types.Reservation{}
+
+	d := &fake.Database{}
+	d.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+	d.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+	d.On("ScanReservations", mock.Anything).Return(func(ctx context.Context) []*types.Reservation {
+		mu.Lock()
+		defer mu.Unlock()
+		rs := []*types.Reservation{}
+		for _, r := range reservations {
+			rs = append(rs, r)
+		}
+		return rs
+	}, nil)
+	d.On("GetPool", mock.Anything, "poolid").Return(func(ctx context.Context, id string) *types.Pool {
+		mu.Lock()
+		defer mu.Unlock()
+		p := *pool
+		return &p
+	}, nil)
+	d.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+	d.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(func(ctx context.Context, r *types.Reservation, p *types.Pool) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := reservations[r.CIDR]; ok || p.Version != pool.Version {
+			return db.ErrConflict
+		}
+		reservations[r.CIDR] = r
+		pool.Version++
+		p.Version++
+		return nil
+	})
+
+	nm := New(d)
+	ctx := context.Background()
+
+	const workers = 16
+	results := make([]netip.Prefix, workers)
+	errs := make([]error, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			size := 24
+			if i%2 == 0 {
+				size = 23
+			}
+			results[i], errs[i] = nm.AllocateNetwork(ctx, "poolid", &types.Network{ID: types.NewUUID()}, size, "")
+		}(i)
+	}
+	wg.Wait()
+
+	allocated := []netip.Prefix{}
+	for i, err := range errs {
+		if err != nil {
+			assert.ErrorIs(t, err, db.ErrConflict)
+			continue
+		}
+		allocated = append(allocated, results[i])
+	}
+
+	assert.NotEmpty(t, allocated)
+	assert.Len(t, reservations, len(allocated))
+	for i := range allocated {
+		for j := i + 1; j < len(allocated); j++ {
+			assert.False(t, allocated[i].Overlaps(allocated[j]), "%s overlaps %s", allocated[i], allocated[j])
+		}
+	}
+}
+
+func TestReserveOverlappingNetworksConcurrently(t *testing.T) {
+	var mu sync.Mutex
+	version := 0
+	reservations := map[string]*types.Reservation{}
+
+	d := &fake.Database{}
+	d.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+	d.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+	d.On("ScanReservations", mock.Anything).Return(func(ctx context.Context) []*types.Reservation {
+		mu.Lock()
+		defer mu.Unlock()
+		rs := []*types.Reservation{}
+		for _, r := range reservations {
+			rs = append(rs, r)
+		}
+		return rs
+	}, nil)
+	d.On("DomainVersion", mock.Anything, "").Return(func(ctx context.Context, domain string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return version
+	}, nil)
+	d.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(func(ctx context.Context, r *types.Reservation, p *types.Pool) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := reservations[r.CIDR]; ok || r.DomainVersion != version {
+			return db.ErrConflict
+		}
+		reservations[r.CIDR] = r
+		version++
+		return nil
+	})
+
+	nm := New(d)
+	ctx := context.Background()
+
+	// every network overlaps 10.0.0.0/16, none the same CIDR
+	prefixes := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/16")}
+	for i := 0; i < 15; i++ {
+		prefixes = append(prefixes, netip.MustParsePrefix(fmt.Sprintf("10.0.%d.0/24", i*16)))
+	}
+	errs := make([]error, len(prefixes))
+
+	var wg sync.WaitGroup
+	for i, prefix := range prefixes {
+		wg.Add(1)
+		go func(i int, prefix netip.Prefix) {
+			defer wg.Done()
+			errs[i] = nm.ReserveNetwork(ctx, &types.Network{ID: types.NewUUID()}, nil, prefix)
+		}(i, prefix)
+	}
+	wg.Wait()
+
+	reserved := []netip.Prefix{}
+	for i, err := range errs {
+		if err == nil {
+			reserved = append(reserved, prefixes[i])
+		}
+	}
+	assert.NotEmpty(t, reserved)
+	for i := range reserved {
+		for j := i + 1; j < len(reserved); j++ {
+			assert.False(t, reserved[i].Overlaps(reserved[j]), "%s overlaps %s", reserved[i], reserved[j])
+		}
+	}
+}
+
 func TestNextSubnet(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -191,3 +698,213 @@ func TestNextSubnet(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckNetwork(t *testing.T) {
+	d := &fake.Database{}
+	d.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+		{CIDR: "10.0.0.0/16", Account: "123", Environment: "prod"},
+		{CIDR: "10.2.1.0/24", IPv6CIDR: "2600:1f18::/56", Account: "456", Environment: "dev"},
+		{CIDR: "10.3.0.0/16", Status: types.StatusDeleted},
+		{CIDR: "10.5.0.0/24", SecondaryCIDRs: []*types.SecondaryCIDR{{CIDR: "10.6.0.0/20"}}, Account: "789"},
+	}, nil)
+	d.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+		{CIDR: "10.0.0.0/16"},
+		{CIDR: "10.4.0.0/24"},
+		{CIDR: "10.6.0.0/20"},
+	}, nil)
+	d.On("ScanPools", mock.Anything).Return([]*types.Pool{
+		{
+			Name:       "prod",
+			SubnetIP:   "10.0.0.0",
+			SubnetMask: types.Int(8),
+			Exclusions: []*types.Exclusion{{CIDR: "10.8.0.0/16", Reason: "on-prem datacenter", Owner: "infra"}},
+		},
+	}, nil)
+	nm := New(d)
+
+	err := nm.CheckNetwork(context.TODO(), "", netip.MustParsePrefix("10.0.1.0/24"))
+	assert.ErrorAs(t, err, &OverlapError{})
+	assert.ErrorIs(t, err, db.ErrConflict)
+	assert.EqualError(t, err, "network 10.0.1.0/24 overlaps with 10.0.0.0/16 (account 123, environment prod)")
+
+	// partial overlaps are caught too
+	err = nm.CheckNetwork(context.TODO(), "", netip.MustParsePrefix("10.2.0.0/16"))
+	assert.EqualError(t, err, "network 10.2.0.0/16 overlaps with 10.2.1.0/24 2600:1f18::/56 (account 456, environment dev)")
+
+	err = nm.CheckNetwork(context.TODO(), "", netip.MustParsePrefix("10.4.0.0/16"))
+	oe := OverlapError{}
+	require.ErrorAs(t, err, &oe)
+	require.Len(t, oe.Conflicts, 1)
+	assert.Equal(t, types.StatusPending, oe.Conflicts[0].Status)
+	assert.Equal(t, "10.4.0.0/24", oe.Conflicts[0].CIDR)
+
+	// secondary blocks are held by their network
+	err = nm.CheckNetwork(context.TODO(), "", netip.MustParsePrefix("10.6.8.0/24"))
+	assert.EqualError(t, err, "network 10.6.8.0/24 overlaps with 10.5.0.0/24 10.6.0.0/20 (account 789)")
+
+	// excluded ranges are never handed out, not even to reserved networks
+	err = nm.CheckNetwork(context.TODO(), "", netip.MustParsePrefix("10.8.4.0/24"))
+	assert.ErrorAs(t, err, &ExcludedError{})
+	assert.EqualError(t, err, "network 10.8.4.0/24 overlaps 10.8.0.0/16 excluded from pool prod by infra: on-prem datacenter")
+
+	err = nm.CheckNetwork(context.TODO(), "", netip.MustParsePrefix("10.1.0.0/24"))
+	assert.NoError(t, err)
+
+	err = nm.CheckNetwork(context.TODO(), "", netip.MustParsePrefix("10.3.0.0/24"))
+	assert.NoError(t, err)
+}
+
+func TestCheckNetworkRoutingDomains(t *testing.T) {
+	d := &fake.Database{}
+	d.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+		{CIDR: "10.0.0.0/16", Account: "123"},
+		{CIDR: "10.1.0.0/16", Account: "456", RoutingDomain: "sandbox"},
+	}, nil)
+	d.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+		{CIDR: "10.0.0.0/16"},
+		{CIDR: "10.1.0.0/16", RoutingDomain: "sandbox"},
+	}, nil)
+	d.On("ScanPools", mock.Anything).Return([]*types.Pool{
+		{Name: "prod", SubnetIP: "10.0.0.0", SubnetMask: types.Int(8)},
+		{
+			Name:          "sandbox",
+			SubnetIP:      "10.0.0.0",
+			SubnetMask:    types.Int(8),
+			RoutingDomain: "sandbox",
+			Exclusions:    []*types.Exclusion{{CIDR: "10.8.0.0/16", Reason: "lab"}},
+		},
+	}, nil)
+	nm := New(d)
+
+	// the same space is free once per domain
+	err := nm.CheckNetwork(context.TODO(), "sandbox", netip.MustParsePrefix("10.0.0.0/16"))
+	assert.NoError(t, err)
+	err = nm.CheckNetwork(context.TODO(), "", netip.MustParsePrefix("10.1.0.0/16"))
+	assert.NoError(t, err)
+
+	err = nm.CheckNetwork(context.TODO(), "sandbox", netip.MustParsePrefix("10.1.4.0/24"))
+	assert.EqualError(t, err, "network 10.1.4.0/24 overlaps with 10.1.0.0/16 (account 456)")
+
+	// exclusions only apply to the pools of their domain
+	err = nm.CheckNetwork(context.TODO(), "", netip.MustParsePrefix("10.8.0.0/24"))
+	assert.NoError(t, err)
+	err = nm.CheckNetwork(context.TODO(), "sandbox", netip.MustParsePrefix("10.8.0.0/24"))
+	assert.ErrorAs(t, err, &ExcludedError{})
+}
+
+func TestNetworkCheck(t *testing.T) {
+	d := &fake.Database{}
+	d.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+		{CIDR: "10.0.0.0/16", Account: "123", Environment: "prod"},
+		{CIDR: "10.1.0.0/16", Account: "456", RoutingDomain: "sandbox"},
+		{CIDR: "10.3.0.0/16", Status: types.StatusDeleted},
+	}, nil)
+	d.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+		{CIDR: "10.0.0.0/16"},
+		{CIDR: "10.4.0.0/24"},
+	}, nil)
+	d.On("ScanPools", mock.Anything).Return([]*types.Pool{
+		{
+			Name:       "prod",
+			SubnetIP:   "10.0.0.0",
+			SubnetMask: types.Int(8),
+			Exclusions: []*types.Exclusion{{CIDR: "10.8.0.0/16", Reason: "on-prem datacenter", Owner: "infra"}},
+		},
+	}, nil)
+	nm := New(d)
+
+	check, err := nm.NewNetworkCheck(context.TODO(), "")
+	require.NoError(t, err)
+
+	err = check.Check(netip.MustParsePrefix("10.0.1.0/24"))
+	assert.EqualError(t, err, "network 10.0.1.0/24 overlaps with 10.0.0.0/16 (account 123, environment prod)")
+
+	err = check.Check(netip.MustParsePrefix("10.4.0.0/16"))
+	oe := OverlapError{}
+	require.ErrorAs(t, err, &oe)
+	require.Len(t, oe.Conflicts, 1)
+	assert.Equal(t, types.StatusPending, oe.Conflicts[0].Status)
+
+	err = check.Check(netip.MustParsePrefix("10.8.4.0/24"))
+	assert.ErrorAs(t, err, &ExcludedError{})
+
+	assert.NoError(t, check.Check(netip.MustParsePrefix("10.1.0.0/24")))
+	assert.NoError(t, check.Check(netip.MustParsePrefix("10.3.0.0/24")))
+
+	d.AssertNumberOfCalls(t, "ScanNetworks", 1)
+	d.AssertNumberOfCalls(t, "ScanReservations", 1)
+	d.AssertNumberOfCalls(t, "ScanPools", 1)
+}
+
+func TestCheckPoolResize(t *testing.T) {
+	d := &fake.Database{}
+	d.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+		{CIDR: "10.0.0.0/16", Account: "123"},
+		{CIDR: "10.0.128.0/24", Account: "456", Status: types.StatusDeleted},
+		{CIDR: "10.1.0.0/24", IPv6CIDR: "2600:1f18::/56", Account: "789"},
+		{CIDR: "192.168.0.0/24", Account: "000"},
+	}, nil)
+	d.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+		{CIDR: "10.2.0.0/24"},
+	}, nil)
+	nm := New(d)
+
+	p := &types.Pool{Name: "prod", SubnetIP: "10.0.0.0", SubnetMask: types.Int(16)}
+	previous := netipx.RangeOfPrefix(netip.MustParsePrefix("10.0.0.0/14"))
+
+	err := nm.CheckPoolResize(context.TODO(), p, previous)
+	oe := NetworksOutsidePoolError{}
+	require.ErrorAs(t, err, &oe)
+	assert.ErrorIs(t, err, db.ErrConflict)
+	assert.EqualError(t, err, "pool prod range 10.0.0.0-10.0.255.255 leaves out 10.1.0.0/24 2600:1f18::/56 (account 789), 10.2.0.0/24 (pending)")
+	require.Len(t, oe.Networks, 2)
+	assert.Equal(t, types.StatusPending, oe.Networks[1].Status)
+
+	// networks outside the previous range are none of the pool business
+	p.SubnetMask = types.Int(14)
+	assert.NoError(t, nm.CheckPoolResize(context.TODO(), p, previous))
+}
+
+func TestDeletePool(t *testing.T) {
+	from := &types.Pool{ID: types.NewUUID(), Name: "us-east", SubnetIP: "10.2.0.0", SubnetMask: types.Int(16)}
+	to := &types.Pool{ID: types.NewUUID(), Name: "corp", SubnetIP: "10.0.0.0", SubnetMask: types.Int(8)}
+
+	d := &fake.Database{}
+	d.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+		{CIDR: "10.2.0.0/24", Account: "123"},
+		{CIDR: "10.3.0.0/24", Account: "456"},
+	}, nil)
+	d.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+		{CIDR: "10.2.0.0/24", NetworkID: "n1", PoolID: from.ID.String()},
+		{CIDR: "10.2.1.0/24", NetworkID: "n2", PoolID: from.ID.String()},
+		{CIDR: "10.3.0.0/24", NetworkID: "n3", PoolID: to.ID.String()},
+	}, nil)
+	d.On("DeletePool", mock.Anything, from, mock.MatchedBy(func(rs []*types.Reservation) bool {
+		return len(rs) == 2 && rs[0].PoolID == to.ID.String() && rs[1].PoolID == to.ID.String()
+	})).Return(nil).Once()
+	nm := New(d)
+
+	err := nm.DeletePool(context.TODO(), from, to)
+	require.NoError(t, err)
+	d.AssertExpectations(t)
+
+	// networks are never moved to a pool not holding them
+	lab := &types.Pool{ID: types.NewUUID(), Name: "lab", SubnetIP: "172.16.0.0", SubnetMask: types.Int(12)}
+	err = nm.DeletePool(context.TODO(), from, lab)
+	assert.ErrorAs(t, err, &NetworkNotInPoolError{})
+	d.AssertNumberOfCalls(t, "DeletePool", 1)
+}
+
+func TestCheckInPool(t *testing.T) {
+	p := &types.Pool{
+		SubnetIP:   "10.0.0.0",
+		SubnetMask: types.Int(16),
+	}
+
+	assert.NoError(t, CheckInPool(p, netip.MustParsePrefix("10.0.128.0/17")))
+
+	err := CheckInPool(p, netip.MustParsePrefix("10.0.0.0/15"))
+	assert.ErrorAs(t, err, &NetworkNotInPoolError{})
+	assert.EqualError(t, err, "network 10.0.0.0/15 not in pool range 10.0.0.0-10.0.255.255")
+	assert.Error(t, CheckInPool(p, netip.MustParsePrefix("10.1.0.0/24")))
+}
@@ -0,0 +1,222 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+
+	"go4.org/netipx"
+
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// holding is what an account or environment holds in a pool.
+type holding struct {
+	networks  int
+	addresses *big.Int
+	// ids are the keys of the networks held, see networkKey.
+	ids map[string]bool
+}
+
+// CheckPolicy makes sure network n, taking a prefix of prefixLength from pool
+// p, follows the policy of p and of every pool above it, reporting every rule
+// it breaks. A network already holding addresses in a pool does not count
+// again against its network quotas.
+func (nm *NetworkManager) CheckPolicy(ctx context.Context, p *types.Pool, n *types.Network, prefixLength int) error {
+	violations := []string{}
+	seen := map[string]bool{}
+	if p.ID != nil {
+		seen[p.ID.String()] = true
+	}
+	for pool := p; ; {
+		broken, err := nm.poolViolations(ctx, pool, n, prefixLength)
+		if err != nil {
+			return err
+		}
+		for _, v := range broken {
+			if pool != p {
+				v = fmt.Sprintf("pool %s: %s", pool.Name, v)
+			}
+			violations = append(violations, v)
+		}
+
+		if pool.ParentID == "" || seen[pool.ParentID] {
+			break
+		}
+		seen[pool.ParentID] = true
+		pool, err = nm.DB.GetPool(ctx, pool.ParentID)
+		if err != nil {
+			return fmt.Errorf("error getting parent pool: %w", err)
+		}
+	}
+
+	if len(violations) > 0 {
+		return PolicyViolationError{Pool: p, Violations: violations}
+	}
+	return nil
+}
+
+// poolViolations lists the rules of the policy of pool p a prefix of
+// prefixLength for network n breaks.
+func (nm *NetworkManager) poolViolations(ctx context.Context, p *types.Pool, n *types.Network, prefixLength int) ([]string, error) {
+	pol := p.Policy
+	if pol == nil {
+		return nil, nil
+	}
+
+	violations := []string{}
+	if len(pol.PrefixLengths) > 0 && !slices.Contains(pol.PrefixLengths, prefixLength) {
+		violations = append(violations, fmt.Sprintf("prefix length /%d not one of %s", prefixLength, prefixLengths(pol.PrefixLengths)))
+	}
+	if len(pol.Environments) > 0 && !slices.Contains(pol.Environments, n.Environment) {
+		violations = append(violations, fmt.Sprintf("environment %s not one of %v", n.Environment, pol.Environments))
+	}
+
+	if len(pol.Quotas) > 0 {
+		holdings, err := nm.poolHoldings(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		size := addressCount(p, prefixLength)
+		for _, q := range pol.Quotas {
+			value := n.Account
+			if q.Scope == types.QuotaEnvironment {
+				value = n.Environment
+			}
+			if q.Value != "" && q.Value != value {
+				continue
+			}
+
+			h := holdingOf(holdings, q.Scope, value)
+			held := n.ID != nil && h.ids[networkKey(n)]
+			if q.MaxNetworks > 0 && !held && h.networks >= q.MaxNetworks {
+				violations = append(violations, fmt.Sprintf("%s %s already holds %d of %d networks", q.Scope, value, h.networks, q.MaxNetworks))
+			}
+			if q.MaxAddresses > 0 {
+				total := new(big.Int).Add(h.addresses, size)
+				if total.Cmp(new(big.Int).SetUint64(q.MaxAddresses)) > 0 {
+					violations = append(violations, fmt.Sprintf("%s %s would hold %s of %d addresses", q.Scope, value, total.String(), q.MaxAddresses))
+				}
+			}
+		}
+	}
+	return violations, nil
+}
+
+// QuotaUsage reports what each account or environment holds against the
+// quotas of pool p, quotas without a value are reported for every account or
+// environment holding networks in the pool.
+func (nm *NetworkManager) QuotaUsage(ctx context.Context, p *types.Pool) ([]*types.QuotaUsage, error) {
+	if p.Policy == nil || len(p.Policy.Quotas) == 0 {
+		return nil, nil
+	}
+
+	holdings, err := nm.poolHoldings(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := []*types.QuotaUsage{}
+	for _, q := range p.Policy.Quotas {
+		values := []string{q.Value}
+		if q.Value == "" {
+			values = []string{}
+			for v := range holdings[q.Scope] {
+				values = append(values, v)
+			}
+			sort.Strings(values)
+		}
+		for _, v := range values {
+			h := holdingOf(holdings, q.Scope, v)
+			usage = append(usage, &types.QuotaUsage{
+				Scope:        q.Scope,
+				Value:        v,
+				Networks:     h.networks,
+				Addresses:    h.addresses,
+				MaxNetworks:  q.MaxNetworks,
+				MaxAddresses: q.MaxAddresses,
+			})
+		}
+	}
+	return usage, nil
+}
+
+// poolHoldings sums the networks and addresses held in pool p by each
+// account and environment, allocations still in flight included through the
+// owner of their reservation. A network holding several prefixes counts once.
+func (nm *NetworkManager) poolHoldings(ctx context.Context, p *types.Pool) (map[types.QuotaScope]map[string]*holding, error) {
+	nets, err := nm.PoolNetworks(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	holdings := map[types.QuotaScope]map[string]*holding{
+		types.QuotaAccount:     {},
+		types.QuotaEnvironment: {},
+	}
+	pr := p.Range()
+	for _, n := range nets {
+		addresses := new(big.Int)
+		for _, prefix := range n.Prefixes() {
+			if netipx.RangeOfPrefix(prefix).Overlaps(pr) {
+				addresses.Add(addresses, addressCount(p, prefix.Bits()))
+			}
+		}
+		owners := map[types.QuotaScope]string{
+			types.QuotaAccount:     n.Account,
+			types.QuotaEnvironment: n.Environment,
+		}
+		for scope, value := range owners {
+			if value == "" {
+				continue
+			}
+			h := holdingOf(holdings, scope, value)
+			if key := networkKey(n); !h.ids[key] {
+				h.ids[key] = true
+				h.networks++
+			}
+			h.addresses.Add(h.addresses, addresses)
+			holdings[scope][value] = h
+		}
+	}
+	return holdings, nil
+}
+
+func holdingOf(holdings map[types.QuotaScope]map[string]*holding, scope types.QuotaScope, value string) *holding {
+	if h, ok := holdings[scope][value]; ok {
+		return h
+	}
+	return &holding{addresses: new(big.Int), ids: map[string]bool{}}
+}
+
+// networkKey tells networks apart by their ID, or by their CIDR for pending
+// reservations naming no valid network ID.
+func networkKey(n *types.Network) string {
+	if n.ID != nil {
+		return n.ID.String()
+	}
+	return n.CIDR
+}
+
+// addressCount returns how many addresses a network of prefixLength holds in
+// the family of pool p.
+func addressCount(p *types.Pool, prefixLength int) *big.Int {
+	bits := 32
+	if p.IsIPv6() {
+		bits = 128
+	}
+	return new(big.Int).Lsh(big.NewInt(1), uint(bits-prefixLength))
+}
+
+func prefixLengths(lengths []int) string {
+	s := ""
+	for i, l := range lengths {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("/%d", l)
+	}
+	return s
+}
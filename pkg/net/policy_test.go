@@ -0,0 +1,191 @@
+package net
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/olxbr/network-api/pkg/db/fake"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var heldID = types.NewUUID()
+
+func policyDatabase() *fake.Database {
+	d := &fake.Database{}
+	d.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+		{ID: heldID, CIDR: "10.0.0.0/20", Account: "123", Environment: "prod"},
+		{CIDR: "10.0.16.0/20", Account: "123", Environment: "dev"},
+		{CIDR: "10.0.32.0/24", Account: "456", Environment: "dev"},
+		{CIDR: "10.0.48.0/20", Account: "123", Environment: "prod", Status: types.StatusDeleted},
+		{CIDR: "10.1.0.0/20", Account: "123", Environment: "prod"},
+	}, nil)
+	d.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+		{CIDR: "10.0.0.0/20"},
+		{CIDR: "10.0.16.0/20"},
+		{CIDR: "10.0.32.0/24"},
+		{CIDR: "10.0.64.0/24", Account: "456", Environment: "qa"},
+		{CIDR: "10.1.0.0/20"},
+	}, nil)
+	d.On("GetPool", mock.Anything, "regional").Return(&types.Pool{
+		Name: "regional", SubnetIP: "10.0.0.0", SubnetMask: types.Int(8), ParentID: "global",
+		Policy: &types.PoolPolicy{Environments: []string{"prod", "dev"}},
+	}, nil)
+	d.On("GetPool", mock.Anything, "global").Return(&types.Pool{
+		Name: "global", SubnetIP: "10.0.0.0", SubnetMask: types.Int(8),
+		Policy: &types.PoolPolicy{PrefixLengths: []int{16, 20, 24}},
+	}, nil)
+	return d
+}
+
+func TestCheckPolicy(t *testing.T) {
+	nm := New(policyDatabase())
+	pool := func(pol *types.PoolPolicy, parentID string) *types.Pool {
+		return &types.Pool{Name: "shared", SubnetIP: "10.0.0.0", SubnetMask: types.Int(16), Policy: pol, ParentID: parentID}
+	}
+
+	tests := []struct {
+		name        string
+		policy      *types.PoolPolicy
+		parentID    string
+		id          *types.DynamoUUID
+		account     string
+		environment string
+		size        int
+		violations  []string
+	}{
+		{
+			name:        "no policy",
+			account:     "123",
+			environment: "prod",
+			size:        16,
+		},
+		{
+			name:        "allowed",
+			policy:      &types.PoolPolicy{PrefixLengths: []int{20, 24}, Environments: []string{"prod", "dev"}},
+			account:     "123",
+			environment: "prod",
+			size:        20,
+		},
+		{
+			name:        "size and environment",
+			policy:      &types.PoolPolicy{PrefixLengths: []int{20, 24}, Environments: []string{"prod"}},
+			account:     "123",
+			environment: "qa",
+			size:        22,
+			violations: []string{
+				"prefix length /22 not one of /20, /24",
+				"environment qa not one of [prod]",
+			},
+		},
+		{
+			name: "network quotas of each account",
+			policy: &types.PoolPolicy{Quotas: []*types.Quota{
+				{Scope: types.QuotaAccount, MaxNetworks: 2},
+			}},
+			account:     "123",
+			environment: "prod",
+			size:        24,
+			violations:  []string{"account 123 already holds 2 of 2 networks"},
+		},
+		{
+			name: "network quotas of another account",
+			policy: &types.PoolPolicy{Quotas: []*types.Quota{
+				{Scope: types.QuotaAccount, MaxNetworks: 2},
+			}},
+			account:     "789",
+			environment: "prod",
+			size:        24,
+		},
+		{
+			name: "network quotas of a network already held",
+			policy: &types.PoolPolicy{Quotas: []*types.Quota{
+				{Scope: types.QuotaAccount, MaxNetworks: 2},
+			}},
+			id:          heldID,
+			account:     "123",
+			environment: "prod",
+			size:        24,
+		},
+		{
+			name: "network quotas with pending reservations",
+			policy: &types.PoolPolicy{Quotas: []*types.Quota{
+				{Scope: types.QuotaEnvironment, Value: "qa", MaxNetworks: 1},
+			}},
+			account:     "789",
+			environment: "qa",
+			size:        24,
+			violations:  []string{"environment qa already holds 1 of 1 networks"},
+		},
+		{
+			name:        "policies of the parent pools",
+			policy:      &types.PoolPolicy{PrefixLengths: []int{22, 24}},
+			parentID:    "regional",
+			account:     "123",
+			environment: "qa",
+			size:        22,
+			violations: []string{
+				"pool regional: environment qa not one of [prod dev]",
+				"pool global: prefix length /22 not one of /16, /20, /24",
+			},
+		},
+		{
+			name: "address quota reached exactly",
+			policy: &types.PoolPolicy{Quotas: []*types.Quota{
+				{Scope: types.QuotaEnvironment, Value: "dev", MaxAddresses: 4608},
+				{Scope: types.QuotaEnvironment, Value: "prod", MaxNetworks: 1},
+			}},
+			account:     "456",
+			environment: "dev",
+			size:        24,
+		},
+		{
+			name: "address quota exceeded",
+			policy: &types.PoolPolicy{Quotas: []*types.Quota{
+				{Scope: types.QuotaEnvironment, Value: "dev", MaxAddresses: 4608},
+			}},
+			account:     "456",
+			environment: "dev",
+			size:        23,
+			violations:  []string{"environment dev would hold 4864 of 4608 addresses"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &types.Network{ID: tt.id, Account: tt.account, Environment: tt.environment}
+			err := nm.CheckPolicy(context.TODO(), pool(tt.policy, tt.parentID), n, tt.size)
+			if len(tt.violations) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			pe := PolicyViolationError{}
+			require.ErrorAs(t, err, &pe)
+			assert.Equal(t, tt.violations, pe.Violations)
+		})
+	}
+}
+
+func TestQuotaUsage(t *testing.T) {
+	nm := New(policyDatabase())
+	p := &types.Pool{Name: "shared", SubnetIP: "10.0.0.0", SubnetMask: types.Int(16), Policy: &types.PoolPolicy{
+		Quotas: []*types.Quota{
+			{Scope: types.QuotaAccount, MaxNetworks: 3},
+			{Scope: types.QuotaEnvironment, Value: "qa", MaxAddresses: 1024},
+		},
+	}}
+
+	usage, err := nm.QuotaUsage(context.TODO(), p)
+	require.NoError(t, err)
+	assert.Equal(t, []*types.QuotaUsage{
+		{Scope: types.QuotaAccount, Value: "123", Networks: 2, Addresses: big.NewInt(8192), MaxNetworks: 3},
+		{Scope: types.QuotaAccount, Value: "456", Networks: 2, Addresses: big.NewInt(512), MaxNetworks: 3},
+		{Scope: types.QuotaEnvironment, Value: "qa", Networks: 1, Addresses: big.NewInt(256), MaxAddresses: 1024},
+	}, usage)
+
+	usage, err = nm.QuotaUsage(context.TODO(), &types.Pool{Name: "open", SubnetIP: "10.0.0.0", SubnetMask: types.Int(16)})
+	require.NoError(t, err)
+	assert.Nil(t, usage)
+}
@@ -0,0 +1,235 @@
+package net
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// labelField prefixes the fields reading a label of the pool, as
+// labels.purpose.
+const labelField = "labels."
+
+// ruleFields are the facts rules may compare, numeric ones set to true.
+var ruleFields = map[string]bool{
+	"account":        false,
+	"environment":    false,
+	"provider":       false,
+	"region":         false,
+	"routingDomain":  false,
+	"pool":           false,
+	"poolID":         false,
+	"ipv6PoolID":     false,
+	"info":           false,
+	"layout":         false,
+	"strategy":       false,
+	"subnetSize":     true,
+	"ipv6SubnetSize": true,
+	"attachTGW":      false,
+	"privateSubnet":  false,
+	"publicSubnet":   false,
+	"reserved":       false,
+	"legacy":         false,
+}
+
+// RuleInput is what rules are evaluated against: a network request along
+// with the pool it is allocated from, its provider and its subnet layout.
+type RuleInput struct {
+	Request  *types.NetworkRequest
+	Pool     *types.Pool
+	Provider *types.Provider
+	Layout   *types.Layout
+}
+
+// CheckRule makes sure every condition of rule r compares a known fact, and
+// limits only numeric ones.
+func CheckRule(r *types.Rule) error {
+	for _, c := range slices.Concat(r.When, r.Require) {
+		if strings.HasPrefix(c.Field, labelField) {
+			if c.Operator == types.RuleMin || c.Operator == types.RuleMax {
+				return fmt.Errorf("rule %s: field %s is not numeric", r.Name, c.Field)
+			}
+			continue
+		}
+		numeric, ok := ruleFields[c.Field]
+		if !ok {
+			return fmt.Errorf("rule %s: unknown field %s", r.Name, c.Field)
+		}
+		if !numeric && (c.Operator == types.RuleMin || c.Operator == types.RuleMax) {
+			return fmt.Errorf("rule %s: field %s is not numeric", r.Name, c.Field)
+		}
+	}
+	return nil
+}
+
+// CheckRules evaluates rules for a network request, failing with the outcome
+// of each when any fails.
+func CheckRules(rules []*types.Rule, in RuleInput) error {
+	results := EvaluateRules(rules, in)
+	if !RulesPassed(results) {
+		return RuleViolationError{Results: results}
+	}
+	return nil
+}
+
+// EvaluateRules returns the outcome of every rule for a network request,
+// by rule name.
+func EvaluateRules(rules []*types.Rule, in RuleInput) []*types.RuleResult {
+	facts := in.facts()
+	results := []*types.RuleResult{}
+	for _, r := range rules {
+		results = append(results, evaluateRule(r, facts))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Rule < results[j].Rule
+	})
+	return results
+}
+
+// RulesPassed reports whether no rule failed.
+func RulesPassed(results []*types.RuleResult) bool {
+	for _, r := range results {
+		if r.Outcome == types.RuleFail {
+			return false
+		}
+	}
+	return true
+}
+
+func evaluateRule(r *types.Rule, facts func(string) string) *types.RuleResult {
+	for _, c := range r.When {
+		if reason := unmetCondition(c, facts); reason != "" {
+			return &types.RuleResult{Rule: r.Name, Outcome: types.RuleSkip, Reason: "when " + reason}
+		}
+	}
+
+	unmet := []string{}
+	for _, c := range r.Require {
+		if reason := unmetCondition(c, facts); reason != "" {
+			unmet = append(unmet, reason)
+		}
+	}
+	if len(unmet) > 0 {
+		return &types.RuleResult{Rule: r.Name, Outcome: types.RuleFail, Reason: strings.Join(unmet, "; ")}
+	}
+	return &types.RuleResult{Rule: r.Name, Outcome: types.RulePass}
+}
+
+// unmetCondition tells why condition c does not hold, or nothing when it
+// does.
+func unmetCondition(c *types.RuleCondition, facts func(string) string) string {
+	v := facts(c.Field)
+	switch c.Operator {
+	case types.RuleIn:
+		if !slices.Contains(c.Values, v) {
+			if v == "" {
+				return fmt.Sprintf("%s not set, not one of %v", c.Field, c.Values)
+			}
+			return fmt.Sprintf("%s %s not one of %v", c.Field, v, c.Values)
+		}
+	case types.RuleNotIn:
+		if slices.Contains(c.Values, v) {
+			return fmt.Sprintf("%s %s is one of %v", c.Field, v, c.Values)
+		}
+	case types.RulePresent:
+		if strings.TrimSpace(v) == "" {
+			return fmt.Sprintf("%s not set", c.Field)
+		}
+	case types.RuleMin, types.RuleMax:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Sprintf("%s not set", c.Field)
+		}
+		if c.Operator == types.RuleMin && n < c.Limit {
+			return fmt.Sprintf("%s %d below %d", c.Field, n, c.Limit)
+		}
+		if c.Operator == types.RuleMax && n > c.Limit {
+			return fmt.Sprintf("%s %d above %d", c.Field, n, c.Limit)
+		}
+	}
+	return ""
+}
+
+// facts returns the value of each field of the input, empty when unset.
+// Networks with subnet layouts or plans attach a TGW or have public and
+// private subnets when they hold a subnet of that type.
+func (in RuleInput) facts() func(string) string {
+	nr := in.Request
+	values := map[string]string{
+		"account":     nr.Account,
+		"environment": nr.Environment,
+		"provider":    nr.Provider,
+		"region":      nr.Region,
+		"poolID":      nr.PoolID,
+		"ipv6PoolID":  nr.IPv6PoolID,
+		"info":        nr.Info,
+		"layout":      nr.Layout,
+		"strategy":    string(nr.Strategy),
+		"reserved":    strconv.FormatBool(isTrue(nr.Reserved)),
+		"legacy":      strconv.FormatBool(isTrue(nr.Legacy)),
+	}
+	if in.Provider != nil {
+		values["provider"] = in.Provider.Name
+	}
+	if in.Pool != nil {
+		values["pool"] = in.Pool.Name
+		if in.Pool.ID != nil {
+			values["poolID"] = in.Pool.ID.String()
+		}
+		values["region"] = in.Pool.Region
+		values["routingDomain"] = in.Pool.RoutingDomain
+	}
+
+	if nr.SubnetSize != 0 {
+		values["subnetSize"] = strconv.Itoa(nr.SubnetSize)
+	} else if prefix, err := netip.ParsePrefix(nr.CIDR); err == nil {
+		values["subnetSize"] = strconv.Itoa(prefix.Bits())
+	}
+	if nr.IPv6SubnetSize != 0 {
+		values["ipv6SubnetSize"] = strconv.Itoa(nr.IPv6SubnetSize)
+	} else if prefix, err := netip.ParsePrefix(nr.IPv6CIDR); err == nil {
+		values["ipv6SubnetSize"] = strconv.Itoa(prefix.Bits())
+	}
+
+	subnetTypes := []types.SubnetType{}
+	if in.Layout != nil {
+		for _, t := range in.Layout.Tiers {
+			subnetTypes = append(subnetTypes, t.Type)
+		}
+	}
+	for _, s := range nr.Subnets {
+		subnetTypes = append(subnetTypes, s.Type)
+	}
+	flags := map[string]struct {
+		flag *bool
+		typ  types.SubnetType
+	}{
+		"attachTGW":     {nr.AttachTGW, types.TransitGateway},
+		"privateSubnet": {nr.PrivateSubnet, types.Private},
+		"publicSubnet":  {nr.PublicSubnet, types.Public},
+	}
+	for field, f := range flags {
+		has := isTrue(f.flag) || slices.Contains(subnetTypes, f.typ)
+		values[field] = strconv.FormatBool(has)
+	}
+
+	return func(field string) string {
+		if key, ok := strings.CutPrefix(field, labelField); ok {
+			if in.Pool == nil {
+				return ""
+			}
+			v, _ := in.Pool.Label(key)
+			return v
+		}
+		return values[field]
+	}
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
@@ -0,0 +1,140 @@
+package net
+
+import (
+	"testing"
+
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func organizationRules() []*types.Rule {
+	return []*types.Rule{
+		{
+			Name:    "prod-tgw",
+			When:    []*types.RuleCondition{{Field: "environment", Operator: types.RuleIn, Values: []string{"prod"}}},
+			Require: []*types.RuleCondition{{Field: "attachTGW", Operator: types.RuleIn, Values: []string{"true"}}},
+		},
+		{
+			Name:    "public-justified",
+			When:    []*types.RuleCondition{{Field: "publicSubnet", Operator: types.RuleIn, Values: []string{"true"}}},
+			Require: []*types.RuleCondition{{Field: "info", Operator: types.RulePresent}},
+		},
+		{
+			Name:    "qa-size",
+			When:    []*types.RuleCondition{{Field: "environment", Operator: types.RuleIn, Values: []string{"qa"}}},
+			Require: []*types.RuleCondition{{Field: "subnetSize", Operator: types.RuleMin, Limit: 20}},
+		},
+		{
+			Name:    "partner-pools",
+			When:    []*types.RuleCondition{{Field: "account", Operator: types.RuleIn, Values: []string{"999"}}},
+			Require: []*types.RuleCondition{{Field: "labels.purpose", Operator: types.RuleIn, Values: []string{"partner"}}},
+		},
+	}
+}
+
+func TestEvaluateRules(t *testing.T) {
+	shared := &types.Pool{ID: types.NewUUID(), Name: "shared", Region: "us-east-1", Labels: map[string]string{"purpose": "shared"}}
+	partner := &types.Pool{ID: types.NewUUID(), Name: "partner", Region: "us-east-1", Labels: map[string]string{"purpose": "partner"}}
+
+	tests := []struct {
+		name     string
+		input    RuleInput
+		expected []*types.RuleResult
+	}{
+		{
+			name: "every rule met",
+			input: RuleInput{
+				Request: &types.NetworkRequest{
+					Account: "999", Environment: "prod", SubnetSize: 22, Info: "customer facing API",
+					AttachTGW: types.Bool(true), PublicSubnet: types.Bool(true), PrivateSubnet: types.Bool(true),
+				},
+				Pool: partner,
+			},
+			expected: []*types.RuleResult{
+				{Rule: "partner-pools", Outcome: types.RulePass},
+				{Rule: "prod-tgw", Outcome: types.RulePass},
+				{Rule: "public-justified", Outcome: types.RulePass},
+				{Rule: "qa-size", Outcome: types.RuleSkip, Reason: "when environment prod not one of [qa]"},
+			},
+		},
+		{
+			name: "every rule failed",
+			input: RuleInput{
+				Request: &types.NetworkRequest{
+					Account: "999", Environment: "qa", SubnetSize: 18, Info: " ",
+					AttachTGW: types.Bool(false), PublicSubnet: types.Bool(true), PrivateSubnet: types.Bool(true),
+				},
+				Pool: shared,
+			},
+			expected: []*types.RuleResult{
+				{Rule: "partner-pools", Outcome: types.RuleFail, Reason: "labels.purpose shared not one of [partner]"},
+				{Rule: "prod-tgw", Outcome: types.RuleSkip, Reason: "when environment qa not one of [prod]"},
+				{Rule: "public-justified", Outcome: types.RuleFail, Reason: "info not set"},
+				{Rule: "qa-size", Outcome: types.RuleFail, Reason: "subnetSize 18 below 20"},
+			},
+		},
+		{
+			name: "layout with public and transit gateway tiers",
+			input: RuleInput{
+				Request: &types.NetworkRequest{Account: "123", Environment: "prod", SubnetSize: 20, Layout: "default"},
+				Pool:    shared,
+				Layout: &types.Layout{Name: "default", Tiers: []*types.LayoutTier{
+					{Name: "public", Type: types.Public},
+					{Name: "tgw", Type: types.TransitGateway},
+				}},
+			},
+			expected: []*types.RuleResult{
+				{Rule: "partner-pools", Outcome: types.RuleSkip, Reason: "when account 123 not one of [999]"},
+				{Rule: "prod-tgw", Outcome: types.RulePass},
+				{Rule: "public-justified", Outcome: types.RuleFail, Reason: "info not set"},
+				{Rule: "qa-size", Outcome: types.RuleSkip, Reason: "when environment prod not one of [qa]"},
+			},
+		},
+		{
+			name: "reserved network sized by its CIDR",
+			input: RuleInput{
+				Request: &types.NetworkRequest{Account: "123", Environment: "qa", Reserved: types.Bool(true), CIDR: "10.0.0.0/19"},
+			},
+			expected: []*types.RuleResult{
+				{Rule: "partner-pools", Outcome: types.RuleSkip, Reason: "when account 123 not one of [999]"},
+				{Rule: "prod-tgw", Outcome: types.RuleSkip, Reason: "when environment qa not one of [prod]"},
+				{Rule: "public-justified", Outcome: types.RuleSkip, Reason: "when publicSubnet false not one of [true]"},
+				{Rule: "qa-size", Outcome: types.RuleFail, Reason: "subnetSize 19 below 20"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, EvaluateRules(organizationRules(), tt.input))
+		})
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	err := CheckRules(organizationRules(), RuleInput{
+		Request: &types.NetworkRequest{Account: "123", Environment: "prod", SubnetSize: 20, AttachTGW: types.Bool(false)},
+	})
+	re := RuleViolationError{}
+	require.ErrorAs(t, err, &re)
+	assert.Len(t, re.Results, 4)
+	assert.EqualError(t, err, "network request fails rules: prod-tgw: attachTGW false not one of [true]")
+
+	assert.NoError(t, CheckRules(nil, RuleInput{Request: &types.NetworkRequest{}}))
+}
+
+func TestCheckRule(t *testing.T) {
+	for _, r := range organizationRules() {
+		assert.NoError(t, CheckRule(r))
+	}
+
+	err := CheckRule(&types.Rule{Name: "typo", Require: []*types.RuleCondition{
+		{Field: "enviroment", Operator: types.RuleIn, Values: []string{"prod"}},
+	}})
+	assert.EqualError(t, err, "rule typo: unknown field enviroment")
+
+	err = CheckRule(&types.Rule{Name: "size", When: []*types.RuleCondition{
+		{Field: "environment", Operator: types.RuleMax, Limit: 20},
+	}})
+	assert.EqualError(t, err, "rule size: field environment is not numeric")
+}
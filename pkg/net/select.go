@@ -0,0 +1,151 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// PoolSelector picks the pools of a region by their labels, for networks
+// created without a pool ID.
+type PoolSelector struct {
+	Region string
+	// Environment is the one of the network, pools labeled with another
+	// environment are left out.
+	Environment string
+	Labels      map[string]string
+	// Check, when set, turns down the pools the network may not use with
+	// a RuleViolationError.
+	Check func(p *types.Pool) error
+}
+
+func (s PoolSelector) String() string {
+	labels := make([]string, 0, len(s.Labels))
+	for k, v := range s.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+
+	desc := "region " + s.Region
+	if s.Environment != "" {
+		desc += ", environment " + s.Environment
+	}
+	if len(labels) > 0 {
+		desc += ", " + strings.Join(labels, ",")
+	}
+	return desc
+}
+
+// Select returns the IPv4 pools matching the selector, highest priority
+// first and by name among equals.
+func (s PoolSelector) Select(pools []*types.Pool) []*types.Pool {
+	selected := []*types.Pool{}
+	for _, p := range pools {
+		if p.Region != s.Region || p.IsIPv6() || !p.Matches(s.Labels) {
+			continue
+		}
+		if env, ok := p.Labels[types.EnvironmentLabel]; ok && s.Environment != "" && env != s.Environment {
+			continue
+		}
+		selected = append(selected, p)
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		if selected[i].Priority != selected[j].Priority {
+			return selected[i].Priority > selected[j].Priority
+		}
+		return selected[i].Name < selected[j].Name
+	})
+	return selected
+}
+
+// AllocateSelected allocates a network from the first pool matching the
+// selector with a free prefix of subnetSize for n, falling over to the next
+// pool when one is exhausted or its policy or the rules turn the network
+// down.
+func (nm *NetworkManager) AllocateSelected(ctx context.Context, s PoolSelector, n *types.Network, subnetSize int, strategy types.AllocationStrategy) (*types.Pool, netip.Prefix, *types.PoolSelection, error) {
+	pools, err := nm.DB.ScanPools(ctx)
+	if err != nil {
+		return nil, netip.Prefix{}, nil, err
+	}
+
+	skipped := []*types.SkippedPool{}
+	var last error
+	for _, p := range s.Select(pools) {
+		err := s.check(p)
+		if errors.As(err, &RuleViolationError{}) {
+			skipped = append(skipped, skippedPool(p, err))
+			last = err
+			continue
+		}
+		if err != nil {
+			return nil, netip.Prefix{}, nil, err
+		}
+
+		prefix, err := nm.AllocateNetwork(ctx, p.ID.String(), n, subnetSize, strategy)
+		if errors.As(err, &PoolExhaustedError{}) || errors.As(err, &PolicyViolationError{}) {
+			skipped = append(skipped, skippedPool(p, err))
+			last = err
+			continue
+		}
+		if err != nil {
+			return nil, netip.Prefix{}, nil, err
+		}
+		return p, prefix, &types.PoolSelection{
+			PoolID:  p.ID.String(),
+			Pool:    p.Name,
+			Reason:  fmt.Sprintf("priority %d pool matching %s with a free /%d", p.Priority, s, subnetSize),
+			Skipped: skipped,
+		}, nil
+	}
+	return nil, netip.Prefix{}, nil, NoPoolMatchError{Selector: s, Skipped: skipped, Err: last}
+}
+
+// SelectPoolOf returns the first pool matching the selector whose range holds
+// network and the rules let the network use, for reserved and legacy
+// networks.
+func (nm *NetworkManager) SelectPoolOf(ctx context.Context, s PoolSelector, network netip.Prefix) (*types.Pool, *types.PoolSelection, error) {
+	pools, err := nm.DB.ScanPools(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	skipped := []*types.SkippedPool{}
+	var last error
+	for _, p := range s.Select(pools) {
+		err := CheckInPool(p, network)
+		if err == nil {
+			err = s.check(p)
+		}
+		if errors.As(err, &RuleViolationError{}) || errors.As(err, &NetworkNotInPoolError{}) {
+			skipped = append(skipped, skippedPool(p, err))
+			last = err
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return p, &types.PoolSelection{
+			PoolID:  p.ID.String(),
+			Pool:    p.Name,
+			Reason:  fmt.Sprintf("priority %d pool matching %s holding %s", p.Priority, s, network.String()),
+			Skipped: skipped,
+		}, nil
+	}
+	return nil, nil, NoPoolMatchError{Selector: s, Skipped: skipped, Err: last}
+}
+
+func (s PoolSelector) check(p *types.Pool) error {
+	if s.Check == nil {
+		return nil
+	}
+	return s.Check(p)
+}
+
+func skippedPool(p *types.Pool, err error) *types.SkippedPool {
+	return &types.SkippedPool{PoolID: p.ID.String(), Pool: p.Name, Reason: err.Error()}
+}
@@ -0,0 +1,209 @@
+package net
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/olxbr/network-api/pkg/db/fake"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func selectionPools() []*types.Pool {
+	return []*types.Pool{
+		{
+			ID: types.NewUUID(), Name: "shared-small", Region: "us-east-1", SubnetIP: "10.0.0.0", SubnetMask: types.Int(24),
+			Labels: map[string]string{"purpose": "shared"}, Priority: 10,
+		},
+		{
+			ID: types.NewUUID(), Name: "shared-large", Region: "us-east-1", SubnetIP: "10.1.0.0", SubnetMask: types.Int(16),
+			Labels: map[string]string{"purpose": "shared"}, Priority: 5,
+		},
+		{
+			ID: types.NewUUID(), Name: "shared-dev", Region: "us-east-1", SubnetIP: "10.2.0.0", SubnetMask: types.Int(16),
+			Labels: map[string]string{"purpose": "shared", "environment": "dev"}, Priority: 20,
+		},
+		{
+			ID: types.NewUUID(), Name: "edge", Region: "us-east-1", SubnetIP: "10.3.0.0", SubnetMask: types.Int(16),
+			Labels: map[string]string{"purpose": "edge"}, Priority: 30,
+		},
+		{
+			ID: types.NewUUID(), Name: "shared-west", Region: "us-west-2", SubnetIP: "10.4.0.0", SubnetMask: types.Int(16),
+			Labels: map[string]string{"purpose": "shared"}, Priority: 30,
+		},
+		{
+			ID: types.NewUUID(), Name: "shared-v6", Region: "us-east-1", SubnetIP: "2600:1f18::", SubnetMask: types.Int(40),
+			Labels: map[string]string{"purpose": "shared"}, Priority: 30,
+		},
+		{
+			ID: types.NewUUID(), Name: "shared-sandbox", Region: "us-east-1", SubnetIP: "10.0.0.0", SubnetMask: types.Int(16),
+			Labels: map[string]string{"purpose": "shared"}, RoutingDomain: "sandbox",
+		},
+	}
+}
+
+func poolNames(pools []*types.Pool) []string {
+	names := []string{}
+	for _, p := range pools {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestPoolSelectorSelect(t *testing.T) {
+	pools := selectionPools()
+
+	tests := []struct {
+		name     string
+		selector PoolSelector
+		expected []string
+	}{
+		{
+			name:     "by priority",
+			selector: PoolSelector{Region: "us-east-1", Environment: "prod", Labels: map[string]string{"purpose": "shared"}},
+			expected: []string{"shared-small", "shared-large", "shared-sandbox"},
+		},
+		{
+			name:     "pools of the environment",
+			selector: PoolSelector{Region: "us-east-1", Environment: "dev", Labels: map[string]string{"purpose": "shared"}},
+			expected: []string{"shared-dev", "shared-small", "shared-large", "shared-sandbox"},
+		},
+		{
+			name:     "routing domain",
+			selector: PoolSelector{Region: "us-east-1", Labels: map[string]string{"routingDomain": "sandbox"}},
+			expected: []string{"shared-sandbox"},
+		},
+		{
+			name:     "no match",
+			selector: PoolSelector{Region: "eu-west-1"},
+			expected: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, poolNames(tt.selector.Select(pools)))
+		})
+	}
+}
+
+func TestAllocateSelected(t *testing.T) {
+	pools := selectionPools()
+	network := &types.Network{ID: types.NewUUID(), Account: "123", Environment: "prod"}
+	s := PoolSelector{Region: "us-east-1", Environment: "prod", Labels: map[string]string{"purpose": "shared", "routingDomain": ""}}
+
+	d := &fake.Database{}
+	d.On("ScanPools", mock.Anything).Return(pools, nil)
+	d.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+		{CIDR: "10.0.0.0/24"},
+	}, nil)
+	d.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+		{CIDR: "10.0.0.0/24"},
+	}, nil)
+	for _, p := range pools {
+		d.On("GetPool", mock.Anything, p.ID.String()).Return(p, nil)
+	}
+	d.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+	d.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	nm := New(d)
+
+	// the first pool is full, the network falls over to the next one
+	p, n, selection, err := nm.AllocateSelected(context.TODO(), s, network, 24, "")
+	require.NoError(t, err)
+	assert.Equal(t, "shared-large", p.Name)
+	assert.Equal(t, "10.1.0.0/24", n.String())
+	assert.Equal(t, "shared-large", selection.Pool)
+	assert.Equal(t, "priority 5 pool matching region us-east-1, environment prod, purpose=shared,routingDomain= with a free /24", selection.Reason)
+	require.Len(t, selection.Skipped, 1)
+	assert.Equal(t, "shared-small", selection.Skipped[0].Pool)
+	assert.Equal(t, "no more networks available: no free /24 in pool range 10.0.0.0-10.0.0.255", selection.Skipped[0].Reason)
+
+	// every matching pool is exhausted
+	_, _, _, err = nm.AllocateSelected(context.TODO(), s, network, 15, "")
+	assert.ErrorAs(t, err, &PoolExhaustedError{})
+	assert.EqualError(t, err, "no pool matching region us-east-1, environment prod, purpose=shared,routingDomain= can take the network: "+
+		"pool shared-small: no more networks available: no free /15 in pool range 10.0.0.0-10.0.0.255; "+
+		"pool shared-large: no more networks available: no free /15 in pool range 10.1.0.0-10.1.255.255")
+
+	_, _, _, err = nm.AllocateSelected(context.TODO(), PoolSelector{Region: "eu-west-1"}, network, 24, "")
+	assert.ErrorAs(t, err, &NoPoolMatchError{})
+	assert.EqualError(t, err, "no pool matches region eu-west-1")
+}
+
+func TestSelectPoolOf(t *testing.T) {
+	d := &fake.Database{}
+	d.On("ScanPools", mock.Anything).Return(selectionPools(), nil)
+	nm := New(d)
+	s := PoolSelector{Region: "us-east-1", Environment: "prod", Labels: map[string]string{"purpose": "shared", "routingDomain": ""}}
+
+	p, selection, err := nm.SelectPoolOf(context.TODO(), s, netip.MustParsePrefix("10.1.4.0/24"))
+	require.NoError(t, err)
+	assert.Equal(t, "shared-large", p.Name)
+	assert.Equal(t, "priority 5 pool matching region us-east-1, environment prod, purpose=shared,routingDomain= holding 10.1.4.0/24", selection.Reason)
+	require.Len(t, selection.Skipped, 1)
+
+	_, _, err = nm.SelectPoolOf(context.TODO(), s, netip.MustParsePrefix("10.9.0.0/24"))
+	assert.ErrorAs(t, err, &NetworkNotInPoolError{})
+}
+
+func TestAllocateSelectedSkipsPolicy(t *testing.T) {
+	pools := selectionPools()
+	network := &types.Network{ID: types.NewUUID(), Account: "123", Environment: "prod"}
+	pools[0].Policy = &types.PoolPolicy{Environments: []string{"dev"}}
+	s := PoolSelector{Region: "us-east-1", Environment: "prod", Labels: map[string]string{"purpose": "shared", "routingDomain": ""}}
+
+	d := &fake.Database{}
+	d.On("ScanPools", mock.Anything).Return(pools, nil)
+	d.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+	d.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+	for _, p := range pools {
+		d.On("GetPool", mock.Anything, p.ID.String()).Return(p, nil)
+	}
+	d.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+	d.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	nm := New(d)
+
+	p, _, selection, err := nm.AllocateSelected(context.TODO(), s, network, 24, "")
+	require.NoError(t, err)
+	assert.Equal(t, "shared-large", p.Name)
+	require.Len(t, selection.Skipped, 1)
+	assert.Equal(t, "network violates the policy of pool shared-small: environment prod not one of [dev]", selection.Skipped[0].Reason)
+	d.AssertNumberOfCalls(t, "ReserveNetwork", 1)
+}
+
+func TestAllocateSelectedSkipsRules(t *testing.T) {
+	pools := selectionPools()
+	network := &types.Network{ID: types.NewUUID(), Account: "123", Environment: "prod"}
+	s := PoolSelector{Region: "us-east-1", Environment: "prod", Labels: map[string]string{"purpose": "shared", "routingDomain": ""}}
+	s.Check = func(p *types.Pool) error {
+		return CheckRules([]*types.Rule{{
+			Name:    "large-pools",
+			Require: []*types.RuleCondition{{Field: "pool", Operator: types.RuleNotIn, Values: []string{"shared-small"}}},
+		}}, RuleInput{Request: &types.NetworkRequest{}, Pool: p})
+	}
+
+	d := &fake.Database{}
+	d.On("ScanPools", mock.Anything).Return(pools, nil)
+	d.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+	d.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+	d.On("GetPool", mock.Anything, pools[1].ID.String()).Return(pools[1], nil)
+	d.On("DomainVersion", mock.Anything, mock.Anything).Return(0, nil)
+	d.On("ReserveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	nm := New(d)
+
+	p, _, selection, err := nm.AllocateSelected(context.TODO(), s, network, 24, "")
+	require.NoError(t, err)
+	assert.Equal(t, "shared-large", p.Name)
+	require.Len(t, selection.Skipped, 1)
+	assert.Equal(t, "network request fails rules: large-pools: pool shared-small is one of [shared-small]", selection.Skipped[0].Reason)
+
+	// any routing domain, the rules turn down the first pool holding the CIDR
+	s.Labels = map[string]string{"purpose": "shared"}
+	p, selection, err = nm.SelectPoolOf(context.TODO(), s, netip.MustParsePrefix("10.0.0.0/24"))
+	require.NoError(t, err)
+	assert.Equal(t, "shared-sandbox", p.Name)
+	require.Len(t, selection.Skipped, 2)
+	assert.Equal(t, "network request fails rules: large-pools: pool shared-small is one of [shared-small]", selection.Skipped[0].Reason)
+}
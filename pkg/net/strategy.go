@@ -0,0 +1,93 @@
+package net
+
+import (
+	"fmt"
+	"net/netip"
+
+	"go4.org/netipx"
+
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// allocator picks a prefix of the given size out of the free space of a pool.
+// free.Prefixes() yields the largest aligned blocks in address order, and any
+// aligned block that fits in a free range sits inside one of them, so every
+// strategy only has to choose which of those blocks to carve from.
+type allocator func(free []netip.Prefix, bits int) (netip.Prefix, bool)
+
+var allocators = map[types.AllocationStrategy]allocator{
+	types.FirstFit: firstFit,
+	types.BestFit:  bestFit,
+	types.Sparse:   sparse,
+}
+
+func firstFit(free []netip.Prefix, bits int) (netip.Prefix, bool) {
+	for _, f := range free {
+		if f.Bits() <= bits {
+			return netip.PrefixFrom(f.Addr(), bits), true
+		}
+	}
+	return netip.Prefix{}, false
+}
+
+func bestFit(free []netip.Prefix, bits int) (netip.Prefix, bool) {
+	var best netip.Prefix
+	for _, f := range free {
+		if f.Bits() <= bits && (!best.IsValid() || f.Bits() > best.Bits()) {
+			best = f
+		}
+	}
+	if !best.IsValid() {
+		return netip.Prefix{}, false
+	}
+	return netip.PrefixFrom(best.Addr(), bits), true
+}
+
+func sparse(free []netip.Prefix, bits int) (netip.Prefix, bool) {
+	var largest netip.Prefix
+	for _, f := range free {
+		if f.Bits() <= bits && (!largest.IsValid() || f.Bits() < largest.Bits()) {
+			largest = f
+		}
+	}
+	if !largest.IsValid() {
+		return netip.Prefix{}, false
+	}
+	return netip.PrefixFrom(largest.Addr(), bits), true
+}
+
+// resolveStrategy returns the strategy requested for an allocation, falling
+// back to the pool default and then to first-fit.
+func resolveStrategy(p *types.Pool, requested types.AllocationStrategy) (allocator, error) {
+	s := requested
+	if s == "" {
+		s = p.Strategy
+	}
+	if s == "" {
+		s = types.FirstFit
+	}
+
+	a, ok := allocators[s]
+	if !ok {
+		return nil, fmt.Errorf("unknown allocation strategy: %s", s)
+	}
+	return a, nil
+}
+
+// freeSet returns the addresses of the pool not taken by anything in used,
+// excluded from it nor delegated to its child pools.
+func freeSet(p *types.Pool, pools []*types.Pool, used *netipx.IPSet) (*netipx.IPSet, error) {
+	b := &netipx.IPSetBuilder{}
+	b.AddRange(p.Range())
+	b.RemoveSet(used)
+	b.RemoveSet(p.ExcludedSet())
+	for _, c := range Children(p, pools) {
+		b.RemoveRange(c.Range())
+	}
+
+	free, err := b.IPSet()
+	if err != nil {
+		return nil, fmt.Errorf("error building ipset: %+v", err)
+	}
+	return free, nil
+}
@@ -1,15 +1,31 @@
 package net
 
 import (
+	"encoding/binary"
 	"fmt"
 	"log"
+	"math/bits"
 	"net"
+	"net/netip"
+	"sort"
+	"strings"
 
 	"github.com/apparentlymart/go-cidr/cidr"
 	"github.com/olxbr/network-api/pkg/types"
 )
 
+// ipv6SubnetSize is the prefix length of every IPv6 subnet, as AWS only
+// accepts /64 subnet blocks.
+const ipv6SubnetSize = 64
+
+// maxSubnetSize is the smallest IPv4 subnet AWS accepts.
+const maxSubnetSize = 28
+
 func GenerateSubnets(n *types.Network) ([]*types.Subnet, error) {
+	if n.Layout != nil {
+		return generateLayoutSubnets(n, n.Layout)
+	}
+
 	snets := []*types.Subnet{}
 
 	_, baseCIDR, err := net.ParseCIDR(n.CIDR)
@@ -21,14 +37,12 @@ func GenerateSubnets(n *types.Network) ([]*types.Subnet, error) {
 
 	ones, _ := baseCIDR.Mask.Size()
 	subnetSize := ones + 2
-	log.Printf("subnetSize: %d", subnetSize)
 
 	for i := 0; i < len(azSubnets); i++ {
 		newSubnet, err := cidr.Subnet(baseCIDR, 2, i)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create subnet: %w", err)
 		}
-		fmt.Printf("%s\n", newSubnet.String())
 		azSubnets[i] = newSubnet
 	}
 
@@ -40,9 +54,10 @@ func GenerateSubnets(n *types.Network) ([]*types.Subnet, error) {
 				log.Printf("failed to create subnet: %s", err)
 			}
 			snets = append(snets, &types.Subnet{
-				Name: fmt.Sprintf("private%02d", i+1),
-				Type: types.Private,
-				CIDR: snet.String(),
+				Name:    fmt.Sprintf("private%02d", i+1),
+				Type:    types.Private,
+				CIDR:    snet.String(),
+				AZIndex: i,
 			})
 			azSubnets[i], _ = cidr.NextSubnet(snet, subnetSize)
 		}
@@ -56,27 +71,281 @@ func GenerateSubnets(n *types.Network) ([]*types.Subnet, error) {
 				log.Printf("failed to create subnet: %s", err)
 			}
 			snets = append(snets, &types.Subnet{
-				Name: fmt.Sprintf("public%02d", i+1),
-				Type: types.Public,
-				CIDR: snet.String(),
+				Name:    fmt.Sprintf("public%02d", i+1),
+				Type:    types.Public,
+				CIDR:    snet.String(),
+				AZIndex: i,
 			})
 			azSubnets[i], _ = cidr.NextSubnet(snet, subnetSize)
 		}
 	}
 
 	if n.AttachTGW {
-		log.Printf("subnet size: %d\n", subnetSize)
 		if subnetSize > 28 {
 			return nil, fmt.Errorf("cannot attach TGW to subnet with size < 28: increase base CIDR range")
 		}
 		for i := 0; i < len(azSubnets)-1; i++ {
 			snet, _ := cidr.Subnet(azSubnets[i], 28-subnetSize, 0)
 			snets = append(snets, &types.Subnet{
-				Name: fmt.Sprintf("tgw%02d", i+1),
-				Type: types.TransitGateway,
-				CIDR: snet.String(),
+				Name:    fmt.Sprintf("tgw%02d", i+1),
+				Type:    types.TransitGateway,
+				CIDR:    snet.String(),
+				AZIndex: i,
 			})
 		}
 	}
+
+	// smaller networks run out of room for the default shape
+	for _, s := range snets {
+		if netip.MustParsePrefix(s.CIDR).Bits() > maxSubnetSize {
+			return nil, fmt.Errorf("default subnets of network %s are smaller than a /%d: increase base CIDR range or give a layout or subnets", n.CIDR, maxSubnetSize)
+		}
+	}
+
+	if n.IPv6CIDR != "" {
+		err := assignIPv6Subnets(n.IPv6CIDR, snets)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return snets, nil
+}
+
+// NetworkSubnets returns the subnets stored with the network. Networks
+// without stored subnets get them generated, along with the IDs the provider
+// reported for them.
+func NetworkSubnets(n *types.Network) ([]*types.Subnet, error) {
+	if len(n.Subnets) > 0 {
+		return n.Subnets, nil
+	}
+
+	snets, err := GenerateSubnets(n)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range snets {
+		s.ID = n.SubnetIDs[s.CIDR]
+	}
+	return snets, nil
+}
+
+// PlanSubnets checks an explicit subnet plan against the network and returns
+// its subnets. CIDRs written from 0.0.0.0 are offsets into the network.
+// Subnets of each type take the availability zones in order, as generated
+// subnets do.
+func PlanSubnets(n *types.Network, plan []*types.SubnetRequest) ([]*types.Subnet, error) {
+	base, err := netip.ParsePrefix(n.CIDR)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base CIDR: %w", err)
+	}
+	offsets := netip.PrefixFrom(netip.IPv4Unspecified(), base.Bits())
+
+	snets := []*types.Subnet{}
+	zones := map[types.SubnetType]int{}
+	for _, sr := range plan {
+		p, err := netip.ParsePrefix(sr.CIDR)
+		if err != nil {
+			return nil, fmt.Errorf("subnet %s: invalid CIDR: %w", sr.Name, err)
+		}
+		if p.Masked() != p {
+			return nil, fmt.Errorf("subnet %s: %s is not a network address", sr.Name, sr.CIDR)
+		}
+		if p.Bits() > maxSubnetSize {
+			return nil, fmt.Errorf("subnet %s: %s is smaller than a /%d", sr.Name, sr.CIDR, maxSubnetSize)
+		}
+		if p.Bits() < base.Bits() || !base.Contains(p.Addr()) && !offsets.Contains(p.Addr()) {
+			return nil, fmt.Errorf("subnet %s: %s is outside network %s", sr.Name, sr.CIDR, n.CIDR)
+		}
+		if !base.Contains(p.Addr()) {
+			p = netip.PrefixFrom(offsetAddr(base.Addr(), p.Addr()), p.Bits())
+		}
+
+		for _, s := range snets {
+			if netip.MustParsePrefix(s.CIDR).Overlaps(p) {
+				return nil, fmt.Errorf("subnet %s: %s overlaps subnet %s (%s)", sr.Name, p, s.Name, s.CIDR)
+			}
+		}
+
+		snets = append(snets, &types.Subnet{
+			Name:    sr.Name,
+			Type:    sr.Type,
+			CIDR:    p.String(),
+			AZIndex: zones[sr.Type],
+		})
+		zones[sr.Type]++
+	}
+
+	if n.IPv6CIDR != "" {
+		err := assignIPv6Subnets(n.IPv6CIDR, snets)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return snets, nil
 }
+
+// offsetAddr adds the IPv4 address offset, written from 0.0.0.0, to base.
+func offsetAddr(base, offset netip.Addr) netip.Addr {
+	b, o := base.As4(), offset.As4()
+	sum := binary.BigEndian.Uint32(b[:]) + binary.BigEndian.Uint32(o[:])
+	var a [4]byte
+	binary.BigEndian.PutUint32(a[:], sum)
+	return netip.AddrFrom4(a)
+}
+
+// generateLayoutSubnets splits the network in zones, the smallest power of
+// two holding the layout AZ count, and lays the tiers out in each zone from
+// the largest to the smallest so every subnet stays aligned. Subnets are
+// returned tier by tier, zone by zone.
+func generateLayoutSubnets(n *types.Network, l *types.Layout) ([]*types.Subnet, error) {
+	_, baseCIDR, err := net.ParseCIDR(n.CIDR)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base CIDR: %w", err)
+	}
+	if l.AZCount < 1 {
+		return nil, fmt.Errorf("layout %s has no availability zones", l.Name)
+	}
+
+	zoneBits := bits.Len(uint(l.AZCount - 1))
+	ones, _ := baseCIDR.Mask.Size()
+	zoneSize := ones + zoneBits
+
+	sizes, err := tierSizes(l, zoneSize)
+	if err != nil {
+		return nil, err
+	}
+	order := make([]int, len(l.Tiers))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return sizes[order[i]] < sizes[order[j]]
+	})
+
+	blocks := make([][]*net.IPNet, len(l.Tiers))
+	for az := 0; az < l.AZCount; az++ {
+		zone, err := cidr.Subnet(baseCIDR, zoneBits, az)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zone: %w", err)
+		}
+
+		var prev *net.IPNet
+		for _, t := range order {
+			var snet *net.IPNet
+			if prev == nil {
+				snet, err = cidr.Subnet(zone, sizes[t]-zoneSize, 0)
+			} else {
+				var exceeded bool
+				snet, exceeded = cidr.NextSubnet(prev, sizes[t])
+				if exceeded {
+					err = fmt.Errorf("address space exhausted")
+				}
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to create subnet: %w", err)
+			}
+			if _, last := cidr.AddressRange(snet); !zone.Contains(last) {
+				return nil, fmt.Errorf("layout %s does not fit in network %s: increase base CIDR range", l.Name, n.CIDR)
+			}
+			blocks[t] = append(blocks[t], snet)
+			prev = snet
+		}
+	}
+
+	snets := []*types.Subnet{}
+	for t, tier := range l.Tiers {
+		for az, snet := range blocks[t] {
+			snets = append(snets, &types.Subnet{
+				Name:    subnetName(tier, az),
+				Type:    tier.Type,
+				Tier:    tier.Name,
+				CIDR:    snet.String(),
+				AZIndex: az,
+			})
+		}
+	}
+
+	if n.IPv6CIDR != "" {
+		err := assignIPv6Subnets(n.IPv6CIDR, snets)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return snets, nil
+}
+
+// tierSizes returns the prefix length of each tier in a zone of zoneSize.
+// Weighted tiers take the largest power of two not above their share, halved
+// until the tiers of fixed size fit beside them.
+func tierSizes(l *types.Layout, zoneSize int) ([]int, error) {
+	total := 0
+	for _, t := range l.Tiers {
+		if t.PrefixLength == 0 && t.Weight <= 0 {
+			return nil, fmt.Errorf("tier %s of layout %s has neither a prefix length nor a weight", t.Name, l.Name)
+		}
+		total += t.Weight
+	}
+
+	sizes := make([]int, len(l.Tiers))
+	for shift := 0; ; shift++ {
+		// blocks aligned from the largest fit when their shares add up to the zone
+		used := 0
+		for i, t := range l.Tiers {
+			size := t.PrefixLength
+			if size == 0 {
+				size = zoneSize + shift
+				for t.Weight<<(size-zoneSize-shift) < total {
+					size++
+				}
+			}
+			if size < zoneSize {
+				return nil, fmt.Errorf("tier %s of layout %s needs a /%d, larger than the /%d of each zone", t.Name, l.Name, size, zoneSize)
+			}
+			if size > maxSubnetSize {
+				return nil, fmt.Errorf("tier %s of layout %s is smaller than a /%d: increase base CIDR range", t.Name, l.Name, maxSubnetSize)
+			}
+			sizes[i] = size
+			used += 1 << (maxSubnetSize - size)
+		}
+		// without weighted tiers placement reports the overflow
+		if total == 0 || used <= 1<<(maxSubnetSize-zoneSize) {
+			return sizes, nil
+		}
+	}
+}
+
+// subnetName fills the tier name pattern for the zone at index az.
+func subnetName(t *types.LayoutTier, az int) string {
+	pattern := t.NamePattern
+	if pattern == "" {
+		pattern = "{tier}{az}"
+	}
+	return strings.NewReplacer(
+		"{tier}", t.Name,
+		"{az}", fmt.Sprintf("%02d", az+1),
+		"{zone}", string(rune('a'+az)),
+	).Replace(pattern)
+}
+
+// assignIPv6Subnets gives every subnet its own /64 out of the network IPv6
+// block, in the order the subnets were generated.
+func assignIPv6Subnets(ipv6CIDR string, snets []*types.Subnet) error {
+	_, baseCIDR, err := net.ParseCIDR(ipv6CIDR)
+	if err != nil {
+		return fmt.Errorf("invalid base IPv6 CIDR: %w", err)
+	}
+
+	ones, _ := baseCIDR.Mask.Size()
+	if ones > ipv6SubnetSize {
+		return fmt.Errorf("IPv6 CIDR %s is smaller than a /%d subnet", ipv6CIDR, ipv6SubnetSize)
+	}
+
+	for i, s := range snets {
+		snet, err := cidr.Subnet(baseCIDR, ipv6SubnetSize-ones, i)
+		if err != nil {
+			return fmt.Errorf("failed to create IPv6 subnet: %w", err)
+		}
+		s.IPv6CIDR = snet.String()
+	}
+	return nil
+}
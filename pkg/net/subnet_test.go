@@ -0,0 +1,244 @@
+package net
+
+import (
+	"testing"
+
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateLayoutSubnets(t *testing.T) {
+	type subnet struct{ name, cidr string }
+	generated := func(snets []*types.Subnet) []subnet {
+		s := []subnet{}
+		for _, sn := range snets {
+			s = append(s, subnet{sn.Name, sn.CIDR})
+		}
+		return s
+	}
+
+	tests := []struct {
+		name     string
+		network  *types.Network
+		expected []subnet
+		err      string
+	}{
+		{
+			name: "default shape",
+			network: &types.Network{
+				CIDR: "10.1.0.0/16",
+				Layout: &types.Layout{
+					Name:    "default",
+					AZCount: 3,
+					Tiers: []*types.LayoutTier{
+						{Name: "private", Type: types.Private, Weight: 2},
+						{Name: "public", Type: types.Public, Weight: 1},
+						{Name: "tgw", Type: types.TransitGateway, PrefixLength: 28},
+					},
+				},
+			},
+			expected: []subnet{
+				{"private01", "10.1.0.0/19"}, {"private02", "10.1.64.0/19"}, {"private03", "10.1.128.0/19"},
+				{"public01", "10.1.32.0/20"}, {"public02", "10.1.96.0/20"}, {"public03", "10.1.160.0/20"},
+				{"tgw01", "10.1.48.0/28"}, {"tgw02", "10.1.112.0/28"}, {"tgw03", "10.1.176.0/28"},
+			},
+		},
+		{
+			name: "two zones, private heavy",
+			network: &types.Network{
+				CIDR: "10.2.0.0/20",
+				Layout: &types.Layout{
+					Name:    "private-heavy",
+					AZCount: 2,
+					Tiers: []*types.LayoutTier{
+						{Name: "private", Type: types.Private, Weight: 3},
+						{Name: "public", Type: types.Public, Weight: 1},
+					},
+				},
+			},
+			expected: []subnet{
+				{"private01", "10.2.0.0/22"}, {"private02", "10.2.8.0/22"},
+				{"public01", "10.2.4.0/23"}, {"public02", "10.2.12.0/23"},
+			},
+		},
+		{
+			name: "six zones with a database tier",
+			network: &types.Network{
+				CIDR: "10.3.0.0/16",
+				Layout: &types.Layout{
+					Name:    "database",
+					AZCount: 6,
+					Tiers: []*types.LayoutTier{
+						{Name: "app", Type: types.Private, Weight: 2, NamePattern: "{tier}-{zone}"},
+						{Name: "data", Type: types.Isolated, Weight: 1, NamePattern: "{tier}-{zone}"},
+					},
+				},
+			},
+			expected: []subnet{
+				{"app-a", "10.3.0.0/20"}, {"app-b", "10.3.32.0/20"}, {"app-c", "10.3.64.0/20"},
+				{"app-d", "10.3.96.0/20"}, {"app-e", "10.3.128.0/20"}, {"app-f", "10.3.160.0/20"},
+				{"data-a", "10.3.16.0/21"}, {"data-b", "10.3.48.0/21"}, {"data-c", "10.3.80.0/21"},
+				{"data-d", "10.3.112.0/21"}, {"data-e", "10.3.144.0/21"}, {"data-f", "10.3.176.0/21"},
+			},
+		},
+		{
+			name: "tiers over the zone",
+			network: &types.Network{
+				CIDR: "10.4.0.0/24",
+				Layout: &types.Layout{
+					Name:    "full",
+					AZCount: 3,
+					Tiers: []*types.LayoutTier{
+						{Name: "private", Type: types.Private, PrefixLength: 26},
+						{Name: "tgw", Type: types.TransitGateway, PrefixLength: 28},
+					},
+				},
+			},
+			err: "layout full does not fit in network 10.4.0.0/24: increase base CIDR range",
+		},
+		{
+			name: "tier below a /28",
+			network: &types.Network{
+				CIDR: "10.5.0.0/24",
+				Layout: &types.Layout{
+					Name:    "tiny",
+					AZCount: 6,
+					Tiers: []*types.LayoutTier{
+						{Name: "a", Type: types.Private, Weight: 1},
+						{Name: "b", Type: types.Private, Weight: 1},
+						{Name: "c", Type: types.Private, Weight: 1},
+					},
+				},
+			},
+			err: "tier a of layout tiny is smaller than a /28: increase base CIDR range",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snets, err := GenerateSubnets(tt.network)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, generated(snets))
+			for _, s := range snets {
+				assert.Equal(t, tt.network.Layout.Tiers[0].Type == s.Type, s.Tier == tt.network.Layout.Tiers[0].Name)
+			}
+		})
+	}
+}
+
+func TestGenerateSubnetsTooSmall(t *testing.T) {
+	_, err := GenerateSubnets(&types.Network{CIDR: "10.3.0.0/26", PrivateSubnet: true, PublicSubnet: true})
+	assert.EqualError(t, err, "default subnets of network 10.3.0.0/26 are smaller than a /28: increase base CIDR range or give a layout or subnets")
+}
+
+func TestGenerateLayoutSubnetsIPv6(t *testing.T) {
+	snets, err := GenerateSubnets(&types.Network{
+		CIDR:     "10.2.0.0/20",
+		IPv6CIDR: "2600:1f18:1000:100::/56",
+		Layout: &types.Layout{
+			Name:    "single",
+			AZCount: 1,
+			Tiers: []*types.LayoutTier{
+				{Name: "private", Type: types.Private, Weight: 1},
+				{Name: "public", Type: types.Public, PrefixLength: 24},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, snets, 2)
+	assert.Equal(t, "10.2.0.0/21", snets[0].CIDR)
+	assert.Equal(t, "2600:1f18:1000:100::/64", snets[0].IPv6CIDR)
+	assert.Equal(t, "10.2.8.0/24", snets[1].CIDR)
+	assert.Equal(t, "2600:1f18:1000:101::/64", snets[1].IPv6CIDR)
+}
+
+func TestPlanSubnets(t *testing.T) {
+	tests := []struct {
+		name     string
+		network  *types.Network
+		plan     []*types.SubnetRequest
+		expected []*types.Subnet
+		err      string
+	}{
+		{
+			name:    "absolute and offset CIDRs",
+			network: &types.Network{CIDR: "10.1.0.0/16"},
+			plan: []*types.SubnetRequest{
+				{Name: "private01", Type: types.Private, CIDR: "10.1.0.0/17"},
+				{Name: "public01", Type: types.Public, CIDR: "0.0.128.0/26"},
+				{Name: "pods01", Type: types.Private, CIDR: "0.0.192.0/18"},
+			},
+			expected: []*types.Subnet{
+				{Name: "private01", Type: types.Private, CIDR: "10.1.0.0/17", AZIndex: 0},
+				{Name: "public01", Type: types.Public, CIDR: "10.1.128.0/26", AZIndex: 0},
+				{Name: "pods01", Type: types.Private, CIDR: "10.1.192.0/18", AZIndex: 1},
+			},
+		},
+		{
+			name:    "dual stack",
+			network: &types.Network{CIDR: "10.1.0.0/16", IPv6CIDR: "2600:1f18:1000:100::/56"},
+			plan: []*types.SubnetRequest{
+				{Name: "private01", Type: types.Private, CIDR: "10.1.0.0/17"},
+				{Name: "public01", Type: types.Public, CIDR: "10.1.128.0/17"},
+			},
+			expected: []*types.Subnet{
+				{Name: "private01", Type: types.Private, CIDR: "10.1.0.0/17", IPv6CIDR: "2600:1f18:1000:100::/64"},
+				{Name: "public01", Type: types.Public, CIDR: "10.1.128.0/17", IPv6CIDR: "2600:1f18:1000:101::/64"},
+			},
+		},
+		{
+			name:    "outside the network",
+			network: &types.Network{CIDR: "10.1.0.0/16"},
+			plan:    []*types.SubnetRequest{{Name: "private01", Type: types.Private, CIDR: "10.2.0.0/24"}},
+			err:     "subnet private01: 10.2.0.0/24 is outside network 10.1.0.0/16",
+		},
+		{
+			name:    "offset past the network",
+			network: &types.Network{CIDR: "10.1.0.0/16"},
+			plan:    []*types.SubnetRequest{{Name: "private01", Type: types.Private, CIDR: "0.1.0.0/24"}},
+			err:     "subnet private01: 0.1.0.0/24 is outside network 10.1.0.0/16",
+		},
+		{
+			name:    "larger than the network",
+			network: &types.Network{CIDR: "10.1.0.0/16"},
+			plan:    []*types.SubnetRequest{{Name: "private01", Type: types.Private, CIDR: "10.0.0.0/15"}},
+			err:     "subnet private01: 10.0.0.0/15 is outside network 10.1.0.0/16",
+		},
+		{
+			name:    "overlapping subnets",
+			network: &types.Network{CIDR: "10.1.0.0/16"},
+			plan: []*types.SubnetRequest{
+				{Name: "private01", Type: types.Private, CIDR: "10.1.0.0/17"},
+				{Name: "public01", Type: types.Public, CIDR: "0.0.64.0/24"},
+			},
+			err: "subnet public01: 10.1.64.0/24 overlaps subnet private01 (10.1.0.0/17)",
+		},
+		{
+			name:    "smaller than a /28",
+			network: &types.Network{CIDR: "10.1.0.0/16"},
+			plan:    []*types.SubnetRequest{{Name: "tgw01", Type: types.TransitGateway, CIDR: "10.1.0.0/29"}},
+			err:     "subnet tgw01: 10.1.0.0/29 is smaller than a /28",
+		},
+		{
+			name:    "host bits set",
+			network: &types.Network{CIDR: "10.1.0.0/16"},
+			plan:    []*types.SubnetRequest{{Name: "private01", Type: types.Private, CIDR: "10.1.0.1/24"}},
+			err:     "subnet private01: 10.1.0.1/24 is not a network address",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snets, err := PlanSubnets(tt.network, tt.plan)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, snets)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package net
+
+import (
+	"sort"
+
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// Children returns the pools delegated from p.
+func Children(p *types.Pool, pools []*types.Pool) []*types.Pool {
+	children := []*types.Pool{}
+	for _, c := range pools {
+		if c.ParentID != "" && c.ParentID == p.ID.String() {
+			children = append(children, c)
+		}
+	}
+	return children
+}
+
+// CheckPoolRange makes sure the range of p sits inside its parent, apart from
+// its siblings, and still holds the pools delegated from it. Pools are looked
+// up in pools, where p itself is skipped.
+func CheckPoolRange(p *types.Pool, parent *types.Pool, pools []*types.Pool) error {
+	pr := p.Range()
+	if parent != nil {
+		parentRange := parent.Range()
+		if !parentRange.Contains(pr.From()) || !parentRange.Contains(pr.To()) {
+			return PoolNotInParentError{Pool: p, Parent: parent}
+		}
+	}
+
+	for _, other := range pools {
+		if p.ID != nil && other.ID.String() == p.ID.String() {
+			continue
+		}
+		if p.ParentID != "" && other.ParentID == p.ParentID && other.Range().Overlaps(pr) {
+			return PoolOverlapError{Pool: p, Other: other}
+		}
+	}
+
+	if p.ID == nil {
+		return nil
+	}
+	for _, c := range Children(p, pools) {
+		cr := c.Range()
+		if !pr.Contains(cr.From()) || !pr.Contains(cr.To()) {
+			return PoolNotInParentError{Pool: c, Parent: p}
+		}
+	}
+	return nil
+}
+
+// CheckPoolOverlap makes sure the range of p overlaps no pool of its routing
+// domain other than the ones it was delegated from or that were delegated
+// from it.
+func CheckPoolOverlap(p *types.Pool, pools []*types.Pool) error {
+	byID := map[string]*types.Pool{}
+	for _, other := range pools {
+		byID[other.ID.String()] = other
+	}
+	id := ""
+	if p.ID != nil {
+		id = p.ID.String()
+	}
+
+	pr := p.Range()
+	for _, other := range pools {
+		otherID := other.ID.String()
+		if other.RoutingDomain != p.RoutingDomain {
+			continue
+		}
+		if otherID == id || descends(p, otherID, byID) || (id != "" && descends(other, id, byID)) {
+			continue
+		}
+		if other.Range().Overlaps(pr) {
+			return PoolOverlapError{Pool: p, Other: other}
+		}
+	}
+	return nil
+}
+
+// descends reports whether p was delegated, directly or not, from the pool
+// with ancestorID.
+func descends(p *types.Pool, ancestorID string, byID map[string]*types.Pool) bool {
+	seen := map[string]bool{}
+	for p.ParentID != "" && !seen[p.ParentID] {
+		if p.ParentID == ancestorID {
+			return true
+		}
+		seen[p.ParentID] = true
+		parent, ok := byID[p.ParentID]
+		if !ok {
+			return false
+		}
+		p = parent
+	}
+	return false
+}
+
+// BuildPoolTree arranges pools by their parents, starting from the pool with
+// rootID or, when empty, from every pool without a known parent. Siblings are
+// sorted by the start of their range.
+func BuildPoolTree(pools []*types.Pool, rootID string) []*types.PoolTree {
+	byID := map[string]*types.PoolTree{}
+	for _, p := range pools {
+		byID[p.ID.String()] = &types.PoolTree{Pool: p}
+	}
+
+	roots := []*types.PoolTree{}
+	for _, p := range pools {
+		node := byID[p.ID.String()]
+		parent, ok := byID[p.ParentID]
+		if p.ParentID == "" || !ok {
+			roots = append(roots, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+
+	if rootID != "" {
+		roots = []*types.PoolTree{}
+		if node, ok := byID[rootID]; ok {
+			roots = append(roots, node)
+		}
+	}
+
+	sortTree(roots)
+	return roots
+}
+
+func sortTree(nodes []*types.PoolTree) {
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := nodes[i].Range().From(), nodes[j].Range().From()
+		if a == b {
+			return nodes[i].Name < nodes[j].Name
+		}
+		return a.Less(b)
+	})
+	for _, n := range nodes {
+		sortTree(n.Children)
+	}
+}
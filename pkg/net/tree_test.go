@@ -0,0 +1,102 @@
+package net
+
+import (
+	"testing"
+
+	"github.com/olxbr/network-api/pkg/db"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPoolRange(t *testing.T) {
+	corp := &types.Pool{ID: types.NewUUID(), Name: "corp", SubnetIP: "10.0.0.0", SubnetMask: types.Int(8)}
+	east := &types.Pool{ID: types.NewUUID(), Name: "us-east", SubnetIP: "10.0.0.0", SubnetMask: types.Int(12), ParentID: corp.ID.String()}
+	payments := &types.Pool{ID: types.NewUUID(), Name: "payments", SubnetIP: "10.1.0.0", SubnetMask: types.Int(16), ParentID: east.ID.String()}
+	pools := []*types.Pool{corp, east, payments}
+
+	west := &types.Pool{Name: "us-west", SubnetIP: "10.16.0.0", SubnetMask: types.Int(12), ParentID: corp.ID.String()}
+	assert.NoError(t, CheckPoolRange(west, corp, pools))
+
+	west.SubnetIP, west.SubnetMask = "10.8.0.0", types.Int(13)
+	err := CheckPoolRange(west, corp, pools)
+	assert.ErrorAs(t, err, &PoolOverlapError{})
+	assert.ErrorIs(t, err, db.ErrConflict)
+	assert.EqualError(t, err, "pool us-west range 10.8.0.0-10.15.255.255 overlaps pool us-east range 10.0.0.0-10.15.255.255")
+
+	west.SubnetIP, west.SubnetMask = "11.0.0.0", types.Int(12)
+	err = CheckPoolRange(west, corp, pools)
+	assert.EqualError(t, err, "pool us-west range 11.0.0.0-11.15.255.255 not in parent pool corp range 10.0.0.0-10.255.255.255")
+
+	// shrinking a pool must keep its children
+	shrunk := *east
+	shrunk.SubnetMask = types.Int(16)
+	err = CheckPoolRange(&shrunk, corp, pools)
+	assert.ErrorAs(t, err, &PoolNotInParentError{})
+	assert.EqualError(t, err, "pool payments range 10.1.0.0-10.1.255.255 not in parent pool us-east range 10.0.0.0-10.0.255.255")
+
+	// a pool does not overlap itself
+	grown := *east
+	grown.SubnetMask = types.Int(11)
+	assert.NoError(t, CheckPoolRange(&grown, corp, pools))
+}
+
+func TestCheckPoolOverlap(t *testing.T) {
+	corp := &types.Pool{ID: types.NewUUID(), Name: "corp", SubnetIP: "10.0.0.0", SubnetMask: types.Int(8)}
+	east := &types.Pool{ID: types.NewUUID(), Name: "us-east", SubnetIP: "10.0.0.0", SubnetMask: types.Int(12), ParentID: corp.ID.String()}
+	payments := &types.Pool{ID: types.NewUUID(), Name: "payments", SubnetIP: "10.1.0.0", SubnetMask: types.Int(16), ParentID: east.ID.String()}
+	lab := &types.Pool{ID: types.NewUUID(), Name: "lab", SubnetIP: "172.16.0.0", SubnetMask: types.Int(12)}
+	pools := []*types.Pool{corp, east, payments, lab}
+
+	// ancestors and descendants share their ranges
+	assert.NoError(t, CheckPoolOverlap(east, pools))
+	assert.NoError(t, CheckPoolOverlap(payments, pools))
+	assert.NoError(t, CheckPoolOverlap(corp, pools))
+
+	grown := *lab
+	grown.SubnetIP, grown.SubnetMask = "0.0.0.0", types.Int(1)
+	err := CheckPoolOverlap(&grown, pools)
+	assert.ErrorIs(t, err, db.ErrConflict)
+	assert.EqualError(t, err, "pool lab range 0.0.0.0-127.255.255.255 overlaps pool corp range 10.0.0.0-10.255.255.255")
+
+	moved := *payments
+	moved.SubnetIP = "172.16.0.0"
+	err = CheckPoolOverlap(&moved, pools)
+	assert.EqualError(t, err, "pool payments range 172.16.0.0-172.16.255.255 overlaps pool lab range 172.16.0.0-172.31.255.255")
+
+	// pools of other routing domains may reuse the space
+	sandbox := &types.Pool{Name: "sandbox", SubnetIP: "10.0.0.0", SubnetMask: types.Int(8), RoutingDomain: "sandbox"}
+	assert.NoError(t, CheckPoolOverlap(sandbox, pools))
+
+	// new pools have no ID yet
+	child := &types.Pool{Name: "dev", SubnetIP: "10.2.0.0", SubnetMask: types.Int(16), ParentID: east.ID.String()}
+	assert.NoError(t, CheckPoolOverlap(child, pools))
+}
+
+func TestBuildPoolTree(t *testing.T) {
+	corp := &types.Pool{ID: types.NewUUID(), Name: "corp", SubnetIP: "10.0.0.0", SubnetMask: types.Int(8)}
+	west := &types.Pool{ID: types.NewUUID(), Name: "us-west", SubnetIP: "10.16.0.0", SubnetMask: types.Int(12), ParentID: corp.ID.String()}
+	east := &types.Pool{ID: types.NewUUID(), Name: "us-east", SubnetIP: "10.0.0.0", SubnetMask: types.Int(12), ParentID: corp.ID.String()}
+	payments := &types.Pool{ID: types.NewUUID(), Name: "payments", SubnetIP: "10.1.0.0", SubnetMask: types.Int(16), ParentID: east.ID.String()}
+	lab := &types.Pool{ID: types.NewUUID(), Name: "lab", SubnetIP: "172.16.0.0", SubnetMask: types.Int(12)}
+	orphan := &types.Pool{ID: types.NewUUID(), Name: "orphan", SubnetIP: "192.168.0.0", SubnetMask: types.Int(16), ParentID: types.NewUUID().String()}
+	pools := []*types.Pool{payments, lab, west, orphan, corp, east}
+
+	tree := BuildPoolTree(pools, "")
+	require.Len(t, tree, 3)
+	assert.Equal(t, corp, tree[0].Pool)
+	assert.Equal(t, lab, tree[1].Pool)
+	assert.Equal(t, orphan, tree[2].Pool)
+	require.Len(t, tree[0].Children, 2)
+	assert.Equal(t, east, tree[0].Children[0].Pool)
+	assert.Equal(t, west, tree[0].Children[1].Pool)
+	require.Len(t, tree[0].Children[0].Children, 1)
+	assert.Equal(t, payments, tree[0].Children[0].Children[0].Pool)
+
+	tree = BuildPoolTree(pools, east.ID.String())
+	require.Len(t, tree, 1)
+	assert.Equal(t, east, tree[0].Pool)
+	assert.Len(t, tree[0].Children, 1)
+
+	assert.Empty(t, BuildPoolTree(pools, "missing"))
+}
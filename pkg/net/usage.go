@@ -0,0 +1,199 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+
+	"go4.org/netipx"
+
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+// networkSizes returns the network sizes reported as available in a pool
+// usage, matching the sizes accepted by NetworkRequest for its family.
+func networkSizes(p *types.Pool) []int {
+	if p.IsIPv6() {
+		return []int{44, 48, 52, 56, 60}
+	}
+	return []int{16, 17, 18, 19, 20, 21, 22, 23, 24}
+}
+
+// PoolUsage reports how much of a pool is taken by networks, by reserved or
+// legacy networks, by exclusions, what is delegated to child pools and not
+// used there yet, and what is still free. Networks of child pools count
+// against the pool, and excluded addresses a network holds anyway count for
+// the network. Only networks and pools of the pool routing domain count.
+func (nm *NetworkManager) PoolUsage(ctx context.Context, poolID string) (*types.PoolUsage, error) {
+	p, err := nm.DB.GetPool(ctx, poolID)
+	if err != nil {
+		return nil, err
+	}
+
+	nets, err := nm.DB.ScanNetworks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nets = domainNetworks(nets, p.RoutingDomain)
+
+	reservations, err := nm.DB.ScanReservations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reservations = domainReservations(reservations, p.RoutingDomain)
+
+	pools, err := nm.DB.ScanPools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pools = domainPools(pools, p.RoutingDomain)
+
+	pr := p.Range()
+	stored := map[string]bool{}
+	allocated := &netipx.IPSetBuilder{}
+	reserved := &netipx.IPSetBuilder{}
+	for _, n := range nets {
+		if !n.HoldsAddresses() {
+			continue
+		}
+		for _, prefix := range n.Prefixes() {
+			stored[prefix.String()] = true
+			if n.Reserved || n.Legacy {
+				reserved.AddPrefix(prefix)
+			} else {
+				allocated.AddPrefix(prefix)
+			}
+		}
+	}
+	// reservations without a stored network are allocations still in flight
+	for _, r := range reservations {
+		if !stored[r.CIDR] {
+			allocated.AddPrefix(r.IPPrefix())
+		}
+	}
+
+	reservedSet, err := clip(reserved, pr)
+	if err != nil {
+		return nil, err
+	}
+	allocated.RemoveSet(reservedSet)
+	allocatedSet, err := clip(allocated, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := &netipx.IPSetBuilder{}
+	excluded.AddSet(p.ExcludedSet())
+	excluded.RemoveSet(allocatedSet)
+	excluded.RemoveSet(reservedSet)
+	excludedSet, err := clip(excluded, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	delegated := &netipx.IPSetBuilder{}
+	for _, c := range Children(p, pools) {
+		delegated.AddRange(c.Range())
+	}
+	delegated.RemoveSet(allocatedSet)
+	delegated.RemoveSet(reservedSet)
+	delegated.RemoveSet(excludedSet)
+	delegatedSet, err := clip(delegated, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	free := &netipx.IPSetBuilder{}
+	free.AddRange(pr)
+	free.RemoveSet(allocatedSet)
+	free.RemoveSet(reservedSet)
+	free.RemoveSet(excludedSet)
+	free.RemoveSet(delegatedSet)
+	freeSet, err := free.IPSet()
+	if err != nil {
+		return nil, fmt.Errorf("error building ipset: %+v", err)
+	}
+
+	u := &types.PoolUsage{
+		PoolID:        p.ID.String(),
+		Range:         pr.String(),
+		RoutingDomain: p.RoutingDomain,
+		Total:         rangeSize(pr),
+		Allocated:     setSize(allocatedSet),
+		Reserved:      setSize(reservedSet),
+		Excluded:      setSize(excludedSet),
+		Delegated:     setSize(delegatedSet),
+		Free:          setSize(freeSet),
+		Available:     map[string]uint64{},
+		FreeRanges:    []string{},
+	}
+
+	freePrefixes := freeSet.Prefixes()
+	var largest netip.Prefix
+	for _, f := range freePrefixes {
+		if !largest.IsValid() || f.Bits() < largest.Bits() {
+			largest = f
+		}
+	}
+	if largest.IsValid() {
+		u.LargestFreePrefix = largest.String()
+	}
+
+	for _, size := range networkSizes(p) {
+		u.Available[fmt.Sprintf("/%d", size)] = countFits(freePrefixes, size)
+	}
+
+	for _, r := range freeSet.Ranges() {
+		u.FreeRanges = append(u.FreeRanges, r.String())
+	}
+
+	return u, nil
+}
+
+// countFits returns how many networks of the given size fit in the free
+// blocks, saturating at the largest uint64 as IPv6 pools hold far more.
+func countFits(free []netip.Prefix, size int) uint64 {
+	n := new(big.Int)
+	for _, f := range free {
+		if f.Bits() <= size {
+			n.Add(n, new(big.Int).Lsh(big.NewInt(1), uint(size-f.Bits())))
+		}
+	}
+	if !n.IsUint64() {
+		return math.MaxUint64
+	}
+	return n.Uint64()
+}
+
+func clip(b *netipx.IPSetBuilder, r netipx.IPRange) (*netipx.IPSet, error) {
+	set, err := b.IPSet()
+	if err != nil {
+		return nil, fmt.Errorf("error building ipset: %+v", err)
+	}
+
+	c := &netipx.IPSetBuilder{}
+	c.AddRange(r)
+	c.Intersect(set)
+	set, err = c.IPSet()
+	if err != nil {
+		return nil, fmt.Errorf("error building ipset: %+v", err)
+	}
+	return set, nil
+}
+
+func setSize(s *netipx.IPSet) *big.Int {
+	total := new(big.Int)
+	for _, r := range s.Ranges() {
+		total.Add(total, rangeSize(r))
+	}
+	return total
+}
+
+func rangeSize(r netipx.IPRange) *big.Int {
+	from := r.From().As16()
+	to := r.To().As16()
+	size := new(big.Int).Sub(new(big.Int).SetBytes(to[:]), new(big.Int).SetBytes(from[:]))
+	return size.Add(size, big.NewInt(1))
+}
@@ -0,0 +1,190 @@
+package net
+
+import (
+	"context"
+	"math"
+	"net/netip"
+	"testing"
+
+	"github.com/olxbr/network-api/pkg/db/fake"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolUsage(t *testing.T) {
+	poolID := types.NewUUID()
+
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, db *fake.Database)
+		assert  func(t *testing.T, db *fake.Database, u *types.PoolUsage, err error)
+	}{
+		{
+			name: "empty pool",
+			prepare: func(t *testing.T, db *fake.Database) {
+				db.On("GetPool", mock.Anything, poolID.String()).Return(&types.Pool{
+					ID:         poolID,
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(16),
+				}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+			},
+			assert: func(t *testing.T, db *fake.Database, u *types.PoolUsage, err error) {
+				db.AssertExpectations(t)
+				require.NoError(t, err)
+				assert.Equal(t, "65536", u.Total.String())
+				assert.Equal(t, "0", u.Allocated.String())
+				assert.Equal(t, "65536", u.Free.String())
+				assert.Equal(t, "10.0.0.0/16", u.LargestFreePrefix)
+				assert.Equal(t, uint64(1), u.Available["/16"])
+				assert.Equal(t, uint64(256), u.Available["/24"])
+				assert.Equal(t, []string{"10.0.0.0-10.0.255.255"}, u.FreeRanges)
+			},
+		},
+		{
+			name: "allocated, reserved and in flight networks",
+			prepare: func(t *testing.T, db *fake.Database) {
+				db.On("GetPool", mock.Anything, poolID.String()).Return(&types.Pool{
+					ID:         poolID,
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(16),
+				}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.0.0.0/24"},
+					{CIDR: "10.0.1.0/24", Reserved: true},
+					{CIDR: "10.1.0.0/16"},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+					{CIDR: "10.0.0.0/24"},
+					{CIDR: "10.0.2.0/23"},
+				}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+			},
+			assert: func(t *testing.T, db *fake.Database, u *types.PoolUsage, err error) {
+				db.AssertExpectations(t)
+				require.NoError(t, err)
+				assert.Equal(t, "65536", u.Total.String())
+				assert.Equal(t, "768", u.Allocated.String())
+				assert.Equal(t, "256", u.Reserved.String())
+				assert.Equal(t, "0", u.Excluded.String())
+				assert.Equal(t, "64512", u.Free.String())
+				assert.Equal(t, "10.0.128.0/17", u.LargestFreePrefix)
+				assert.Equal(t, uint64(0), u.Available["/16"])
+				assert.Equal(t, uint64(1), u.Available["/17"])
+				assert.Equal(t, uint64(252), u.Available["/24"])
+				assert.Equal(t, []string{"10.0.4.0-10.0.255.255"}, u.FreeRanges)
+			},
+		},
+		{
+			name: "exclusions",
+			prepare: func(t *testing.T, db *fake.Database) {
+				db.On("GetPool", mock.Anything, poolID.String()).Return(&types.Pool{
+					ID:         poolID,
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(16),
+					Exclusions: []*types.Exclusion{
+						{CIDR: "10.0.0.0/23", Reason: "legacy datacenter", Owner: "infra"},
+						{CIDR: "10.0.128.0/17", Reason: "partner VPN", Owner: "network-team"},
+					},
+				}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.0.0.0/24", Legacy: true},
+					{CIDR: "10.0.2.0/24"},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+			},
+			assert: func(t *testing.T, db *fake.Database, u *types.PoolUsage, err error) {
+				db.AssertExpectations(t)
+				require.NoError(t, err)
+				assert.Equal(t, "65536", u.Total.String())
+				assert.Equal(t, "256", u.Allocated.String())
+				assert.Equal(t, "256", u.Reserved.String())
+				assert.Equal(t, "33024", u.Excluded.String())
+				assert.Equal(t, "32000", u.Free.String())
+				assert.Equal(t, "10.0.64.0/18", u.LargestFreePrefix)
+				assert.Equal(t, []string{"10.0.3.0-10.0.127.255"}, u.FreeRanges)
+			},
+		},
+		{
+			name: "child pools",
+			prepare: func(t *testing.T, db *fake.Database) {
+				db.On("GetPool", mock.Anything, poolID.String()).Return(&types.Pool{
+					ID:         poolID,
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(16),
+				}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{CIDR: "10.0.0.0/24"},
+					{CIDR: "10.0.128.0/24"},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{
+					{ID: types.NewUUID(), SubnetIP: "10.0.128.0", SubnetMask: types.Int(17), ParentID: poolID.String()},
+					{ID: types.NewUUID(), SubnetIP: "10.0.0.0", SubnetMask: types.Int(17)},
+				}, nil)
+			},
+			assert: func(t *testing.T, db *fake.Database, u *types.PoolUsage, err error) {
+				db.AssertExpectations(t)
+				require.NoError(t, err)
+				assert.Equal(t, "512", u.Allocated.String())
+				assert.Equal(t, "32512", u.Delegated.String())
+				assert.Equal(t, "32512", u.Free.String())
+				assert.Equal(t, []string{"10.0.1.0-10.0.127.255"}, u.FreeRanges)
+			},
+		},
+		{
+			name: "secondary blocks",
+			prepare: func(t *testing.T, db *fake.Database) {
+				db.On("GetPool", mock.Anything, poolID.String()).Return(&types.Pool{
+					ID:         poolID,
+					SubnetIP:   "10.0.0.0",
+					SubnetMask: types.Int(16),
+				}, nil)
+				db.On("ScanNetworks", mock.Anything).Return([]*types.Network{
+					{
+						CIDR:           "10.1.0.0/16",
+						SecondaryCIDRs: []*types.SecondaryCIDR{{CIDR: "10.0.0.0/17", PoolID: poolID.String()}},
+					},
+				}, nil)
+				db.On("ScanReservations", mock.Anything).Return([]*types.Reservation{
+					{CIDR: "10.1.0.0/16"},
+					{CIDR: "10.0.0.0/17"},
+				}, nil)
+				db.On("ScanPools", mock.Anything).Return([]*types.Pool{}, nil)
+			},
+			assert: func(t *testing.T, db *fake.Database, u *types.PoolUsage, err error) {
+				db.AssertExpectations(t)
+				require.NoError(t, err)
+				assert.Equal(t, "32768", u.Allocated.String())
+				assert.Equal(t, "32768", u.Free.String())
+				assert.Equal(t, []string{"10.0.128.0-10.0.255.255"}, u.FreeRanges)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fake.Database{}
+			nm := New(db)
+			tt.prepare(t, db)
+
+			u, err := nm.PoolUsage(context.Background(), poolID.String())
+			tt.assert(t, db, u, err)
+		})
+	}
+}
+
+func TestCountFits(t *testing.T) {
+	free := []netip.Prefix{netip.MustParsePrefix("2001:db8::/48"), netip.MustParsePrefix("2001:db9::/56")}
+	assert.Equal(t, uint64(65536+256), countFits(free, 64))
+	assert.Equal(t, uint64(0), countFits(free, 40))
+
+	// 2^64 /64 networks are past what a uint64 counts
+	halves := []netip.Prefix{netip.MustParsePrefix("::/1"), netip.MustParsePrefix("8000::/1")}
+	assert.Equal(t, uint64(math.MaxUint64), countFits(halves, 64))
+}
@@ -13,9 +13,12 @@ import (
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/kms"
 	ktypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
 	"github.com/go-playground/validator/v10"
@@ -116,7 +119,32 @@ func LambdaHandler(ctx context.Context, request events.APIGatewayProxyRequest) (
 		return apiGatewayResponse(&types.ProviderWebhookResponse{
 			StatusCode: 200,
 			ID:         resp,
+			Status:     types.StatusProvisioning,
 		}, 200), nil
+	case types.DeleteNetwork:
+		resp, err := DeleteNetwork(ctx, cfg, webhook)
+		if err != nil {
+			return apiGatewayError(err, 500), err
+		}
+		return apiGatewayResponse(resp, 200), nil
+	case types.CheckNework:
+		resp, err := CheckNetwork(ctx, cfg, webhook)
+		if err != nil {
+			return apiGatewayError(err, 500), err
+		}
+		return apiGatewayResponse(resp, 200), nil
+	case types.QueryNetwork:
+		resp, err := QueryNetwork(ctx, cfg, webhook)
+		if err != nil {
+			return apiGatewayError(err, 500), err
+		}
+		return apiGatewayResponse(resp, 200), nil
+	case types.AddCIDR:
+		resp, err := AddCIDR(ctx, cfg, webhook)
+		if err != nil {
+			return apiGatewayError(err, 500), err
+		}
+		return apiGatewayResponse(resp, 200), nil
 	}
 
 	return apiGatewayResponse("{\"message\": \"success\"}", 200), nil
@@ -130,12 +158,21 @@ func CreateNetwork(ctx context.Context, cfg aws.Config, pw *types.ProviderWebhoo
 
 	d := NewDeployer(cli)
 
+	// stack events are reported back to the API by StackEventHandler
+	notifications := stackNotifications(os.Getenv("STACK_EVENTS_TOPIC"), cfg.Region)
+
+	params, err := BuildParameters(pw)
+	if err != nil {
+		return "", err
+	}
+
 	stackName := fmt.Sprintf("network-%s", pw.NetworkID)
 	cs, err := d.CreateStack(ctx, &DeployerInput{
-		StackName:   stackName,
-		NetworkID:   pw.NetworkID,
-		Params:      BuildParameters(pw),
-		TemplateURL: fmt.Sprintf("%s/%s", templates, "template.yaml"),
+		StackName:        stackName,
+		NetworkID:        pw.NetworkID,
+		Params:           params,
+		TemplateURL:      fmt.Sprintf("%s/%s", templates, "template.yaml"),
+		NotificationARNs: notifications,
 	})
 	if err != nil {
 		log.Printf("error creating stack: %+v", err)
@@ -144,6 +181,267 @@ func CreateNetwork(ctx context.Context, cfg aws.Config, pw *types.ProviderWebhoo
 	return cs.ID, nil
 }
 
+// stackNotifications returns the stack events topic for stacks in region.
+// CloudFormation only publishes to topics of the stack region, stacks
+// elsewhere get no topic and are followed by check_network instead.
+func stackNotifications(topic, region string) []string {
+	if topic == "" {
+		return nil
+	}
+	a, err := arn.Parse(topic)
+	if err != nil || a.Region != region {
+		log.Printf("stack events topic %s not in region %s, relying on check_network", topic, region)
+		return nil
+	}
+	return []string{topic}
+}
+
+// DeleteNetwork starts tearing down the network stack. The API frees the
+// CIDR once CheckNetwork reports the stack gone; a missing stack is reported
+// as already deleted.
+func DeleteNetwork(ctx context.Context, cfg aws.Config, pw *types.ProviderWebhook) (*types.ProviderWebhookResponse, error) {
+	cli := cloudformation.NewFromConfig(cfg)
+	d := NewDeployer(cli)
+
+	stackName := fmt.Sprintf("network-%s", pw.NetworkID)
+	exists, err := d.HasStack(ctx, stackName)
+	if err != nil {
+		log.Printf("error describing stack: %+v", err)
+		return nil, err
+	}
+	if !exists {
+		log.Printf("stack %s does not exist, nothing to delete", stackName)
+		return &types.ProviderWebhookResponse{
+			StatusCode: 200,
+			ID:         stackName,
+			Status:     types.StatusDeleted,
+		}, nil
+	}
+
+	err = d.DeleteStack(ctx, stackName)
+	if err != nil {
+		log.Printf("error deleting stack: %+v", err)
+		return nil, err
+	}
+	return &types.ProviderWebhookResponse{
+		StatusCode: 200,
+		ID:         stackName,
+		Status:     types.StatusDeleting,
+	}, nil
+}
+
+// CheckNetwork reports the network status from its stack status and, for
+// complete stacks, how they drifted from the network the API expects.
+func CheckNetwork(ctx context.Context, cfg aws.Config, pw *types.ProviderWebhook) (*types.ProviderWebhookResponse, error) {
+	cli := cloudformation.NewFromConfig(cfg)
+	d := NewDeployer(cli)
+
+	stackName := fmt.Sprintf("network-%s", pw.NetworkID)
+	stack, err := d.DescribeStack(ctx, stackName)
+	if err != nil {
+		log.Printf("error describing stack: %+v", err)
+		return nil, err
+	}
+
+	resp := &types.ProviderWebhookResponse{
+		StatusCode: 200,
+		ID:         stackName,
+		Status:     types.StatusDeleted,
+		Drift: &types.DriftCheck{
+			Status: types.DriftMissing,
+		},
+	}
+	if stack == nil {
+		return resp, nil
+	}
+
+	resp.ID = aws.ToString(stack.StackId)
+	resp.Status = NetworkStatus(stack.StackStatus)
+	resp.Reason = aws.ToString(stack.StackStatusReason)
+	resp.Drift = nil
+	if resp.Status == types.StatusActive {
+		resp.Drift = StackDrift(stack, pw)
+
+		// detection runs in the background, the next check sees its result
+		err = d.DetectDrift(ctx, stackName)
+		if err != nil {
+			log.Printf("error detecting stack drift: %+v", err)
+		}
+	}
+	return resp, nil
+}
+
+// StackDrift compares a live stack with the network the API expects: the
+// parameters it would be created with, an output for every subnet and the
+// last CloudFormation drift detection.
+func StackDrift(stack *cftypes.Stack, pw *types.ProviderWebhook) *types.DriftCheck {
+	live := map[string]string{}
+	for _, p := range stack.Parameters {
+		live[aws.ToString(p.ParameterKey)] = aws.ToString(p.ParameterValue)
+	}
+
+	diffs := []string{}
+	params, err := BuildParameters(pw)
+	if err != nil {
+		diffs = append(diffs, err.Error())
+	}
+	for _, p := range params {
+		key := aws.ToString(p.ParameterKey)
+		// the pool comes from the provider configuration, not the network
+		if key == "VPCIpv6Pool" {
+			continue
+		}
+		expected := aws.ToString(p.ParameterValue)
+		if live[key] != expected {
+			diffs = append(diffs, fmt.Sprintf("parameter %s is %q, expected %q", key, live[key], expected))
+		}
+	}
+
+	// stacks created from templates without outputs cannot be matched
+	if len(stack.Outputs) > 0 {
+		_, subnets := StackResources(stack)
+		found := map[string]bool{}
+		for _, s := range subnets {
+			found[s.CIDR] = true
+		}
+		for _, s := range pw.Subnets {
+			if !found[s.CIDR] {
+				diffs = append(diffs, fmt.Sprintf("subnet %s (%s) not found in stack outputs", s.Name, s.CIDR))
+			}
+		}
+	}
+
+	if stack.DriftInformation != nil && stack.DriftInformation.StackDriftStatus == cftypes.StackDriftStatusDrifted {
+		diffs = append(diffs, "stack resources drifted from the template")
+	}
+
+	dc := &types.DriftCheck{
+		Status: types.DriftInSync,
+	}
+	if len(diffs) > 0 {
+		dc.Status = types.DriftDrifted
+		dc.Differences = diffs
+	}
+	return dc
+}
+
+// QueryNetwork lists every VPC in the account and region. VPCs created by the
+// API carry the network-id tag propagated from their stack.
+func QueryNetwork(ctx context.Context, cfg aws.Config, pw *types.ProviderWebhook) (*types.ProviderWebhookResponse, error) {
+	cli := ec2.NewFromConfig(cfg)
+
+	networks := []*types.DiscoveredNetwork{}
+	pages := ec2.NewDescribeVpcsPaginator(cli, &ec2.DescribeVpcsInput{})
+	for pages.HasMorePages() {
+		out, err := pages.NextPage(ctx)
+		if err != nil {
+			log.Printf("error describing vpcs: %+v", err)
+			return nil, err
+		}
+		for _, v := range out.Vpcs {
+			networks = append(networks, VpcNetwork(v))
+		}
+	}
+
+	return &types.ProviderWebhookResponse{
+		StatusCode: 200,
+		Networks:   networks,
+	}, nil
+}
+
+// AddCIDR associates a secondary IPv4 block with the network VPC. The block
+// lives outside the network stack, deleting the VPC drops the association.
+func AddCIDR(ctx context.Context, cfg aws.Config, pw *types.ProviderWebhook) (*types.ProviderWebhookResponse, error) {
+	cli := ec2.NewFromConfig(cfg)
+
+	out, err := cli.AssociateVpcCidrBlock(ctx, &ec2.AssociateVpcCidrBlockInput{
+		VpcId:     aws.String(pw.VpcID),
+		CidrBlock: aws.String(pw.CIDR),
+	})
+	if err != nil {
+		log.Printf("error associating vpc cidr block: %+v", err)
+		return nil, err
+	}
+
+	resp := &types.ProviderWebhookResponse{
+		StatusCode: 200,
+		Status:     types.StatusProvisioning,
+	}
+	if a := out.CidrBlockAssociation; a != nil {
+		resp.ID = aws.ToString(a.AssociationId)
+		if a.CidrBlockState != nil {
+			resp.Status = CIDRStatus(a.CidrBlockState.State)
+			resp.Reason = aws.ToString(a.CidrBlockState.StatusMessage)
+		}
+	}
+	return resp, nil
+}
+
+// CIDRStatus maps the state of a VPC block association to the status of the
+// secondary block.
+func CIDRStatus(s ec2types.VpcCidrBlockStateCode) types.NetworkStatus {
+	switch s {
+	case ec2types.VpcCidrBlockStateCodeAssociated:
+		return types.StatusActive
+	case ec2types.VpcCidrBlockStateCodeFailing,
+		ec2types.VpcCidrBlockStateCodeFailed:
+		return types.StatusFailed
+	case ec2types.VpcCidrBlockStateCodeDisassociating:
+		return types.StatusDeleting
+	case ec2types.VpcCidrBlockStateCodeDisassociated:
+		return types.StatusDeleted
+	default:
+		return types.StatusProvisioning
+	}
+}
+
+// VpcNetwork describes a VPC by its primary IPv4 block and its associated
+// IPv6 block, if any.
+func VpcNetwork(v ec2types.Vpc) *types.DiscoveredNetwork {
+	dn := &types.DiscoveredNetwork{
+		VpcID:   aws.ToString(v.VpcId),
+		CIDR:    aws.ToString(v.CidrBlock),
+		Default: aws.ToBool(v.IsDefault),
+	}
+	for _, a := range v.Ipv6CidrBlockAssociationSet {
+		if a.Ipv6CidrBlockState != nil && a.Ipv6CidrBlockState.State == ec2types.VpcCidrBlockStateCodeAssociated {
+			dn.IPv6CIDR = aws.ToString(a.Ipv6CidrBlock)
+			break
+		}
+	}
+	for _, t := range v.Tags {
+		switch aws.ToString(t.Key) {
+		case "Name":
+			dn.Name = aws.ToString(t.Value)
+		case "network-id":
+			dn.NetworkID = aws.ToString(t.Value)
+		}
+	}
+	return dn
+}
+
+// NetworkStatus maps a stack status to the status of the network it holds.
+func NetworkStatus(s cftypes.StackStatus) types.NetworkStatus {
+	switch s {
+	case cftypes.StackStatusCreateComplete,
+		cftypes.StackStatusUpdateComplete,
+		cftypes.StackStatusUpdateRollbackComplete:
+		return types.StatusActive
+	case cftypes.StackStatusCreateFailed,
+		cftypes.StackStatusRollbackInProgress,
+		cftypes.StackStatusRollbackFailed,
+		cftypes.StackStatusRollbackComplete:
+		return types.StatusFailed
+	case cftypes.StackStatusDeleteInProgress,
+		cftypes.StackStatusDeleteFailed:
+		return types.StatusDeleting
+	case cftypes.StackStatusDeleteComplete:
+		return types.StatusDeleted
+	default:
+		return types.StatusProvisioning
+	}
+}
+
 func CreateNetworkWithChangeSet(ctx context.Context, cfg aws.Config, pw *types.ProviderWebhook) (string, error) {
 	cli := cloudformation.NewFromConfig(cfg)
 
@@ -152,10 +450,15 @@ func CreateNetworkWithChangeSet(ctx context.Context, cfg aws.Config, pw *types.P
 
 	d := NewDeployer(cli)
 
+	params, err := BuildParameters(pw)
+	if err != nil {
+		return "", err
+	}
+
 	stackName := fmt.Sprintf("network-%s", pw.NetworkID)
 	csr, err := d.CreateChangeSet(ctx, &DeployerInput{
 		StackName:   stackName,
-		Params:      BuildParameters(pw),
+		Params:      params,
 		TemplateURL: fmt.Sprintf("%s/%s", templates, "template.yaml"),
 	})
 	if err != nil {
@@ -178,7 +481,14 @@ func CreateNetworkWithChangeSet(ctx context.Context, cfg aws.Config, pw *types.P
 	return "", nil
 }
 
-func BuildParameters(pw *types.ProviderWebhook) []cftypes.Parameter {
+// templateZones is the number of subnets of each type the network template
+// can hold, one per availability zone.
+const templateZones = 6
+
+// BuildParameters maps the network to the template parameters. Subnets take
+// the template slots of their type in order, so a type may only come from a
+// single layout tier. Layout and planned subnets also name their resources.
+func BuildParameters(pw *types.ProviderWebhook) ([]cftypes.Parameter, error) {
 	params := []cftypes.Parameter{
 		{
 			ParameterKey:   aws.String("VPCName"),
@@ -194,27 +504,62 @@ func BuildParameters(pw *types.ProviderWebhook) []cftypes.Parameter {
 		},
 	}
 
-	private := 0
-	public := 0
-	tgw := 0
+	if pw.IPv6CIDR != "" {
+		params = append(params, cftypes.Parameter{
+			ParameterKey:   aws.String("VPCIpv6Cidr"),
+			ParameterValue: aws.String(pw.IPv6CIDR),
+		}, cftypes.Parameter{
+			ParameterKey:   aws.String("VPCIpv6Pool"),
+			ParameterValue: aws.String(os.Getenv("IPV6_POOL")),
+		})
+	}
+
+	slots := map[types.SubnetType]int{}
+	tiers := map[types.SubnetType]string{}
 	for _, s := range pw.Subnets {
-		var key string
+		var prefix string
 		switch s.Type {
 		case types.Private:
-			key = fmt.Sprintf("PrivateSubnet%dCidr", private)
-			private++
+			prefix = "PrivateSubnet"
 		case types.Public:
-			key = fmt.Sprintf("PublicSubnet%dCidr", public)
-			public++
+			prefix = "PublicSubnet"
 		case types.TransitGateway:
-			key = fmt.Sprintf("TGWSubnet%dCidr", tgw)
-			tgw++
+			prefix = "TGWSubnet"
+		case types.Isolated:
+			prefix = "IsolatedSubnet"
+		default:
+			return nil, fmt.Errorf("subnet %s has unsupported type %q", s.Name, s.Type)
 		}
+		if t, ok := tiers[s.Type]; ok && t != s.Tier {
+			return nil, fmt.Errorf("tiers %s and %s are both %s subnets, the template holds one tier of each type", t, s.Tier, s.Type)
+		}
+		tiers[s.Type] = s.Tier
+		if slots[s.Type] == templateZones {
+			return nil, fmt.Errorf("too many %s subnets, the template holds %d", s.Type, templateZones)
+		}
+		name := fmt.Sprintf("%s%d", prefix, slots[s.Type])
+		slots[s.Type]++
+
 		p := cftypes.Parameter{
-			ParameterKey:   aws.String(key),
+			ParameterKey:   aws.String(name + "Cidr"),
 			ParameterValue: aws.String(s.CIDR),
 		}
 		params = append(params, p)
+
+		if s.IPv6CIDR != "" {
+			params = append(params, cftypes.Parameter{
+				ParameterKey:   aws.String(name + "Ipv6Cidr"),
+				ParameterValue: aws.String(s.IPv6CIDR),
+			})
+		}
+
+		// subnets named after their slot keep the names the template gives them
+		if s.Tier != "" || s.Name != defaultSubnetName(s.Type, slots[s.Type]-1) {
+			params = append(params, cftypes.Parameter{
+				ParameterKey:   aws.String(name + "Name"),
+				ParameterValue: aws.String(s.Name),
+			})
+		}
 	}
-	return params
+	return params, nil
 }
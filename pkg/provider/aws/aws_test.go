@@ -3,13 +3,17 @@ package aws
 import (
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/olxbr/network-api/pkg/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBuildParameters(t *testing.T) {
 
-	params := BuildParameters(&types.ProviderWebhook{
+	params, err := BuildParameters(&types.ProviderWebhook{
 		NetworkID:   "f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0",
 		CIDR:        "10.1.0.0/16",
 		Environment: "prod",
@@ -26,5 +30,239 @@ func TestBuildParameters(t *testing.T) {
 		},
 	})
 
+	require.NoError(t, err)
 	assert.Equal(t, "f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0", *params[0].ParameterValue)
 }
+
+func TestBuildParametersIPv6(t *testing.T) {
+	t.Setenv("IPV6_POOL", "ipv6pool-ec2-0123456789abcdef0")
+
+	params, err := BuildParameters(&types.ProviderWebhook{
+		NetworkID:   "f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0",
+		CIDR:        "10.1.0.0/16",
+		IPv6CIDR:    "2600:1f18:1000:100::/56",
+		Environment: "prod",
+		Subnets: []*types.Subnet{
+			{Name: "private-0", Type: types.Private, CIDR: "10.1.0.0/19", IPv6CIDR: "2600:1f18:1000:100::/64"},
+			{Name: "public-0", Type: types.Public, CIDR: "10.1.32.0/20", IPv6CIDR: "2600:1f18:1000:101::/64"},
+		},
+	})
+
+	require.NoError(t, err)
+	values := map[string]string{}
+	for _, p := range params {
+		values[*p.ParameterKey] = *p.ParameterValue
+	}
+	assert.Equal(t, "2600:1f18:1000:100::/56", values["VPCIpv6Cidr"])
+	assert.Equal(t, "ipv6pool-ec2-0123456789abcdef0", values["VPCIpv6Pool"])
+	assert.Equal(t, "10.1.0.0/19", values["PrivateSubnet0Cidr"])
+	assert.Equal(t, "2600:1f18:1000:100::/64", values["PrivateSubnet0Ipv6Cidr"])
+	assert.Equal(t, "2600:1f18:1000:101::/64", values["PublicSubnet0Ipv6Cidr"])
+}
+
+func TestBuildParametersLayout(t *testing.T) {
+	params, err := BuildParameters(&types.ProviderWebhook{
+		NetworkID:   "f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0",
+		CIDR:        "10.3.0.0/16",
+		Environment: "prod",
+		Subnets: []*types.Subnet{
+			{Name: "app-a", Type: types.Private, Tier: "app", CIDR: "10.3.0.0/20"},
+			{Name: "app-b", Type: types.Private, Tier: "app", CIDR: "10.3.32.0/20"},
+			{Name: "data-a", Type: types.Isolated, Tier: "data", CIDR: "10.3.16.0/21"},
+			{Name: "data-b", Type: types.Isolated, Tier: "data", CIDR: "10.3.48.0/21"},
+		},
+	})
+
+	require.NoError(t, err)
+	values := map[string]string{}
+	for _, p := range params {
+		values[*p.ParameterKey] = *p.ParameterValue
+	}
+	assert.Equal(t, "10.3.32.0/20", values["PrivateSubnet1Cidr"])
+	assert.Equal(t, "app-b", values["PrivateSubnet1Name"])
+	assert.Equal(t, "10.3.16.0/21", values["IsolatedSubnet0Cidr"])
+	assert.Equal(t, "data-a", values["IsolatedSubnet0Name"])
+	assert.NotContains(t, values, "PublicSubnet0Cidr")
+
+	_, err = BuildParameters(&types.ProviderWebhook{
+		Subnets: []*types.Subnet{
+			{Name: "app01", Type: types.Private, Tier: "app", CIDR: "10.3.0.0/20"},
+			{Name: "batch01", Type: types.Private, Tier: "batch", CIDR: "10.3.16.0/20"},
+		},
+	})
+	assert.EqualError(t, err, "tiers app and batch are both private subnets, the template holds one tier of each type")
+}
+
+func TestBuildParametersPlan(t *testing.T) {
+	params, err := BuildParameters(&types.ProviderWebhook{
+		NetworkID:   "f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0",
+		CIDR:        "10.4.0.0/16",
+		Environment: "prod",
+		Subnets: []*types.Subnet{
+			{Name: "private01", Type: types.Private, CIDR: "10.4.0.0/18"},
+			{Name: "pods01", Type: types.Private, CIDR: "10.4.64.0/18"},
+			{Name: "public01", Type: types.Public, CIDR: "10.4.128.0/26"},
+		},
+	})
+
+	require.NoError(t, err)
+	values := map[string]string{}
+	for _, p := range params {
+		values[*p.ParameterKey] = *p.ParameterValue
+	}
+	assert.NotContains(t, values, "PrivateSubnet0Name")
+	assert.Equal(t, "10.4.64.0/18", values["PrivateSubnet1Cidr"])
+	assert.Equal(t, "pods01", values["PrivateSubnet1Name"])
+	assert.NotContains(t, values, "PublicSubnet0Name")
+}
+
+func TestNetworkStatus(t *testing.T) {
+	tests := map[cftypes.StackStatus]types.NetworkStatus{
+		cftypes.StackStatusCreateInProgress: types.StatusProvisioning,
+		cftypes.StackStatusCreateComplete:   types.StatusActive,
+		cftypes.StackStatusRollbackComplete: types.StatusFailed,
+		cftypes.StackStatusDeleteInProgress: types.StatusDeleting,
+		cftypes.StackStatusDeleteFailed:     types.StatusDeleting,
+		cftypes.StackStatusDeleteComplete:   types.StatusDeleted,
+		cftypes.StackStatusUpdateInProgress: types.StatusProvisioning,
+		cftypes.StackStatusUpdateComplete:   types.StatusActive,
+		cftypes.StackStatusReviewInProgress: types.StatusProvisioning,
+	}
+	for stack, network := range tests {
+		assert.Equal(t, network, NetworkStatus(stack), string(stack))
+	}
+}
+
+func TestStackDrift(t *testing.T) {
+	pw := &types.ProviderWebhook{
+		NetworkID:   "f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0",
+		CIDR:        "10.1.0.0/16",
+		Environment: "prod",
+		Subnets: []*types.Subnet{
+			{Name: "private01", Type: types.Private, CIDR: "10.1.0.0/19"},
+		},
+	}
+	stack := func() *cftypes.Stack {
+		return &cftypes.Stack{
+			Parameters: []cftypes.Parameter{
+				{ParameterKey: aws.String("VPCName"), ParameterValue: aws.String(pw.NetworkID)},
+				{ParameterKey: aws.String("VPCCidr"), ParameterValue: aws.String("10.1.0.0/16")},
+				{ParameterKey: aws.String("Environment"), ParameterValue: aws.String("prod")},
+				{ParameterKey: aws.String("PrivateSubnet0Cidr"), ParameterValue: aws.String("10.1.0.0/19")},
+			},
+			Outputs: []cftypes.Output{
+				{OutputKey: aws.String("VpcId"), OutputValue: aws.String("vpc-1234")},
+				{OutputKey: aws.String("PrivateSubnet0Id"), OutputValue: aws.String("subnet-1")},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(s *cftypes.Stack)
+		status  types.DriftStatus
+		diffs   []string
+	}{
+		{
+			name:   "in sync",
+			status: types.DriftInSync,
+		},
+		{
+			name: "changed cidr",
+			prepare: func(s *cftypes.Stack) {
+				s.Parameters[1].ParameterValue = aws.String("10.2.0.0/16")
+			},
+			status: types.DriftDrifted,
+			diffs:  []string{`parameter VPCCidr is "10.2.0.0/16", expected "10.1.0.0/16"`},
+		},
+		{
+			name: "missing subnet",
+			prepare: func(s *cftypes.Stack) {
+				s.Outputs = s.Outputs[:1]
+			},
+			status: types.DriftDrifted,
+			diffs:  []string{"subnet private01 (10.1.0.0/19) not found in stack outputs"},
+		},
+		{
+			name: "template without outputs",
+			prepare: func(s *cftypes.Stack) {
+				s.Outputs = nil
+			},
+			status: types.DriftInSync,
+		},
+		{
+			name: "resources drifted",
+			prepare: func(s *cftypes.Stack) {
+				s.DriftInformation = &cftypes.StackDriftInformation{
+					StackDriftStatus: cftypes.StackDriftStatusDrifted,
+				}
+			},
+			status: types.DriftDrifted,
+			diffs:  []string{"stack resources drifted from the template"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := stack()
+			if tt.prepare != nil {
+				tt.prepare(s)
+			}
+
+			dc := StackDrift(s, pw)
+
+			assert.Equal(t, tt.status, dc.Status)
+			assert.Equal(t, tt.diffs, dc.Differences)
+		})
+	}
+}
+
+func TestVpcNetwork(t *testing.T) {
+	dn := VpcNetwork(ec2types.Vpc{
+		VpcId:     aws.String("vpc-1234"),
+		CidrBlock: aws.String("10.1.0.0/16"),
+		IsDefault: aws.Bool(false),
+		Ipv6CidrBlockAssociationSet: []ec2types.VpcIpv6CidrBlockAssociation{
+			{
+				Ipv6CidrBlock:      aws.String("2600:1f18:1000:200::/56"),
+				Ipv6CidrBlockState: &ec2types.VpcCidrBlockState{State: ec2types.VpcCidrBlockStateCodeDisassociated},
+			},
+			{
+				Ipv6CidrBlock:      aws.String("2600:1f18:1000:100::/56"),
+				Ipv6CidrBlockState: &ec2types.VpcCidrBlockState{State: ec2types.VpcCidrBlockStateCodeAssociated},
+			},
+		},
+		Tags: []ec2types.Tag{
+			{Key: aws.String("Name"), Value: aws.String("payments")},
+			{Key: aws.String("network-id"), Value: aws.String("f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0")},
+		},
+	})
+
+	assert.Equal(t, &types.DiscoveredNetwork{
+		VpcID:     "vpc-1234",
+		Name:      "payments",
+		CIDR:      "10.1.0.0/16",
+		IPv6CIDR:  "2600:1f18:1000:100::/56",
+		NetworkID: "f0f0f0f0-f0f0-f0f0-f0f0-f0f0f0f0f0f0",
+	}, dn)
+}
+
+func TestCIDRStatus(t *testing.T) {
+	tests := map[ec2types.VpcCidrBlockStateCode]types.NetworkStatus{
+		ec2types.VpcCidrBlockStateCodeAssociating:    types.StatusProvisioning,
+		ec2types.VpcCidrBlockStateCodeAssociated:     types.StatusActive,
+		ec2types.VpcCidrBlockStateCodeFailed:         types.StatusFailed,
+		ec2types.VpcCidrBlockStateCodeDisassociating: types.StatusDeleting,
+		ec2types.VpcCidrBlockStateCodeDisassociated:  types.StatusDeleted,
+	}
+	for s, expected := range tests {
+		assert.Equal(t, expected, CIDRStatus(s), string(s))
+	}
+}
+
+func TestStackNotifications(t *testing.T) {
+	topic := "arn:aws:sns:us-east-1:123456789012:napi-stack-events"
+
+	assert.Equal(t, []string{topic}, stackNotifications(topic, "us-east-1"))
+	assert.Empty(t, stackNotifications(topic, "sa-east-1"))
+	assert.Empty(t, stackNotifications("", "us-east-1"))
+}
@@ -20,10 +20,11 @@ type Deployer struct {
 }
 
 type DeployerInput struct {
-	StackName   string
-	NetworkID   string
-	Params      []cftypes.Parameter
-	TemplateURL string
+	StackName        string
+	NetworkID        string
+	Params           []cftypes.Parameter
+	TemplateURL      string
+	NotificationARNs []string
 }
 
 type ChangeSetResult struct {
@@ -42,6 +43,15 @@ func NewDeployer(cli *cloudformation.Client) *Deployer {
 }
 
 func (d *Deployer) HasStack(ctx context.Context, name string) (bool, error) {
+	stack, err := d.DescribeStack(ctx, name)
+	if err != nil || stack == nil {
+		return false, err
+	}
+	return stack.StackStatus != cftypes.StackStatusReviewInProgress, nil
+}
+
+// DescribeStack returns the stack, or nil when it does not exist.
+func (d *Deployer) DescribeStack(ctx context.Context, name string) (*cftypes.Stack, error) {
 	do, err := d.Client.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
 		StackName: aws.String(name),
 	})
@@ -49,22 +59,23 @@ func (d *Deployer) HasStack(ctx context.Context, name string) (bool, error) {
 		var ae smithy.APIError
 		if errors.As(err, &ae) {
 			if strings.Contains(ae.ErrorMessage(), name+" does not exist") {
-				return false, nil
+				return nil, nil
 			}
 		}
-		return false, err
+		return nil, err
 	}
-	if len(do.Stacks) != 0 {
-		return false, nil
+	if len(do.Stacks) == 0 {
+		return nil, nil
 	}
-	return do.Stacks[0].StackStatus != cftypes.StackStatusReviewInProgress, nil
+	return &do.Stacks[0], nil
 }
 
 func (d *Deployer) CreateStack(ctx context.Context, input *DeployerInput) (*StackResult, error) {
 	cs, err := d.Client.CreateStack(ctx, &cloudformation.CreateStackInput{
-		StackName:   aws.String(input.StackName),
-		Parameters:  input.Params,
-		TemplateURL: aws.String(input.TemplateURL),
+		StackName:        aws.String(input.StackName),
+		Parameters:       input.Params,
+		TemplateURL:      aws.String(input.TemplateURL),
+		NotificationARNs: input.NotificationARNs,
 		Tags: []cftypes.Tag{
 			{Key: aws.String("network-api-managed"), Value: aws.String("true")},
 			{Key: aws.String("network-id"), Value: aws.String(input.NetworkID)},
@@ -78,6 +89,22 @@ func (d *Deployer) CreateStack(ctx context.Context, input *DeployerInput) (*Stac
 	}, nil
 }
 
+func (d *Deployer) DeleteStack(ctx context.Context, name string) error {
+	_, err := d.Client.DeleteStack(ctx, &cloudformation.DeleteStackInput{
+		StackName: aws.String(name),
+	})
+	return err
+}
+
+// DetectDrift starts a drift detection on the stack, its result shows up in
+// the stack drift information once done.
+func (d *Deployer) DetectDrift(ctx context.Context, name string) error {
+	_, err := d.Client.DetectStackDrift(ctx, &cloudformation.DetectStackDriftInput{
+		StackName: aws.String(name),
+	})
+	return err
+}
+
 func (d *Deployer) CreateChangeSet(ctx context.Context, input *DeployerInput) (*ChangeSetResult, error) {
 	changeSetType := cftypes.ChangeSetTypeUpdate
 	hasStack, err := d.HasStack(ctx, input.StackName)
@@ -0,0 +1,186 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/olxbr/network-api/pkg/client"
+	"github.com/olxbr/network-api/pkg/types"
+)
+
+const (
+	// stackResourceType marks the notifications about the stack itself,
+	// the ones about each of its resources are ignored.
+	stackResourceType = "AWS::CloudFormation::Stack"
+
+	callbackTimeout = 10 * time.Second
+)
+
+// subnetOutputs maps the subnet names used by the network template to the
+// subnet types and names generated by the API.
+var subnetOutputs = []struct {
+	prefix  string
+	name    string
+	subType types.SubnetType
+}{
+	{"PrivateSubnet", "private", types.Private},
+	{"PublicSubnet", "public", types.Public},
+	{"TGWSubnet", "tgw", types.TransitGateway},
+	{"IsolatedSubnet", "isolated", types.Isolated},
+}
+
+// StackEventHandler receives the CloudFormation notifications of network
+// stacks and reports the ones reaching a terminal state back to the API.
+func StackEventHandler(ctx context.Context, e events.SNSEvent) error {
+	cli := client.NewClient(&client.ClientOptions{
+		Endpoint: os.Getenv("NETWORK_API_URL"),
+		Client: &http.Client{
+			Timeout: callbackTimeout,
+		},
+	})
+
+	for _, r := range e.Records {
+		err := reportStackEvent(ctx, cli, r.SNS.Message)
+		if err != nil {
+			log.Printf("error reporting stack event: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func reportStackEvent(ctx context.Context, cli *client.Client, message string) error {
+	fields := ParseStackNotification(message)
+	if fields["ResourceType"] != stackResourceType {
+		return nil
+	}
+
+	networkID, ok := strings.CutPrefix(fields["StackName"], "network-")
+	if !ok {
+		return nil
+	}
+
+	status := NetworkStatus(cftypes.StackStatus(fields["ResourceStatus"]))
+	if !status.Settled() {
+		return nil
+	}
+
+	pe := &types.ProviderEvent{
+		Status: status,
+		Reason: fields["ResourceStatusReason"],
+	}
+	if status == types.StatusActive {
+		stackARN, err := arn.Parse(fields["StackId"])
+		if err != nil {
+			return fmt.Errorf("invalid stack id: %w", err)
+		}
+
+		cfg := AssumeRole(stackARN.AccountID, stackARN.Region, os.Getenv("TRUST_ROLE"))
+		d := NewDeployer(cloudformation.NewFromConfig(cfg))
+		stack, err := d.DescribeStack(ctx, fields["StackId"])
+		if err != nil {
+			return err
+		}
+		if stack != nil {
+			pe.VpcID, pe.Subnets = StackResources(stack)
+		}
+	}
+
+	log.Printf("reporting network %s as %s", networkID, status)
+	_, err := cli.SendProviderEvent(ctx, networkID, os.Getenv("NETWORK_API_TOKEN"), pe)
+	return err
+}
+
+// ParseStackNotification reads the key='value' lines CloudFormation posts
+// to its notification topics.
+func ParseStackNotification(message string) map[string]string {
+	fields := map[string]string{}
+	for _, line := range strings.Split(message, "\n") {
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		fields[k] = strings.Trim(v, "'")
+	}
+	return fields
+}
+
+// defaultSubnetName is the name the API generates for the subnet of type in
+// the given template slot.
+func defaultSubnetName(t types.SubnetType, slot int) string {
+	for _, so := range subnetOutputs {
+		if so.subType == t {
+			return fmt.Sprintf("%s%02d", so.name, slot+1)
+		}
+	}
+	return ""
+}
+
+// StackResources returns the VPC ID and subnets from the network stack
+// outputs, each subnet paired with the CIDR it was created with.
+func StackResources(stack *cftypes.Stack) (string, []*types.Subnet) {
+	params := map[string]string{}
+	for _, p := range stack.Parameters {
+		params[aws.ToString(p.ParameterKey)] = aws.ToString(p.ParameterValue)
+	}
+	outputs := map[string]string{}
+	for _, o := range stack.Outputs {
+		outputs[aws.ToString(o.OutputKey)] = aws.ToString(o.OutputValue)
+	}
+
+	vpcID := ""
+	subnets := []*types.Subnet{}
+	for _, o := range stack.Outputs {
+		key := aws.ToString(o.OutputKey)
+		if key == "VpcId" {
+			vpcID = aws.ToString(o.OutputValue)
+			continue
+		}
+
+		name, ok := strings.CutSuffix(key, "Id")
+		if !ok || params[name+"Cidr"] == "" {
+			continue
+		}
+		for _, so := range subnetOutputs {
+			idx, ok := strings.CutPrefix(name, so.prefix)
+			if !ok {
+				continue
+			}
+			i, err := strconv.Atoi(idx)
+			if err != nil {
+				continue
+			}
+			// layout and planned subnets carry their name as a parameter
+			subName := params[name+"Name"]
+			if subName == "" {
+				subName = defaultSubnetName(so.subType, i)
+			}
+			subnets = append(subnets, &types.Subnet{
+				ID:       aws.ToString(o.OutputValue),
+				Name:     subName,
+				Type:     so.subType,
+				CIDR:     params[name+"Cidr"],
+				IPv6CIDR: params[name+"Ipv6Cidr"],
+				AZIndex:  i,
+				AZ:       outputs[name+"Az"],
+			})
+		}
+	}
+
+	sort.Slice(subnets, func(i, j int) bool {
+		return subnets[i].Name < subnets[j].Name
+	})
+	return vpcID, subnets
+}
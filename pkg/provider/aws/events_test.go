@@ -0,0 +1,81 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/olxbr/network-api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStackNotification(t *testing.T) {
+	message := "StackId='arn:aws:cloudformation:us-east-1:123456789012:stack/network-1234/f0f0'\n" +
+		"Timestamp='2024-01-01T00:00:00.000Z'\n" +
+		"LogicalResourceId='network-1234'\n" +
+		"ResourceStatus='CREATE_COMPLETE'\n" +
+		"ResourceStatusReason=''\n" +
+		"ResourceType='AWS::CloudFormation::Stack'\n" +
+		"StackName='network-1234'\n"
+
+	fields := ParseStackNotification(message)
+
+	assert.Equal(t, "arn:aws:cloudformation:us-east-1:123456789012:stack/network-1234/f0f0", fields["StackId"])
+	assert.Equal(t, "CREATE_COMPLETE", fields["ResourceStatus"])
+	assert.Equal(t, "", fields["ResourceStatusReason"])
+	assert.Equal(t, stackResourceType, fields["ResourceType"])
+	assert.Equal(t, "network-1234", fields["StackName"])
+}
+
+func TestStackResources(t *testing.T) {
+	param := func(k, v string) cftypes.Parameter {
+		return cftypes.Parameter{ParameterKey: aws.String(k), ParameterValue: aws.String(v)}
+	}
+	output := func(k, v string) cftypes.Output {
+		return cftypes.Output{OutputKey: aws.String(k), OutputValue: aws.String(v)}
+	}
+
+	vpcID, subnets := StackResources(&cftypes.Stack{
+		Parameters: []cftypes.Parameter{
+			param("VPCCidr", "10.1.0.0/16"),
+			param("PrivateSubnet0Cidr", "10.1.0.0/19"),
+			param("PublicSubnet0Cidr", "10.1.32.0/20"),
+			param("PublicSubnet0Ipv6Cidr", "2600:1f18:1000:100::/64"),
+			param("TGWSubnet0Cidr", "10.1.48.0/28"),
+		},
+		Outputs: []cftypes.Output{
+			output("VpcId", "vpc-1234"),
+			output("TGWSubnet0Id", "subnet-3"),
+			output("PublicSubnet0Id", "subnet-2"),
+			output("PrivateSubnet0Id", "subnet-1"),
+			output("PrivateSubnet0Az", "us-east-1a"),
+			output("PrivateSubnet1Id", "subnet-4"),
+		},
+	})
+
+	assert.Equal(t, "vpc-1234", vpcID)
+	require.Len(t, subnets, 3)
+	assert.Equal(t, &types.Subnet{ID: "subnet-1", Name: "private01", Type: types.Private, CIDR: "10.1.0.0/19", AZ: "us-east-1a"}, subnets[0])
+	assert.Equal(t, &types.Subnet{
+		ID:       "subnet-2",
+		Name:     "public01",
+		Type:     types.Public,
+		CIDR:     "10.1.32.0/20",
+		IPv6CIDR: "2600:1f18:1000:100::/64",
+	}, subnets[1])
+	assert.Equal(t, &types.Subnet{ID: "subnet-3", Name: "tgw01", Type: types.TransitGateway, CIDR: "10.1.48.0/28"}, subnets[2])
+
+	_, subnets = StackResources(&cftypes.Stack{
+		Parameters: []cftypes.Parameter{
+			param("IsolatedSubnet1Cidr", "10.3.48.0/21"),
+			param("IsolatedSubnet1Name", "data-b"),
+		},
+		Outputs: []cftypes.Output{
+			output("IsolatedSubnet1Id", "subnet-5"),
+			output("IsolatedSubnet1Az", "us-east-1b"),
+		},
+	})
+	require.Len(t, subnets, 1)
+	assert.Equal(t, &types.Subnet{ID: "subnet-5", Name: "data-b", Type: types.Isolated, CIDR: "10.3.48.0/21", AZIndex: 1, AZ: "us-east-1b"}, subnets[0])
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
@@ -14,12 +16,20 @@ import (
 	"github.com/olxbr/network-api/pkg/types"
 )
 
+// webhookTimeout keeps provider calls within the API Gateway integration
+// timeout. Providers only acknowledge events and report progress through
+// CheckNetwork, they must not wait for the network to be ready.
+const webhookTimeout = 25 * time.Second
+
 type ProviderManager struct {
 	d db.Database
 	s secret.Secrets
 }
 
 type ProviderClient struct {
+	// Provider is the one the client sends events to.
+	Provider *types.Provider
+
 	cli  http.Client
 	auth string
 	url  string
@@ -44,8 +54,9 @@ func (p *ProviderManager) GetClient(ctx context.Context, name string) (*Provider
 	}
 
 	return &ProviderClient{
+		Provider: provider,
 		cli: http.Client{
-			Timeout: 300 * time.Second,
+			Timeout: webhookTimeout,
 		},
 		auth: token,
 		url:  provider.WebhookURL,
@@ -53,7 +64,7 @@ func (p *ProviderManager) GetClient(ctx context.Context, name string) (*Provider
 }
 
 func (p *ProviderClient) CreateNetwork(ctx context.Context, n *types.Network) (*types.ProviderWebhookResponse, error) {
-	subnets, err := net.GenerateSubnets(n)
+	subnets, err := net.NetworkSubnets(n)
 	if err != nil {
 		return nil, err
 	}
@@ -62,11 +73,97 @@ func (p *ProviderClient) CreateNetwork(ctx context.Context, n *types.Network) (*
 		Event:       types.CreateNetwork,
 		NetworkID:   n.ID.String(),
 		CIDR:        n.CIDR,
+		IPv6CIDR:    n.IPv6CIDR,
+		Account:     n.Account,
+		Region:      n.Region,
+		Environment: n.Environment,
+		Subnets:     subnets,
+	}
+	pwr, err := p.send(ctx, webhook)
+	if err != nil {
+		return nil, fmt.Errorf("error creating network: %w", err)
+	}
+	return pwr, nil
+}
+
+// DeleteNetwork asks the provider to tear down the network. The response
+// status tells whether it is already gone or still being deleted.
+func (p *ProviderClient) DeleteNetwork(ctx context.Context, n *types.Network) (*types.ProviderWebhookResponse, error) {
+	webhook := types.ProviderWebhook{
+		Event:       types.DeleteNetwork,
+		NetworkID:   n.ID.String(),
+		Account:     n.Account,
+		Region:      n.Region,
+		Environment: n.Environment,
+	}
+	pwr, err := p.send(ctx, webhook)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting network: %w", err)
+	}
+	return pwr, nil
+}
+
+// CheckNetwork asks the provider for the current status of the network and
+// how its resources compare to the expected CIDRs and subnets.
+func (p *ProviderClient) CheckNetwork(ctx context.Context, n *types.Network) (*types.ProviderWebhookResponse, error) {
+	subnets, err := net.NetworkSubnets(n)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := types.ProviderWebhook{
+		Event:       types.CheckNework,
+		NetworkID:   n.ID.String(),
+		CIDR:        n.CIDR,
+		IPv6CIDR:    n.IPv6CIDR,
 		Account:     n.Account,
 		Region:      n.Region,
 		Environment: n.Environment,
 		Subnets:     subnets,
 	}
+	pwr, err := p.send(ctx, webhook)
+	if err != nil {
+		return nil, fmt.Errorf("error checking network: %w", err)
+	}
+	return pwr, nil
+}
+
+// AddCIDR asks the provider to associate a secondary block with the
+// network. The response ID identifies the association.
+func (p *ProviderClient) AddCIDR(ctx context.Context, n *types.Network, cidr string) (*types.ProviderWebhookResponse, error) {
+	webhook := types.ProviderWebhook{
+		Event:       types.AddCIDR,
+		NetworkID:   n.ID.String(),
+		CIDR:        cidr,
+		VpcID:       n.VpcID,
+		Account:     n.Account,
+		Region:      n.Region,
+		Environment: n.Environment,
+	}
+	pwr, err := p.send(ctx, webhook)
+	if err != nil {
+		return nil, fmt.Errorf("error adding CIDR: %w", err)
+	}
+	return pwr, nil
+}
+
+// QueryNetworks asks the provider for the networks that already exist in the
+// account and region.
+func (p *ProviderClient) QueryNetworks(ctx context.Context, account, region, environment string) (*types.ProviderWebhookResponse, error) {
+	webhook := types.ProviderWebhook{
+		Event:       types.QueryNetwork,
+		Account:     account,
+		Region:      region,
+		Environment: environment,
+	}
+	pwr, err := p.send(ctx, webhook)
+	if err != nil {
+		return nil, fmt.Errorf("error querying networks: %w", err)
+	}
+	return pwr, nil
+}
+
+func (p *ProviderClient) send(ctx context.Context, webhook types.ProviderWebhook) (*types.ProviderWebhookResponse, error) {
 	body, err := json.Marshal(webhook)
 	if err != nil {
 		return nil, err
@@ -82,9 +179,14 @@ func (p *ProviderClient) CreateNetwork(ctx context.Context, n *types.Network) (*
 	if err != nil {
 		return nil, err
 	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("error closing response body: %v", closeErr)
+		}
+	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error creating network: %s", resp.Status)
+		return nil, errors.New(resp.Status)
 	}
 
 	pwr := &types.ProviderWebhookResponse{}
@@ -120,3 +120,117 @@ func TestProviderClientCanCreateNetwork(t *testing.T) {
 	}
 
 }
+
+func TestProviderClientCanDeleteNetwork(t *testing.T) {
+	tests := []struct {
+		name    string
+		network *types.Network
+		handler func(t *testing.T) http.Handler
+		assert  func(t *testing.T, pwr *types.ProviderWebhookResponse, err error)
+	}{
+		{
+			name: "valid network",
+			network: &types.Network{
+				ID:          types.NewUUID(),
+				Account:     "123456789012",
+				Region:      "us-east-1",
+				Environment: "prod",
+				CIDR:        "10.10.0.0/20",
+			},
+			handler: func(t *testing.T) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					pw := &types.ProviderWebhook{}
+					err := json.NewDecoder(r.Body).Decode(pw)
+					assert.NoError(t, err)
+
+					assert.Equal(t, types.DeleteNetwork, pw.Event)
+					assert.Equal(t, "123456789012", pw.Account)
+					assert.Empty(t, pw.Subnets)
+
+					w.WriteHeader(http.StatusOK)
+					err = json.NewEncoder(w).Encode(&types.ProviderWebhookResponse{
+						StatusCode: http.StatusOK,
+						ID:         "network-id",
+					})
+					assert.NoError(t, err)
+				})
+			},
+			assert: func(t *testing.T, pwr *types.ProviderWebhookResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "network-id", pwr.ID)
+			},
+		},
+		{
+			name: "provider failure",
+			network: &types.Network{
+				ID: types.NewUUID(),
+			},
+			handler: func(t *testing.T) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusInternalServerError)
+				})
+			},
+			assert: func(t *testing.T, pwr *types.ProviderWebhookResponse, err error) {
+				assert.EqualError(t, err, "error deleting network: 500 Internal Server Error")
+				assert.Nil(t, pwr)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler(t))
+			defer server.Close()
+
+			p := &ProviderClient{
+				cli:  http.Client{},
+				auth: "token",
+				url:  server.URL,
+			}
+
+			resp, err := p.DeleteNetwork(context.Background(), tt.network)
+
+			tt.assert(t, resp, err)
+		})
+	}
+}
+
+func TestProviderClientCanAddCIDR(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pw := &types.ProviderWebhook{}
+		err := json.NewDecoder(r.Body).Decode(pw)
+		assert.NoError(t, err)
+
+		assert.Equal(t, types.AddCIDR, pw.Event)
+		assert.Equal(t, "10.20.0.0/20", pw.CIDR)
+		assert.Equal(t, "vpc-1234", pw.VpcID)
+		assert.Empty(t, pw.Subnets)
+
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(&types.ProviderWebhookResponse{
+			StatusCode: http.StatusOK,
+			ID:         "vpc-cidr-assoc-1234",
+			Status:     types.StatusActive,
+		})
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	p := &ProviderClient{
+		cli:  http.Client{},
+		auth: "token",
+		url:  server.URL,
+	}
+
+	resp, err := p.AddCIDR(context.Background(), &types.Network{
+		ID:          types.NewUUID(),
+		Account:     "123456789012",
+		Region:      "us-east-1",
+		Environment: "prod",
+		CIDR:        "10.10.0.0/20",
+		VpcID:       "vpc-1234",
+	}, "10.20.0.0/20")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "vpc-cidr-assoc-1234", resp.ID)
+	assert.Equal(t, types.StatusActive, resp.Status)
+}
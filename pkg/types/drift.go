@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+type DriftStatus string
+
+const (
+	DriftInSync  DriftStatus = "in_sync"
+	DriftDrifted DriftStatus = "drifted"
+	DriftMissing DriftStatus = "missing"
+)
+
+// DriftCheck is the outcome of comparing a network with what the provider
+// actually runs.
+type DriftCheck struct {
+	Status      DriftStatus `json:"status" dynamodbav:"status"`
+	Differences []string    `json:"differences,omitempty" dynamodbav:"differences,omitempty"`
+	CheckedAt   time.Time   `json:"checkedAt" dynamodbav:"checkedAt"`
+}
+
+type NetworkCheckResult struct {
+	ID    *DynamoUUID `json:"id"`
+	Drift *DriftCheck `json:"drift,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+type NetworkCheckResponse struct {
+	Items []*NetworkCheckResult `json:"items"`
+}
@@ -1,7 +1,35 @@
 package types
 
+// ErrorCode tells clients which kind of error a response carries.
+type ErrorCode string
+
+const (
+	ErrorNotFound      ErrorCode = "not_found"
+	ErrorConflict      ErrorCode = "conflict"
+	ErrorOverlap       ErrorCode = "overlap"
+	ErrorPoolExhausted ErrorCode = "pool_exhausted"
+	ErrorNotInPool     ErrorCode = "not_in_pool"
+	ErrorExcluded      ErrorCode = "excluded"
+	ErrorInvalid       ErrorCode = "invalid"
+	ErrorNoPoolMatch   ErrorCode = "no_pool_match"
+	ErrorPolicy        ErrorCode = "policy_violation"
+	ErrorRule          ErrorCode = "rule_violation"
+)
+
 type ErrorResponse struct {
+	Code   ErrorCode         `json:"code,omitempty"`
 	Errors map[string]string `json:"errors"`
+
+	// Conflicts lists the networks an overlapping CIDR collides with.
+	Conflicts []*Network `json:"conflicts,omitempty"`
+	// Violations lists the pool policy rules a network breaks.
+	Violations []string `json:"violations,omitempty"`
+	// Rules holds the outcome of every rule for a network request some
+	// rule turned down.
+	Rules []*RuleResult `json:"rules,omitempty"`
+	// Skipped lists the pools matching a selector and why none could take
+	// the network.
+	Skipped []*SkippedPool `json:"skipped,omitempty"`
 }
 
 func NewSingleErrorResponse(message string) *ErrorResponse {
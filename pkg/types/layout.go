@@ -0,0 +1,34 @@
+package types
+
+// Layout is a named subnet layout profile. Networks created with it are
+// split into AZCount zones, each holding a subnet of every tier.
+type Layout struct {
+	Name    string        `json:"name" dynamodbav:"name"`
+	AZCount int           `json:"azCount" dynamodbav:"azCount"`
+	Tiers   []*LayoutTier `json:"tiers" dynamodbav:"tiers"`
+}
+
+// LayoutTier is the subnet a layout places in every zone. It is sized either
+// by PrefixLength or by Weight, its share of the zone relative to the other
+// weighted tiers, rounded down to a power of two and halved as needed to
+// leave room for the tiers of fixed size. NamePattern names each
+// subnet, replacing {tier} with the tier name, {az} with the zone number
+// counted from 01 and {zone} with the zone letter, {tier}{az} by default.
+type LayoutTier struct {
+	Name         string     `json:"name" dynamodbav:"name" validate:"required,alphanum"`
+	Type         SubnetType `json:"type" dynamodbav:"type" validate:"required,oneof=private public transitGateway isolated"`
+	PrefixLength int        `json:"prefixLength,omitempty" dynamodbav:"prefixLength,omitempty" validate:"required_without=Weight,excluded_with=Weight,omitempty,min=16,max=28"`
+	Weight       int        `json:"weight,omitempty" dynamodbav:"weight,omitempty" validate:"omitempty,min=1"`
+	NamePattern  string     `json:"namePattern,omitempty" dynamodbav:"namePattern,omitempty" validate:"omitempty,contains={az}|contains={zone}"`
+}
+
+type LayoutRequest struct {
+	Name    string        `json:"name" validate:"required,max=64,excludesall=/?#"`
+	AZCount int           `json:"azCount" validate:"required,min=1,max=6"`
+	Tiers   []*LayoutTier `json:"tiers" validate:"required,min=1,unique=Name,dive"`
+}
+
+type LayoutListResponse struct {
+	Items     []*Layout `json:"items"`
+	NextToken string    `json:"nextToken,omitempty"`
+}
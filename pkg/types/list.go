@@ -0,0 +1,66 @@
+package types
+
+import (
+	"net/url"
+	"strconv"
+)
+
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+// ListOptions pages and sorts the list endpoints. Without a limit every item
+// is returned at once, otherwise NextToken resumes from the previous page.
+type ListOptions struct {
+	Limit     int       `json:"limit,omitempty" validate:"min=0,max=1000"`
+	NextToken string    `json:"nextToken,omitempty"`
+	Sort      string    `json:"sort,omitempty"`
+	Order     SortOrder `json:"order,omitempty" validate:"omitempty,oneof=asc desc"`
+}
+
+// Values encodes the options as query parameters.
+func (o *ListOptions) Values(v url.Values) {
+	if o == nil {
+		return
+	}
+	if o.Limit > 0 {
+		v.Set("limit", strconv.Itoa(o.Limit))
+	}
+	setValue(v, "nextToken", o.NextToken)
+	setValue(v, "sort", o.Sort)
+	setValue(v, "order", string(o.Order))
+}
+
+// NetworkFilter narrows the networks listed. Provider, region, account and
+// environment follow the network sort key, Contains takes an IP or a CIDR the
+// networks must hold.
+type NetworkFilter struct {
+	Provider    string `json:"provider,omitempty"`
+	Region      string `json:"region,omitempty"`
+	Account     string `json:"account,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	PoolID      string `json:"poolID,omitempty"`
+	Contains    string `json:"contains,omitempty" validate:"omitempty,cidr|ip"`
+}
+
+// Values encodes the filter as query parameters.
+func (f *NetworkFilter) Values(v url.Values) {
+	if f == nil {
+		return
+	}
+	setValue(v, "provider", f.Provider)
+	setValue(v, "region", f.Region)
+	setValue(v, "account", f.Account)
+	setValue(v, "environment", f.Environment)
+	setValue(v, "poolID", f.PoolID)
+	setValue(v, "contains", f.Contains)
+}
+
+func setValue(v url.Values, key, value string) {
+	if value != "" {
+		v.Set(key, value)
+	}
+}
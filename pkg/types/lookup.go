@@ -0,0 +1,40 @@
+package types
+
+type LookupStatus string
+
+const (
+	// LookupAllocated is an address held by a network or by an allocation in flight.
+	LookupAllocated LookupStatus = "allocated"
+	// LookupPartial is a block only partly held by networks.
+	LookupPartial LookupStatus = "partially_allocated"
+	// LookupUnallocated is an address nothing holds.
+	LookupUnallocated LookupStatus = "unallocated"
+)
+
+type LookupRequest struct {
+	IP   string `json:"ip,omitempty" validate:"required_without=CIDR,excluded_with=CIDR,omitempty,ip"`
+	CIDR string `json:"cidr,omitempty" validate:"omitempty,cidr"`
+
+	RoutingDomain string `json:"routingDomain,omitempty" validate:"omitempty"`
+}
+
+// LookupResponse tells who owns an address or a block: the pool it falls in,
+// the network holding it and the subnet generated for it, with the index of
+// its availability zone. Unallocated addresses come with the free range
+// around them instead.
+type LookupResponse struct {
+	Query         string       `json:"query"`
+	RoutingDomain string       `json:"routingDomain,omitempty"`
+	Status        LookupStatus `json:"status"`
+	Pool          *Pool        `json:"pool,omitempty"`
+	Network       *Network     `json:"network,omitempty"`
+	Subnet        *Subnet      `json:"subnet,omitempty"`
+	AZIndex       *int         `json:"azIndex,omitempty"`
+	FreeRange     string       `json:"freeRange,omitempty"`
+}
+
+// LookupListResponse holds a lookup per routing domain, only the one asked
+// for when the request names a domain.
+type LookupListResponse struct {
+	Items []*LookupResponse `json:"items"`
+}
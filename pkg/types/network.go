@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"net/netip"
+	"time"
 )
 
 // SortKey: [Provider]#[Region]#[Account]#[Environment]#[CIDR]
@@ -14,47 +15,175 @@ type Network struct {
 	Account     string      `json:"account" dynamodbav:"account"`
 	Environment string      `json:"environment" dynamodbav:"environment"`
 	CIDR        string      `json:"cidr" dynamodbav:"cidr"`
+	IPv6CIDR    string      `json:"ipv6CIDR,omitempty" dynamodbav:"ipv6CIDR,omitempty"`
+
+	// RoutingDomain is the one of the pools the network was allocated from,
+	// its CIDRs only have to be unique within the domain.
+	RoutingDomain string `json:"routingDomain,omitempty" dynamodbav:"routingDomain,omitempty"`
 
 	VpcID string `json:"vpcID" dynamodbav:"vpcID"`
 	Info  string `json:"info" dynamodbav:"info"`
 
+	// SubnetIDs maps each subnet CIDR to the ID given by the provider.
+	SubnetIDs map[string]string `json:"subnetIDs,omitempty" dynamodbav:"subnetIDs,omitempty"`
+	// Subnets is the subnet plan the network was provisioned with.
+	Subnets []*Subnet `json:"subnets,omitempty" dynamodbav:"subnets,omitempty"`
+	// SecondaryCIDRs are the blocks attached to the network after it was
+	// created.
+	SecondaryCIDRs []*SecondaryCIDR `json:"secondaryCIDRs,omitempty" dynamodbav:"secondaryCIDRs,omitempty"`
+
 	AttachTGW     bool `json:"attachTGW,omitempty" dynamodbav:"attachTGW"`
 	PrivateSubnet bool `json:"privateSubnet,omitempty" dynamodbav:"privateSubnet"`
 	PublicSubnet  bool `json:"publicSubnet,omitempty" dynamodbav:"publicSubnet"`
 	Legacy        bool `json:"legacy,omitempty" dynamodbav:"legacy"`
 	Reserved      bool `json:"reserved,omitempty" dynamodbav:"reserved"`
+
+	Status        NetworkStatus   `json:"status,omitempty" dynamodbav:"status"`
+	StatusHistory []*StatusChange `json:"-" dynamodbav:"statusHistory,omitempty"`
+
+	Drift *DriftCheck `json:"drift,omitempty" dynamodbav:"drift,omitempty"`
+
+	// Layout is the subnet layout the network was created with, replacing the
+	// private, public and TGW subnet flags.
+	Layout *Layout `json:"layout,omitempty" dynamodbav:"layout,omitempty"`
 }
 
 type NetworkRequest struct {
 	Account     string `json:"account" validate:"required"`
-	PoolID      string `json:"poolID" validate:"required"`
+	PoolID      string `json:"poolID,omitempty" validate:"required_without=Region"`
 	Provider    string `json:"provider" validate:"required"`
 	Environment string `json:"environment" validate:"required"`
 
+	// Region and Selector pick the pool instead of PoolID: the pools of the
+	// region carrying every label of the selector are tried by priority.
+	Region   string            `json:"region,omitempty" validate:"excluded_with=PoolID"`
+	Selector map[string]string `json:"selector,omitempty" validate:"excluded_with=PoolID"`
+
 	Info string `json:"info,omitempty" validate:"omitempty"`
 
-	SubnetSize int `json:"subnetSize" validate:"required_without_all=Reserved Legacy,omitempty,max=24,min=16"`
+	SubnetSize int                `json:"subnetSize" validate:"required_without_all=Reserved Legacy,omitempty,max=28,min=16"`
+	Strategy   AllocationStrategy `json:"strategy,omitempty" validate:"omitempty,oneof=first-fit best-fit sparse"`
+
+	IPv6PoolID     string `json:"ipv6PoolID,omitempty" validate:"omitempty"`
+	IPv6SubnetSize int    `json:"ipv6SubnetSize,omitempty" validate:"excluded_without=IPv6PoolID,omitempty,max=60,min=44"`
 
-	AttachTGW     *bool `json:"attachTGW,omitempty" validate:"required"`
-	PrivateSubnet *bool `json:"privateSubnet,omitempty" validate:"required"`
-	PublicSubnet  *bool `json:"publicSubnet,omitempty" validate:"required"`
+	AttachTGW     *bool `json:"attachTGW,omitempty" validate:"required_without_all=Layout Subnets"`
+	PrivateSubnet *bool `json:"privateSubnet,omitempty" validate:"required_without_all=Layout Subnets"`
+	PublicSubnet  *bool `json:"publicSubnet,omitempty" validate:"required_without_all=Layout Subnets"`
 	Legacy        *bool `json:"legacy,omitempty" validate:"omitempty"`
 
+	// Layout names the subnet layout profile to create the network with.
+	Layout string `json:"layout,omitempty" validate:"omitempty"`
+	// Subnets is an explicit subnet plan, used instead of a layout.
+	Subnets []*SubnetRequest `json:"subnets,omitempty" validate:"excluded_with=Layout,omitempty,unique=Name,dive"`
+
 	Reserved *bool  `json:"reserved,omitempty" validate:"omitempty"`
-	CIDR     string `json:"cidr,omitempty" validate:"excluded_without_all=Reserved Legacy,omitempty,cidr"`
+	CIDR     string `json:"cidr,omitempty" validate:"excluded_without_all=Reserved Legacy,omitempty,cidrv4"`
+	IPv6CIDR string `json:"ipv6CIDR,omitempty" validate:"excluded_without_all=Reserved Legacy,omitempty,cidrv6"`
+}
+
+// SecondaryCIDR is an additional block of a network, allocated from PoolID
+// and associated by the provider under AssociationID.
+type SecondaryCIDR struct {
+	CIDR          string        `json:"cidr" dynamodbav:"cidr"`
+	PoolID        string        `json:"poolID,omitempty" dynamodbav:"poolID,omitempty"`
+	AssociationID string        `json:"associationID,omitempty" dynamodbav:"associationID,omitempty"`
+	Status        NetworkStatus `json:"status" dynamodbav:"status"`
+}
+
+// NetworkCIDRRequest attaches a secondary block to a network. Without a pool
+// the block comes from the pool holding the network primary CIDR.
+type NetworkCIDRRequest struct {
+	PoolID     string             `json:"poolID,omitempty" validate:"omitempty"`
+	SubnetSize int                `json:"subnetSize" validate:"required,min=16,max=28"`
+	Strategy   AllocationStrategy `json:"strategy,omitempty" validate:"omitempty,oneof=first-fit best-fit sparse"`
 }
 
 type NetworkResponse struct {
 	Network *Network                 `json:"network"`
 	Webhook *ProviderWebhookResponse `json:"webhook,omitempty"`
+	// Selection tells which pool was picked when the request gave a region
+	// instead of a pool.
+	Selection *PoolSelection `json:"selection,omitempty"`
+}
+
+// PoolSelection is the pool picked for a network and why, along with the
+// matching pools tried before it.
+type PoolSelection struct {
+	PoolID  string         `json:"poolID"`
+	Pool    string         `json:"pool"`
+	Reason  string         `json:"reason"`
+	Skipped []*SkippedPool `json:"skipped,omitempty"`
 }
+
+// SkippedPool is a pool matching the selector that could not take the
+// network.
+type SkippedPool struct {
+	PoolID string `json:"poolID"`
+	Pool   string `json:"pool"`
+	Reason string `json:"reason"`
+}
+
 type NetworkUpdateRequest struct {
 	VpcID *string `json:"vpcID,omitempty"`
 	Info  *string `json:"info,omitempty"`
 }
 
+type NetworkImportRequest struct {
+	Provider    string `json:"provider" validate:"required"`
+	Account     string `json:"account" validate:"required"`
+	PoolID      string `json:"poolID" validate:"required"`
+	IPv6PoolID  string `json:"ipv6PoolID,omitempty" validate:"omitempty"`
+	Environment string `json:"environment" validate:"required"`
+
+	// VpcIDs limits the import to these networks, default ones included.
+	VpcIDs []string `json:"vpcIDs,omitempty" validate:"omitempty"`
+	DryRun bool     `json:"dryRun,omitempty"`
+}
+
+type ImportAction string
+
+const (
+	ImportCreate ImportAction = "import"
+	ImportSkip   ImportAction = "skip"
+)
+
+type NetworkImportResult struct {
+	VpcID     string       `json:"vpcID"`
+	Name      string       `json:"name,omitempty"`
+	CIDR      string       `json:"cidr"`
+	IPv6CIDR  string       `json:"ipv6CIDR,omitempty"`
+	Action    ImportAction `json:"action"`
+	Reason    string       `json:"reason,omitempty"`
+	NetworkID *DynamoUUID  `json:"networkID,omitempty"`
+}
+
+type NetworkImportResponse struct {
+	DryRun bool                   `json:"dryRun"`
+	Items  []*NetworkImportResult `json:"items"`
+}
+
+// NetworkValidateRequest plans a network, its CIDRs are checked against the
+// networks in use in the routing domain and, with a pool, against the pool
+// range. The domain defaults to the one of the pool.
+type NetworkValidateRequest struct {
+	CIDR       string `json:"cidr,omitempty" validate:"required_without=IPv6CIDR,omitempty,cidrv4"`
+	IPv6CIDR   string `json:"ipv6CIDR,omitempty" validate:"omitempty,cidrv6"`
+	PoolID     string `json:"poolID,omitempty" validate:"omitempty"`
+	IPv6PoolID string `json:"ipv6PoolID,omitempty" validate:"omitempty"`
+
+	RoutingDomain string `json:"routingDomain,omitempty" validate:"omitempty"`
+}
+
+type NetworkValidateResponse struct {
+	Valid     bool       `json:"valid"`
+	Conflicts []*Network `json:"conflicts"`
+	Errors    []string   `json:"errors,omitempty"`
+}
+
 type NetworkListResponse struct {
-	Items []*Network `json:"items"`
+	Items     []*Network `json:"items"`
+	NextToken string     `json:"nextToken,omitempty"`
 }
 
 type SubnetResponse struct {
@@ -67,12 +196,30 @@ const (
 	Private        SubnetType = "private"
 	Public         SubnetType = "public"
 	TransitGateway SubnetType = "transitGateway"
+	// Isolated subnets have no route out of the network, as database tiers.
+	Isolated SubnetType = "isolated"
 )
 
+// Subnet is a subnet of a network, placed in the availability zone at
+// AZIndex counted from 0. ID and AZ are given by the provider once created.
 type Subnet struct {
-	Name string     `json:"name"`
-	Type SubnetType `json:"type"`
-	CIDR string     `json:"cidr"`
+	ID       string     `json:"id,omitempty" dynamodbav:"id,omitempty"`
+	Name     string     `json:"name" dynamodbav:"name"`
+	Type     SubnetType `json:"type" dynamodbav:"type"`
+	Tier     string     `json:"tier,omitempty" dynamodbav:"tier,omitempty"`
+	CIDR     string     `json:"cidr" dynamodbav:"cidr" validate:"required,cidr"`
+	IPv6CIDR string     `json:"ipv6CIDR,omitempty" dynamodbav:"ipv6CIDR,omitempty" validate:"omitempty,cidrv6"`
+	AZIndex  int        `json:"azIndex" dynamodbav:"azIndex"`
+	AZ       string     `json:"az,omitempty" dynamodbav:"az,omitempty"`
+}
+
+// SubnetRequest is a subnet of an explicit plan. As the network CIDR is only
+// known once allocated, CIDR may also be written from 0.0.0.0, as an offset
+// into the network.
+type SubnetRequest struct {
+	Name string     `json:"name" validate:"required,max=64,excludesall=/?#"`
+	Type SubnetType `json:"type" validate:"required,oneof=private public transitGateway isolated"`
+	CIDR string     `json:"cidr" validate:"required,cidrv4"`
 }
 
 func (n Network) Network() net.IPNet {
@@ -84,6 +231,35 @@ func (n Network) IPPrefix() netip.Prefix {
 	return netip.MustParsePrefix(n.CIDR)
 }
 
+// SetStatus moves the network to status, recording the change in its history.
+func (n *Network) SetStatus(status NetworkStatus, reason string) {
+	n.Status = status
+	n.StatusHistory = append(n.StatusHistory, &StatusChange{
+		Status:    status,
+		Reason:    reason,
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+// HoldsAddresses reports whether the network still owns its CIDRs. Failed and
+// deleted networks are kept for their history but their blocks are free.
+func (n Network) HoldsAddresses() bool {
+	return n.Status != StatusFailed && n.Status != StatusDeleted
+}
+
+// Prefixes returns every block assigned to the network, secondary blocks
+// included.
+func (n Network) Prefixes() []netip.Prefix {
+	prefixes := []netip.Prefix{n.IPPrefix()}
+	if n.IPv6CIDR != "" {
+		prefixes = append(prefixes, netip.MustParsePrefix(n.IPv6CIDR))
+	}
+	for _, sc := range n.SecondaryCIDRs {
+		prefixes = append(prefixes, netip.MustParsePrefix(sc.CIDR))
+	}
+	return prefixes
+}
+
 func (n Network) String() string {
 	return fmt.Sprintf("<CIDR: %s, Account: %s, Region: %s>", n.CIDR, n.Account, n.Region)
 }
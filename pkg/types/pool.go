@@ -1,11 +1,32 @@
 package types
 
 import (
+	"math/big"
 	"net/netip"
+	"time"
 
 	"go4.org/netipx"
 )
 
+// Well-known pool labels.
+const (
+	EnvironmentLabel   = "environment"
+	PurposeLabel       = "purpose"
+	RoutingDomainLabel = "routingDomain"
+)
+
+// AllocationStrategy decides where a new network is placed in a pool.
+type AllocationStrategy string
+
+const (
+	// FirstFit takes the lowest free block that fits.
+	FirstFit AllocationStrategy = "first-fit"
+	// BestFit takes the smallest free block that fits, keeping large blocks intact.
+	BestFit AllocationStrategy = "best-fit"
+	// Sparse takes the largest free block, leaving every network room to grow.
+	Sparse AllocationStrategy = "sparse"
+)
+
 type Pool struct {
 	ID          *DynamoUUID `json:"id" dynamodbav:"id"`
 	Name        string      `json:"name" dynamodbav:"name"`
@@ -13,24 +34,207 @@ type Pool struct {
 	SubnetIP    string      `json:"subnetIP" dynamodbav:"cidr"`
 	SubnetMask  *int        `json:"subnetMask,omitempty" dynamodbav:"subnetMask"`
 	SubnetMaxIP *string     `json:"subnetMaxIP,omitempty" dynamodbav:"subnetMaxIP"`
+
+	Strategy AllocationStrategy `json:"strategy,omitempty" dynamodbav:"strategy"`
+
+	Exclusions []*Exclusion `json:"exclusions,omitempty" dynamodbav:"exclusions,omitempty"`
+
+	// ParentID is the pool this one was delegated from, its range sits inside
+	// the parent range and apart from its siblings.
+	ParentID string `json:"parentID,omitempty" dynamodbav:"parentID,omitempty"`
+	// Admins records the teams the pool is delegated to. It is informative
+	// only, the API does not restrict who may change the pool.
+	Admins []string `json:"admins,omitempty" dynamodbav:"admins,omitempty"`
+
+	// RoutingDomain separates address spaces that are never routed to each
+	// other, pools in different domains may overlap.
+	RoutingDomain string `json:"routingDomain,omitempty" dynamodbav:"routingDomain,omitempty"`
+
+	// Labels tell what the pool is meant for, as its environment or purpose,
+	// so networks can pick it by selector instead of by ID.
+	Labels map[string]string `json:"labels,omitempty" dynamodbav:"labels,omitempty"`
+	// Priority orders the pools matching a selector, the highest is tried
+	// first.
+	Priority int `json:"priority,omitempty" dynamodbav:"priority,omitempty"`
+
+	// Policy restricts the networks allocated from the pool.
+	Policy *PoolPolicy `json:"policy,omitempty" dynamodbav:"policy,omitempty"`
+	// QuotaUsage is the consumption of the policy quotas, only filled in
+	// the pool detail.
+	QuotaUsage []*QuotaUsage `json:"quotaUsage,omitempty" dynamodbav:"-"`
+
+	// History lists the renames and resizes of the pool, oldest first.
+	History []*PoolChange `json:"history,omitempty" dynamodbav:"history,omitempty"`
+
+	// Version is bumped on every allocation, serializing concurrent writers.
+	Version int `json:"-" dynamodbav:"version"`
+	// SortKey is the key the pool was stored under, it changes along with
+	// the region, range start or name.
+	SortKey string `json:"-" dynamodbav:"sk,omitempty"`
 }
 
 type PoolRequest struct {
 	Name        string  `json:"name" validate:"required"`
 	Region      string  `json:"region" validate:"required"`
 	SubnetIP    string  `json:"subnetIP" validate:"required,ip"`
-	SubnetMask  *int    `json:"subnetMask,omitempty" validate:"omitempty,max=24,min=8"`
+	SubnetMask  *int    `json:"subnetMask,omitempty" validate:"omitempty"`
 	SubnetMaxIP *string `json:"subnetMaxIP,omitempty" validate:"required_without=SubnetMask,excluded_with=SubnetMask,omitempty,ip"`
+
+	Strategy AllocationStrategy `json:"strategy,omitempty" validate:"omitempty,oneof=first-fit best-fit sparse"`
+
+	Exclusions []*Exclusion `json:"exclusions,omitempty" validate:"omitempty,dive"`
+
+	ParentID string   `json:"parentID,omitempty"`
+	Admins   []string `json:"admins,omitempty" validate:"omitempty,dive,required"`
+
+	RoutingDomain string `json:"routingDomain,omitempty"`
+
+	Labels   map[string]string `json:"labels,omitempty" validate:"omitempty,dive,keys,required,endkeys,required"`
+	Priority int               `json:"priority,omitempty"`
+
+	Policy *PoolPolicy `json:"policy,omitempty" validate:"omitempty"`
+}
+
+// PoolUpdateRequest renames a pool or changes its range, fields left empty
+// are kept as they are.
+type PoolUpdateRequest struct {
+	Name        string  `json:"name,omitempty" validate:"required_without=SubnetIP"`
+	SubnetIP    string  `json:"subnetIP,omitempty" validate:"required_without=Name,omitempty,ip"`
+	SubnetMask  *int    `json:"subnetMask,omitempty" validate:"omitempty"`
+	SubnetMaxIP *string `json:"subnetMaxIP,omitempty" validate:"excluded_with=SubnetMask,omitempty,ip"`
+}
+
+// PoolChange records a rename or resize of a pool.
+type PoolChange struct {
+	Name          string    `json:"name" dynamodbav:"name"`
+	PreviousName  string    `json:"previousName" dynamodbav:"previousName"`
+	Range         string    `json:"range" dynamodbav:"range"`
+	PreviousRange string    `json:"previousRange" dynamodbav:"previousRange"`
+	Timestamp     time.Time `json:"timestamp" dynamodbav:"timestamp"`
+}
+
+// PoolTree is a pool along with the pools delegated from it.
+type PoolTree struct {
+	*Pool
+	Children []*PoolTree `json:"children,omitempty"`
+}
+
+type PoolTreeResponse struct {
+	Items []*PoolTree `json:"items"`
+}
+
+// Exclusion is a range of a pool that is never allocated, such as space
+// used on-prem or by partners.
+type Exclusion struct {
+	CIDR   string `json:"cidr" dynamodbav:"cidr" validate:"required,cidr"`
+	Reason string `json:"reason" dynamodbav:"reason" validate:"required"`
+	Owner  string `json:"owner" dynamodbav:"owner" validate:"required"`
+}
+
+type ExclusionsRequest struct {
+	Exclusions []*Exclusion `json:"exclusions" validate:"dive"`
+}
+
+// PoolPolicy restricts the networks allocated from a pool, on top of the
+// sizes NetworkRequest accepts. Legacy networks are exempt.
+type PoolPolicy struct {
+	// PrefixLengths are the network sizes handed out, any when empty.
+	PrefixLengths []int `json:"prefixLengths,omitempty" dynamodbav:"prefixLengths,omitempty" validate:"omitempty,dive,min=1,max=128"`
+	// Environments may allocate from the pool, any when empty.
+	Environments []string `json:"environments,omitempty" dynamodbav:"environments,omitempty" validate:"omitempty,dive,required"`
+	Quotas       []*Quota `json:"quotas,omitempty" dynamodbav:"quotas,omitempty" validate:"omitempty,dive"`
+}
+
+// QuotaScope is what a quota is counted by.
+type QuotaScope string
+
+const (
+	QuotaAccount     QuotaScope = "account"
+	QuotaEnvironment QuotaScope = "environment"
+)
+
+// Quota caps the networks, or the addresses, an account or environment holds
+// in a pool. Without a value it applies to each account or environment.
+type Quota struct {
+	Scope        QuotaScope `json:"scope" dynamodbav:"scope" validate:"required,oneof=account environment"`
+	Value        string     `json:"value,omitempty" dynamodbav:"value,omitempty"`
+	MaxNetworks  int        `json:"maxNetworks,omitempty" dynamodbav:"maxNetworks,omitempty" validate:"required_without=MaxAddresses,omitempty,min=1"`
+	MaxAddresses uint64     `json:"maxAddresses,omitempty" dynamodbav:"maxAddresses,omitempty" validate:"omitempty,min=1"`
+}
+
+// QuotaUsage is what an account or environment holds against a quota.
+type QuotaUsage struct {
+	Scope        QuotaScope `json:"scope"`
+	Value        string     `json:"value"`
+	Networks     int        `json:"networks"`
+	Addresses    *big.Int   `json:"addresses"`
+	MaxNetworks  int        `json:"maxNetworks,omitempty"`
+	MaxAddresses uint64     `json:"maxAddresses,omitempty"`
+}
+
+// PoolLabelsRequest replaces the labels and priority of a pool.
+type PoolLabelsRequest struct {
+	Labels   map[string]string `json:"labels" validate:"dive,keys,required,endkeys,required"`
+	Priority int               `json:"priority"`
 }
 
 type PoolListResponse struct {
-	Items []*Pool `json:"items"`
+	Items     []*Pool `json:"items"`
+	NextToken string  `json:"nextToken,omitempty"`
+}
+
+// RecordChange adds the move from the given name and range to the current
+// ones to the pool history.
+func (p *Pool) RecordChange(previousName string, previousRange netipx.IPRange) {
+	p.History = append(p.History, &PoolChange{
+		Name:          p.Name,
+		PreviousName:  previousName,
+		Range:         p.Range().String(),
+		PreviousRange: previousRange.String(),
+		Timestamp:     time.Now().UTC(),
+	})
+}
+
+// Label returns the value of a pool label, the routing domain is read as the
+// routingDomain label.
+func (p Pool) Label(key string) (string, bool) {
+	if key == RoutingDomainLabel {
+		return p.RoutingDomain, true
+	}
+	v, ok := p.Labels[key]
+	return v, ok
+}
+
+// Matches tells whether the pool carries every label of selector.
+func (p Pool) Matches(selector map[string]string) bool {
+	for k, v := range selector {
+		if l, ok := p.Label(k); !ok || l != v {
+			return false
+		}
+	}
+	return true
 }
 
 func (p Pool) Network() netip.Addr {
 	return netip.MustParseAddr(p.SubnetIP)
 }
 
+func (p Pool) IsIPv6() bool {
+	return p.Network().Is6()
+}
+
+// ExcludedSet returns the excluded ranges of the pool.
+func (p Pool) ExcludedSet() *netipx.IPSet {
+	var b netipx.IPSetBuilder
+	for _, e := range p.Exclusions {
+		if prefix, err := netip.ParsePrefix(e.CIDR); err == nil {
+			b.AddPrefix(prefix.Masked())
+		}
+	}
+	s, _ := b.IPSet()
+	return s
+}
+
 func (p Pool) Range() netipx.IPRange {
 	ip := netip.MustParseAddr(p.SubnetIP)
 	if p.SubnetMask != nil {
@@ -40,3 +244,20 @@ func (p Pool) Range() netipx.IPRange {
 	maxIP := netip.MustParseAddr(*p.SubnetMaxIP)
 	return netipx.IPRangeFrom(ip, maxIP)
 }
+
+type PoolUsage struct {
+	PoolID            string   `json:"poolID"`
+	Range             string   `json:"range"`
+	RoutingDomain     string   `json:"routingDomain,omitempty"`
+	Total             *big.Int `json:"total"`
+	Allocated         *big.Int `json:"allocated"`
+	Reserved          *big.Int `json:"reserved"`
+	Excluded          *big.Int `json:"excluded"`
+	Delegated         *big.Int `json:"delegated"`
+	Free              *big.Int `json:"free"`
+	LargestFreePrefix string   `json:"largestFreePrefix,omitempty"`
+	// Available counts the networks of each size that still fit, capped at
+	// the largest uint64.
+	Available  map[string]uint64 `json:"available"`
+	FreeRanges []string          `json:"freeRanges"`
+}
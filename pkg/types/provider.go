@@ -14,7 +14,8 @@ type ProviderRequest struct {
 }
 
 type ProviderListResponse struct {
-	Items []*Provider `json:"items"`
+	Items     []*Provider `json:"items"`
+	NextToken string      `json:"nextToken,omitempty"`
 }
 
 type ProviderUpdateRequest struct {
@@ -29,19 +30,49 @@ const (
 	CheckNework   EventType = "check_network"
 	DeleteNetwork EventType = "delete_network"
 	QueryNetwork  EventType = "query_network"
+	AddCIDR       EventType = "add_cidr"
 )
 
 type ProviderWebhook struct {
 	Event       EventType `json:"event"`
-	NetworkID   string    `json:"networkID" validate:"required"`
+	NetworkID   string    `json:"networkID" validate:"required_unless=Event query_network"`
 	Account     string    `json:"account" validate:"required"`
 	Region      string    `json:"region" validate:"required"`
 	Environment string    `json:"environment" validate:"required"`
-	CIDR        string    `json:"cidr" validate:"required_if=Event create_network,omitempty,cidr"`
+	CIDR        string    `json:"cidr" validate:"required_if=Event create_network,required_if=Event add_cidr,omitempty,cidr"`
+	IPv6CIDR    string    `json:"ipv6CIDR,omitempty" validate:"omitempty,cidrv6"`
 	Subnets     []*Subnet `json:"subnets,omitempty" validate:"required_if=Event create_network,omitempty"`
+	// VpcID is the network the add_cidr block is associated with.
+	VpcID string `json:"vpcID,omitempty" validate:"required_if=Event add_cidr"`
+}
+
+// ProviderEvent is posted back by a provider once the work for a network
+// event is done, carrying the resources it created.
+type ProviderEvent struct {
+	Status  NetworkStatus `json:"status" validate:"required,oneof=provisioning active failed deleting deleted"`
+	Reason  string        `json:"reason,omitempty"`
+	VpcID   string        `json:"vpcID,omitempty"`
+	Subnets []*Subnet     `json:"subnets,omitempty" validate:"omitempty,dive"`
 }
 
 type ProviderWebhookResponse struct {
-	StatusCode int    `json:"statusCode"`
-	ID         string `json:"id"`
+	StatusCode int           `json:"statusCode"`
+	ID         string        `json:"id"`
+	Status     NetworkStatus `json:"status,omitempty"`
+	Reason     string        `json:"reason,omitempty"`
+	Drift      *DriftCheck   `json:"drift,omitempty"`
+
+	Networks []*DiscoveredNetwork `json:"networks,omitempty"`
+}
+
+// DiscoveredNetwork is an existing network listed by a provider in answer to
+// query_network.
+type DiscoveredNetwork struct {
+	VpcID    string `json:"vpcID"`
+	Name     string `json:"name,omitempty"`
+	CIDR     string `json:"cidr"`
+	IPv6CIDR string `json:"ipv6CIDR,omitempty"`
+	Default  bool   `json:"default,omitempty"`
+	// NetworkID is set on networks the API already created.
+	NetworkID string `json:"networkID,omitempty"`
 }
@@ -0,0 +1,38 @@
+package types
+
+import "net/netip"
+
+// Reservation locks a CIDR for a network within a routing domain, keyed by
+// Key.
+type Reservation struct {
+	CIDR          string `json:"cidr" dynamodbav:"cidr"`
+	NetworkID     string `json:"networkID" dynamodbav:"networkID"`
+	PoolID        string `json:"poolID,omitempty" dynamodbav:"poolID"`
+	RoutingDomain string `json:"routingDomain,omitempty" dynamodbav:"routingDomain,omitempty"`
+	// Account and Environment own the network, so allocations still in
+	// flight count against the pool quotas.
+	Account     string `json:"account,omitempty" dynamodbav:"account,omitempty"`
+	Environment string `json:"environment,omitempty" dynamodbav:"environment,omitempty"`
+
+	// DomainVersion is the version of the routing domain the reservation was
+	// checked against, see Database.DomainVersion.
+	DomainVersion int `json:"-" dynamodbav:"-"`
+}
+
+// Key is the CIDR, prefixed by the routing domain outside the default one, so
+// each domain can reserve the same CIDR once.
+func (r Reservation) Key() string {
+	return ReservationKey(r.RoutingDomain, r.CIDR)
+}
+
+// ReservationKey returns the key of the reservation of cidr in domain.
+func ReservationKey(domain, cidr string) string {
+	if domain == "" {
+		return cidr
+	}
+	return domain + "#" + cidr
+}
+
+func (r Reservation) IPPrefix() netip.Prefix {
+	return netip.MustParsePrefix(r.CIDR)
+}
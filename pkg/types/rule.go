@@ -0,0 +1,81 @@
+package types
+
+// RuleOperator compares a fact of a network request with a condition.
+type RuleOperator string
+
+const (
+	// RuleIn holds when the fact is one of the values.
+	RuleIn RuleOperator = "in"
+	// RuleNotIn holds when the fact is none of the values.
+	RuleNotIn RuleOperator = "notIn"
+	// RulePresent holds when the fact is set and not blank.
+	RulePresent RuleOperator = "present"
+	// RuleMin and RuleMax hold when the numeric fact is at least or at most
+	// the limit. For subnetSize a minimum caps how large the network is.
+	RuleMin RuleOperator = "min"
+	RuleMax RuleOperator = "max"
+)
+
+// Rule is an organizational rule network requests are held to. A request
+// matching every When condition must meet every Require condition, rules
+// without When apply to every request.
+type Rule struct {
+	Name        string           `json:"name" dynamodbav:"name"`
+	Description string           `json:"description,omitempty" dynamodbav:"description,omitempty"`
+	When        []*RuleCondition `json:"when,omitempty" dynamodbav:"when,omitempty"`
+	Require     []*RuleCondition `json:"require" dynamodbav:"require"`
+}
+
+// RuleCondition compares Field, a fact of the request, its pool or its
+// provider, such as environment, attachTGW or labels.purpose.
+type RuleCondition struct {
+	Field    string       `json:"field" dynamodbav:"field" validate:"required"`
+	Operator RuleOperator `json:"operator" dynamodbav:"operator" validate:"required,oneof=in notIn present min max"`
+	Values   []string     `json:"values,omitempty" dynamodbav:"values,omitempty" validate:"required_if=Operator in,required_if=Operator notIn"`
+	Limit    int          `json:"limit,omitempty" dynamodbav:"limit,omitempty"`
+}
+
+type RuleRequest struct {
+	Name        string           `json:"name" validate:"required,max=64,excludesall=/?#"`
+	Description string           `json:"description,omitempty"`
+	When        []*RuleCondition `json:"when,omitempty" validate:"omitempty,dive"`
+	Require     []*RuleCondition `json:"require" validate:"required,min=1,dive"`
+}
+
+type RuleListResponse struct {
+	Items     []*Rule `json:"items"`
+	NextToken string  `json:"nextToken,omitempty"`
+}
+
+type RuleOutcome string
+
+const (
+	RulePass RuleOutcome = "pass"
+	RuleFail RuleOutcome = "fail"
+	// RuleSkip is the outcome of rules whose When conditions the request
+	// does not match.
+	RuleSkip RuleOutcome = "skip"
+)
+
+// RuleResult is the outcome of a rule for a network request, Reason tells
+// the conditions a failed or skipped rule did not meet.
+type RuleResult struct {
+	Rule    string      `json:"rule"`
+	Outcome RuleOutcome `json:"outcome"`
+	Reason  string      `json:"reason,omitempty"`
+}
+
+// RuleEvaluateRequest evaluates the rules for a network request without
+// creating it. Rules, when given, are evaluated instead of the stored ones to
+// try them out before saving them.
+type RuleEvaluateRequest struct {
+	Network *NetworkRequest `json:"network" validate:"required"`
+	Rules   []*RuleRequest  `json:"rules,omitempty" validate:"omitempty,unique=Name,dive"`
+}
+
+type RuleEvaluateResponse struct {
+	Allowed bool          `json:"allowed"`
+	PoolID  string        `json:"poolID,omitempty"`
+	Pool    string        `json:"pool,omitempty"`
+	Results []*RuleResult `json:"results"`
+}
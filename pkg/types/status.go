@@ -0,0 +1,32 @@
+package types
+
+import "time"
+
+type NetworkStatus string
+
+const (
+	StatusPending      NetworkStatus = "pending"
+	StatusProvisioning NetworkStatus = "provisioning"
+	StatusActive       NetworkStatus = "active"
+	StatusFailed       NetworkStatus = "failed"
+	StatusDeleting     NetworkStatus = "deleting"
+	StatusDeleted      NetworkStatus = "deleted"
+)
+
+// Settled reports whether the network is done transitioning, so there is
+// nothing left to wait for.
+func (s NetworkStatus) Settled() bool {
+	return s == StatusActive || s == StatusFailed || s == StatusDeleted
+}
+
+type StatusChange struct {
+	Status    NetworkStatus `json:"status" dynamodbav:"status"`
+	Reason    string        `json:"reason,omitempty" dynamodbav:"reason,omitempty"`
+	Timestamp time.Time     `json:"timestamp" dynamodbav:"timestamp"`
+}
+
+type NetworkStatusResponse struct {
+	ID      *DynamoUUID     `json:"id"`
+	Status  NetworkStatus   `json:"status"`
+	History []*StatusChange `json:"history"`
+}